@@ -12,6 +12,7 @@ import (
 	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/dbaccess"
 	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/kaspanet/kaspad/util/network"
 	"github.com/pkg/errors"
 	"io"
 	"math/rand"
@@ -60,6 +61,9 @@ type AddressManager struct {
 	subnetworkNewAddressCounts        map[subnetworkid.SubnetworkID]int
 	subnetworkTriedAddresBucketArrays map[subnetworkid.SubnetworkID]*triedAddressBucketArray
 	subnetworkTriedAddressCounts      map[subnetworkid.SubnetworkID]int
+
+	bannedNetworks map[string]*BannedNetwork // network CIDR strings to banned networks
+	banScores      map[string]*banScore      // IP strings to ban scores
 }
 
 type serializedKnownAddress struct {
@@ -89,6 +93,8 @@ type PeersStateForSerialization struct {
 	FullNodeNewAddressBucketArray      serializedNewAddressBucketArray
 	SubnetworkTriedAddressBucketArrays map[string]*serializedTriedAddressBucketArray // string is Subnetwork ID
 	FullNodeTriedAddressBucketArray    serializedTriedAddressBucketArray
+
+	BannedNetworks []*serializedBannedNetwork
 }
 
 type localAddress struct {
@@ -183,6 +189,11 @@ const (
 	// will share with a call to AddressCache.
 	getAddrPercent = 23
 
+	// getAddressMaxRandomPicks is the number of random addresses that
+	// GetAddress picks before it falls back to picking out of the
+	// addresses that aren't banned, which is much slower.
+	getAddressMaxRandomPicks = 20
+
 	// serializationVersion is the current version of the on-disk format.
 	serializationVersion = 1
 )
@@ -475,6 +486,7 @@ func (am *AddressManager) PeersStateForSerialization() (*PeersStateForSerializat
 	peersState := new(PeersStateForSerialization)
 	peersState.Version = serializationVersion
 	copy(peersState.Key[:], am.key[:])
+	peersState.BannedNetworks = am.serializeBannedNetworks()
 
 	peersState.Addresses = make([]*serializedKnownAddress, len(am.addressIndex))
 	i := 0
@@ -491,8 +503,10 @@ func (am *AddressManager) PeersStateForSerialization() (*PeersStateForSerializat
 		serializedAddress.Attempts = knownAddress.attempts
 		serializedAddress.LastAttempt = knownAddress.lastAttempt.UnixMilliseconds()
 		serializedAddress.LastSuccess = knownAddress.lastSuccess.UnixMilliseconds()
-		serializedAddress.IsBanned = knownAddress.isBanned
-		serializedAddress.BannedTime = knownAddress.bannedTime.UnixMilliseconds()
+		if bannedNetwork, ok := am.bannedNetwork(knownAddress.netAddress.IP); ok && !am.IsWhitelisted(knownAddress.netAddress.IP) {
+			serializedAddress.IsBanned = true
+			serializedAddress.BannedTime = bannedNetwork.BannedTime.UnixMilliseconds()
+		}
 		// Tried and referenceCount are implicit in the rest of the structure
 		// and will be worked out from context on unserialisation.
 		peersState.Addresses[i] = serializedAddress
@@ -612,9 +626,24 @@ func (am *AddressManager) deserializePeersState(serializedPeerState []byte) erro
 		knownAddress.attempts = serializedKnownAddress.Attempts
 		knownAddress.lastAttempt = mstime.UnixMilliseconds(serializedKnownAddress.LastAttempt)
 		knownAddress.lastSuccess = mstime.UnixMilliseconds(serializedKnownAddress.LastSuccess)
-		knownAddress.isBanned = serializedKnownAddress.IsBanned
-		knownAddress.bannedTime = mstime.UnixMilliseconds(serializedKnownAddress.BannedTime)
 		am.addressIndex[NetAddressKey(knownAddress.netAddress)] = knownAddress
+
+		// Peers states serialized before bans were kept by network
+		// mark the banned addresses themselves.
+		if serializedKnownAddress.IsBanned && peersState.BannedNetworks == nil {
+			bannedTime := mstime.UnixMilliseconds(serializedKnownAddress.BannedTime)
+			ipNetwork := network.SingleIPNetwork(knownAddress.netAddress.IP)
+			am.bannedNetworks[ipNetwork.String()] = &BannedNetwork{
+				Network:    ipNetwork,
+				BannedTime: bannedTime,
+				Expiry:     bannedTime.Add(am.cfg.BanDuration),
+			}
+		}
+	}
+
+	err = am.deserializeBannedNetworks(peersState.BannedNetworks)
+	if err != nil {
+		return err
 	}
 
 	for subnetworkIDStr := range peersState.SubnetworkNewAddressBucketArrays {
@@ -903,6 +932,8 @@ func (am *AddressManager) reset() {
 	}
 	am.fullNodeNewAddressCount = 0
 	am.fullNodeTriedAddressCount = 0
+	am.bannedNetworks = make(map[string]*BannedNetwork)
+	am.banScores = make(map[string]*banScore)
 }
 
 // HostToNetAddress returns a netaddress given a host address. If
@@ -955,30 +986,62 @@ func (am *AddressManager) GetAddress() *KnownAddress {
 func (am *AddressManager) getAddress(triedAddressBucketArray *triedAddressBucketArray, triedAddressCount int,
 	newAddressBucketArray *newAddressBucketArray, newAddressCount int) *KnownAddress {
 
-	// Use a 50% chance for choosing between tried and new addresses.
-	var bucketArray addressBucketArray
-	if triedAddressCount > 0 && (newAddressCount == 0 || am.random.Intn(2) == 0) {
-		bucketArray = triedAddressBucketArray
-	} else if newAddressCount > 0 {
-		bucketArray = newAddressBucketArray
-	} else {
+	var bucketArrays []addressBucketArray
+	if triedAddressCount > 0 {
+		bucketArrays = append(bucketArrays, triedAddressBucketArray)
+	}
+	if newAddressCount > 0 {
+		bucketArrays = append(bucketArrays, newAddressBucketArray)
+	}
+	if len(bucketArrays) == 0 {
 		// There aren't any addresses in any of the buckets
 		return nil
 	}
 
-	// Pick a random bucket
-	randomBucket := bucketArray.randomBucket(am.random)
+	// Most addresses aren't expected to be banned, so random addresses
+	// are picked first, using a 50% chance for choosing between tried
+	// and new addresses.
+	for i := 0; i < getAddressMaxRandomPicks; i++ {
+		bucketArray := bucketArrays[0]
+		if len(bucketArrays) == 2 && am.random.Intn(2) == 1 {
+			bucketArray = bucketArrays[1]
+		}
+		knownAddress := am.randomAddress(bucketArray.randomBucket(am.random))
+		if am.isNotBanned(knownAddress) {
+			return knownAddress
+		}
+	}
+
+	// Fall back to picking out of the addresses that aren't banned.
+	// If all the addresses in the chosen bucket array are banned, fall
+	// back to the other one.
+	if len(bucketArrays) == 2 && am.random.Intn(2) == 1 {
+		bucketArrays[0], bucketArrays[1] = bucketArrays[1], bucketArrays[0]
+	}
+	for _, bucketArray := range bucketArrays {
+		randomBucket := bucketArray.randomEligibleBucket(am.random, am.isNotBanned)
+		if len(randomBucket) > 0 {
+			return am.randomAddress(randomBucket)
+		}
+	}
 
+	// All the addresses are banned
+	return nil
+}
+
+// randomAddress picks a random address out of the given non-empty bucket,
+// weighted by the chance of every address.
+func (am *AddressManager) randomAddress(bucket []*KnownAddress) *KnownAddress {
 	// Get the sum of all chances
 	totalChance := float64(0)
-	for _, knownAddress := range randomBucket {
+	for _, knownAddress := range bucket {
 		totalChance += knownAddress.chance()
 	}
 
 	// Pick a random address weighted by chance
 	randomValue := am.random.Float64()
 	accumulatedChance := float64(0)
-	for _, knownAddress := range randomBucket {
+	for _, knownAddress := range bucket {
 		normalizedChance := knownAddress.chance() / totalChance
 		accumulatedChance += normalizedChance
 		if randomValue < accumulatedChance {
@@ -989,9 +1052,17 @@ func (am *AddressManager) getAddress(triedAddressBucketArray *triedAddressBucket
 	panic("randomValue is equal to or greater than 1, which cannot happen")
 }
 
+// isNotBanned returns whether the given known address isn't banned.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) isNotBanned(knownAddress *KnownAddress) bool {
+	return !am.isBanned(knownAddress.netAddress.IP)
+}
+
 type addressBucketArray interface {
 	name() string
 	randomBucket(random *rand.Rand) []*KnownAddress
+	randomEligibleBucket(random *rand.Rand, isEligible func(*KnownAddress) bool) []*KnownAddress
 }
 
 func (nb *newAddressBucketArray) randomBucket(random *rand.Rand) []*KnownAddress {
@@ -1012,6 +1083,28 @@ func (nb *newAddressBucketArray) randomBucket(random *rand.Rand) []*KnownAddress
 	return randomBucketSlice
 }
 
+// randomEligibleBucket returns the eligible addresses of a random bucket out
+// of the buckets that have any, or nil if there are no eligible addresses.
+func (nb *newAddressBucketArray) randomEligibleBucket(random *rand.Rand, isEligible func(*KnownAddress) bool) []*KnownAddress {
+	eligibleBuckets := make([][]*KnownAddress, 0, NewBucketCount)
+	for _, bucket := range nb {
+		eligibleAddresses := make([]*KnownAddress, 0, len(bucket))
+		for _, knownAddress := range bucket {
+			if isEligible(knownAddress) {
+				eligibleAddresses = append(eligibleAddresses, knownAddress)
+			}
+		}
+		if len(eligibleAddresses) > 0 {
+			eligibleBuckets = append(eligibleBuckets, eligibleAddresses)
+		}
+	}
+	if len(eligibleBuckets) == 0 {
+		return nil
+	}
+	randomIndex := random.Intn(len(eligibleBuckets))
+	return eligibleBuckets[randomIndex]
+}
+
 func (nb *newAddressBucketArray) name() string {
 	return "new"
 }
@@ -1027,6 +1120,28 @@ func (tb *triedAddressBucketArray) randomBucket(random *rand.Rand) []*KnownAddre
 	return nonEmptyBuckets[randomIndex]
 }
 
+// randomEligibleBucket returns the eligible addresses of a random bucket out
+// of the buckets that have any, or nil if there are no eligible addresses.
+func (tb *triedAddressBucketArray) randomEligibleBucket(random *rand.Rand, isEligible func(*KnownAddress) bool) []*KnownAddress {
+	eligibleBuckets := make([][]*KnownAddress, 0, TriedBucketCount)
+	for _, bucket := range tb {
+		eligibleAddresses := make([]*KnownAddress, 0, len(bucket))
+		for _, knownAddress := range bucket {
+			if isEligible(knownAddress) {
+				eligibleAddresses = append(eligibleAddresses, knownAddress)
+			}
+		}
+		if len(eligibleAddresses) > 0 {
+			eligibleBuckets = append(eligibleBuckets, eligibleAddresses)
+		}
+	}
+	if len(eligibleBuckets) == 0 {
+		return nil
+	}
+	randomIndex := random.Intn(len(eligibleBuckets))
+	return eligibleBuckets[randomIndex]
+}
+
 func (tb *triedAddressBucketArray) name() string {
 	return "tried"
 }
@@ -1382,40 +1497,3 @@ func (am *AddressManager) GetBestLocalAddress(remoteAddress *domainmessage.NetAd
 
 	return bestAddress
 }
-
-// Ban marks the given address as banned
-func (am *AddressManager) Ban(address *domainmessage.NetAddress) error {
-	return am.setBanned(address, true, mstime.Now())
-}
-
-// Unban marks the given address as not banned
-func (am *AddressManager) Unban(address *domainmessage.NetAddress) error {
-	return am.setBanned(address, false, mstime.Time{})
-}
-
-func (am *AddressManager) setBanned(address *domainmessage.NetAddress, isBanned bool, bannedTime mstime.Time) error {
-	am.localAddressesLock.Lock()
-	defer am.localAddressesLock.Unlock()
-
-	knownAddress := am.knownAddress(address)
-	if knownAddress == nil {
-		return errors.Wrapf(ErrAddressNotFound, "address %s "+
-			"is not registered with the address manager", address.TCPAddress())
-	}
-	knownAddress.isBanned = isBanned
-	knownAddress.bannedTime = bannedTime
-	return nil
-}
-
-// IsBanned returns whether the given address is banned
-func (am *AddressManager) IsBanned(address *domainmessage.NetAddress) (bool, error) {
-	am.localAddressesLock.Lock()
-	defer am.localAddressesLock.Unlock()
-
-	knownAddress := am.knownAddress(address)
-	if knownAddress == nil {
-		return false, errors.Wrapf(ErrAddressNotFound, "address %s "+
-			"is not registered with the address manager", address.TCPAddress())
-	}
-	return knownAddress.isBanned, nil
-}
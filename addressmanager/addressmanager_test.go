@@ -15,6 +15,7 @@ import (
 	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/dbaccess"
 	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/kaspanet/kaspad/util/network"
 	"github.com/kaspanet/kaspad/util/subnetworkid"
 
 	"github.com/pkg/errors"
@@ -633,3 +634,235 @@ func TestNetAddressKey(t *testing.T) {
 	}
 
 }
+
+func TestBans(t *testing.T) {
+	amgr, teardown := newAddrManagerForTest(t, "TestBans", nil)
+	defer teardown()
+
+	bannedAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 16111, 0)
+	subnetAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("10.0.1.2"), 16111, 0)
+	otherAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("10.1.0.1"), 16111, 0)
+
+	isBanned := func(address *domainmessage.NetAddress) bool {
+		isBanned, err := amgr.IsBanned(address)
+		if err != nil {
+			t.Fatalf("IsBanned: %s", err)
+		}
+		return isBanned
+	}
+
+	// Addresses that aren't known to the address manager may be banned too.
+	err := amgr.Ban(bannedAddress)
+	if err != nil {
+		t.Fatalf("Ban: %s", err)
+	}
+	_, subnet, err := net.ParseCIDR("10.0.0.0/16")
+	if err != nil {
+		t.Fatalf("ParseCIDR: %s", err)
+	}
+	amgr.BanNetwork(subnet, time.Hour)
+	if !isBanned(bannedAddress) || !isBanned(subnetAddress) || isBanned(otherAddress) {
+		t.Fatalf("IsBanned: unexpected ban state after banning")
+	}
+	if bannedNetworks := amgr.BannedNetworks(); len(bannedNetworks) != 2 {
+		t.Fatalf("BannedNetworks: expected 2 banned networks, got %d", len(bannedNetworks))
+	}
+
+	// The bans should survive a serialization round trip.
+	serializedPeersState, err := amgr.serializePeersState()
+	if err != nil {
+		t.Fatalf("serializePeersState: %s", err)
+	}
+	amgr.reset()
+	err = amgr.deserializePeersState(serializedPeersState)
+	if err != nil {
+		t.Fatalf("deserializePeersState: %s", err)
+	}
+	bannedNetworks := amgr.BannedNetworks()
+	if len(bannedNetworks) != 2 {
+		t.Fatalf("BannedNetworks: expected 2 banned networks after "+
+			"deserialization, got %d", len(bannedNetworks))
+	}
+	// Both bans might have the same ban time, so their order isn't checked
+	bannedNetworksByString := make(map[string]*BannedNetwork)
+	for _, bannedNetwork := range bannedNetworks {
+		bannedNetworksByString[bannedNetwork.Network.String()] = bannedNetwork
+	}
+	bannedAddressNetwork, ok := bannedNetworksByString["173.194.115.66/32"]
+	if !ok {
+		t.Fatalf("BannedNetworks: unexpected networks %s and %s",
+			bannedNetworks[0].Network, bannedNetworks[1].Network)
+	}
+	if _, ok := bannedNetworksByString[subnet.String()]; !ok {
+		t.Fatalf("BannedNetworks: unexpected networks %s and %s",
+			bannedNetworks[0].Network, bannedNetworks[1].Network)
+	}
+	expectedExpiry := bannedAddressNetwork.BannedTime.Add(amgr.cfg.BanDuration)
+	if bannedAddressNetwork.Expiry.UnixMilliseconds() != expectedExpiry.UnixMilliseconds() {
+		t.Errorf("BannedNetworks: expected expiry %s, got %s", expectedExpiry, bannedAddressNetwork.Expiry)
+	}
+
+	err = amgr.Unban(bannedAddress)
+	if err != nil {
+		t.Fatalf("Unban: %s", err)
+	}
+	if isBanned(bannedAddress) {
+		t.Fatalf("IsBanned: expected address to be unbanned")
+	}
+	err = amgr.Unban(bannedAddress)
+	if !errors.Is(err, ErrBanNotFound) {
+		t.Fatalf("Unban: expected ErrBanNotFound, got %v", err)
+	}
+
+	// Expired bans should be ignored and pruned.
+	amgr.BanNetwork(network.SingleIPNetwork(otherAddress.IP), -time.Second)
+	if isBanned(otherAddress) {
+		t.Fatalf("IsBanned: expected an expired ban to be ignored")
+	}
+	if bannedNetworks := amgr.BannedNetworks(); len(bannedNetworks) != 1 {
+		t.Fatalf("BannedNetworks: expected 1 banned network, got %d", len(bannedNetworks))
+	}
+
+	amgr.ClearBanned()
+	if isBanned(subnetAddress) || len(amgr.BannedNetworks()) != 0 {
+		t.Fatalf("ClearBanned: expected no banned networks")
+	}
+}
+
+func TestSubnetBans(t *testing.T) {
+	amgr, teardown := newAddrManagerForTest(t, "TestSubnetBans", nil)
+	defer teardown()
+
+	_, whitelist, err := net.ParseCIDR("173.194.1.0/24")
+	if err != nil {
+		t.Fatalf("ParseCIDR: %s", err)
+	}
+	amgr.cfg.Whitelists = []*net.IPNet{whitelist}
+
+	tests := []struct {
+		address          string
+		bannedNetwork    string
+		expectedIsBanned bool
+	}{
+		{address: "173.194.0.1", bannedNetwork: "173.194.0.0/16", expectedIsBanned: true},
+		{address: "173.195.0.1", bannedNetwork: "173.194.0.0/16", expectedIsBanned: false},
+		{address: "173.194.1.1", bannedNetwork: "173.194.0.0/16", expectedIsBanned: false}, // whitelisted
+		{address: "2001:db8::1", bannedNetwork: "2001:db8::/32", expectedIsBanned: true},
+		{address: "2001:db9::1", bannedNetwork: "2001:db8::/32", expectedIsBanned: false},
+	}
+	for _, test := range tests {
+		amgr.ClearBanned()
+		ipNetwork, err := network.ParseIPNetwork(test.bannedNetwork)
+		if err != nil {
+			t.Fatalf("ParseIPNetwork: %s", err)
+		}
+		amgr.BanNetwork(ipNetwork, time.Hour)
+
+		address := domainmessage.NewNetAddressIPPort(net.ParseIP(test.address), 16111, 0)
+		isBanned, err := amgr.IsBanned(address)
+		if err != nil {
+			t.Fatalf("IsBanned: %s", err)
+		}
+		if isBanned != test.expectedIsBanned {
+			t.Errorf("IsBanned: expected %s to be banned: %t, got %t",
+				test.address, test.expectedIsBanned, isBanned)
+		}
+	}
+
+	// Whitelisted addresses should never be banned directly.
+	whitelistedAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.1.2"), 16111, 0)
+	amgr.ClearBanned()
+	err = amgr.Ban(whitelistedAddress)
+	if err != nil {
+		t.Fatalf("Ban: %s", err)
+	}
+	if bannedNetworks := amgr.BannedNetworks(); len(bannedNetworks) != 0 {
+		t.Fatalf("Ban: expected a whitelisted address not to be banned")
+	}
+
+	// GetAddress should only return addresses that aren't banned.
+	for i := 0; i < 10; i++ {
+		err := amgr.AddAddressByIP(fmt.Sprintf("173.194.0.%d:16111", i+1), nil)
+		if err != nil {
+			t.Fatalf("AddAddressByIP: %s", err)
+		}
+	}
+	err = amgr.AddAddressByIP("173.195.0.1:16111", nil)
+	if err != nil {
+		t.Fatalf("AddAddressByIP: %s", err)
+	}
+	err = amgr.AddAddressByIP("173.194.1.1:16111", nil)
+	if err != nil {
+		t.Fatalf("AddAddressByIP: %s", err)
+	}
+	_, subnet, err := net.ParseCIDR("173.194.0.0/16")
+	if err != nil {
+		t.Fatalf("ParseCIDR: %s", err)
+	}
+	amgr.BanNetwork(subnet, time.Hour)
+	for i := 0; i < 100; i++ {
+		knownAddress := amgr.GetAddress()
+		if knownAddress == nil {
+			t.Fatalf("GetAddress: expected an address")
+		}
+		ip := knownAddress.NetAddress().IP.String()
+		if ip != "173.195.0.1" && ip != "173.194.1.1" {
+			t.Fatalf("GetAddress: got address %s in a banned network", ip)
+		}
+	}
+
+	// No address should be returned once all of them are banned.
+	amgr.cfg.Whitelists = nil
+	_, allNetwork, err := net.ParseCIDR("173.192.0.0/12")
+	if err != nil {
+		t.Fatalf("ParseCIDR: %s", err)
+	}
+	amgr.BanNetwork(allNetwork, time.Hour)
+	if knownAddress := amgr.GetAddress(); knownAddress != nil {
+		t.Fatalf("GetAddress: expected no address, got %s", knownAddress.NetAddress().IP)
+	}
+}
+
+func TestBanScores(t *testing.T) {
+	amgr, teardown := newAddrManagerForTest(t, "TestBanScores", nil)
+	defer teardown()
+
+	address := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 16111, 0)
+	reconnectedAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 16112, 0)
+	otherAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.67"), 16111, 0)
+
+	// Ban scores are kept per IP, so they should accumulate across
+	// connections from different ports of the same IP.
+	if banScore := amgr.AddBanScore(address, 32); banScore != 32 {
+		t.Fatalf("AddBanScore: expected ban score 32, got %d", banScore)
+	}
+	if banScore := amgr.AddBanScore(reconnectedAddress, 32); banScore != 64 {
+		t.Fatalf("AddBanScore: expected ban score 64 after reconnecting, got %d", banScore)
+	}
+	if banScore := amgr.BanScore(address); banScore != 64 {
+		t.Fatalf("BanScore: expected ban score 64, got %d", banScore)
+	}
+	if banScore := amgr.BanScore(otherAddress); banScore != 0 {
+		t.Fatalf("BanScore: expected ban score 0 for another IP, got %d", banScore)
+	}
+
+	// Ban scores should decay to half of their value every banScoreHalfLife.
+	// Some time passes between setting the update time and reading the
+	// score, so the score is expected to be slightly less than half.
+	key := address.IP.String()
+	amgr.banScores[key].lastUpdate = mstime.Now().Add(-banScoreHalfLife)
+	if banScore := amgr.BanScore(address); banScore < 31 || banScore > 32 {
+		t.Fatalf("BanScore: expected ban score of about 32 after "+
+			"one half-life, got %d", banScore)
+	}
+
+	// Ban scores that decayed to 0 should be removed.
+	amgr.banScores[key].lastUpdate = mstime.Now().Add(-10 * banScoreHalfLife)
+	amgr.AddBanScore(otherAddress, 1)
+	if _, ok := amgr.banScores[key]; ok {
+		t.Fatalf("AddBanScore: expected the decayed ban score of %s to be removed", key)
+	}
+	if banScore := amgr.BanScore(address); banScore != 0 {
+		t.Fatalf("BanScore: expected ban score 0 after decaying, got %d", banScore)
+	}
+}
@@ -0,0 +1,199 @@
+package addressmanager
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/kaspanet/kaspad/util/network"
+	"github.com/pkg/errors"
+)
+
+// ErrBanNotFound is an error returned from UnbanNetwork when
+// the given network is not banned.
+var ErrBanNotFound = errors.New("ban not found")
+
+// BannedNetwork is an IP network whose addresses are banned.
+type BannedNetwork struct {
+	Network    *net.IPNet
+	BannedTime mstime.Time
+	Expiry     mstime.Time
+}
+
+type serializedBannedNetwork struct {
+	Network    string
+	BannedTime int64
+	Expiry     int64
+}
+
+// Ban bans the IP of the given address for the configured ban duration.
+// Whitelisted addresses are never banned.
+func (am *AddressManager) Ban(address *domainmessage.NetAddress) error {
+	if am.IsWhitelisted(address.IP) {
+		log.Debugf("Not banning whitelisted address %s", address.IP)
+		return nil
+	}
+	am.BanNetwork(network.SingleIPNetwork(address.IP), am.cfg.BanDuration)
+	return nil
+}
+
+// Unban lifts the ban of the IP of the given address.
+func (am *AddressManager) Unban(address *domainmessage.NetAddress) error {
+	return am.UnbanNetwork(network.SingleIPNetwork(address.IP))
+}
+
+// IsBanned returns whether the IP of the given address is in a banned
+// network. Whitelisted addresses are never considered banned, even if
+// they're in a banned network.
+func (am *AddressManager) IsBanned(address *domainmessage.NetAddress) (bool, error) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	return am.isBanned(address.IP), nil
+}
+
+// isBanned returns whether the given IP is in a banned network and
+// isn't whitelisted.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) isBanned(ip net.IP) bool {
+	if am.IsWhitelisted(ip) {
+		return false
+	}
+	_, ok := am.bannedNetwork(ip)
+	return ok
+}
+
+// IsWhitelisted returns whether the given IP is in one of the networks
+// whitelisted with --whitelist.
+func (am *AddressManager) IsWhitelisted(ip net.IP) bool {
+	for _, whitelist := range am.cfg.Whitelists {
+		if whitelist.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// bannedNetwork returns a banned network that contains the given IP
+// and whose ban hasn't expired yet, if there is one.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) bannedNetwork(ip net.IP) (*BannedNetwork, bool) {
+	now := mstime.Now()
+	for _, bannedNetwork := range am.bannedNetworks {
+		if bannedNetwork.Expiry.After(now) && bannedNetwork.Network.Contains(ip) {
+			return bannedNetwork, true
+		}
+	}
+	return nil, false
+}
+
+// BanNetwork bans all the addresses in the given IP network for the given
+// duration. Banning a network that is already banned replaces its ban.
+func (am *AddressManager) BanNetwork(ipNetwork *net.IPNet, duration time.Duration) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	now := mstime.Now()
+	am.bannedNetworks[ipNetwork.String()] = &BannedNetwork{
+		Network:    ipNetwork,
+		BannedTime: now,
+		Expiry:     now.Add(duration),
+	}
+}
+
+// UnbanNetwork lifts the ban of the given IP network. It returns
+// ErrBanNotFound if the network isn't banned.
+func (am *AddressManager) UnbanNetwork(ipNetwork *net.IPNet) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.removeExpiredBans()
+	key := ipNetwork.String()
+	if _, ok := am.bannedNetworks[key]; !ok {
+		return errors.Wrapf(ErrBanNotFound, "network %s is not banned", key)
+	}
+	delete(am.bannedNetworks, key)
+	return nil
+}
+
+// ClearBanned lifts all the bans.
+func (am *AddressManager) ClearBanned() {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.bannedNetworks = make(map[string]*BannedNetwork)
+}
+
+// BannedNetworks returns all the banned IP networks whose bans haven't
+// expired yet, ordered by the time they were banned.
+func (am *AddressManager) BannedNetworks() []*BannedNetwork {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.removeExpiredBans()
+	bannedNetworks := make([]*BannedNetwork, 0, len(am.bannedNetworks))
+	for _, bannedNetwork := range am.bannedNetworks {
+		bannedNetworkCopy := *bannedNetwork
+		bannedNetworks = append(bannedNetworks, &bannedNetworkCopy)
+	}
+	sort.Slice(bannedNetworks, func(i, j int) bool {
+		if bannedNetworks[i].BannedTime.UnixMilliseconds() == bannedNetworks[j].BannedTime.UnixMilliseconds() {
+			return bannedNetworks[i].Network.String() < bannedNetworks[j].Network.String()
+		}
+		return bannedNetworks[i].BannedTime.Before(bannedNetworks[j].BannedTime)
+	})
+	return bannedNetworks
+}
+
+// removeExpiredBans removes the bans that have expired.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) removeExpiredBans() {
+	now := mstime.Now()
+	for key, bannedNetwork := range am.bannedNetworks {
+		if !bannedNetwork.Expiry.After(now) {
+			delete(am.bannedNetworks, key)
+		}
+	}
+}
+
+// serializeBannedNetworks returns the serialized form of the bans
+// that haven't expired yet.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) serializeBannedNetworks() []*serializedBannedNetwork {
+	am.removeExpiredBans()
+	serialized := make([]*serializedBannedNetwork, 0, len(am.bannedNetworks))
+	for key, bannedNetwork := range am.bannedNetworks {
+		serialized = append(serialized, &serializedBannedNetwork{
+			Network:    key,
+			BannedTime: bannedNetwork.BannedTime.UnixMilliseconds(),
+			Expiry:     bannedNetwork.Expiry.UnixMilliseconds(),
+		})
+	}
+	return serialized
+}
+
+// deserializeBannedNetworks restores the passed serialized bans.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) deserializeBannedNetworks(serialized []*serializedBannedNetwork) error {
+	for _, serializedBannedNetwork := range serialized {
+		_, ipNetwork, err := net.ParseCIDR(serializedBannedNetwork.Network)
+		if err != nil {
+			return errors.Errorf("failed to deserialize banned network "+
+				"%s: %s", serializedBannedNetwork.Network, err)
+		}
+		am.bannedNetworks[ipNetwork.String()] = &BannedNetwork{
+			Network:    ipNetwork,
+			BannedTime: mstime.UnixMilliseconds(serializedBannedNetwork.BannedTime),
+			Expiry:     mstime.UnixMilliseconds(serializedBannedNetwork.Expiry),
+		}
+	}
+	am.removeExpiredBans()
+	return nil
+}
@@ -0,0 +1,76 @@
+package addressmanager
+
+import (
+	"math"
+	"net"
+	"time"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// banScoreHalfLife is the time it takes for the ban score of an IP
+// to decay to half of its value.
+const banScoreHalfLife = 10 * time.Minute
+
+// banScore is the ban score of an IP, as it was when it was last updated.
+type banScore struct {
+	score      float64
+	lastUpdate mstime.Time
+}
+
+// decayed returns the score after it decayed since it was last updated.
+func (s *banScore) decayed(now mstime.Time) float64 {
+	halfLives := float64(now.Sub(s.lastUpdate)) / float64(banScoreHalfLife)
+	return s.score / math.Pow(2, halfLives)
+}
+
+// AddBanScore adds the passed score to the ban score of the IP of the
+// given address, and returns the updated ban score. Ban scores are kept
+// per IP rather than per connection, so that misbehaving peers can't
+// reset their ban score by reconnecting.
+func (am *AddressManager) AddBanScore(address *domainmessage.NetAddress, score uint32) uint32 {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	now := mstime.Now()
+	am.removeDecayedBanScores(now)
+	key := address.IP.String()
+	updatedScore := float64(score)
+	if currentScore, ok := am.banScores[key]; ok {
+		updatedScore += currentScore.decayed(now)
+	}
+	am.banScores[key] = &banScore{score: updatedScore, lastUpdate: now}
+	return uint32(updatedScore)
+}
+
+// BanScore returns the current ban score of the IP of the given address.
+func (am *AddressManager) BanScore(address *domainmessage.NetAddress) uint32 {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	return am.banScore(address.IP, mstime.Now())
+}
+
+// banScore returns the ban score of the given IP at the given time.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) banScore(ip net.IP, now mstime.Time) uint32 {
+	score, ok := am.banScores[ip.String()]
+	if !ok {
+		return 0
+	}
+	return uint32(score.decayed(now))
+}
+
+// removeDecayedBanScores removes the ban scores that decayed to 0, so
+// that the ban scores of IPs that stopped misbehaving don't accumulate.
+//
+// This function MUST be called with the address manager lock held.
+func (am *AddressManager) removeDecayedBanScores(now mstime.Time) {
+	for key, score := range am.banScores {
+		if score.decayed(now) < 1 {
+			delete(am.banScores, key)
+		}
+	}
+}
@@ -24,8 +24,6 @@ type KnownAddress struct {
 	tried          bool
 	referenceCount int // reference count of new buckets
 	subnetworkID   *subnetworkid.SubnetworkID
-	isBanned       bool
-	bannedTime     mstime.Time
 }
 
 // NetAddress returns the underlying domainmessage.NetAddress associated with the
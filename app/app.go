@@ -1,7 +1,9 @@
 package app
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"sync/atomic"
 
 	"github.com/kaspanet/kaspad/addressmanager"
@@ -23,7 +25,10 @@ import (
 	"github.com/kaspanet/kaspad/signal"
 	"github.com/kaspanet/kaspad/txscript"
 	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/metrics"
 	"github.com/kaspanet/kaspad/util/panics"
+	"github.com/pkg/errors"
 )
 
 // App is a wrapper for all the kaspad services
@@ -34,6 +39,10 @@ type App struct {
 	protocolManager   *protocol.Manager
 	connectionManager *connmanager.ConnectionManager
 	netAdapter        *netadapter.NetAdapter
+	txMempool         *mempool.TxPool
+	feeEstimator      *mempool.FeeEstimator
+	databaseContext   *dbaccess.DatabaseContext
+	metricsRegistry   *metrics.Registry
 
 	started, shutdown int32
 }
@@ -52,6 +61,8 @@ func (a *App) Start() {
 		panics.Exit(log, fmt.Sprintf("Error starting the p2p protocol: %+v", err))
 	}
 
+	a.maybeLoadMempool()
+
 	a.maybeSeedFromDNS()
 
 	a.connectionManager.Start()
@@ -59,6 +70,10 @@ func (a *App) Start() {
 	if !a.cfg.DisableRPC {
 		a.rpcServer.Start()
 	}
+
+	if a.cfg.Metrics != "" {
+		metrics.Start(a.cfg.Metrics, a.metricsRegistry, log)
+	}
 }
 
 // Stop gracefully shuts down all the kaspad services.
@@ -86,14 +101,65 @@ func (a *App) Stop() error {
 		}
 	}
 
+	err = a.saveFeeEstimator()
+	if err != nil {
+		log.Errorf("Error saving the fee estimator: %+v", err)
+	}
+
+	err = a.maybeSaveMempool()
+	if err != nil {
+		log.Errorf("Error saving the mempool: %+v", err)
+	}
+
 	return nil
 }
 
+// maybeLoadMempool loads the transactions that were saved to the data
+// directory on the last shutdown back into the mempool, unless mempool
+// persistence is disabled. Since every transaction is revalidated, this is
+// done in the background.
+func (a *App) maybeLoadMempool() {
+	if a.cfg.NoPersistMempool {
+		return
+	}
+	spawn("maybeLoadMempool", func() {
+		err := a.txMempool.LoadFromFile(mempool.DumpFilePath(a.cfg.DataDir))
+		if err != nil {
+			log.Errorf("Error loading the mempool: %+v", err)
+		}
+	})
+}
+
+// maybeSaveMempool saves the transactions in the mempool to the data
+// directory, so that they survive restarts, unless mempool persistence is
+// disabled. The mempool isn't saved if it hadn't finished loading, in order
+// not to overwrite the transactions that weren't loaded yet.
+func (a *App) maybeSaveMempool() error {
+	if a.cfg.NoPersistMempool {
+		return nil
+	}
+	if !a.txMempool.LoadStatus().IsLoaded {
+		log.Warnf("Not saving the mempool since it hadn't finished loading")
+		return nil
+	}
+	return a.txMempool.SaveToFile(mempool.DumpFilePath(a.cfg.DataDir))
+}
+
+// saveFeeEstimator stores the fee estimator in the database, so that
+// the fee estimates survive restarts.
+func (a *App) saveFeeEstimator() error {
+	serializedFeeEstimator, err := a.feeEstimator.Serialize()
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreFeeEstimator(a.databaseContext, serializedFeeEstimator)
+}
+
 // New returns a new App instance configured to listen on addr for the
 // kaspa network type specified by dagParams. Use start to begin accepting
 // connections from peers.
 func New(cfg *config.Config, databaseContext *dbaccess.DatabaseContext, interrupt <-chan struct{}) (*App, error) {
-	indexManager, acceptanceIndex := setupIndexes(cfg)
+	indexManager, acceptanceIndex, utxoIndex, txIndex, addrIndex := setupIndexes(cfg)
 
 	sigCache := txscript.NewSigCache(cfg.SigCacheMaxSize)
 
@@ -103,7 +169,12 @@ func New(cfg *config.Config, databaseContext *dbaccess.DatabaseContext, interrup
 		return nil, err
 	}
 
-	txMempool := setupMempool(cfg, dag, sigCache)
+	feeEstimator, err := setupFeeEstimator(databaseContext)
+	if err != nil {
+		return nil, err
+	}
+
+	txMempool := setupMempool(cfg, dag, sigCache, feeEstimator)
 
 	netAdapter, err := netadapter.NewNetAdapter(cfg)
 	if err != nil {
@@ -121,7 +192,8 @@ func New(cfg *config.Config, databaseContext *dbaccess.DatabaseContext, interrup
 		return nil, err
 	}
 	rpcServer, err := setupRPC(
-		cfg, dag, txMempool, sigCache, acceptanceIndex, connectionManager, addressManager, protocolManager)
+		cfg, dag, txMempool, feeEstimator, sigCache, acceptanceIndex, utxoIndex, txIndex, addrIndex, connectionManager, addressManager,
+		protocolManager)
 	if err != nil {
 		return nil, err
 	}
@@ -133,6 +205,10 @@ func New(cfg *config.Config, databaseContext *dbaccess.DatabaseContext, interrup
 		connectionManager: connectionManager,
 		netAdapter:        netAdapter,
 		addressManager:    addressManager,
+		txMempool:         txMempool,
+		feeEstimator:      feeEstimator,
+		databaseContext:   databaseContext,
+		metricsRegistry:   setupMetrics(dag, txMempool, protocolManager),
 	}, nil
 }
 
@@ -158,11 +234,50 @@ func setupDAG(cfg *config.Config, databaseContext *dbaccess.DatabaseContext, int
 		SigCache:        sigCache,
 		IndexManager:    indexManager,
 		SubnetworkID:    cfg.SubnetworkID,
+		Prune:           cfg.Prune,
 	})
-	return dag, err
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ImportUTXOSnapshot != "" {
+		err := importUTXOSnapshot(dag, cfg.ImportUTXOSnapshot, cfg.UTXOSnapshotHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dag, nil
 }
 
-func setupIndexes(cfg *config.Config) (blockdag.IndexManager, *indexers.AcceptanceIndex) {
+// importUTXOSnapshot bootstraps the DAG from the UTXO snapshot in the
+// given file, whose snapshot block must be of the given hash. The snapshot
+// is only imported into an empty database, so that kaspad could be
+// restarted with the same configuration.
+func importUTXOSnapshot(dag *blockdag.BlockDAG, snapshotPath string, snapshotHash *daghash.Hash) error {
+	if dag.BlockCount() > 1 {
+		log.Infof("Skipping the import of the UTXO snapshot %s because the database is not empty",
+			snapshotPath)
+		return nil
+	}
+
+	snapshotFile, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer snapshotFile.Close()
+
+	log.Infof("Importing the UTXO snapshot %s", snapshotPath)
+	err = dag.ImportUTXOSnapshot(bufio.NewReader(snapshotFile), snapshotHash, blockdag.BFNone)
+	if err != nil {
+		return errors.Wrapf(err, "failed to import the UTXO snapshot %s", snapshotPath)
+	}
+	log.Infof("Imported the UTXO snapshot of block %s", dag.SelectedTipHash())
+	return nil
+}
+
+func setupIndexes(cfg *config.Config) (blockdag.IndexManager, *indexers.AcceptanceIndex,
+	*indexers.UTXOIndex, *indexers.TxIndex, *indexers.AddrIndex) {
+
 	// Create indexes if needed.
 	var indexes []indexers.Indexer
 	var acceptanceIndex *indexers.AcceptanceIndex
@@ -171,23 +286,64 @@ func setupIndexes(cfg *config.Config) (blockdag.IndexManager, *indexers.Acceptan
 		acceptanceIndex = indexers.NewAcceptanceIndex()
 		indexes = append(indexes, acceptanceIndex)
 	}
+	var utxoIndex *indexers.UTXOIndex
+	if cfg.UTXOIndex {
+		log.Info("UTXO index is enabled")
+		utxoIndex = indexers.NewUTXOIndex()
+		indexes = append(indexes, utxoIndex)
+	}
+	var txIndex *indexers.TxIndex
+	if cfg.TxIndex {
+		log.Info("transaction index is enabled")
+		txIndex = indexers.NewTxIndex()
+		indexes = append(indexes, txIndex)
+	}
+	var addrIndex *indexers.AddrIndex
+	if cfg.AddrIndex {
+		log.Info("address index is enabled")
+		addrIndex = indexers.NewAddrIndex()
+		indexes = append(indexes, addrIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	if len(indexes) < 0 {
-		return nil, nil
+		return nil, nil, nil, nil, nil
 	}
 	indexManager := indexers.NewManager(indexes)
-	return indexManager, acceptanceIndex
+	return indexManager, acceptanceIndex, utxoIndex, txIndex, addrIndex
 }
 
-func setupMempool(cfg *config.Config, dag *blockdag.BlockDAG, sigCache *txscript.SigCache) *mempool.TxPool {
+// setupFeeEstimator restores the fee estimator that was stored in the
+// database on the last shutdown, or creates a new one if there's none.
+func setupFeeEstimator(databaseContext *dbaccess.DatabaseContext) (*mempool.FeeEstimator, error) {
+	serializedFeeEstimator, err := dbaccess.FetchFeeEstimator(databaseContext)
+	if dbaccess.IsNotFoundError(err) {
+		return mempool.NewFeeEstimator(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	feeEstimator, err := mempool.RestoreFeeEstimator(serializedFeeEstimator)
+	if err != nil {
+		log.Warnf("Discarding the stored fee estimator: %s", err)
+		return mempool.NewFeeEstimator(), nil
+	}
+	return feeEstimator, nil
+}
+
+func setupMempool(cfg *config.Config, dag *blockdag.BlockDAG, sigCache *txscript.SigCache,
+	feeEstimator *mempool.FeeEstimator) *mempool.TxPool {
+
 	mempoolConfig := mempool.Config{
 		Policy: mempool.Policy{
-			AcceptNonStd:    cfg.RelayNonStd,
-			MaxOrphanTxs:    cfg.MaxOrphanTxs,
-			MaxOrphanTxSize: config.DefaultMaxOrphanTxSize,
-			MinRelayTxFee:   cfg.MinRelayTxFee,
-			MaxTxVersion:    1,
+			AcceptNonStd:            cfg.RelayNonStd,
+			MaxOrphanTxs:            cfg.MaxOrphanTxs,
+			MaxOrphanTxSize:         config.DefaultMaxOrphanTxSize,
+			MinRelayTxFee:           cfg.MinRelayTxFee,
+			MaxPoolMass:             cfg.MaxMempoolMass,
+			MempoolExpiry:           cfg.MempoolExpiry,
+			MaxTxVersion:            1,
+			MaxReplacementEvictions: mempool.DefaultMaxReplacementEvictions,
 		},
 		CalcSequenceLockNoLock: func(tx *util.Tx, utxoSet blockdag.UTXOSet) (*blockdag.SequenceLock, error) {
 			return dag.CalcSequenceLockNoLock(tx, utxoSet, true)
@@ -195,6 +351,7 @@ func setupMempool(cfg *config.Config, dag *blockdag.BlockDAG, sigCache *txscript
 		IsDeploymentActive: dag.IsDeploymentActive,
 		SigCache:           sigCache,
 		DAG:                dag,
+		FeeEstimator:       feeEstimator,
 	}
 
 	return mempool.New(&mempoolConfig)
@@ -203,8 +360,12 @@ func setupMempool(cfg *config.Config, dag *blockdag.BlockDAG, sigCache *txscript
 func setupRPC(cfg *config.Config,
 	dag *blockdag.BlockDAG,
 	txMempool *mempool.TxPool,
+	feeEstimator *mempool.FeeEstimator,
 	sigCache *txscript.SigCache,
 	acceptanceIndex *indexers.AcceptanceIndex,
+	utxoIndex *indexers.UTXOIndex,
+	txIndex *indexers.TxIndex,
+	addrIndex *indexers.AddrIndex,
 	connectionManager *connmanager.ConnectionManager,
 	addressManager *addressmanager.AddressManager,
 	protocolManager *protocol.Manager) (*rpc.Server, error) {
@@ -215,7 +376,7 @@ func setupRPC(cfg *config.Config,
 		}
 		blockTemplateGenerator := mining.NewBlkTmplGenerator(&policy, txMempool, dag, sigCache)
 
-		rpcServer, err := rpc.NewRPCServer(cfg, dag, txMempool, acceptanceIndex, blockTemplateGenerator,
+		rpcServer, err := rpc.NewRPCServer(cfg, dag, txMempool, feeEstimator, acceptanceIndex, utxoIndex, txIndex, addrIndex, blockTemplateGenerator,
 			connectionManager, addressManager, protocolManager)
 		if err != nil {
 			return nil, err
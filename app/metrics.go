@@ -0,0 +1,68 @@
+package app
+
+import (
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/protocol"
+	"github.com/kaspanet/kaspad/rpc"
+	"github.com/kaspanet/kaspad/util/metrics"
+)
+
+// setupMetrics returns a registry of the metrics that are exported
+// by the metrics server.
+func setupMetrics(dag *blockdag.BlockDAG, txMempool *mempool.TxPool,
+	protocolManager *protocol.Manager) *metrics.Registry {
+
+	registry := metrics.NewRegistry()
+
+	registry.RegisterGauge("kaspad_dag_block_count", "The number of blocks in the DAG",
+		func() float64 { return float64(dag.BlockCount()) })
+	registry.RegisterGauge("kaspad_dag_virtual_blue_score", "The blue score of the virtual block",
+		func() float64 { return float64(dag.VirtualBlueScore()) })
+	registry.RegisterGauge("kaspad_dag_tip_count", "The number of tips of the DAG",
+		func() float64 { return float64(len(dag.TipHashes())) })
+	registry.RegisterGauge("kaspad_dag_orphan_block_count", "The number of orphan blocks",
+		func() float64 { return float64(dag.OrphanCount()) })
+	registry.RegisterGauge("kaspad_dag_delayed_block_count",
+		"The number of blocks whose processing is delayed since their timestamps are in the future",
+		func() float64 { return float64(dag.DelayedBlockCount()) })
+	registry.RegisterGauge("kaspad_dag_sync_rate", "The number of blocks processed per second in the last 15 minutes",
+		dag.SyncRate)
+
+	registry.RegisterGauge("kaspad_mempool_transaction_count", "The number of transactions in the mempool",
+		func() float64 { return float64(txMempool.Count()) })
+	registry.RegisterGauge("kaspad_mempool_orphan_count", "The number of orphan transactions in the mempool",
+		func() float64 { return float64(txMempool.OrphanCount()) })
+	registry.RegisterGauge("kaspad_mempool_mass", "The total mass of the transactions in the mempool",
+		func() float64 { return float64(txMempool.TotalMass()) })
+
+	registry.RegisterGauge("kaspad_peers_inbound", "The number of connected inbound peers",
+		func() float64 { return float64(countPeers(protocolManager, false)) })
+	registry.RegisterGauge("kaspad_peers_outbound", "The number of connected outbound peers",
+		func() float64 { return float64(countPeers(protocolManager, true)) })
+	registry.RegisterGauge("kaspad_ibd_running", "1 if initial block download is running, 0 otherwise",
+		func() float64 {
+			if protocolManager.IBDPeer() != nil {
+				return 1
+			}
+			return 0
+		})
+
+	registry.RegisterSummary(rpc.RequestDurations)
+	registry.RegisterSummary(dbaccess.WriteDurations)
+
+	return registry
+}
+
+// countPeers returns the number of connected peers that are outbound if
+// isOutbound is true, or inbound otherwise.
+func countPeers(protocolManager *protocol.Manager, isOutbound bool) int {
+	count := 0
+	for _, peer := range protocolManager.Peers() {
+		if peer.IsOutbound() == isOutbound {
+			count++
+		}
+	}
+	return count
+}
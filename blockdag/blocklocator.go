@@ -68,9 +68,11 @@ func (dag *BlockDAG) blockLocator(highNode, lowNode *blockNode) (BlockLocator, e
 
 		// Calculate blueScore of previous node to include ensuring the
 		// final node is lowNode.
-		nextBlueScore := node.blueScore - step
-		if nextBlueScore < lowNode.blueScore {
-			nextBlueScore = lowNode.blueScore
+		// Note that the blue score of the node is greater than the blue
+		// score of the low node, so the subtraction can't underflow.
+		nextBlueScore := lowNode.blueScore
+		if node.blueScore-lowNode.blueScore > step {
+			nextBlueScore = node.blueScore - step
 		}
 
 		// walk backwards through the nodes to the correct ancestor.
@@ -0,0 +1,63 @@
+package blockdag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestBlockLocatorFromHashes(t *testing.T) {
+	dag := newTestDAG(&dagconfig.SimnetParams)
+
+	// Build a chain of 20 blocks on top of the genesis block, so that the
+	// blue score of every block equals its index in the chain.
+	chain := []*blockNode{dag.genesis}
+	blockTime := dag.genesis.Header().Timestamp
+	for i := 0; i < 20; i++ {
+		blockTime = blockTime.Add(time.Second)
+		node := newTestNode(dag, blockSetFromSlice(chain[len(chain)-1]), int32(0x10000000), 0, blockTime)
+		dag.index.AddNode(node)
+		chain = append(chain, node)
+	}
+
+	tests := []struct {
+		name              string
+		high, low         int
+		expectedBlueScore []int
+	}{
+		{
+			name:              "from the tip to the genesis",
+			high:              20,
+			low:               0,
+			expectedBlueScore: []int{19, 18, 16, 12, 4, 0},
+		},
+		{
+			name:              "from the tip to a block in the middle",
+			high:              20,
+			low:               10,
+			expectedBlueScore: []int{19, 18, 16, 12, 10},
+		},
+		{
+			name:              "between adjacent blocks",
+			high:              5,
+			low:               4,
+			expectedBlueScore: []int{4},
+		},
+	}
+
+	for _, test := range tests {
+		locator, err := dag.BlockLocatorFromHashes(chain[test.high].hash, chain[test.low].hash)
+		if err != nil {
+			t.Fatalf("%s: BlockLocatorFromHashes: %s", test.name, err)
+		}
+		expectedLocator := make(BlockLocator, len(test.expectedBlueScore))
+		for i, blueScore := range test.expectedBlueScore {
+			expectedLocator[i] = chain[blueScore].hash
+		}
+		if !daghash.AreEqual(locator, expectedLocator) {
+			t.Errorf("%s: expected locator %s, got %s", test.name, expectedLocator, locator)
+		}
+	}
+}
@@ -107,7 +107,22 @@ type blockNode struct {
 // selectedParentAnticone is used to update reachability data we store for future reachability queries.
 // This function is NOT safe for concurrent access.
 func (dag *BlockDAG) newBlockNode(blockHeader *domainmessage.BlockHeader, parents blockSet) (node *blockNode, selectedParentAnticone []*blockNode) {
-	node = &blockNode{
+	node = dag.initBlockNode(blockHeader, parents)
+	if node.isGenesis() {
+		return node, nil
+	}
+
+	selectedParentAnticone, err := dag.ghostdag(node)
+	if err != nil {
+		panic(errors.Wrap(err, "unexpected error in GHOSTDAG"))
+	}
+	return node, selectedParentAnticone
+}
+
+// initBlockNode returns a new block node for the given block header and parents,
+// without running GHOSTDAG on it.
+func (dag *BlockDAG) initBlockNode(blockHeader *domainmessage.BlockHeader, parents blockSet) *blockNode {
+	node := &blockNode{
 		parents:            parents,
 		children:           make(blockSet),
 		blueScore:          math.MaxUint64, // Initialized to the max value to avoid collisions with the genesis block
@@ -132,14 +147,8 @@ func (dag *BlockDAG) newBlockNode(blockHeader *domainmessage.BlockHeader, parent
 	if len(parents) == 0 {
 		// The genesis block is defined to have a blueScore of 0
 		node.blueScore = 0
-		return node, nil
-	}
-
-	selectedParentAnticone, err := dag.ghostdag(node)
-	if err != nil {
-		panic(errors.Wrap(err, "unexpected error in GHOSTDAG"))
 	}
-	return node, selectedParentAnticone
+	return node
 }
 
 // updateParentsChildren updates the node's parents to point to new node
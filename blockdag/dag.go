@@ -72,6 +72,15 @@ type BlockDAG struct {
 	indexManager    IndexManager
 	genesis         *blockNode
 
+	// prune specifies whether the data of blocks that are deep below
+	// the finality point is removed from the database.
+	prune bool
+
+	// isPruning is set while blocks are pruned and the block store is
+	// compacted in the background, so that only one such run happens
+	// at a time. It's protected by the DAG lock.
+	isPruning bool
+
 	// The following fields are calculated based upon the provided DAG
 	// parameters. They are also set when the instance is created and
 	// can't be changed afterwards, so there is no need to protect them with
@@ -186,6 +195,7 @@ func New(config *Config) (*BlockDAG, error) {
 		timeSource:                     config.TimeSource,
 		sigCache:                       config.SigCache,
 		indexManager:                   config.IndexManager,
+		prune:                          config.Prune,
 		difficultyAdjustmentWindowSize: params.DifficultyAdjustmentWindowSize,
 		TimestampDeviationTolerance:    params.TimestampDeviationTolerance,
 		powMaxBits:                     util.BigToCompact(params.PowMax),
@@ -217,7 +227,7 @@ func New(config *Config) (*BlockDAG, error) {
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
-		err = config.IndexManager.Init(dag, dag.databaseContext)
+		err = config.IndexManager.Init(dag, dag.databaseContext, config.Interrupt)
 		if err != nil {
 			return nil, err
 		}
@@ -255,6 +265,18 @@ func New(config *Config) (*BlockDAG, error) {
 		return nil, err
 	}
 
+	// Prune the blocks that are deep enough below the finality
+	// point, in case the database was just now switched to pruning
+	// mode or the node was shut down before they were pruned.
+	// This is done after the indexes are caught up, since catching
+	// them up requires the data of the blocks.
+	if dag.prune {
+		err = dag.pruneBlocks()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	selectedTip := dag.selectedTip()
 	log.Infof("DAG state (blue score %d, hash %s)",
 		selectedTip.blueScore, selectedTip.hash)
@@ -307,6 +329,16 @@ func (dag *BlockDAG) IsKnownOrphan(hash *daghash.Hash) bool {
 	return exists
 }
 
+// OrphanCount returns the number of orphan blocks that are currently held.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) OrphanCount() int {
+	dag.orphanLock.RLock()
+	defer dag.orphanLock.RUnlock()
+
+	return len(dag.orphans)
+}
+
 // IsKnownInvalid returns whether the passed hash is known to be an invalid block.
 // Note that if the block is not found this method will return false.
 //
@@ -817,6 +849,7 @@ func (dag *BlockDAG) saveChangesFromBlock(block *util.Block, virtualUTXODiff *UT
 		TipHashes:         dag.TipHashes(),
 		LastFinalityPoint: dag.lastFinalityPoint.hash,
 		LocalSubnetworkID: dag.subnetworkID,
+		IsPruned:          dag.prune,
 	}
 	err = saveDAGState(dbTx, state)
 	if err != nil {
@@ -986,6 +1019,12 @@ func (dag *BlockDAG) updateFinalityPoint() {
 	spawn("dag.finalizeNodesBelowFinalityPoint", func() {
 		dag.finalizeNodesBelowFinalityPoint(true)
 	})
+	// A run that's still in progress doesn't reach the blocks that were
+	// just finalized, but they're pruned on the next finality point.
+	if dag.prune && !dag.isPruning {
+		dag.isPruning = true
+		spawn("dag.pruneAndCompactBlocks", dag.pruneAndCompactBlocks)
+	}
 }
 
 func (dag *BlockDAG) finalizeNodesBelowFinalityPoint(deleteDiffData bool) {
@@ -1578,6 +1617,94 @@ func (dag *BlockDAG) BlockConfirmationsByHashNoLock(hash *daghash.Hash) (uint64,
 	return dag.blockConfirmations(node)
 }
 
+// AcceptingBlockHash returns the hash of the selected parent chain block
+// that accepted the block of the given hash. It returns nil if the block
+// had not been accepted by any chain block, which is the case for red
+// blocks and blocks in the anticone of the selected tip.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) AcceptingBlockHash(hash *daghash.Hash) (*daghash.Hash, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	node, ok := dag.index.LookupNode(hash)
+	if !ok {
+		return nil, errors.Errorf("block %s is unknown", hash)
+	}
+
+	acceptingBlock, err := dag.acceptingBlock(node)
+	if err != nil {
+		return nil, err
+	}
+	if acceptingBlock == nil {
+		return nil, nil
+	}
+	return acceptingBlock.hash, nil
+}
+
+// TxAcceptingBlockHash returns the hash of the selected parent chain block
+// that accepted the transaction of the given ID, which is in the block of
+// the given hash. Unlike AcceptingBlockHash, it returns nil if the chain
+// block that accepted the block didn't accept the transaction itself, e.g.
+// because it double spends a transaction from a parallel block. Note that
+// if the transaction is also in another block that the same chain block
+// accepted, it's enough for one of them to be accepted.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) TxAcceptingBlockHash(txID *daghash.TxID, blockHash *daghash.Hash) (*daghash.Hash, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	node, ok := dag.index.LookupNode(blockHash)
+	if !ok {
+		return nil, errors.Errorf("block %s is unknown", blockHash)
+	}
+
+	acceptingBlock, err := dag.acceptingBlock(node)
+	if err != nil {
+		return nil, err
+	}
+	if acceptingBlock == nil {
+		return nil, nil
+	}
+
+	_, _, txsAcceptanceData, err := dag.pastUTXO(acceptingBlock)
+	if err != nil {
+		return nil, err
+	}
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if txAcceptanceData.IsAccepted && txAcceptanceData.Tx.ID().IsEqual(txID) {
+				return acceptingBlock.hash, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// AcceptingBlockConfirmations returns the confirmations of the transactions
+// that were accepted by the selected parent chain block of the given hash.
+// It returns 0 if the block is no longer in the selected parent chain.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) AcceptingBlockConfirmations(acceptingBlockHash *daghash.Hash) (uint64, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	node, ok := dag.index.LookupNode(acceptingBlockHash)
+	if !ok {
+		return 0, errors.Errorf("block %s is unknown", acceptingBlockHash)
+	}
+	isInSelectedParentChain, err := dag.IsInSelectedParentChain(acceptingBlockHash)
+	if err != nil {
+		return 0, err
+	}
+	if !isInSelectedParentChain {
+		return 0, nil
+	}
+	return dag.selectedTip().blueScore - node.blueScore + 1, nil
+}
+
 // UTXOConfirmations returns the confirmations for the given outpoint, if it exists
 // in the DAG's UTXO set.
 //
@@ -1932,6 +2059,70 @@ func (dag *BlockDAG) AntiPastHashesBetween(lowHash, highHash *daghash.Hash, maxH
 	return hashes, nil
 }
 
+// BlockHashesNotInPastOf returns the hashes of all the blocks in the DAG
+// that are neither one of the given blocks nor in the past of any of them,
+// ordered from the lowest blue score to the highest. If no hashes are
+// given, the hashes of all the blocks in the DAG are returned.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) BlockHashesNotInPastOf(hashes []*daghash.Hash) ([]*daghash.Hash, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	lowNodes := newBlockSet()
+	for _, hash := range hashes {
+		node, ok := dag.index.LookupNode(hash)
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidParameter, "couldn't find hash %s", hash)
+		}
+		lowNodes.add(node)
+	}
+
+	// Walk down from the DAG's tips and collect every node that isn't
+	// one of the low nodes or in their past into an up-heap (a heap
+	// sorted by blueScore from lowest to greatest).
+	visited := newBlockSet()
+	candidateNodes := newUpHeap()
+	queue := newDownHeap()
+	for tip := range dag.virtual.tips() {
+		queue.Push(tip)
+	}
+	for queue.Len() > 0 {
+		current := queue.pop()
+		if visited.contains(current) {
+			continue
+		}
+		visited.add(current)
+		if lowNodes.contains(current) {
+			continue
+		}
+		isInPastOfLowNodes := false
+		for lowNode := range lowNodes {
+			isInPast, err := dag.isInPast(current, lowNode)
+			if err != nil {
+				return nil, err
+			}
+			if isInPast {
+				isInPastOfLowNodes = true
+				break
+			}
+		}
+		if isInPastOfLowNodes {
+			continue
+		}
+		candidateNodes.Push(current)
+		for parent := range current.parents {
+			queue.Push(parent)
+		}
+	}
+
+	blockHashes := make([]*daghash.Hash, candidateNodes.Len())
+	for i := range blockHashes {
+		blockHashes[i] = candidateNodes.pop().hash
+	}
+	return blockHashes, nil
+}
+
 // antiPastHeadersBetween returns the headers of the blocks between the
 // lowHash's antiPast and highHash's antiPast, or up to the provided
 // max number of block headers.
@@ -2084,11 +2275,19 @@ func (dag *BlockDAG) peekDelayedBlock() *delayedBlock {
 type IndexManager interface {
 	// Init is invoked during DAG initialize in order to allow the index
 	// manager to initialize itself and any indexes it is managing.
-	Init(*BlockDAG, *dbaccess.DatabaseContext) error
+	// The given interrupt channel may be closed in order to stop catching
+	// up indexes that lag behind the DAG.
+	Init(*BlockDAG, *dbaccess.DatabaseContext, <-chan struct{}) error
 
 	// ConnectBlock is invoked when a new block has been connected to the
 	// DAG.
 	ConnectBlock(dbContext *dbaccess.TxContext, blockHash *daghash.Hash, acceptedTxsData MultiBlockTxsAcceptanceData) error
+
+	// PruneBlocks is invoked when the data of blocks that are deep below
+	// the finality point is removed from the database, in order to allow
+	// the index manager to remove the data of the indexes that isn't
+	// needed for them anymore.
+	PruneBlocks(dbContext *dbaccess.TxContext, blockHashes []*daghash.Hash) error
 }
 
 // Config is a descriptor which specifies the blockDAG instance configuration.
@@ -2135,6 +2334,22 @@ type Config struct {
 	// DatabaseContext is the context in which all database queries related to
 	// this DAG are going to run.
 	DatabaseContext *dbaccess.DatabaseContext
+
+	// Prune specifies whether the data of blocks that are deep below the
+	// finality point is removed from the database. Once a database is
+	// pruned, the DAG can't be created from it without pruning.
+	Prune bool
+}
+
+// DelayedBlockCount returns the number of blocks whose processing is
+// delayed since their timestamps are too far in the future.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) DelayedBlockCount() int {
+	dag.RLock()
+	defer dag.RUnlock()
+
+	return len(dag.delayedBlocks)
 }
 
 func (dag *BlockDAG) isKnownDelayedBlock(hash *daghash.Hash) bool {
@@ -1268,6 +1268,102 @@ func TestDoubleSpends(t *testing.T) {
 	testProcessBlockRuleError(t, dag, blockWithDuplicateTransaction, ruleError(ErrDuplicateTx, ""))
 }
 
+// TestTxAcceptingBlockHash checks that the accepting block of a transaction
+// depends on whether the transaction itself was accepted, and not only on
+// whether its block was, by double spending a transaction in parallel blocks.
+func TestTxAcceptingBlockHash(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+	dag, teardownFunc, err := DAGSetup("TestTxAcceptingBlockHash", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup dag instance: %v", err)
+	}
+	defer teardownFunc()
+
+	fundingBlock := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	cbTx := fundingBlock.Transactions[0]
+
+	signatureScript, err := txscript.PayToScriptHashSignatureScript(OpTrueScript, nil)
+	if err != nil {
+		t.Fatalf("Failed to build signature script: %s", err)
+	}
+	txIn := &domainmessage.TxIn{
+		PreviousOutpoint: domainmessage.Outpoint{TxID: *cbTx.TxID(), Index: 0},
+		SignatureScript:  signatureScript,
+		Sequence:         domainmessage.MaxTxInSequenceNum,
+	}
+	tx := domainmessage.NewNativeMsgTx(domainmessage.TxVersion, []*domainmessage.TxIn{txIn},
+		[]*domainmessage.TxOut{{ScriptPubKey: OpTrueScript, Value: 1}})
+	doubleSpendTx := domainmessage.NewNativeMsgTx(domainmessage.TxVersion, []*domainmessage.TxIn{txIn},
+		[]*domainmessage.TxOut{{ScriptPubKey: OpTrueScript, Value: 2}})
+
+	// The transaction is in two parallel blocks, and the transaction that
+	// double spends it is in a third one. All of them are accepted by the
+	// block that merges them, but only one of the two transactions is.
+	blockWithTx := PrepareAndProcessBlockForTest(t, dag,
+		[]*daghash.Hash{fundingBlock.BlockHash()}, []*domainmessage.MsgTx{tx})
+	anotherBlockWithTx := PrepareAndProcessBlockForTest(t, dag,
+		[]*daghash.Hash{fundingBlock.BlockHash()}, []*domainmessage.MsgTx{tx})
+	blockWithDoubleSpendTx := PrepareAndProcessBlockForTest(t, dag,
+		[]*daghash.Hash{fundingBlock.BlockHash()}, []*domainmessage.MsgTx{doubleSpendTx})
+	mergingBlock := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockWithTx.BlockHash(),
+		anotherBlockWithTx.BlockHash(), blockWithDoubleSpendTx.BlockHash()}, nil)
+
+	for _, block := range []*domainmessage.MsgBlock{blockWithTx, anotherBlockWithTx, blockWithDoubleSpendTx} {
+		acceptingBlockHash, err := dag.AcceptingBlockHash(block.BlockHash())
+		if err != nil {
+			t.Fatalf("AcceptingBlockHash: %s", err)
+		}
+		if acceptingBlockHash == nil || !acceptingBlockHash.IsEqual(mergingBlock.BlockHash()) {
+			t.Fatalf("AcceptingBlockHash: expected block %s to be accepted by the merging block, "+
+				"but got %v", block.BlockHash(), acceptingBlockHash)
+		}
+	}
+
+	txAcceptingBlockHashes := make([]*daghash.Hash, 3)
+	for i, test := range []struct {
+		txID      *daghash.TxID
+		blockHash *daghash.Hash
+	}{
+		{txID: tx.TxID(), blockHash: blockWithTx.BlockHash()},
+		{txID: tx.TxID(), blockHash: anotherBlockWithTx.BlockHash()},
+		{txID: doubleSpendTx.TxID(), blockHash: blockWithDoubleSpendTx.BlockHash()},
+	} {
+		txAcceptingBlockHashes[i], err = dag.TxAcceptingBlockHash(test.txID, test.blockHash)
+		if err != nil {
+			t.Fatalf("TxAcceptingBlockHash: %s", err)
+		}
+		if txAcceptingBlockHashes[i] != nil && !txAcceptingBlockHashes[i].IsEqual(mergingBlock.BlockHash()) {
+			t.Fatalf("TxAcceptingBlockHash: expected transaction %s to be accepted by the "+
+				"merging block, but got %s", test.txID, txAcceptingBlockHashes[i])
+		}
+	}
+
+	// Both blocks that contain the transaction report the same acceptance,
+	// since it's enough for one of its copies to be accepted
+	isTxAccepted := txAcceptingBlockHashes[0] != nil
+	if (txAcceptingBlockHashes[1] != nil) != isTxAccepted {
+		t.Fatalf("TxAcceptingBlockHash: the acceptance of the transaction differs between " +
+			"the blocks that contain it")
+	}
+	isDoubleSpendTxAccepted := txAcceptingBlockHashes[2] != nil
+	if isTxAccepted == isDoubleSpendTxAccepted {
+		t.Fatalf("TxAcceptingBlockHash: expected exactly one of the double spending transactions "+
+			"to be accepted, but the acceptance of both is %t", isTxAccepted)
+	}
+
+	confirmations, err := dag.AcceptingBlockConfirmations(mergingBlock.BlockHash())
+	if err != nil {
+		t.Fatalf("AcceptingBlockConfirmations: %s", err)
+	}
+	if confirmations != 1 {
+		t.Fatalf("AcceptingBlockConfirmations: expected 1 confirmation for the selected tip, "+
+			"got %d", confirmations)
+	}
+}
+
 func TestUTXOCommitment(t *testing.T) {
 	// Create a new database and dag instance to run tests against.
 	params := dagconfig.SimnetParams
@@ -65,7 +65,7 @@ var outpointSerializeSize = daghash.TxIDSize + 4
 // term storage. This format is described in detail above.
 func deserializeOutpoint(r io.Reader) (*domainmessage.Outpoint, error) {
 	outpoint := &domainmessage.Outpoint{}
-	_, err := r.Read(outpoint.TxID[:])
+	_, err := io.ReadFull(r, outpoint.TxID[:])
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +129,7 @@ type dagState struct {
 	TipHashes         []*daghash.Hash
 	LastFinalityPoint *daghash.Hash
 	LocalSubnetworkID *subnetworkid.SubnetworkID
+	IsPruned          bool `json:",omitempty"`
 }
 
 // serializeDAGState returns the serialization of the DAG state.
@@ -168,6 +169,7 @@ func (dag *BlockDAG) createDAGState(localSubnetworkID *subnetworkid.SubnetworkID
 		TipHashes:         []*daghash.Hash{dag.Params.GenesisHash},
 		LastFinalityPoint: dag.Params.GenesisHash,
 		LocalSubnetworkID: localSubnetworkID,
+		IsPruned:          dag.prune,
 	})
 }
 
@@ -196,6 +198,11 @@ func (dag *BlockDAG) initDAGState() error {
 		return err
 	}
 
+	err = dag.validatePruning(dagState)
+	if err != nil {
+		return err
+	}
+
 	log.Debugf("Loading block index...")
 	unprocessedBlockNodes, err := dag.initBlockIndex()
 	if err != nil {
@@ -262,6 +269,16 @@ func (dag *BlockDAG) validateLocalSubnetworkID(state *dagState) error {
 	return nil
 }
 
+func (dag *BlockDAG) validatePruning(state *dagState) error {
+	if state.IsPruned && !dag.prune {
+		return errors.New("Cannot start kaspad without --prune because" +
+			" its database is already pruned. If you want to switch to" +
+			" a database that isn't pruned, please reset the database by" +
+			" starting kaspad with --reset-db flag")
+	}
+	return nil
+}
+
 func (dag *BlockDAG) initBlockIndex() (unprocessedBlockNodes []*blockNode, err error) {
 	blockIndexCursor, err := dbaccess.BlockIndexCursor(dag.databaseContext)
 	if err != nil {
@@ -610,8 +627,21 @@ func (dag *BlockDAG) BlockByHash(hash *daghash.Hash) (*util.Block, error) {
 		return nil, ErrNotInDAG(str)
 	}
 
+	// The data of blocks that were pruned or imported from a UTXO
+	// snapshot is not stored, so they're treated as if they weren't
+	// in the DAG.
+	if dag.index.NodeStatus(node)&statusDataStored == 0 {
+		str := fmt.Sprintf("the data of block %s is not stored", hash)
+		return nil, ErrNotInDAG(str)
+	}
+
 	block, err := dag.fetchBlockByHash(node.hash)
 	if err != nil {
+		// The block might have been pruned since its status was checked
+		if dbaccess.IsNotFoundError(err) {
+			str := fmt.Sprintf("the data of block %s is not stored", hash)
+			return nil, ErrNotInDAG(str)
+		}
 		return nil, err
 	}
 	return block, err
@@ -26,10 +26,22 @@ import (
 //
 // For further details see the article https://eprint.iacr.org/2018/104.pdf
 func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blockNode, err error) {
+	return dag.ghostdagWithIsInPast(newNode, dag.isInPast)
+}
+
+// isInPastFunc returns whether `this` is in the past of `other`.
+type isInPastFunc func(this *blockNode, other *blockNode) (bool, error)
+
+// ghostdagWithIsInPast runs the GHOSTDAG protocol as described in ghostdag,
+// using isInPast to check the relations between blocks. This allows running
+// GHOSTDAG on blocks that are not in the reachability tree.
+func (dag *BlockDAG) ghostdagWithIsInPast(newNode *blockNode, isInPast isInPastFunc) (
+	selectedParentAnticone []*blockNode, err error) {
+
 	newNode.selectedParent = newNode.parents.bluest()
 	newNode.bluesAnticoneSizes[newNode.selectedParent] = 0
 	newNode.blues = []*blockNode{newNode.selectedParent}
-	selectedParentAnticone, err = dag.selectedParentAnticone(newNode)
+	selectedParentAnticone, err = dag.selectedParentAnticone(newNode, isInPast)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +69,7 @@ func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blo
 			// newNode is always in the future of blueCandidate, so there's
 			// no point in checking it.
 			if chainBlock != newNode {
-				if isAncestorOfBlueCandidate, err := dag.isInPast(chainBlock, blueCandidate); err != nil {
+				if isAncestorOfBlueCandidate, err := isInPast(chainBlock, blueCandidate); err != nil {
 					return nil, err
 				} else if isAncestorOfBlueCandidate {
 					break
@@ -66,7 +78,7 @@ func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blo
 
 			for _, block := range chainBlock.blues {
 				// Skip blocks that exist in the past of blueCandidate.
-				if isAncestorOfBlueCandidate, err := dag.isInPast(block, blueCandidate); err != nil {
+				if isAncestorOfBlueCandidate, err := isInPast(block, blueCandidate); err != nil {
 					return nil, err
 				} else if isAncestorOfBlueCandidate {
 					continue
@@ -125,7 +137,7 @@ func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blo
 // For each node in the queue:
 //   we check whether it is in the past of the selected parent.
 //   If not, we add the node to the resulting anticone-set and queue it for processing.
-func (dag *BlockDAG) selectedParentAnticone(node *blockNode) ([]*blockNode, error) {
+func (dag *BlockDAG) selectedParentAnticone(node *blockNode, isInPast isInPastFunc) ([]*blockNode, error) {
 	anticoneSet := newBlockSet()
 	var anticoneSlice []*blockNode
 	selectedParentPast := newBlockSet()
@@ -148,7 +160,7 @@ func (dag *BlockDAG) selectedParentAnticone(node *blockNode) ([]*blockNode, erro
 			if anticoneSet.contains(parent) || selectedParentPast.contains(parent) {
 				continue
 			}
-			isAncestorOfSelectedParent, err := dag.isInPast(parent, node.selectedParent)
+			isAncestorOfSelectedParent, err := isInPast(parent, node.selectedParent)
 			if err != nil {
 				return nil, err
 			}
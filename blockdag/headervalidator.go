@@ -0,0 +1,184 @@
+package blockdag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// HeaderValidator validates block headers before their blocks are
+// downloaded, so that a chain of invalid headers can be rejected
+// cheaply. Every header is validated on top of the DAG and of the
+// headers that were validated before it: its proof of work, difficulty
+// and timestamp are checked, and GHOSTDAG is run on it in order to
+// check the difficulty and timestamp of the headers that follow it.
+//
+// The validated headers are not added to the DAG. They are kept by the
+// validator, so it should be discarded once their blocks are processed.
+type HeaderValidator struct {
+	dag   *BlockDAG
+	nodes map[daghash.Hash]*blockNode
+}
+
+// NewHeaderValidator returns a new HeaderValidator that validates
+// headers on top of the DAG.
+func (dag *BlockDAG) NewHeaderValidator() *HeaderValidator {
+	return &HeaderValidator{
+		dag:   dag,
+		nodes: make(map[daghash.Hash]*blockNode),
+	}
+}
+
+// ValidateHeader validates the given block header. All of its parents
+// must be either in the DAG or validated by this validator, which means
+// that headers must be validated in topological order. Headers of blocks
+// that are already in the DAG are not validated again.
+//
+// The flags modify the behavior of this function as follows:
+//  - BFNoPoWCheck: The check to ensure the block hash is less than the target
+//    difficulty is not performed.
+//
+// This function is NOT safe for concurrent access.
+func (hv *HeaderValidator) ValidateHeader(header *domainmessage.BlockHeader, flags BehaviorFlags) error {
+	hv.dag.dagLock.RLock()
+	defer hv.dag.dagLock.RUnlock()
+
+	blockHash := header.BlockHash()
+	if _, ok := hv.lookupNode(blockHash); ok {
+		return nil
+	}
+
+	err := hv.checkHeaderSanity(header, flags)
+	if err != nil {
+		return err
+	}
+
+	parents, err := hv.lookupParents(header)
+	if err != nil {
+		return err
+	}
+	err = validateParentsWithIsInPast(header, parents, hv.isInPast)
+	if err != nil {
+		return err
+	}
+
+	node := hv.dag.initBlockNode(header, parents)
+	_, err = hv.dag.ghostdagWithIsInPast(node, hv.isInPast)
+	if err != nil {
+		return err
+	}
+
+	err = hv.dag.checkBlockHeaderContext(header, parents.bluest(), false)
+	if err != nil {
+		return err
+	}
+
+	hv.nodes[*blockHash] = node
+	return nil
+}
+
+// checkHeaderSanity performs the context free checks of checkBlockHeaderSanity.
+// Unlike blocks, headers that are too far in the future are rejected rather
+// than delayed.
+func (hv *HeaderValidator) checkHeaderSanity(header *domainmessage.BlockHeader, flags BehaviorFlags) error {
+	err := hv.dag.checkProofOfWork(header, flags)
+	if err != nil {
+		return err
+	}
+
+	// The genesis is always in the DAG, so every validated
+	// header must have parents.
+	if len(header.ParentHashes) == 0 {
+		return ruleError(ErrNoParents, "block has no parents")
+	}
+	err = checkBlockParentsOrder(header)
+	if err != nil {
+		return err
+	}
+
+	maxTimestamp := hv.dag.Now().Add(time.Duration(hv.dag.TimestampDeviationTolerance) * hv.dag.Params.TargetTimePerBlock)
+	if header.Timestamp.After(maxTimestamp) {
+		str := fmt.Sprintf("block timestamp of %s is too far in the future", header.Timestamp)
+		return ruleError(ErrTimeTooNew, str)
+	}
+
+	return nil
+}
+
+func (hv *HeaderValidator) lookupParents(header *domainmessage.BlockHeader) (blockSet, error) {
+	parents := newBlockSet()
+	for _, parentHash := range header.ParentHashes {
+		parent, ok := hv.lookupNode(parentHash)
+		if !ok {
+			str := fmt.Sprintf("parent %s of block %s is unknown", parentHash, header.BlockHash())
+			return nil, ruleError(ErrParentBlockUnknown, str)
+		}
+		if hv.dag.index.NodeStatus(parent).KnownInvalid() {
+			str := fmt.Sprintf("parent %s of block %s is known to be invalid", parentHash, header.BlockHash())
+			return nil, ruleError(ErrInvalidAncestorBlock, str)
+		}
+		parents.add(parent)
+	}
+	return parents, nil
+}
+
+// lookupNode returns the node of the block with the given hash, either
+// from the validated headers or from the DAG.
+func (hv *HeaderValidator) lookupNode(hash *daghash.Hash) (*blockNode, bool) {
+	if node, ok := hv.nodes[*hash]; ok {
+		return node, true
+	}
+	return hv.dag.index.LookupNode(hash)
+}
+
+// isInPast returns whether `this` is in the past of `other`. The nodes of
+// the validated headers are not in the reachability tree, so the past of
+// such nodes is traversed instead, down to the blue score of `this`.
+func (hv *HeaderValidator) isInPast(this *blockNode, other *blockNode) (bool, error) {
+	_, isThisValidatedHeader := hv.nodes[*this.hash]
+	if _, ok := hv.nodes[*other.hash]; !ok {
+		// Blocks in the DAG can't have validated headers in their past
+		if isThisValidatedHeader {
+			return false, nil
+		}
+		return hv.dag.isInPast(this, other)
+	}
+
+	visited := newBlockSet()
+	queue := []*blockNode{other}
+	for len(queue) > 0 {
+		var current *blockNode
+		current, queue = queue[0], queue[1:]
+		for parent := range current.parents {
+			if parent == this {
+				return true, nil
+			}
+			// The blue score of a block is greater than the blue scores
+			// of all the blocks in its past, so blocks whose blue score is
+			// not greater than the blue score of `this` can't have it in
+			// their past.
+			if visited.contains(parent) || parent.blueScore <= this.blueScore {
+				continue
+			}
+			visited.add(parent)
+
+			if _, ok := hv.nodes[*parent.hash]; ok {
+				queue = append(queue, parent)
+				continue
+			}
+			if isThisValidatedHeader {
+				continue
+			}
+			isInPast, err := hv.dag.isInPast(this, parent)
+			if err != nil {
+				return false, err
+			}
+			if isInPast {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,109 @@
+package blockdag
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestHeaderValidator(t *testing.T) {
+	params := dagconfig.SimnetParams
+	syncerDAG, teardownFunc, err := DAGSetup("TestHeaderValidatorSyncer", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup syncer DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	synceeDAG, teardownFunc, err := DAGSetup("TestHeaderValidatorSyncee", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup syncee DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	// Build a DAG in which every block has two parents, so that
+	// GHOSTDAG colors some blocks in the anticone of the selected parent.
+	var blocks []*domainmessage.MsgBlock
+	tips := []*daghash.Hash{params.GenesisHash}
+	for i := 0; i < 10; i++ {
+		blockA := PrepareAndProcessBlockForTest(t, syncerDAG, tips, nil)
+		blockB := PrepareAndProcessBlockForTest(t, syncerDAG, tips, nil)
+		blocks = append(blocks, blockA, blockB)
+		tips = []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}
+	}
+
+	validator := synceeDAG.NewHeaderValidator()
+	for _, block := range blocks {
+		err := validator.ValidateHeader(&block.Header, BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ValidateHeader: unexpected error for block %s: %s", block.BlockHash(), err)
+		}
+
+		node := validator.nodes[*block.BlockHash()]
+		expectedNode, _ := syncerDAG.index.LookupNode(block.BlockHash())
+		if node.blueScore != expectedNode.blueScore {
+			t.Errorf("ValidateHeader: unexpected blue score for block %s. Want: %d, got: %d",
+				node, expectedNode.blueScore, node.blueScore)
+		}
+		if !node.selectedParent.hash.IsEqual(expectedNode.selectedParent.hash) {
+			t.Errorf("ValidateHeader: unexpected selected parent for block %s. Want: %s, got: %s",
+				node, expectedNode.selectedParent, node.selectedParent)
+		}
+	}
+	if synceeDAG.IsInDAG(blocks[0].BlockHash()) {
+		t.Errorf("ValidateHeader: validated headers unexpectedly added to the DAG")
+	}
+
+	// Headers whose parents were not validated are rejected
+	err = synceeDAG.NewHeaderValidator().ValidateHeader(&blocks[2].Header, BFNoPoWCheck)
+	if err := checkRuleError(err, ruleError(ErrParentBlockUnknown, "")); err != nil {
+		t.Errorf("ValidateHeader: %s", err)
+	}
+
+	highHashHeader := blocks[0].Header
+	highHashHeader.Bits = util.BigToCompact(big.NewInt(1))
+	err = synceeDAG.NewHeaderValidator().ValidateHeader(&highHashHeader, BFNone)
+	if err := checkRuleError(err, ruleError(ErrHighHash, "")); err != nil {
+		t.Errorf("ValidateHeader: %s", err)
+	}
+
+	wrongDifficultyHeader := blocks[0].Header
+	wrongDifficultyHeader.Bits = util.BigToCompact(new(big.Int).Rsh(params.PowMax, 1))
+	err = synceeDAG.NewHeaderValidator().ValidateHeader(&wrongDifficultyHeader, BFNoPoWCheck)
+	if err := checkRuleError(err, ruleError(ErrUnexpectedDifficulty, "")); err != nil {
+		t.Errorf("ValidateHeader: %s", err)
+	}
+
+	tooOldHeader := blocks[0].Header
+	tooOldHeader.Timestamp = params.GenesisBlock.Header.Timestamp.Add(-time.Second)
+	err = synceeDAG.NewHeaderValidator().ValidateHeader(&tooOldHeader, BFNoPoWCheck)
+	if err := checkRuleError(err, ruleError(ErrTimeTooOld, "")); err != nil {
+		t.Errorf("ValidateHeader: %s", err)
+	}
+
+	tooNewHeader := blocks[0].Header
+	tooNewHeader.Timestamp = synceeDAG.Now().Add(
+		time.Duration(synceeDAG.TimestampDeviationTolerance)*params.TargetTimePerBlock + time.Hour)
+	err = synceeDAG.NewHeaderValidator().ValidateHeader(&tooNewHeader, BFNoPoWCheck)
+	if err := checkRuleError(err, ruleError(ErrTimeTooNew, "")); err != nil {
+		t.Errorf("ValidateHeader: %s", err)
+	}
+
+	// Headers whose parents are ancestors of each other are rejected,
+	// also when one of the parents is a validated header.
+	invalidParentsHeader := blocks[1].Header
+	invalidParentsHeader.ParentHashes = []*daghash.Hash{params.GenesisHash, blocks[0].BlockHash()}
+	daghash.Sort(invalidParentsHeader.ParentHashes)
+	err = validator.ValidateHeader(&invalidParentsHeader, BFNoPoWCheck)
+	if err := checkRuleError(err, ruleError(ErrInvalidParentsRelation, "")); err != nil {
+		t.Errorf("ValidateHeader: %s", err)
+	}
+}
@@ -22,6 +22,9 @@ type AcceptanceIndex struct {
 // Ensure the AcceptanceIndex type implements the Indexer interface.
 var _ Indexer = (*AcceptanceIndex)(nil)
 
+// acceptanceIndexKey is the key of the acceptance index.
+var acceptanceIndexKey = []byte("acceptanceindex")
+
 // NewAcceptanceIndex returns a new instance of an indexer that is used to create a
 // mapping between block hashes and their txAcceptanceData.
 //
@@ -45,48 +48,35 @@ func DropAcceptanceIndex(databaseContext *dbaccess.DatabaseContext) error {
 		return err
 	}
 
+	err = dropIndexTips(dbTx, acceptanceIndexKey)
+	if err != nil {
+		return err
+	}
+
 	return dbTx.Commit()
 }
 
-// Init initializes the hash-based acceptance index.
+// Key returns the key of the acceptance index.
 //
 // This is part of the Indexer interface.
-func (idx *AcceptanceIndex) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error {
-	idx.dag = dag
-	idx.databaseContext = databaseContext
-	return idx.recover()
+func (idx *AcceptanceIndex) Key() []byte {
+	return acceptanceIndexKey
 }
 
-// recover attempts to insert any data that's missing from the
-// acceptance index.
+// Name returns the human-readable name of the acceptance index.
 //
 // This is part of the Indexer interface.
-func (idx *AcceptanceIndex) recover() error {
-	return idx.dag.ForEachHash(func(hash daghash.Hash) error {
-		dbTx, err := idx.databaseContext.NewTx()
-		if err != nil {
-			return err
-		}
-		defer dbTx.RollbackUnlessClosed()
-
-		exists, err := dbaccess.HasAcceptanceData(dbTx, &hash)
-		if err != nil {
-			return err
-		}
-		if exists {
-			return nil
-		}
-		txAcceptanceData, err := idx.dag.TxsAcceptedByBlockHash(&hash)
-		if err != nil {
-			return err
-		}
-		err = idx.ConnectBlock(dbTx, &hash, txAcceptanceData)
-		if err != nil {
-			return err
-		}
+func (idx *AcceptanceIndex) Name() string {
+	return "acceptance index"
+}
 
-		return dbTx.Commit()
-	})
+// Init initializes the hash-based acceptance index.
+//
+// This is part of the Indexer interface.
+func (idx *AcceptanceIndex) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error {
+	idx.dag = dag
+	idx.databaseContext = databaseContext
+	return nil
 }
 
 // ConnectBlock is invoked by the index manager when a new block has been
@@ -102,16 +92,78 @@ func (idx *AcceptanceIndex) ConnectBlock(dbContext *dbaccess.TxContext, blockHas
 	return dbaccess.StoreAcceptanceData(dbContext, blockHash, serializedTxsAcceptanceData)
 }
 
+// ConnectChainBlock is invoked by the index manager when a block has been
+// added to the selected parent chain. It marks every transaction that the
+// block accepts as accepted by it.
+//
+// This is part of the Indexer interface.
+func (idx *AcceptanceIndex) ConnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash,
+	txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if !txAcceptanceData.IsAccepted {
+				continue
+			}
+			err := dbaccess.StoreAcceptingBlockHash(dbContext, txAcceptanceData.Tx.ID(), chainBlockHash)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DisconnectChainBlock is invoked by the index manager when a block has been
+// removed from the selected parent chain. It unmarks every transaction that
+// the block accepts, since they're no longer accepted by the DAG unless a
+// chain block that replaces it accepts them again.
+//
+// This is part of the Indexer interface.
+func (idx *AcceptanceIndex) DisconnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash) error {
+	// The acceptance data of every block is stored when it's connected to
+	// the DAG, so it's always available for chain blocks.
+	txsAcceptanceData, err := idx.txsAcceptanceData(dbContext, chainBlockHash)
+	if err != nil {
+		return err
+	}
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if !txAcceptanceData.IsAccepted {
+				continue
+			}
+			err := dbaccess.RemoveAcceptingBlockHash(dbContext, txAcceptanceData.Tx.ID())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // TxsAcceptanceData returns the acceptance data of all the transactions that
 // were accepted by the block with hash blockHash.
 func (idx *AcceptanceIndex) TxsAcceptanceData(blockHash *daghash.Hash) (blockdag.MultiBlockTxsAcceptanceData, error) {
-	serializedTxsAcceptanceData, err := dbaccess.FetchAcceptanceData(idx.databaseContext, blockHash)
+	return idx.txsAcceptanceData(idx.databaseContext, blockHash)
+}
+
+func (idx *AcceptanceIndex) txsAcceptanceData(context dbaccess.Context,
+	blockHash *daghash.Hash) (blockdag.MultiBlockTxsAcceptanceData, error) {
+
+	serializedTxsAcceptanceData, err := dbaccess.FetchAcceptanceData(context, blockHash)
 	if err != nil {
 		return nil, err
 	}
 	return deserializeMultiBlockTxsAcceptanceData(serializedTxsAcceptanceData)
 }
 
+// AcceptingBlockHash returns the hash of the selected parent chain block
+// that accepted the transaction with the given ID.
+// Returns ErrNotFound if the transaction is not accepted by the DAG.
+func (idx *AcceptanceIndex) AcceptingBlockHash(txID *daghash.TxID) (*daghash.Hash, error) {
+	return dbaccess.FetchAcceptingBlockHash(idx.databaseContext, txID)
+}
+
 type serializableTxAcceptanceData struct {
 	MsgTx      domainmessage.MsgTx
 	IsAccepted bool
@@ -0,0 +1,654 @@
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// AddrIndex implements a transaction by address index. That is to say, it
+// stores a mapping between an address and all the transactions that either
+// credit or debit it, as accepted by the blocks of the selected parent chain.
+//
+// The entries of every address are ordered by the blue score of the chain
+// block that accepted them, which allows paginating over the history of an
+// address from its oldest transaction to its newest.
+type AddrIndex struct {
+	dag             *blockdag.BlockDAG
+	databaseContext *dbaccess.DatabaseContext
+}
+
+// Ensure the AddrIndex type implements the Indexer interface.
+var _ Indexer = (*AddrIndex)(nil)
+
+// addrIndexKey is the key of the address index.
+var addrIndexKey = []byte("addrindex")
+
+// AddrIndexEntry represents a single transaction that credits or debits
+// an address.
+type AddrIndexEntry struct {
+	Address                 string
+	TxID                    *daghash.TxID
+	AcceptingBlockHash      *daghash.Hash
+	AcceptingBlockBlueScore uint64
+
+	// Received is the total amount that the transaction's outputs
+	// pay to the address.
+	Received uint64
+
+	// Sent is the total amount of the address's outputs that the
+	// transaction spends.
+	Sent uint64
+}
+
+// NewAddrIndex returns a new instance of an indexer that is used to create a
+// mapping between addresses and the transactions that involve them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockdag package. This allows the index to be
+// seamlessly maintained along with the DAG.
+func NewAddrIndex() *AddrIndex {
+	return &AddrIndex{}
+}
+
+// DropAddrIndex drops the address index.
+func DropAddrIndex(databaseContext *dbaccess.DatabaseContext) error {
+	dbTx, err := databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dbaccess.DropAddrIndex(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dropIndexTips(dbTx, addrIndexKey)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Key returns the key of the address index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Key() []byte {
+	return addrIndexKey
+}
+
+// Name returns the human-readable name of the address index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Name() string {
+	return "address index"
+}
+
+// Init initializes the address index. Chain blocks can't be disconnected from
+// an address index that was built before the index manager kept track of its chain
+// tip, so such an index is dropped in order for the index manager to rebuild
+// it from scratch.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error {
+	idx.dag = dag
+	idx.databaseContext = databaseContext
+
+	shouldRebuild, err := isBuiltWithoutChainTip(databaseContext, idx)
+	if err != nil {
+		return err
+	}
+	if !shouldRebuild {
+		return nil
+	}
+	return DropAddrIndex(databaseContext)
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the DAG. Only blocks that are in the selected parent chain
+// modify the index, since only they define which transactions the DAG
+// accepts, so this is a no-op. See ConnectChainBlock.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) ConnectBlock(_ *dbaccess.TxContext, _ *daghash.Hash,
+	_ blockdag.MultiBlockTxsAcceptanceData) error {
+
+	return nil
+}
+
+// addrIndexOutput is an output that pays to an address. Outputs are kept
+// in the index until they're spent, so that spending transactions could be
+// recorded as debiting the address.
+type addrIndexOutput struct {
+	address string
+	amount  uint64
+}
+
+type addrIndexEntryID struct {
+	address string
+	txID    daghash.TxID
+}
+
+// ConnectChainBlock is invoked by the index manager when a block has been
+// added to the selected parent chain. It adds an entry for every address
+// that any of the transactions accepted by the given chain block credit or
+// debit. The outputs that these transactions spend are kept aside in the
+// block's undo data, so that they could be restored if the block is removed
+// from the selected parent chain.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) ConnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash,
+	txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+
+	blueScore, err := idx.dag.BlueScoreByBlockHash(chainBlockHash)
+	if err != nil {
+		return err
+	}
+
+	// Database transactions don't see their own writes, so outputs that
+	// are both created and spent by this block must never reach the
+	// database. Collect the block's outputs first, and only then write them.
+	addedOutputs := make(map[domainmessage.Outpoint]*addrIndexOutput)
+	undoData := &addrIndexUndoData{}
+
+	// Entries are kept in the order they were created, so that the
+	// entries of the chain block are always serialized the same way.
+	entries := make(map[addrIndexEntryID]*AddrIndexEntry)
+	var entryIDs []addrIndexEntryID
+	entry := func(address string, txID *daghash.TxID) *AddrIndexEntry {
+		entryID := addrIndexEntryID{address: address, txID: *txID}
+		if entry, ok := entries[entryID]; ok {
+			return entry
+		}
+		entry := &AddrIndexEntry{
+			Address:                 address,
+			TxID:                    txID,
+			AcceptingBlockHash:      chainBlockHash,
+			AcceptingBlockBlueScore: blueScore,
+		}
+		entries[entryID] = entry
+		entryIDs = append(entryIDs, entryID)
+		return entry
+	}
+
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if !txAcceptanceData.IsAccepted {
+				continue
+			}
+			tx := txAcceptanceData.Tx
+			msgTx := tx.MsgTx()
+			if !tx.IsCoinBase() {
+				for _, txIn := range msgTx.TxIn {
+					output, err := idx.spendOutput(dbContext, addedOutputs, undoData, &txIn.PreviousOutpoint)
+					if err != nil {
+						return err
+					}
+					if output == nil {
+						continue
+					}
+					entry(output.address, tx.ID()).Sent += output.amount
+				}
+			}
+			for i, txOut := range msgTx.TxOut {
+				_, address, err := txscript.ExtractScriptPubKeyAddress(txOut.ScriptPubKey, idx.dag.Params)
+				if err != nil || address == nil {
+					// Outputs that don't pay to an address are not indexed.
+					continue
+				}
+				encodedAddress := address.EncodeAddress()
+				outpoint := *domainmessage.NewOutpoint(tx.ID(), uint32(i))
+				addedOutputs[outpoint] = &addrIndexOutput{address: encodedAddress, amount: txOut.Value}
+				entry(encodedAddress, tx.ID()).Received += txOut.Value
+			}
+		}
+	}
+
+	for outpoint, output := range addedOutputs {
+		outpointKey := serializeUTXOIndexOutpoint(&outpoint)
+		err := dbaccess.StoreAddrIndexOutput(dbContext, outpointKey, serializeAddrIndexOutput(output))
+		if err != nil {
+			return err
+		}
+		undoData.addedOutpoints = append(undoData.addedOutpoints, outpointKey)
+	}
+
+	chainBlockEntries := make([]*AddrIndexEntry, len(entryIDs))
+	for i, entryID := range entryIDs {
+		entry := entries[entryID]
+		serializedEntry, err := serializeAddrIndexEntry(entry)
+		if err != nil {
+			return err
+		}
+		err = dbaccess.StoreAddrIndexEntry(dbContext, []byte(entry.Address),
+			addrIndexEntryKey(entry.AcceptingBlockBlueScore, entry.TxID), serializedEntry)
+		if err != nil {
+			return err
+		}
+		chainBlockEntries[i] = entry
+	}
+	serializedChainBlockEntries, err := serializeAddrIndexChainBlockEntries(chainBlockEntries)
+	if err != nil {
+		return err
+	}
+	err = dbaccess.StoreAddrIndexChainBlockEntries(dbContext, chainBlockHash, serializedChainBlockEntries)
+	if err != nil {
+		return err
+	}
+
+	serializedUndoData, err := serializeAddrIndexUndoData(undoData)
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreAddrIndexUndoData(dbContext, chainBlockHash, serializedUndoData)
+}
+
+// spendOutput removes the output of the given outpoint from the index and
+// returns it. It returns nil if the outpoint doesn't pay to an address.
+// Outputs that are removed from the database are recorded in the given
+// undo data.
+func (idx *AddrIndex) spendOutput(dbContext *dbaccess.TxContext,
+	addedOutputs map[domainmessage.Outpoint]*addrIndexOutput, undoData *addrIndexUndoData,
+	outpoint *domainmessage.Outpoint) (*addrIndexOutput, error) {
+
+	if output, ok := addedOutputs[*outpoint]; ok {
+		delete(addedOutputs, *outpoint)
+		return output, nil
+	}
+
+	outpointKey := serializeUTXOIndexOutpoint(outpoint)
+	serializedOutput, err := dbaccess.FetchAddrIndexOutput(dbContext, outpointKey)
+	if dbaccess.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = dbaccess.RemoveAddrIndexOutput(dbContext, outpointKey)
+	if err != nil {
+		return nil, err
+	}
+	undoData.spentOutputs = append(undoData.spentOutputs, &addrIndexSpentOutput{
+		outpointKey:      outpointKey,
+		serializedOutput: serializedOutput,
+	})
+	return deserializeAddrIndexOutput(serializedOutput)
+}
+
+// DisconnectChainBlock is invoked by the index manager when a block has been
+// removed from the selected parent chain. It removes all the entries and
+// outputs that the block had added to the index, and restores all the outputs
+// that it had spent.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) DisconnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash) error {
+	serializedChainBlockEntries, err := dbaccess.FetchAddrIndexChainBlockEntries(dbContext, chainBlockHash)
+	if err != nil {
+		return err
+	}
+	entryKeys, err := deserializeAddrIndexChainBlockEntries(serializedChainBlockEntries)
+	if err != nil {
+		return err
+	}
+	for _, entryKey := range entryKeys {
+		err := dbaccess.RemoveAddrIndexEntry(dbContext, []byte(entryKey.address), entryKey.key)
+		if err != nil {
+			return err
+		}
+	}
+	err = dbaccess.RemoveAddrIndexChainBlockEntries(dbContext, chainBlockHash)
+	if err != nil {
+		return err
+	}
+
+	serializedUndoData, err := dbaccess.FetchAddrIndexUndoData(dbContext, chainBlockHash)
+	if err != nil {
+		return err
+	}
+	undoData, err := deserializeAddrIndexUndoData(serializedUndoData)
+	if err != nil {
+		return err
+	}
+	for _, outpointKey := range undoData.addedOutpoints {
+		err := dbaccess.RemoveAddrIndexOutput(dbContext, outpointKey)
+		if err != nil {
+			return err
+		}
+	}
+	for _, spentOutput := range undoData.spentOutputs {
+		err := dbaccess.StoreAddrIndexOutput(dbContext, spentOutput.outpointKey, spentOutput.serializedOutput)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbaccess.RemoveAddrIndexUndoData(dbContext, chainBlockHash)
+}
+
+// TxsByAddress returns up to limit entries of the given address, starting
+// from the entry that the given cursor points to. A nil cursor starts from
+// the address's oldest entry. The returned cursor points to the entry that
+// follows the last returned one, and is nil if there are no more entries.
+func (idx *AddrIndex) TxsByAddress(address string, startCursor []byte, limit int) (
+	entries []*AddrIndexEntry, nextCursor []byte, err error) {
+
+	cursor, err := dbaccess.AddrIndexCursor(idx.databaseContext, []byte(address))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close()
+
+	var ok bool
+	if startCursor == nil {
+		ok = cursor.Next()
+	} else {
+		// Seek returns ErrNotFound when the cursor's entry doesn't exist,
+		// while still positioning the cursor on the entry that follows it.
+		err := cursor.Seek(dbaccess.AddrIndexEntryKey([]byte(address), startCursor))
+		if err != nil && !dbaccess.IsNotFoundError(err) {
+			return nil, nil, err
+		}
+		_, err = cursor.Key()
+		ok = err == nil
+	}
+
+	entries = make([]*AddrIndexEntry, 0)
+	for ok && len(entries) < limit {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, nil, err
+		}
+		serializedEntry, err := cursor.Value()
+		if err != nil {
+			return nil, nil, err
+		}
+		entry, err := deserializeAddrIndexEntry(address, key.Suffix(), serializedEntry)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+
+		ok = cursor.Next()
+	}
+
+	if !ok {
+		return entries, nil, nil
+	}
+	key, err := cursor.Key()
+	if err != nil {
+		return nil, nil, err
+	}
+	nextCursor = make([]byte, len(key.Suffix()))
+	copy(nextCursor, key.Suffix())
+	return entries, nextCursor, nil
+}
+
+// EntriesByChainBlock returns all the entries that were added to the index
+// by the given chain block.
+// Returns ErrNotFound if the chain block has not been indexed.
+func (idx *AddrIndex) EntriesByChainBlock(chainBlockHash *daghash.Hash) ([]*AddrIndexEntry, error) {
+	serializedChainBlockEntries, err := dbaccess.FetchAddrIndexChainBlockEntries(idx.databaseContext, chainBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	entryKeys, err := deserializeAddrIndexChainBlockEntries(serializedChainBlockEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*AddrIndexEntry, len(entryKeys))
+	for i, entryKey := range entryKeys {
+		serializedEntry, err := dbaccess.FetchAddrIndexEntry(idx.databaseContext, []byte(entryKey.address), entryKey.key)
+		if err != nil {
+			return nil, err
+		}
+		entries[i], err = deserializeAddrIndexEntry(entryKey.address, entryKey.key, serializedEntry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// addrIndexEntryKeySize is the size of an entry key: a big-endian uint64
+// blue score followed by a transaction ID. Big-endian is used so that
+// the entries of an address are sorted by their blue score.
+const addrIndexEntryKeySize = 8 + daghash.TxIDSize
+
+func addrIndexEntryKey(blueScore uint64, txID *daghash.TxID) []byte {
+	key := make([]byte, addrIndexEntryKeySize)
+	binary.BigEndian.PutUint64(key, blueScore)
+	copy(key[8:], txID[:])
+	return key
+}
+
+// ValidateAddrIndexCursor returns an error if the given cursor
+// can't possibly point to an address index entry.
+func ValidateAddrIndexCursor(cursor []byte) error {
+	if len(cursor) != addrIndexEntryKeySize {
+		return errors.Errorf("unexpected cursor size %d, expected %d",
+			len(cursor), addrIndexEntryKeySize)
+	}
+	return nil
+}
+
+// serializeAddrIndexEntry serializes an address index entry in the
+// following format:
+//
+//	Name               | Data type | Description
+//	------------------ | --------- | -----------
+//	acceptingBlockHash | Hash      | The hash of the chain block that accepted the transaction
+//	received           | uint64    | The total amount paid to the address
+//	sent               | uint64    | The total amount spent from the address
+//
+// The blue score and the transaction ID are omitted since they're
+// already part of the entry's key.
+func serializeAddrIndexEntry(entry *AddrIndexEntry) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := domainmessage.WriteElement(w, entry.AcceptingBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint64(w, binary.LittleEndian, entry.Received)
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint64(w, binary.LittleEndian, entry.Sent)
+	if err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeAddrIndexEntry(address string, entryKey []byte, serializedEntry []byte) (*AddrIndexEntry, error) {
+	err := ValidateAddrIndexCursor(entryKey)
+	if err != nil {
+		return nil, err
+	}
+	blueScore := binary.BigEndian.Uint64(entryKey)
+	txID, err := daghash.NewTxID(entryKey[8:])
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(serializedEntry)
+	acceptingBlockHash := &daghash.Hash{}
+	err = domainmessage.ReadElement(r, acceptingBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	received, err := binaryserializer.Uint64(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := binaryserializer.Uint64(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddrIndexEntry{
+		Address:                 address,
+		TxID:                    txID,
+		AcceptingBlockHash:      acceptingBlockHash,
+		AcceptingBlockBlueScore: blueScore,
+		Received:                received,
+		Sent:                    sent,
+	}, nil
+}
+
+// serializeAddrIndexOutput serializes an output as its amount
+// in little-endian followed by its encoded address.
+func serializeAddrIndexOutput(output *addrIndexOutput) []byte {
+	serializedOutput := make([]byte, 8+len(output.address))
+	binary.LittleEndian.PutUint64(serializedOutput, output.amount)
+	copy(serializedOutput[8:], output.address)
+	return serializedOutput
+}
+
+func deserializeAddrIndexOutput(serializedOutput []byte) (*addrIndexOutput, error) {
+	if len(serializedOutput) < 8 {
+		return nil, errors.Errorf("serialized output is too short: %d bytes", len(serializedOutput))
+	}
+	return &addrIndexOutput{
+		amount:  binary.LittleEndian.Uint64(serializedOutput),
+		address: string(serializedOutput[8:]),
+	}, nil
+}
+
+type addrIndexChainBlockEntry struct {
+	address string
+	key     []byte
+}
+
+// serializeAddrIndexChainBlockEntries serializes the list of the
+// entries added by a chain block as the number of entries, followed
+// by the address and key of each entry.
+func serializeAddrIndexChainBlockEntries(entries []*AddrIndexEntry) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := domainmessage.WriteVarInt(w, uint64(len(entries)))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		err := domainmessage.WriteVarString(w, entry.Address)
+		if err != nil {
+			return nil, err
+		}
+		_, err = w.Write(addrIndexEntryKey(entry.AcceptingBlockBlueScore, entry.TxID))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeAddrIndexChainBlockEntries(serializedEntries []byte) ([]*addrIndexChainBlockEntry, error) {
+	r := bytes.NewReader(serializedEntries)
+	count, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	// Every entry takes at least its key, so a count that's larger
+	// than that is certainly corrupt.
+	if count > uint64(len(serializedEntries)/addrIndexEntryKeySize) {
+		return nil, errors.Errorf("too many address index entries: %d", count)
+	}
+	entries := make([]*addrIndexChainBlockEntry, count)
+	for i := range entries {
+		address, err := domainmessage.ReadVarString(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		key := make([]byte, addrIndexEntryKeySize)
+		_, err = io.ReadFull(r, key)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = &addrIndexChainBlockEntry{address: address, key: key}
+	}
+	return entries, nil
+}
+
+// addrIndexUndoData is the data that's required to revert the changes
+// that a chain block made to the outputs kept by the address index.
+type addrIndexUndoData struct {
+	addedOutpoints [][]byte
+	spentOutputs   []*addrIndexSpentOutput
+}
+
+type addrIndexSpentOutput struct {
+	outpointKey      []byte
+	serializedOutput []byte
+}
+
+// serializeAddrIndexUndoData serializes undo data as the number of the added
+// outpoints followed by the outpoints themselves, and then the number of the
+// spent outputs followed by the outpoint and serialized output of each of them.
+func serializeAddrIndexUndoData(undoData *addrIndexUndoData) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := serializeOutpointKeys(w, undoData.addedOutpoints)
+	if err != nil {
+		return nil, err
+	}
+	err = domainmessage.WriteVarInt(w, uint64(len(undoData.spentOutputs)))
+	if err != nil {
+		return nil, err
+	}
+	for _, spentOutput := range undoData.spentOutputs {
+		_, err := w.Write(spentOutput.outpointKey)
+		if err != nil {
+			return nil, err
+		}
+		err = domainmessage.WriteVarBytes(w, 0, spentOutput.serializedOutput)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeAddrIndexUndoData(serializedUndoData []byte) (*addrIndexUndoData, error) {
+	r := bytes.NewReader(serializedUndoData)
+	addedOutpoints, err := deserializeOutpointKeys(r, len(serializedUndoData))
+	if err != nil {
+		return nil, err
+	}
+	count, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(serializedUndoData)/utxoIndexOutpointSize) {
+		return nil, errors.Errorf("too many spent outputs: %d", count)
+	}
+	spentOutputs := make([]*addrIndexSpentOutput, count)
+	for i := range spentOutputs {
+		outpointKey := make([]byte, utxoIndexOutpointSize)
+		_, err := io.ReadFull(r, outpointKey)
+		if err != nil {
+			return nil, err
+		}
+		serializedOutput, err := domainmessage.ReadVarBytes(r, 0,
+			uint32(len(serializedUndoData)), "serializedOutput")
+		if err != nil {
+			return nil, err
+		}
+		spentOutputs[i] = &addrIndexSpentOutput{
+			outpointKey:      outpointKey,
+			serializedOutput: serializedOutput,
+		}
+	}
+	return &addrIndexUndoData{
+		addedOutpoints: addedOutpoints,
+		spentOutputs:   spentOutputs,
+	}, nil
+}
@@ -0,0 +1,330 @@
+package indexers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestAddrIndexSerializationAndDeserialization(t *testing.T) {
+	txID, _ := daghash.NewTxIDFromStr("1111111111111111111111111111111111111111111111111111111111111111")
+	blockHash, _ := daghash.NewHashFromStr("2222222222222222222222222222222222222222222222222222222222222222")
+	entry := &AddrIndexEntry{
+		Address:                 "kaspasim:qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq",
+		TxID:                    txID,
+		AcceptingBlockHash:      blockHash,
+		AcceptingBlockBlueScore: 0x0102030405060708,
+		Received:                1234,
+		Sent:                    5678,
+	}
+
+	serializedEntry, err := serializeAddrIndexEntry(entry)
+	if err != nil {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: entry serialization failed: %s", err)
+	}
+	entryKey := addrIndexEntryKey(entry.AcceptingBlockBlueScore, entry.TxID)
+	deserializedEntry, err := deserializeAddrIndexEntry(entry.Address, entryKey, serializedEntry)
+	if err != nil {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: entry deserialization failed: %s", err)
+	}
+	if !reflect.DeepEqual(entry, deserializedEntry) {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: original entry and " +
+			"deserialized entry aren't equal")
+	}
+
+	output := &addrIndexOutput{amount: 1234, address: entry.Address}
+	deserializedOutput, err := deserializeAddrIndexOutput(serializeAddrIndexOutput(output))
+	if err != nil {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: output deserialization failed: %s", err)
+	}
+	if *deserializedOutput != *output {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: original output and "+
+			"deserialized output aren't equal: %v != %v", output, deserializedOutput)
+	}
+
+	serializedChainBlockEntries, err := serializeAddrIndexChainBlockEntries([]*AddrIndexEntry{entry})
+	if err != nil {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: chain block entries serialization failed: %s", err)
+	}
+	chainBlockEntries, err := deserializeAddrIndexChainBlockEntries(serializedChainBlockEntries)
+	if err != nil {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: chain block entries deserialization failed: %s", err)
+	}
+	expectedChainBlockEntries := []*addrIndexChainBlockEntry{{address: entry.Address, key: entryKey}}
+	if !reflect.DeepEqual(chainBlockEntries, expectedChainBlockEntries) {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: original chain block entries and " +
+			"deserialized chain block entries aren't equal")
+	}
+
+	err = ValidateAddrIndexCursor(entryKey[1:])
+	if err == nil {
+		t.Fatalf("TestAddrIndexSerializationAndDeserialization: ValidateAddrIndexCursor " +
+			"unexpectedly accepted a truncated cursor")
+	}
+}
+
+// TestAddrIndex tests that the address index holds exactly the transactions
+// that the selected parent chain accepted for every address, that paginating
+// over it returns every entry, and that an index that's rebuilt from scratch
+// is identical to one that had been maintained while processing blocks.
+func TestAddrIndex(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	testFiles := []string{
+		"blk_0_to_4.dat",
+		"blk_3B.dat",
+	}
+
+	var blocks []*util.Block
+	for _, file := range testFiles {
+		blockTmp, err := blockdag.LoadBlocks(filepath.Join("../testdata/", file))
+		if err != nil {
+			t.Fatalf("Error loading file: %v\n", err)
+		}
+		blocks = append(blocks, blockTmp...)
+	}
+
+	dbPath, err := ioutil.TempDir("", "TestAddrIndex")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	databaseContext1, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+
+	addrIndex1 := NewAddrIndex()
+	dag1, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{addrIndex1}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext1,
+	})
+	if err != nil {
+		t.Fatalf("TestAddrIndex: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		isOrphan, isDelayed, err := dag1.ProcessBlock(blocks[i], blockdag.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+		if isDelayed {
+			t.Fatalf("ProcessBlock: block %d "+
+				"is too far in the future", i)
+		}
+		if isOrphan {
+			t.Fatalf("ProcessBlock incorrectly returned block %v "+
+				"is an orphan\n", i)
+		}
+	}
+
+	txOuts := make(map[domainmessage.Outpoint]*domainmessage.TxOut)
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			for i, txOut := range tx.MsgTx().TxOut {
+				txOuts[*domainmessage.NewOutpoint(tx.ID(), uint32(i))] = txOut
+			}
+		}
+	}
+	extractAddress := func(scriptPubKey []byte) string {
+		_, address, err := txscript.ExtractScriptPubKeyAddress(scriptPubKey, &params)
+		if err != nil || address == nil {
+			return ""
+		}
+		return address.EncodeAddress()
+	}
+
+	// Build the expected entries by walking the selected parent chain
+	// and summing what every accepted transaction pays to and spends
+	// from every address.
+	_, addedChainBlockHashes, err := dag1.SelectedParentChain(params.GenesisHash)
+	if err != nil {
+		t.Fatalf("SelectedParentChain: %s", err)
+	}
+	chainBlockHashes := append([]*daghash.Hash{params.GenesisHash}, addedChainBlockHashes...)
+	expectedEntries := make(map[string][]*AddrIndexEntry)
+	for _, chainBlockHash := range chainBlockHashes {
+		blueScore, err := dag1.BlueScoreByBlockHash(chainBlockHash)
+		if err != nil {
+			t.Fatalf("BlueScoreByBlockHash: %s", err)
+		}
+		txsAcceptanceData, err := dag1.TxsAcceptedByBlockHash(chainBlockHash)
+		if err != nil {
+			t.Fatalf("TxsAcceptedByBlockHash: %s", err)
+		}
+		for _, blockTxsAcceptanceData := range txsAcceptanceData {
+			for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+				if !txAcceptanceData.IsAccepted {
+					continue
+				}
+				tx := txAcceptanceData.Tx
+				txEntries := make(map[string]*AddrIndexEntry)
+				entryOf := func(address string) *AddrIndexEntry {
+					entry, ok := txEntries[address]
+					if !ok {
+						entry = &AddrIndexEntry{
+							Address:                 address,
+							TxID:                    tx.ID(),
+							AcceptingBlockHash:      chainBlockHash,
+							AcceptingBlockBlueScore: blueScore,
+						}
+						txEntries[address] = entry
+					}
+					return entry
+				}
+				if !tx.IsCoinBase() {
+					for _, txIn := range tx.MsgTx().TxIn {
+						txOut, ok := txOuts[txIn.PreviousOutpoint]
+						if !ok {
+							continue
+						}
+						if address := extractAddress(txOut.ScriptPubKey); address != "" {
+							entryOf(address).Sent += txOut.Value
+						}
+					}
+				}
+				for _, txOut := range tx.MsgTx().TxOut {
+					if address := extractAddress(txOut.ScriptPubKey); address != "" {
+						entryOf(address).Received += txOut.Value
+					}
+				}
+				for address, entry := range txEntries {
+					expectedEntries[address] = append(expectedEntries[address], entry)
+				}
+			}
+		}
+	}
+	if len(expectedEntries) == 0 {
+		t.Fatalf("the test blocks unexpectedly don't pay to any address")
+	}
+
+	entriesByAddress := make(map[string]map[daghash.TxID]*AddrIndexEntry)
+	for address, expectedAddressEntries := range expectedEntries {
+		entries, nextCursor, err := addrIndex1.TxsByAddress(address, nil, len(expectedAddressEntries)+1)
+		if err != nil {
+			t.Fatalf("TxsByAddress: %s", err)
+		}
+		if nextCursor != nil {
+			t.Fatalf("TxsByAddress unexpectedly returned a cursor after the last entry of %s", address)
+		}
+		if len(entries) != len(expectedAddressEntries) {
+			t.Fatalf("unexpected number of entries for address %s. Want: %d, got: %d",
+				address, len(expectedAddressEntries), len(entries))
+		}
+
+		entriesByTxID := make(map[daghash.TxID]*AddrIndexEntry)
+		for i, entry := range entries {
+			if i > 0 && entry.AcceptingBlockBlueScore < entries[i-1].AcceptingBlockBlueScore {
+				t.Fatalf("the entries of address %s are not sorted by blue score", address)
+			}
+			entriesByTxID[*entry.TxID] = entry
+		}
+		for _, expectedEntry := range expectedAddressEntries {
+			entry, ok := entriesByTxID[*expectedEntry.TxID]
+			if !ok {
+				t.Fatalf("transaction %s is missing from the entries of address %s", expectedEntry.TxID, address)
+			}
+			if !reflect.DeepEqual(entry, expectedEntry) {
+				t.Fatalf("unexpected entry of transaction %s for address %s. Want: %+v, got: %+v",
+					expectedEntry.TxID, address, expectedEntry, entry)
+			}
+		}
+		entriesByAddress[address] = entriesByTxID
+
+		// Paginate one entry at a time and make sure that
+		// the same entries are returned in the same order.
+		var cursor []byte
+		for i := 0; ; i++ {
+			page, nextCursor, err := addrIndex1.TxsByAddress(address, cursor, 1)
+			if err != nil {
+				t.Fatalf("TxsByAddress: %s", err)
+			}
+			if len(page) != 1 || !reflect.DeepEqual(page[0], entries[i]) {
+				t.Fatalf("unexpected page %d of the entries of address %s", i, address)
+			}
+			if nextCursor == nil {
+				if i != len(entries)-1 {
+					t.Fatalf("pagination over the entries of address %s stopped "+
+						"after %d out of %d entries", address, i+1, len(entries))
+				}
+				break
+			}
+			cursor = nextCursor
+		}
+	}
+
+	for _, chainBlockHash := range chainBlockHashes {
+		chainBlockEntries, err := addrIndex1.EntriesByChainBlock(chainBlockHash)
+		if err != nil {
+			t.Fatalf("EntriesByChainBlock: %s", err)
+		}
+		for _, entry := range chainBlockEntries {
+			if !entry.AcceptingBlockHash.IsEqual(chainBlockHash) {
+				t.Fatalf("EntriesByChainBlock of %s returned an entry accepted by %s",
+					chainBlockHash, entry.AcceptingBlockHash)
+			}
+			if !reflect.DeepEqual(entry, entriesByAddress[entry.Address][*entry.TxID]) {
+				t.Fatalf("EntriesByChainBlock of %s returned an unexpected entry", chainBlockHash)
+			}
+		}
+	}
+
+	err = databaseContext1.Close()
+	if err != nil {
+		t.Fatalf("Error closing the database: %s", err)
+	}
+	databaseContext2, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext2.Close()
+
+	err = DropAddrIndex(databaseContext2)
+	if err != nil {
+		t.Fatalf("DropAddrIndex: %s", err)
+	}
+
+	addrIndex2 := NewAddrIndex()
+	_, teardown, err = blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{addrIndex2}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext2,
+	})
+	if err != nil {
+		t.Fatalf("TestAddrIndex: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for address, expectedAddressEntries := range expectedEntries {
+		entries2, _, err := addrIndex2.TxsByAddress(address, nil, len(expectedAddressEntries))
+		if err != nil {
+			t.Fatalf("TxsByAddress: %s", err)
+		}
+		if len(entries2) != len(entriesByAddress[address]) {
+			t.Fatalf("recovery failed: the rebuilt address index differs from the original one")
+		}
+		for _, entry := range entries2 {
+			if !reflect.DeepEqual(entry, entriesByAddress[address][*entry.TxID]) {
+				t.Fatalf("recovery failed: the rebuilt address index differs from the original one")
+			}
+		}
+	}
+}
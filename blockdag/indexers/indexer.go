@@ -16,6 +16,13 @@ import (
 // Indexer provides a generic interface for an indexer that is managed by an
 // index manager such as the Manager type provided by this package.
 type Indexer interface {
+	// Key returns the key of the index. It's used by the index manager
+	// to keep track of the blocks that the index had processed.
+	Key() []byte
+
+	// Name returns the human-readable name of the index.
+	Name() string
+
 	// Init is invoked when the index manager is first initializing the
 	// index.
 	Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error
@@ -25,4 +32,18 @@ type Indexer interface {
 	ConnectBlock(dbContext *dbaccess.TxContext,
 		blockHash *daghash.Hash,
 		acceptedTxsData blockdag.MultiBlockTxsAcceptanceData) error
+
+	// ConnectChainBlock is invoked when the index manager is notified that a
+	// block has been added to the selected parent chain, which means that the
+	// transactions it accepts are now accepted by the DAG.
+	ConnectChainBlock(dbContext *dbaccess.TxContext,
+		chainBlockHash *daghash.Hash,
+		acceptedTxsData blockdag.MultiBlockTxsAcceptanceData) error
+
+	// DisconnectChainBlock is invoked when the index manager is notified that
+	// a block has been removed from the selected parent chain, which means
+	// that the transactions it accepts are no longer accepted by the DAG.
+	// Chain blocks are always disconnected in the reverse order of their
+	// connection.
+	DisconnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash) error
 }
@@ -5,51 +5,684 @@
 package indexers
 
 import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
 )
 
+// catchUpLogInterval is the minimum time between two
+// consecutive progress messages while catching up indexes.
+const catchUpLogInterval = 10 * time.Second
+
+// errInterruptRequested indicates that an operation was cancelled due
+// to a user-requested interrupt.
+var errInterruptRequested = errors.New("interrupt requested")
+
+// interruptRequested returns true when the provided channel has been closed.
+// This simplifies early shutdown slightly since the caller can just use an if
+// statement instead of a select.
+func interruptRequested(interrupt <-chan struct{}) bool {
+	select {
+	case <-interrupt:
+		return true
+	default:
+	}
+
+	return false
+}
+
+// errResyncRequired indicates that an index can't be built, because the
+// data that's required in order to build it had already been removed from
+// the database.
+var errResyncRequired = errors.New("the DAG has to be resynced in order to build " +
+	"the index. Restart with --reset-db, or disable the index")
+
 // Manager defines an index manager that manages multiple optional indexes and
 // implements the blockdag.IndexManager interface so it can be seamlessly
 // plugged into normal DAG processing.
+//
+// The manager keeps track of the tips of every index, which are the blocks
+// that the index had processed that no other processed block points to. This
+// allows it to detect indexes that lag behind the DAG, such as ones that had
+// just been enabled on an existing database, and to catch them up.
+//
+// The manager also keeps track of the chain tip of every index, which is the
+// last selected parent chain block that was connected to the index. Whenever
+// the selected parent chain changes, the chain blocks that were removed from
+// it are disconnected from the index, starting from its chain tip, and the
+// chain blocks that were added to it are connected.
 type Manager struct {
-	enabledIndexes []Indexer
+	dag             *blockdag.BlockDAG
+	databaseContext *dbaccess.DatabaseContext
+	enabledIndexes  []Indexer
+
+	// chainLock protects the chain tips of the indexes from being
+	// concurrently updated by multiple chain changed notifications.
+	chainLock sync.Mutex
+
+	// lastConnectedBlockHash and lastConnectedTxsAcceptanceData hold the
+	// acceptance data of the last block that was connected to the DAG.
+	// The block that's added to the selected parent chain is almost always
+	// the one that had just been connected, so this saves recalculating
+	// its acceptance data.
+	// They're protected by the DAG lock.
+	lastConnectedBlockHash         *daghash.Hash
+	lastConnectedTxsAcceptanceData blockdag.MultiBlockTxsAcceptanceData
 }
 
 // Ensure the Manager type implements the blockdag.IndexManager interface.
 var _ blockdag.IndexManager = (*Manager)(nil)
 
-// Init initializes the enabled indexes.
+// Init initializes the enabled indexes, and then catches up any of them that
+// lags behind the DAG by replaying the blocks and the selected parent chain
+// changes that it had missed.
 // This is part of the blockdag.IndexManager interface.
-func (m *Manager) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error {
+func (m *Manager) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext,
+	interrupt <-chan struct{}) error {
+
+	m.dag = dag
+	m.databaseContext = databaseContext
+
 	for _, indexer := range m.enabledIndexes {
 		if err := indexer.Init(dag, databaseContext); err != nil {
 			return err
 		}
 	}
 
+	err := m.catchUp(interrupt)
+	if err != nil {
+		return err
+	}
+
+	err = m.catchUpChains(interrupt)
+	if err != nil {
+		return err
+	}
+
+	dag.Subscribe(m.handleBlockDAGNotification)
+	return nil
+}
+
+// catchUp replays every block that any of the enabled indexes had missed,
+// in topological order.
+func (m *Manager) catchUp(interrupt <-chan struct{}) error {
+	// The DAG is empty. There's nothing to catch up with.
+	if !m.dag.IsInDAG(m.dag.Params.GenesisHash) {
+		return nil
+	}
+
+	missingBlocksByIndex := make(map[Indexer]map[daghash.Hash]struct{})
+	missingBlocks := make(map[daghash.Hash]*daghash.Hash)
+	for _, indexer := range m.enabledIndexes {
+		tips, err := fetchIndexTips(m.databaseContext, indexer)
+		if err != nil {
+			return err
+		}
+		indexMissingBlocks, err := m.dag.BlockHashesNotInPastOf(tips)
+		if err != nil {
+			return errors.Wrapf(err, "failed finding the blocks that the %s is missing",
+				indexer.Name())
+		}
+		if len(indexMissingBlocks) == 0 {
+			continue
+		}
+
+		if tips == nil {
+			log.Infof("Building the %s. This might take a while...", indexer.Name())
+		} else {
+			log.Infof("The %s is %d blocks behind the DAG. Catching up...",
+				indexer.Name(), len(indexMissingBlocks))
+		}
+
+		missingBlocksByIndex[indexer] = make(map[daghash.Hash]struct{}, len(indexMissingBlocks))
+		for _, blockHash := range indexMissingBlocks {
+			missingBlocksByIndex[indexer][*blockHash] = struct{}{}
+			missingBlocks[*blockHash] = blockHash
+		}
+	}
+	if len(missingBlocks) == 0 {
+		return nil
+	}
+
+	// A block's blue score is always greater than the blue scores of
+	// its parents, so sorting by blue score yields a topological order.
+	blockHashes, err := m.sortTopologically(missingBlocks)
+	if err != nil {
+		return err
+	}
+
+	lastLogTime := time.Now()
+	for i, blockHash := range blockHashes {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		var indexes []Indexer
+		for _, indexer := range m.enabledIndexes {
+			if _, ok := missingBlocksByIndex[indexer][*blockHash]; ok {
+				indexes = append(indexes, indexer)
+			}
+		}
+		err := m.replayBlock(blockHash, indexes)
+		if err != nil {
+			return err
+		}
+
+		if time.Since(lastLogTime) >= catchUpLogInterval {
+			log.Infof("Caught up %d out of %d blocks (%.2f%%)",
+				i+1, len(blockHashes), float64(i+1)*100/float64(len(blockHashes)))
+			lastLogTime = time.Now()
+		}
+	}
+
+	log.Infof("Finished catching up the indexes")
 	return nil
 }
 
+func (m *Manager) sortTopologically(blockHashSet map[daghash.Hash]*daghash.Hash) ([]*daghash.Hash, error) {
+	blueScores := make(map[daghash.Hash]uint64, len(blockHashSet))
+	blockHashes := make([]*daghash.Hash, 0, len(blockHashSet))
+	for _, blockHash := range blockHashSet {
+		blueScore, err := m.dag.BlueScoreByBlockHash(blockHash)
+		if err != nil {
+			return nil, err
+		}
+		blueScores[*blockHash] = blueScore
+		blockHashes = append(blockHashes, blockHash)
+	}
+	sort.Slice(blockHashes, func(i, j int) bool {
+		blueScoreI, blueScoreJ := blueScores[*blockHashes[i]], blueScores[*blockHashes[j]]
+		if blueScoreI == blueScoreJ {
+			return daghash.Less(blockHashes[i], blockHashes[j])
+		}
+		return blueScoreI < blueScoreJ
+	})
+	return blockHashes, nil
+}
+
+// replayBlock connects the block of the given hash to the given indexes.
+func (m *Manager) replayBlock(blockHash *daghash.Hash, indexes []Indexer) error {
+	txsAcceptanceData, err := m.txsAcceptanceData(blockHash)
+	if err != nil {
+		return errors.Wrapf(err, "failed replaying block %s", blockHash)
+	}
+
+	dbTx, err := m.databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	for _, indexer := range indexes {
+		err := m.connectBlock(dbTx, indexer, blockHash, txsAcceptanceData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbTx.Commit()
+}
+
 // ConnectBlock must be invoked when a block is added to the DAG. It
 // keeps track of the state of each index it is managing, performs some sanity
 // checks, and invokes each indexer.
 //
 // This is part of the blockdag.IndexManager interface.
 func (m *Manager) ConnectBlock(dbContext *dbaccess.TxContext, blockHash *daghash.Hash, txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+	// Blocks may be connected while the DAG is initializing, before
+	// the indexes themselves are. Such blocks are replayed once the
+	// manager is initialized, so it's safe to skip them here.
+	if m.dag == nil {
+		return nil
+	}
+
+	m.lastConnectedBlockHash = blockHash
+	m.lastConnectedTxsAcceptanceData = txsAcceptanceData
 
 	// Call each of the currently active optional indexes with the block
 	// being connected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
 		// Notify the indexer with the connected block so it can index it.
-		if err := index.ConnectBlock(dbContext, blockHash, txsAcceptanceData); err != nil {
+		if err := m.connectBlock(dbContext, index, blockHash, txsAcceptanceData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connectBlock connects the block of the given hash to the given index,
+// and updates the tips of the index accordingly.
+func (m *Manager) connectBlock(dbContext *dbaccess.TxContext, indexer Indexer,
+	blockHash *daghash.Hash, txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+
+	err := indexer.ConnectBlock(dbContext, blockHash, txsAcceptanceData)
+	if err != nil {
+		return err
+	}
+
+	header, err := m.dag.HeaderByHash(blockHash)
+	if err != nil {
+		return err
+	}
+	tips, err := fetchIndexTips(dbContext, indexer)
+	if err != nil {
+		return err
+	}
+
+	// Blocks are connected in topological order, so the new block
+	// is always a tip, and it replaces those of its parents that
+	// had been tips.
+	parentHashes := make(map[daghash.Hash]struct{}, len(header.ParentHashes))
+	for _, parentHash := range header.ParentHashes {
+		parentHashes[*parentHash] = struct{}{}
+	}
+	newTips := make([]*daghash.Hash, 0, len(tips)+1)
+	for _, tip := range tips {
+		if _, ok := parentHashes[*tip]; !ok {
+			newTips = append(newTips, tip)
+		}
+	}
+	newTips = append(newTips, blockHash)
+
+	serializedTips, err := serializeIndexTips(newTips)
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreIndexTips(dbContext, indexer.Key(), serializedTips)
+}
+
+// PruneBlocks is invoked when the data of blocks that are deep below the
+// finality point is removed from the database. It removes the acceptance
+// data of these blocks, except for the acceptance data of selected parent
+// chain blocks while the acceptance index is enabled, which is still needed
+// by the getChainFromBlock RPC. Note that the acceptance data is removed
+// even if the acceptance index is disabled, in case it was left over by an
+// acceptance index that was disabled without being dropped.
+//
+// This is part of the blockdag.IndexManager interface.
+func (m *Manager) PruneBlocks(dbContext *dbaccess.TxContext, blockHashes []*daghash.Hash) error {
+	isAcceptanceIndexEnabled := false
+	for _, indexer := range m.enabledIndexes {
+		if _, ok := indexer.(*AcceptanceIndex); ok {
+			isAcceptanceIndexEnabled = true
+		}
+	}
+
+	for _, blockHash := range blockHashes {
+		if isAcceptanceIndexEnabled {
+			isInSelectedParentChain, err := m.dag.IsInSelectedParentChain(blockHash)
+			if err != nil {
+				return err
+			}
+			if isInSelectedParentChain {
+				continue
+			}
+		}
+		err := dbaccess.RemoveAcceptanceData(dbContext, blockHash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// catchUpChains brings the chain tip of every enabled index in sync with
+// the selected parent chain.
+func (m *Manager) catchUpChains(interrupt <-chan struct{}) error {
+	// The DAG is empty. There's no selected parent chain to catch up with.
+	if !m.dag.IsInDAG(m.dag.Params.GenesisHash) {
+		return nil
+	}
+
+	for _, indexer := range m.enabledIndexes {
+		chainTip, err := fetchIndexChainTip(m.databaseContext, indexer)
+		if err != nil {
+			return err
+		}
+		removed, added, err := m.chainChanges(chainTip)
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 && len(added) == 0 {
+			continue
+		}
+
+		log.Infof("Applying %d selected parent chain changes to the %s...",
+			len(removed)+len(added), indexer.Name())
+		err = m.applyChainChanges(indexer, removed, added, interrupt)
+		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// handleBlockDAGNotification applies the changes of the selected parent chain
+// to the enabled indexes whenever they're notified by the DAG.
+func (m *Manager) handleBlockDAGNotification(notification *blockdag.Notification) {
+	if notification.Type != blockdag.NTChainChanged {
+		return
+	}
+	data, ok := notification.Data.(*blockdag.ChainChangedNotificationData)
+	if !ok {
+		log.Warnf("Chain changed notification data is of wrong type.")
+		return
+	}
+
+	err := m.handleChainChanged(data)
+	if err != nil {
+		// The chain tips of the indexes are updated along with every
+		// chain block, so whatever failed here will be retried with the
+		// next notification, or on the next startup.
+		log.Errorf("Failed to apply the selected parent chain changes to the indexes: %s", err)
+	}
+}
+
+// handleChainChanged disconnects the removed chain blocks from every
+// enabled index, and connects the added ones.
+func (m *Manager) handleChainChanged(data *blockdag.ChainChangedNotificationData) error {
+	m.dag.RLock()
+	defer m.dag.RUnlock()
+
+	m.chainLock.Lock()
+	defer m.chainLock.Unlock()
+
+	for _, indexer := range m.enabledIndexes {
+		chainTip, err := fetchIndexChainTip(m.databaseContext, indexer)
+		if err != nil {
+			return err
+		}
+
+		// Notifications are sent after the DAG lock is released, so
+		// the selected parent chain might have changed again by now.
+		// The changes of the notification are only used if they start
+		// at the chain tip of the index and end at the current
+		// selected tip. Otherwise, they're recalculated.
+		removed, added := data.RemovedChainBlockHashes, data.AddedChainBlockHashes
+		isApplicable, err := m.areChainChangesApplicable(chainTip, removed, added)
+		if err != nil {
+			return err
+		}
+		if !isApplicable {
+			removed, added, err = m.chainChanges(chainTip)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = m.applyChainChanges(indexer, removed, added, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed applying the selected parent chain changes "+
+				"to the %s", indexer.Name())
+		}
+	}
+	return nil
+}
+
+// areChainChangesApplicable returns whether the given removed and added
+// chain blocks take an index with the given chain tip to the current
+// selected tip.
+func (m *Manager) areChainChangesApplicable(chainTip *daghash.Hash,
+	removed []*daghash.Hash, added []*daghash.Hash) (bool, error) {
+
+	if chainTip == nil || len(added) == 0 {
+		return false, nil
+	}
+	if !added[len(added)-1].IsEqual(m.dag.SelectedTipHash()) {
+		return false, nil
+	}
+	if len(removed) > 0 {
+		return removed[0].IsEqual(chainTip), nil
+	}
+	selectedParentHash, err := m.dag.SelectedParentHash(added[0])
+	if err != nil {
+		return false, err
+	}
+	return selectedParentHash != nil && selectedParentHash.IsEqual(chainTip), nil
+}
+
+// chainChanges returns the chain blocks that have to be disconnected from an
+// index with the given chain tip, and the chain blocks that have to be
+// connected to it, in order for it to be in sync with the selected parent
+// chain. A nil chain tip denotes an index that no chain block had been
+// connected to.
+func (m *Manager) chainChanges(chainTip *daghash.Hash) (removed []*daghash.Hash, added []*daghash.Hash, err error) {
+	if chainTip != nil {
+		return m.dag.SelectedParentChain(chainTip)
+	}
+
+	genesisHash := m.dag.Params.GenesisHash
+	_, added, err = m.dag.SelectedParentChain(genesisHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, append([]*daghash.Hash{genesisHash}, added...), nil
+}
+
+// applyChainChanges disconnects the given removed chain blocks from the given
+// index, and then connects the given added chain blocks to it. Every chain
+// block is applied in its own database transaction, along with the chain tip
+// of the index, since database transactions don't see their own writes.
+func (m *Manager) applyChainChanges(indexer Indexer, removed []*daghash.Hash, added []*daghash.Hash,
+	interrupt <-chan struct{}) error {
+
+	total := len(removed) + len(added)
+	lastLogTime := time.Now()
+	logProgress := func(applied int) {
+		if time.Since(lastLogTime) >= catchUpLogInterval {
+			log.Infof("Applied %d out of %d selected parent chain changes to the %s (%.2f%%)",
+				applied, total, indexer.Name(), float64(applied)*100/float64(total))
+			lastLogTime = time.Now()
+		}
+	}
+
+	for i, chainBlockHash := range removed {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+		err := m.disconnectChainBlock(indexer, chainBlockHash)
+		if err != nil {
+			return err
+		}
+		logProgress(i + 1)
+	}
+	for i, chainBlockHash := range added {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+		err := m.connectChainBlock(indexer, chainBlockHash)
+		if err != nil {
+			return err
+		}
+		logProgress(len(removed) + i + 1)
+	}
+	return nil
+}
+
+// disconnectChainBlock disconnects the given chain block from the given
+// index, and sets the chain tip of the index to its selected parent.
+func (m *Manager) disconnectChainBlock(indexer Indexer, chainBlockHash *daghash.Hash) error {
+	selectedParentHash, err := m.dag.SelectedParentHash(chainBlockHash)
+	if err != nil {
+		return err
+	}
+
+	dbTx, err := m.databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = indexer.DisconnectChainBlock(dbTx, chainBlockHash)
+	if err != nil {
+		return err
+	}
+
+	// Only the genesis has no selected parent, and it's never
+	// removed from the selected parent chain.
+	err = dbaccess.StoreIndexChainTip(dbTx, indexer.Key(), selectedParentHash)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// connectChainBlock connects the given chain block to the given
+// index, and sets the chain tip of the index to it.
+func (m *Manager) connectChainBlock(indexer Indexer, chainBlockHash *daghash.Hash) error {
+	txsAcceptanceData := m.lastConnectedTxsAcceptanceData
+	if m.lastConnectedBlockHash == nil || !m.lastConnectedBlockHash.IsEqual(chainBlockHash) {
+		var err error
+		txsAcceptanceData, err = m.txsAcceptanceData(chainBlockHash)
+		if err != nil {
+			return errors.Wrapf(err, "failed connecting chain block %s", chainBlockHash)
+		}
+	}
+
+	dbTx, err := m.databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = indexer.ConnectChainBlock(dbTx, chainBlockHash, txsAcceptanceData)
+	if err != nil {
+		return err
+	}
+
+	err = dbaccess.StoreIndexChainTip(dbTx, indexer.Key(), chainBlockHash)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// txsAcceptanceData returns the acceptance data of the block of the given
+// hash. The DAG can't calculate the acceptance data of blocks whose UTXO
+// diffs were removed once they had been finalized, so the acceptance data
+// that was stored by the acceptance index is preferred, since it outlives
+// the finalization of the block.
+func (m *Manager) txsAcceptanceData(blockHash *daghash.Hash) (blockdag.MultiBlockTxsAcceptanceData, error) {
+	serializedTxsAcceptanceData, err := dbaccess.FetchAcceptanceData(m.databaseContext, blockHash)
+	if err == nil {
+		return deserializeMultiBlockTxsAcceptanceData(serializedTxsAcceptanceData)
+	}
+	if !dbaccess.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	txsAcceptanceData, err := m.dag.TxsAcceptedByBlockHash(blockHash)
+	if err != nil {
+		if m.dag.IsKnownFinalizedBlock(blockHash) {
+			return nil, errors.Wrapf(errResyncRequired, "the acceptance data of block %s "+
+				"was removed when it was finalized", blockHash)
+		}
+		return nil, err
+	}
+	return txsAcceptanceData, nil
+}
+
+// fetchIndexChainTip returns the chain tip of the given index, or nil
+// if no chain block had ever been connected to the index.
+func fetchIndexChainTip(context dbaccess.Context, indexer Indexer) (*daghash.Hash, error) {
+	chainTip, err := dbaccess.FetchIndexChainTip(context, indexer.Key())
+	if dbaccess.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chainTip, nil
+}
+
+// isBuiltWithoutChainTip returns whether the given index had processed
+// blocks before the index manager kept track of its chain tip. Chain blocks
+// can't be disconnected from such an index.
+func isBuiltWithoutChainTip(context dbaccess.Context, indexer Indexer) (bool, error) {
+	tips, err := fetchIndexTips(context, indexer)
+	if err != nil {
+		return false, err
+	}
+	if tips == nil {
+		return false, nil
+	}
+	chainTip, err := fetchIndexChainTip(context, indexer)
+	if err != nil {
+		return false, err
+	}
+	return chainTip == nil, nil
+}
+
+// dropIndexTips removes the tips and the chain tip of the index with the
+// given key, which marks the index as one that has to be rebuilt from scratch.
+func dropIndexTips(dbTx *dbaccess.TxContext, indexKey []byte) error {
+	err := dbaccess.RemoveIndexTips(dbTx, indexKey)
+	if err != nil {
+		return err
+	}
+	return dbaccess.RemoveIndexChainTip(dbTx, indexKey)
+}
+
+// fetchIndexTips returns the tips of the given index, or nil
+// if the index had never processed any blocks.
+func fetchIndexTips(context dbaccess.Context, indexer Indexer) ([]*daghash.Hash, error) {
+	serializedTips, err := dbaccess.FetchIndexTips(context, indexer.Key())
+	if dbaccess.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deserializeIndexTips(serializedTips)
+}
+
+// serializeIndexTips serializes the tips of an index as
+// their number followed by their hashes.
+func serializeIndexTips(tips []*daghash.Hash) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := domainmessage.WriteVarInt(w, uint64(len(tips)))
+	if err != nil {
+		return nil, err
+	}
+	for _, tip := range tips {
+		err := domainmessage.WriteElement(w, tip)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeIndexTips(serializedTips []byte) ([]*daghash.Hash, error) {
+	r := bytes.NewReader(serializedTips)
+	count, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(serializedTips)/daghash.HashSize) {
+		return nil, errors.Errorf("too many index tips: %d", count)
+	}
+	tips := make([]*daghash.Hash, count)
+	for i := range tips {
+		tip := &daghash.Hash{}
+		err := domainmessage.ReadElement(r, tip)
+		if err != nil {
+			return nil, err
+		}
+		tips[i] = tip
+	}
+	return tips, nil
+}
+
 // NewManager returns a new index manager with the provided indexes enabled.
 //
 // The manager returned satisfies the blockdag.IndexManager interface and thus
@@ -0,0 +1,535 @@
+package indexers
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestIndexTipsSerializationAndDeserialization(t *testing.T) {
+	tip1, _ := daghash.NewHashFromStr("1111111111111111111111111111111111111111111111111111111111111111")
+	tip2, _ := daghash.NewHashFromStr("2222222222222222222222222222222222222222222222222222222222222222")
+	tips := []*daghash.Hash{tip1, tip2}
+
+	serializedTips, err := serializeIndexTips(tips)
+	if err != nil {
+		t.Fatalf("TestIndexTipsSerializationAndDeserialization: serialization failed: %s", err)
+	}
+	deserializedTips, err := deserializeIndexTips(serializedTips)
+	if err != nil {
+		t.Fatalf("TestIndexTipsSerializationAndDeserialization: deserialization failed: %s", err)
+	}
+	if !reflect.DeepEqual(tips, deserializedTips) {
+		t.Fatalf("TestIndexTipsSerializationAndDeserialization: original tips and " +
+			"deserialized tips aren't equal")
+	}
+}
+
+// TestManagerCatchUp tests that the index manager catches up indexes that
+// lag behind the DAG.
+// It does it by following these steps:
+// * It creates a DAG with enabled transaction and UTXO indexes (let's call it
+//   dag1) and makes it process some blocks.
+// * It creates a copy of dag1 (let's call it dag2) with no indexes at all.
+// * It processes two more blocks in both dag1 and dag2.
+// * It reopens dag2 with the transaction and UTXO indexes, as well as with an
+//   address index that had never been built, while requesting an interrupt,
+//   and checks that catching up the indexes is interrupted.
+// * It reopens dag2 once more, and checks that the indexes are caught up by
+//   comparing them with the indexes of dag1.
+func TestManagerCatchUp(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	testFiles := []string{
+		"blk_0_to_4.dat",
+		"blk_3B.dat",
+	}
+
+	var blocks []*util.Block
+	for _, file := range testFiles {
+		blockTmp, err := blockdag.LoadBlocks(filepath.Join("../testdata/", file))
+		if err != nil {
+			t.Fatalf("Error loading file: %v\n", err)
+		}
+		blocks = append(blocks, blockTmp...)
+	}
+
+	processBlocks := func(dag *blockdag.BlockDAG, blocks []*util.Block) {
+		for i, block := range blocks {
+			isOrphan, isDelayed, err := dag.ProcessBlock(block, blockdag.BFNone)
+			if err != nil {
+				t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+			}
+			if isDelayed {
+				t.Fatalf("ProcessBlock: block %d "+
+					"is too far in the future", i)
+			}
+			if isOrphan {
+				t.Fatalf("ProcessBlock incorrectly returned block %v "+
+					"is an orphan\n", i)
+			}
+		}
+	}
+
+	db1Path, err := ioutil.TempDir("", "TestManagerCatchUp1")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(db1Path)
+
+	databaseContext1, err := dbaccess.New(db1Path)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext1.Close()
+
+	db1TxIndex := NewTxIndex()
+	db1UTXOIndex := NewUTXOIndex()
+	dag1, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{db1TxIndex, db1UTXOIndex}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext1,
+	})
+	if err != nil {
+		t.Fatalf("TestManagerCatchUp: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+	processBlocks(dag1, blocks[1:len(blocks)-2])
+
+	db2Path, err := ioutil.TempDir("", "TestManagerCatchUp2")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(db2Path)
+
+	err = copyDirectory(db1Path, db2Path)
+	if err != nil {
+		t.Fatalf("copyDirectory: %s", err)
+	}
+	processBlocks(dag1, blocks[len(blocks)-2:])
+
+	databaseContext2, err := dbaccess.New(db2Path)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	dag2, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+		DAGParams:       &params,
+		DatabaseContext: databaseContext2,
+	})
+	if err != nil {
+		t.Fatalf("TestManagerCatchUp: Failed to setup DAG instance: %v", err)
+	}
+	processBlocks(dag2, blocks[len(blocks)-2:])
+	if teardown != nil {
+		teardown()
+	}
+
+	interrupt := make(chan struct{})
+	close(interrupt)
+	_, _, err = blockdag.DAGSetup("", false, blockdag.Config{
+		Interrupt:       interrupt,
+		IndexManager:    NewManager([]Indexer{NewTxIndex(), NewUTXOIndex(), NewAddrIndex()}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext2,
+	})
+	if err == nil || !strings.Contains(err.Error(), errInterruptRequested.Error()) {
+		t.Fatalf("TestManagerCatchUp: expected catching up the indexes to be "+
+			"interrupted, but got: %v", err)
+	}
+
+	db2TxIndex := NewTxIndex()
+	db2UTXOIndex := NewUTXOIndex()
+	db2AddrIndex := NewAddrIndex()
+	db2Indexes := []Indexer{db2TxIndex, db2UTXOIndex, db2AddrIndex}
+	dag2, teardown, err = blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager(db2Indexes),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext2,
+	})
+	if err != nil {
+		t.Fatalf("TestManagerCatchUp: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+	defer databaseContext2.Close()
+
+	for _, indexer := range db2Indexes {
+		tips, err := fetchIndexTips(databaseContext2, indexer)
+		if err != nil {
+			t.Fatalf("fetchIndexTips: %s", err)
+		}
+		missingBlocks, err := dag2.BlockHashesNotInPastOf(tips)
+		if err != nil {
+			t.Fatalf("BlockHashesNotInPastOf: %s", err)
+		}
+		if len(missingBlocks) != 0 {
+			t.Fatalf("the %s is still missing %d blocks", indexer.Name(), len(missingBlocks))
+		}
+	}
+
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			_, expectedBlockHash, err := db1TxIndex.Tx(tx.ID())
+			if err != nil {
+				t.Fatalf("Tx: %s", err)
+			}
+			_, blockHash, err := db2TxIndex.Tx(tx.ID())
+			if err != nil {
+				t.Fatalf("the caught up transaction index is missing transaction %s: %s", tx.ID(), err)
+			}
+			if !blockHash.IsEqual(expectedBlockHash) {
+				t.Fatalf("the caught up transaction index differs from the original one")
+			}
+
+			for _, txOut := range tx.MsgTx().TxOut {
+				expectedUTXOs, err := db1UTXOIndex.UTXOsByScriptPubKey(txOut.ScriptPubKey)
+				if err != nil {
+					t.Fatalf("UTXOsByScriptPubKey: %s", err)
+				}
+				utxos, err := db2UTXOIndex.UTXOsByScriptPubKey(txOut.ScriptPubKey)
+				if err != nil {
+					t.Fatalf("UTXOsByScriptPubKey: %s", err)
+				}
+				if !reflect.DeepEqual(utxos, expectedUTXOs) {
+					t.Fatalf("the caught up UTXO index differs from the original one")
+				}
+			}
+		}
+	}
+
+	entries, err := db2AddrIndex.EntriesByChainBlock(dag2.SelectedTipHash())
+	if err != nil {
+		t.Fatalf("the address index wasn't built up to the selected tip: %s", err)
+	}
+	if len(entries) == 0 {
+		t.Fatalf("the address index is unexpectedly empty at the selected tip")
+	}
+}
+
+// TestManagerChainChanged tests that the index manager disconnects the chain
+// blocks that are removed from the selected parent chain from the indexes.
+// It does it by following these steps:
+// * It creates a DAG with all the indexes enabled, and makes it process a
+//   transaction that spends an output, on top of a short chain (let's call
+//   it the "a" branch).
+// * It makes the DAG process a longer competing branch (let's call it the
+//   "c" branch), and checks that the transaction is no longer accepted and
+//   that the output it spends is unspent again.
+// * It extends the "a" branch further, and checks that the transaction is
+//   accepted again.
+// * It rebuilds all the indexes from scratch, and checks that they're equal
+//   to the indexes that went through the reorganizations.
+func TestManagerChainChanged(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	dbPath, err := ioutil.TempDir("", "TestManagerChainChanged")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	databaseContext, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext.Close()
+
+	acceptanceIndex := NewAcceptanceIndex()
+	utxoIndex := NewUTXOIndex()
+	addrIndex := NewAddrIndex()
+	dag, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{NewTxIndex(), acceptanceIndex, utxoIndex, addrIndex}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext,
+	})
+	if err != nil {
+		t.Fatalf("TestManagerChainChanged: Failed to setup DAG instance: %v", err)
+	}
+
+	removedChainBlockCount := 0
+	dag.Subscribe(func(notification *blockdag.Notification) {
+		if notification.Type == blockdag.NTChainChanged {
+			data := notification.Data.(*blockdag.ChainChangedNotificationData)
+			removedChainBlockCount += len(data.RemovedChainBlockHashes)
+		}
+	})
+
+	address, err := util.NewAddressScriptHash(blockdag.OpTrueScript, params.Prefix)
+	if err != nil {
+		t.Fatalf("NewAddressScriptHash: %s", err)
+	}
+	scriptPubKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %s", err)
+	}
+
+	processChain := func(parentHash *daghash.Hash, length int,
+		transactions []*domainmessage.MsgTx) *daghash.Hash {
+
+		for i := 0; i < length; i++ {
+			block := blockdag.PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{parentHash}, transactions)
+			parentHash = block.BlockHash()
+			transactions = nil
+		}
+		return parentHash
+	}
+	forkHash := processChain(params.GenesisHash, 3, nil)
+
+	utxos, err := utxoIndex.UTXOsByScriptPubKey(scriptPubKey)
+	if err != nil {
+		t.Fatalf("UTXOsByScriptPubKey: %s", err)
+	}
+	if len(utxos) == 0 {
+		t.Fatalf("TestManagerChainChanged: no UTXOs to spend")
+	}
+	var spentOutpoint domainmessage.Outpoint
+	for outpoint := range utxos {
+		spentOutpoint = outpoint
+		break
+	}
+	signatureScript, err := txscript.PayToScriptHashSignatureScript(blockdag.OpTrueScript, nil)
+	if err != nil {
+		t.Fatalf("Failed to build signature script: %s", err)
+	}
+	txIn := &domainmessage.TxIn{
+		PreviousOutpoint: spentOutpoint,
+		SignatureScript:  signatureScript,
+		Sequence:         domainmessage.MaxTxInSequenceNum,
+	}
+	txOut := &domainmessage.TxOut{
+		ScriptPubKey: scriptPubKey,
+		Value:        utxos[spentOutpoint].Amount(),
+	}
+	tx := domainmessage.NewNativeMsgTx(domainmessage.TxVersion, []*domainmessage.TxIn{txIn}, []*domainmessage.TxOut{txOut})
+	txOutpoint := *domainmessage.NewOutpoint(tx.TxID(), 0)
+
+	checkTxAccepted := func(expectedAcceptingBlockHash *daghash.Hash) {
+		acceptingBlockHash, err := acceptanceIndex.AcceptingBlockHash(tx.TxID())
+		if err != nil {
+			t.Fatalf("AcceptingBlockHash: %s", err)
+		}
+		if !acceptingBlockHash.IsEqual(expectedAcceptingBlockHash) {
+			t.Fatalf("expected the transaction to be accepted by %s, but got %s",
+				expectedAcceptingBlockHash, acceptingBlockHash)
+		}
+		utxos, err := utxoIndex.UTXOsByScriptPubKey(scriptPubKey)
+		if err != nil {
+			t.Fatalf("UTXOsByScriptPubKey: %s", err)
+		}
+		if _, ok := utxos[spentOutpoint]; ok {
+			t.Fatalf("the output spent by the transaction is unexpectedly in the UTXO index")
+		}
+		if _, ok := utxos[txOutpoint]; !ok {
+			t.Fatalf("the output of the transaction is unexpectedly missing from the UTXO index")
+		}
+		entries, err := addrIndex.EntriesByChainBlock(expectedAcceptingBlockHash)
+		if err != nil {
+			t.Fatalf("EntriesByChainBlock: %s", err)
+		}
+		found := false
+		for _, entry := range entries {
+			if entry.TxID.IsEqual(tx.TxID()) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("the transaction is unexpectedly missing from the address index")
+		}
+	}
+
+	aTip := processChain(forkHash, 2, []*domainmessage.MsgTx{tx})
+	checkTxAccepted(aTip)
+	if removedChainBlockCount != 0 {
+		t.Fatalf("expected no chain blocks to be removed, but %d were", removedChainBlockCount)
+	}
+
+	processChain(forkHash, 3, nil)
+	if removedChainBlockCount != 2 {
+		t.Fatalf("expected 2 chain blocks to be removed, but %d were", removedChainBlockCount)
+	}
+	_, err = acceptanceIndex.AcceptingBlockHash(tx.TxID())
+	if !dbaccess.IsNotFoundError(err) {
+		t.Fatalf("expected the transaction to no longer be accepted, but got: %v", err)
+	}
+	utxos, err = utxoIndex.UTXOsByScriptPubKey(scriptPubKey)
+	if err != nil {
+		t.Fatalf("UTXOsByScriptPubKey: %s", err)
+	}
+	if _, ok := utxos[spentOutpoint]; !ok {
+		t.Fatalf("the output spent by the removed transaction wasn't restored to the UTXO index")
+	}
+	if _, ok := utxos[txOutpoint]; ok {
+		t.Fatalf("the output of the removed transaction is unexpectedly in the UTXO index")
+	}
+	_, err = addrIndex.EntriesByChainBlock(aTip)
+	if !dbaccess.IsNotFoundError(err) {
+		t.Fatalf("expected the entries of a removed chain block to be removed, but got: %v", err)
+	}
+
+	processChain(aTip, 3, nil)
+	if removedChainBlockCount != 5 {
+		t.Fatalf("expected 5 chain blocks to be removed, but %d were", removedChainBlockCount)
+	}
+	checkTxAccepted(aTip)
+
+	expectedUTXOs, err := utxoIndex.UTXOsByScriptPubKey(scriptPubKey)
+	if err != nil {
+		t.Fatalf("UTXOsByScriptPubKey: %s", err)
+	}
+	expectedEntries, _, err := addrIndex.TxsByAddress(address.EncodeAddress(), nil, math.MaxInt32)
+	if err != nil {
+		t.Fatalf("TxsByAddress: %s", err)
+	}
+	if teardown != nil {
+		teardown()
+	}
+
+	for _, drop := range []func(*dbaccess.DatabaseContext) error{DropUTXOIndex, DropAddrIndex} {
+		err := drop(databaseContext)
+		if err != nil {
+			t.Fatalf("failed dropping an index: %s", err)
+		}
+	}
+	rebuiltUTXOIndex := NewUTXOIndex()
+	rebuiltAddrIndex := NewAddrIndex()
+	_, teardown, err = blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{rebuiltUTXOIndex, rebuiltAddrIndex}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext,
+	})
+	if err != nil {
+		t.Fatalf("TestManagerChainChanged: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	utxos, err = rebuiltUTXOIndex.UTXOsByScriptPubKey(scriptPubKey)
+	if err != nil {
+		t.Fatalf("UTXOsByScriptPubKey: %s", err)
+	}
+	if !reflect.DeepEqual(utxos, expectedUTXOs) {
+		t.Fatalf("the UTXO index differs from one that's rebuilt from scratch")
+	}
+	entries, _, err := rebuiltAddrIndex.TxsByAddress(address.EncodeAddress(), nil, math.MaxInt32)
+	if err != nil {
+		t.Fatalf("TxsByAddress: %s", err)
+	}
+	if !reflect.DeepEqual(entries, expectedEntries) {
+		t.Fatalf("the address index differs from one that's rebuilt from scratch")
+	}
+}
+
+// TestManagerCatchUpBelowFinality tests that indexes can be caught up on a DAG
+// that has passed its finality point only if the acceptance data of the
+// finalized blocks was stored by the acceptance index, and that catching them
+// up fails with errResyncRequired otherwise.
+func TestManagerCatchUpBelowFinality(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+	params.FinalityDuration = 10 * params.TargetTimePerBlock
+
+	tests := []struct {
+		name            string
+		originalIndexes []Indexer
+		expectedErr     error
+	}{
+		{
+			name:            "without stored acceptance data",
+			originalIndexes: nil,
+			expectedErr:     errResyncRequired,
+		},
+		{
+			name:            "with stored acceptance data",
+			originalIndexes: []Indexer{NewAcceptanceIndex()},
+			expectedErr:     nil,
+		},
+	}
+
+	for _, test := range tests {
+		func() {
+			dbPath, err := ioutil.TempDir("", "TestManagerCatchUpBelowFinality")
+			if err != nil {
+				t.Fatalf("Error creating temporary directory: %s", err)
+			}
+			defer os.RemoveAll(dbPath)
+
+			databaseContext, err := dbaccess.New(dbPath)
+			if err != nil {
+				t.Fatalf("error creating db: %s", err)
+			}
+			defer databaseContext.Close()
+
+			var indexManager blockdag.IndexManager
+			if test.originalIndexes != nil {
+				indexManager = NewManager(test.originalIndexes)
+			}
+			dag, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+				IndexManager:    indexManager,
+				DAGParams:       &params,
+				DatabaseContext: databaseContext,
+			})
+			if err != nil {
+				t.Fatalf("%s: Failed to setup DAG instance: %v", test.name, err)
+			}
+			tipHash := params.GenesisHash
+			for i := uint64(0); i < 4*dag.FinalityInterval(); i++ {
+				block := blockdag.PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{tipHash}, nil)
+				tipHash = block.BlockHash()
+			}
+
+			// The teardown waits for the UTXO diffs of the
+			// finalized blocks to be removed.
+			teardown()
+			if !dag.IsKnownFinalizedBlock(params.GenesisHash) {
+				t.Fatalf("%s: expected the genesis to be finalized", test.name)
+			}
+
+			txIndex := NewTxIndex()
+			indexes := append([]Indexer{txIndex}, test.originalIndexes...)
+			_, teardown, err = blockdag.DAGSetup("", false, blockdag.Config{
+				IndexManager:    NewManager(indexes),
+				DAGParams:       &params,
+				DatabaseContext: databaseContext,
+			})
+			if test.expectedErr != nil {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr.Error()) {
+					t.Fatalf("%s: expected catching up the indexes to fail with %q, "+
+						"but got: %v", test.name, test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: Failed to setup DAG instance: %v", test.name, err)
+			}
+			defer teardown()
+
+			_, blockHash, err := txIndex.Tx(params.GenesisBlock.Transactions[0].TxID())
+			if err != nil {
+				t.Fatalf("%s: the transaction index is missing the genesis coinbase: %s",
+					test.name, err)
+			}
+			if !blockHash.IsEqual(params.GenesisHash) {
+				t.Fatalf("%s: expected the genesis coinbase to be in the genesis, but got %s",
+					test.name, blockHash)
+			}
+		}()
+	}
+}
@@ -0,0 +1,230 @@
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TxIndex implements a transaction by ID index. That is to say, it stores
+// a mapping between a transaction's ID and the block that contains it,
+// along with the location of the transaction within the serialized block.
+//
+// Note that a transaction may be included in more than one block. In such
+// a case, the index points to the first such block that had been indexed.
+type TxIndex struct {
+	dag             *blockdag.BlockDAG
+	databaseContext *dbaccess.DatabaseContext
+}
+
+// Ensure the TxIndex type implements the Indexer interface.
+var _ Indexer = (*TxIndex)(nil)
+
+// txIndexKey is the key of the transaction index.
+var txIndexKey = []byte("txindex")
+
+// NewTxIndex returns a new instance of an indexer that is used to create a
+// mapping between transaction IDs and the blocks that contain them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockdag package. This allows the index to be
+// seamlessly maintained along with the DAG.
+func NewTxIndex() *TxIndex {
+	return &TxIndex{}
+}
+
+// DropTxIndex drops the transaction index.
+func DropTxIndex(databaseContext *dbaccess.DatabaseContext) error {
+	dbTx, err := databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dbaccess.DropTxIndex(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dropIndexTips(dbTx, txIndexKey)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Key returns the key of the transaction index.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) Key() []byte {
+	return txIndexKey
+}
+
+// Name returns the human-readable name of the transaction index.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) Name() string {
+	return "transaction index"
+}
+
+// Init initializes the transaction index.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error {
+	idx.dag = dag
+	idx.databaseContext = databaseContext
+	return nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the DAG.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) ConnectBlock(dbContext *dbaccess.TxContext, blockHash *daghash.Hash,
+	_ blockdag.MultiBlockTxsAcceptanceData) error {
+
+	return idx.indexBlock(dbContext, blockHash)
+}
+
+// ConnectChainBlock is invoked by the index manager when a block has been
+// added to the selected parent chain. The transaction index maps
+// transactions to the blocks that contain them, which doesn't depend on
+// the selected parent chain, so this is a no-op.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) ConnectChainBlock(_ *dbaccess.TxContext, _ *daghash.Hash,
+	_ blockdag.MultiBlockTxsAcceptanceData) error {
+
+	return nil
+}
+
+// DisconnectChainBlock is invoked by the index manager when a block has been
+// removed from the selected parent chain. This is a no-op for the same reason
+// ConnectChainBlock is.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) DisconnectChainBlock(_ *dbaccess.TxContext, _ *daghash.Hash) error {
+	return nil
+}
+
+// indexBlock adds all the transactions of the block of the given hash to the index.
+func (idx *TxIndex) indexBlock(dbContext *dbaccess.TxContext, blockHash *daghash.Hash) error {
+	block, err := idx.dag.BlockByHash(blockHash)
+	if err != nil {
+		return err
+	}
+	txLocs, err := block.TxLoc()
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range block.Transactions() {
+		exists, err := dbaccess.HasTxIndexEntry(dbContext, tx.ID())
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		serializedTxIndexEntry, err := serializeTxIndexEntry(blockHash, txLocs[i])
+		if err != nil {
+			return err
+		}
+		err = dbaccess.StoreTxIndexEntry(dbContext, tx.ID(), serializedTxIndexEntry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TxLocation returns the hash of the block that contains the transaction
+// with the given ID, along with the location of the transaction within the
+// serialized block.
+// Returns ErrNotFound if the transaction is not in the index.
+func (idx *TxIndex) TxLocation(txID *daghash.TxID) (*daghash.Hash, *domainmessage.TxLoc, error) {
+	serializedTxIndexEntry, err := dbaccess.FetchTxIndexEntry(idx.databaseContext, txID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return deserializeTxIndexEntry(serializedTxIndexEntry)
+}
+
+// Tx returns the transaction with the given ID, along with the hash of the
+// block that contains it.
+// Returns ErrNotFound if the transaction is not in the index.
+func (idx *TxIndex) Tx(txID *daghash.TxID) (*util.Tx, *daghash.Hash, error) {
+	blockHash, txLoc, err := idx.TxLocation(txID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blockBytes, err := dbaccess.FetchBlock(idx.databaseContext, blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	txEnd := txLoc.TxStart + txLoc.TxLen
+	if txLoc.TxStart < 0 || txEnd > len(blockBytes) {
+		return nil, nil, errors.Errorf("location of transaction %s is out of "+
+			"the bounds of block %s", txID, blockHash)
+	}
+	tx, err := util.NewTxFromBytes(blockBytes[txLoc.TxStart:txEnd])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx, blockHash, nil
+}
+
+// serializeTxIndexEntry serializes a tx index entry in the following format:
+//
+//	Name      | Data type | Description
+//	--------- | --------- | -----------
+//	blockHash | Hash      | The hash of the block that contains the transaction
+//	txStart   | uint32    | The offset of the transaction within the serialized block
+//	txLen     | uint32    | The length of the serialized transaction
+func serializeTxIndexEntry(blockHash *daghash.Hash, txLoc domainmessage.TxLoc) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := domainmessage.WriteElement(w, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint32(w, binary.LittleEndian, uint32(txLoc.TxStart))
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint32(w, binary.LittleEndian, uint32(txLoc.TxLen))
+	if err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeTxIndexEntry(serializedTxIndexEntry []byte) (*daghash.Hash, *domainmessage.TxLoc, error) {
+	r := bytes.NewReader(serializedTxIndexEntry)
+	blockHash := &daghash.Hash{}
+	err := domainmessage.ReadElement(r, blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	txStart, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, nil, err
+	}
+	txLen, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blockHash, &domainmessage.TxLoc{TxStart: int(txStart), TxLen: int(txLen)}, nil
+}
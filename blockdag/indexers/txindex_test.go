@@ -0,0 +1,154 @@
+package indexers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestTxIndexSerializationAndDeserialization(t *testing.T) {
+	blockHash := &daghash.Hash{1, 2, 3}
+	txLoc := domainmessage.TxLoc{TxStart: 123, TxLen: 456}
+
+	serializedEntry, err := serializeTxIndexEntry(blockHash, txLoc)
+	if err != nil {
+		t.Fatalf("TestTxIndexSerializationAndDeserialization: serialization failed: %s", err)
+	}
+	deserializedBlockHash, deserializedTxLoc, err := deserializeTxIndexEntry(serializedEntry)
+	if err != nil {
+		t.Fatalf("TestTxIndexSerializationAndDeserialization: deserialization failed: %s", err)
+	}
+	if *deserializedBlockHash != *blockHash {
+		t.Fatalf("TestTxIndexSerializationAndDeserialization: original block hash and "+
+			"deserialized block hash aren't equal: %s != %s", blockHash, deserializedBlockHash)
+	}
+	if *deserializedTxLoc != txLoc {
+		t.Fatalf("TestTxIndexSerializationAndDeserialization: original tx location and "+
+			"deserialized tx location aren't equal: %v != %v", txLoc, deserializedTxLoc)
+	}
+}
+
+// TestTxIndex tests that every transaction of every processed block can
+// be fetched from the transaction index, and that the index is rebuilt
+// after being dropped.
+func TestTxIndex(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	testFiles := []string{
+		"blk_0_to_4.dat",
+		"blk_3B.dat",
+	}
+
+	var blocks []*util.Block
+	for _, file := range testFiles {
+		blockTmp, err := blockdag.LoadBlocks(filepath.Join("../testdata/", file))
+		if err != nil {
+			t.Fatalf("Error loading file: %v\n", err)
+		}
+		blocks = append(blocks, blockTmp...)
+	}
+
+	dbPath, err := ioutil.TempDir("", "TestTxIndex")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	databaseContext1, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+
+	txIndex1 := NewTxIndex()
+	dag1, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{txIndex1}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext1,
+	})
+	if err != nil {
+		t.Fatalf("TestTxIndex: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		isOrphan, isDelayed, err := dag1.ProcessBlock(blocks[i], blockdag.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+		if isDelayed {
+			t.Fatalf("ProcessBlock: block %d "+
+				"is too far in the future", i)
+		}
+		if isOrphan {
+			t.Fatalf("ProcessBlock incorrectly returned block %v "+
+				"is an orphan\n", i)
+		}
+	}
+
+	checkTxIndex := func(txIndex *TxIndex) {
+		for _, block := range blocks {
+			for _, expectedTx := range block.Transactions() {
+				tx, blockHash, err := txIndex.Tx(expectedTx.ID())
+				if err != nil {
+					t.Fatalf("Tx: %s", err)
+				}
+				if !blockHash.IsEqual(block.Hash()) {
+					t.Fatalf("unexpected containing block for transaction %s. "+
+						"Want: %s, got: %s", expectedTx.ID(), block.Hash(), blockHash)
+				}
+				if !reflect.DeepEqual(tx.MsgTx(), expectedTx.MsgTx()) {
+					t.Fatalf("transaction %s fetched from the index differs "+
+						"from the original one", expectedTx.ID())
+				}
+			}
+		}
+	}
+	checkTxIndex(txIndex1)
+
+	_, _, err = txIndex1.Tx(&daghash.TxID{})
+	if !dbaccess.IsNotFoundError(err) {
+		t.Fatalf("Tx: expected a not-found error for a missing transaction, got: %v", err)
+	}
+
+	err = databaseContext1.Close()
+	if err != nil {
+		t.Fatalf("Error closing the database: %s", err)
+	}
+	databaseContext2, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext2.Close()
+
+	err = DropTxIndex(databaseContext2)
+	if err != nil {
+		t.Fatalf("DropTxIndex: %s", err)
+	}
+
+	txIndex2 := NewTxIndex()
+	_, teardown, err = blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{txIndex2}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext2,
+	})
+	if err != nil {
+		t.Fatalf("TestTxIndex: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	checkTxIndex(txIndex2)
+}
@@ -0,0 +1,462 @@
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// UTXOIndex implements a UTXO by scriptPubKey index. That is to say, it
+// stores a mapping between a scriptPubKey and all the unspent outputs that
+// pay to it, as accepted by the blocks of the selected parent chain.
+type UTXOIndex struct {
+	dag             *blockdag.BlockDAG
+	databaseContext *dbaccess.DatabaseContext
+}
+
+// Ensure the UTXOIndex type implements the Indexer interface.
+var _ Indexer = (*UTXOIndex)(nil)
+
+// utxoIndexKey is the key of the UTXO index.
+var utxoIndexKey = []byte("utxoindex")
+
+// NewUTXOIndex returns a new instance of an indexer that is used to create a
+// mapping between scriptPubKeys and the UTXOs that pay to them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockdag package. This allows the index to be
+// seamlessly maintained along with the DAG.
+func NewUTXOIndex() *UTXOIndex {
+	return &UTXOIndex{}
+}
+
+// DropUTXOIndex drops the UTXO index.
+func DropUTXOIndex(databaseContext *dbaccess.DatabaseContext) error {
+	dbTx, err := databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dbaccess.DropUTXOIndex(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dropIndexTips(dbTx, utxoIndexKey)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Key returns the key of the UTXO index.
+//
+// This is part of the Indexer interface.
+func (idx *UTXOIndex) Key() []byte {
+	return utxoIndexKey
+}
+
+// Name returns the human-readable name of the UTXO index.
+//
+// This is part of the Indexer interface.
+func (idx *UTXOIndex) Name() string {
+	return "UTXO index"
+}
+
+// Init initializes the UTXO index. Chain blocks can't be disconnected from
+// a UTXO index that was built before the index manager kept track of its chain
+// tip, so such an index is dropped in order for the index manager to rebuild
+// it from scratch.
+//
+// This is part of the Indexer interface.
+func (idx *UTXOIndex) Init(dag *blockdag.BlockDAG, databaseContext *dbaccess.DatabaseContext) error {
+	idx.dag = dag
+	idx.databaseContext = databaseContext
+
+	shouldRebuild, err := isBuiltWithoutChainTip(databaseContext, idx)
+	if err != nil {
+		return err
+	}
+	if !shouldRebuild {
+		return nil
+	}
+	return DropUTXOIndex(databaseContext)
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the DAG. Only blocks that are in the selected parent chain
+// modify the index, since only they define which transactions the DAG
+// accepts, so this is a no-op. See ConnectChainBlock.
+//
+// This is part of the Indexer interface.
+func (idx *UTXOIndex) ConnectBlock(_ *dbaccess.TxContext, _ *daghash.Hash,
+	_ blockdag.MultiBlockTxsAcceptanceData) error {
+
+	return nil
+}
+
+// ConnectChainBlock is invoked by the index manager when a block has been
+// added to the selected parent chain. It removes all the outputs spent by
+// the transactions accepted by the given chain block from the index, and
+// adds all the outputs they create. The removed outputs are kept aside in
+// the block's undo data, so that they could be restored if the block is
+// removed from the selected parent chain.
+//
+// This is part of the Indexer interface.
+func (idx *UTXOIndex) ConnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash,
+	txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+
+	blueScore, err := idx.dag.BlueScoreByBlockHash(chainBlockHash)
+	if err != nil {
+		return err
+	}
+
+	// Database transactions don't see their own writes, so outputs that
+	// are both created and spent by this block must never reach the
+	// database. Collect the block's outputs first, and only then write them.
+	added := make(map[domainmessage.Outpoint]*blockdag.UTXOEntry)
+	undoData := &utxoIndexUndoData{}
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if !txAcceptanceData.IsAccepted {
+				continue
+			}
+			tx := txAcceptanceData.Tx
+			msgTx := tx.MsgTx()
+			isCoinbase := tx.IsCoinBase()
+			if !isCoinbase {
+				for _, txIn := range msgTx.TxIn {
+					if _, ok := added[txIn.PreviousOutpoint]; ok {
+						delete(added, txIn.PreviousOutpoint)
+						continue
+					}
+					err := idx.spendOutput(dbContext, undoData, &txIn.PreviousOutpoint)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			for i, txOut := range msgTx.TxOut {
+				outpoint := *domainmessage.NewOutpoint(tx.ID(), uint32(i))
+				added[outpoint] = blockdag.NewUTXOEntry(txOut, isCoinbase, blueScore)
+			}
+		}
+	}
+
+	for outpoint, entry := range added {
+		serializedEntry, err := serializeUTXOIndexEntry(entry)
+		if err != nil {
+			return err
+		}
+		outpointKey := serializeUTXOIndexOutpoint(&outpoint)
+		err = dbaccess.AddToUTXOIndex(dbContext, entry.ScriptPubKey(), outpointKey, serializedEntry)
+		if err != nil {
+			return err
+		}
+		undoData.addedOutpoints = append(undoData.addedOutpoints, outpointKey)
+	}
+
+	serializedUndoData, err := serializeUTXOIndexUndoData(undoData)
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreUTXOIndexUndoData(dbContext, chainBlockHash, serializedUndoData)
+}
+
+// spendOutput removes the output of the given outpoint from the
+// index, and records it in the given undo data.
+func (idx *UTXOIndex) spendOutput(dbContext *dbaccess.TxContext, undoData *utxoIndexUndoData,
+	outpoint *domainmessage.Outpoint) error {
+
+	outpointKey := serializeUTXOIndexOutpoint(outpoint)
+	scriptPubKey, serializedEntry, err := dbaccess.FetchFromUTXOIndex(dbContext, outpointKey)
+	if dbaccess.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = dbaccess.RemoveFromUTXOIndex(dbContext, outpointKey)
+	if err != nil {
+		return err
+	}
+	undoData.spentUTXOs = append(undoData.spentUTXOs, &utxoIndexSpentUTXO{
+		outpointKey:     outpointKey,
+		scriptPubKey:    scriptPubKey,
+		serializedEntry: serializedEntry,
+	})
+	return nil
+}
+
+// DisconnectChainBlock is invoked by the index manager when a block has been
+// removed from the selected parent chain. It removes all the outputs that the
+// block had added to the index, and restores all the outputs that it had
+// removed.
+//
+// This is part of the Indexer interface.
+func (idx *UTXOIndex) DisconnectChainBlock(dbContext *dbaccess.TxContext, chainBlockHash *daghash.Hash) error {
+	serializedUndoData, err := dbaccess.FetchUTXOIndexUndoData(dbContext, chainBlockHash)
+	if err != nil {
+		return err
+	}
+	undoData, err := deserializeUTXOIndexUndoData(serializedUndoData)
+	if err != nil {
+		return err
+	}
+
+	for _, outpointKey := range undoData.addedOutpoints {
+		err := dbaccess.RemoveFromUTXOIndex(dbContext, outpointKey)
+		if err != nil {
+			return err
+		}
+	}
+	for _, spentUTXO := range undoData.spentUTXOs {
+		err := dbaccess.AddToUTXOIndex(dbContext, spentUTXO.scriptPubKey,
+			spentUTXO.outpointKey, spentUTXO.serializedEntry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbaccess.RemoveUTXOIndexUndoData(dbContext, chainBlockHash)
+}
+
+// UTXOsByScriptPubKey returns all the UTXOs that pay to the given scriptPubKey.
+func (idx *UTXOIndex) UTXOsByScriptPubKey(scriptPubKey []byte) (map[domainmessage.Outpoint]*blockdag.UTXOEntry, error) {
+	cursor, err := dbaccess.UTXOIndexCursor(idx.databaseContext, scriptPubKey)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	utxos := make(map[domainmessage.Outpoint]*blockdag.UTXOEntry)
+	for cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, err
+		}
+		outpoint, err := deserializeUTXOIndexOutpoint(key.Suffix())
+		if err != nil {
+			return nil, err
+		}
+
+		serializedEntry, err := cursor.Value()
+		if err != nil {
+			return nil, err
+		}
+		entry, err := deserializeUTXOIndexEntry(serializedEntry, scriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		utxos[*outpoint] = entry
+	}
+
+	return utxos, nil
+}
+
+// utxoIndexOutpointSize is the size of a serialized outpoint:
+// a transaction ID followed by a big-endian uint32 output index.
+// Big-endian is used so that the outputs of the same transaction are
+// sorted by their index.
+const utxoIndexOutpointSize = daghash.TxIDSize + 4
+
+func serializeUTXOIndexOutpoint(outpoint *domainmessage.Outpoint) []byte {
+	serializedOutpoint := make([]byte, utxoIndexOutpointSize)
+	copy(serializedOutpoint, outpoint.TxID[:])
+	binary.BigEndian.PutUint32(serializedOutpoint[daghash.TxIDSize:], outpoint.Index)
+	return serializedOutpoint
+}
+
+func deserializeUTXOIndexOutpoint(serializedOutpoint []byte) (*domainmessage.Outpoint, error) {
+	if len(serializedOutpoint) != utxoIndexOutpointSize {
+		return nil, errors.Errorf("unexpected serialized outpoint size %d, expected %d",
+			len(serializedOutpoint), utxoIndexOutpointSize)
+	}
+	txID, err := daghash.NewTxID(serializedOutpoint[:daghash.TxIDSize])
+	if err != nil {
+		return nil, err
+	}
+	index := binary.BigEndian.Uint32(serializedOutpoint[daghash.TxIDSize:])
+	return domainmessage.NewOutpoint(txID, index), nil
+}
+
+// serializeUTXOIndexEntry serializes a UTXO entry in the following format:
+//
+//	Name       | Data type | Description
+//	---------- | --------- | -----------
+//	blueScore  | uint64    | The blue score of the block accepting the output
+//	isCoinbase | bool      | Whether the output belongs to a coinbase transaction
+//	amount     | uint64    | The amount of the output
+//
+// The scriptPubKey is omitted since it's already part of the entry's key.
+func serializeUTXOIndexEntry(entry *blockdag.UTXOEntry) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := binaryserializer.PutUint64(w, binary.LittleEndian, entry.BlockBlueScore())
+	if err != nil {
+		return nil, err
+	}
+	err = domainmessage.WriteElement(w, entry.IsCoinbase())
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint64(w, binary.LittleEndian, entry.Amount())
+	if err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeUTXOIndexEntry(serializedEntry []byte, scriptPubKey []byte) (*blockdag.UTXOEntry, error) {
+	r := bytes.NewReader(serializedEntry)
+	blueScore, err := binaryserializer.Uint64(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	var isCoinbase bool
+	err = domainmessage.ReadElement(r, &isCoinbase)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := binaryserializer.Uint64(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+
+	txOut := &domainmessage.TxOut{
+		Value:        amount,
+		ScriptPubKey: scriptPubKey,
+	}
+	return blockdag.NewUTXOEntry(txOut, isCoinbase, blueScore), nil
+}
+
+// utxoIndexUndoData is the data that's required to revert the
+// changes that a chain block made to the UTXO index.
+type utxoIndexUndoData struct {
+	addedOutpoints [][]byte
+	spentUTXOs     []*utxoIndexSpentUTXO
+}
+
+type utxoIndexSpentUTXO struct {
+	outpointKey     []byte
+	scriptPubKey    []byte
+	serializedEntry []byte
+}
+
+// serializeUTXOIndexUndoData serializes undo data as the number of the added
+// outpoints followed by the outpoints themselves, and then the number of the
+// spent UTXOs followed by the outpoint, scriptPubKey and serialized entry of
+// each of them.
+func serializeUTXOIndexUndoData(undoData *utxoIndexUndoData) ([]byte, error) {
+	w := &bytes.Buffer{}
+	err := serializeOutpointKeys(w, undoData.addedOutpoints)
+	if err != nil {
+		return nil, err
+	}
+	err = domainmessage.WriteVarInt(w, uint64(len(undoData.spentUTXOs)))
+	if err != nil {
+		return nil, err
+	}
+	for _, spentUTXO := range undoData.spentUTXOs {
+		_, err := w.Write(spentUTXO.outpointKey)
+		if err != nil {
+			return nil, err
+		}
+		err = domainmessage.WriteVarBytes(w, 0, spentUTXO.scriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+		err = domainmessage.WriteVarBytes(w, 0, spentUTXO.serializedEntry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
+
+func deserializeUTXOIndexUndoData(serializedUndoData []byte) (*utxoIndexUndoData, error) {
+	r := bytes.NewReader(serializedUndoData)
+	addedOutpoints, err := deserializeOutpointKeys(r, len(serializedUndoData))
+	if err != nil {
+		return nil, err
+	}
+	count, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(serializedUndoData)/utxoIndexOutpointSize) {
+		return nil, errors.Errorf("too many spent UTXOs: %d", count)
+	}
+	spentUTXOs := make([]*utxoIndexSpentUTXO, count)
+	for i := range spentUTXOs {
+		outpointKey := make([]byte, utxoIndexOutpointSize)
+		_, err := io.ReadFull(r, outpointKey)
+		if err != nil {
+			return nil, err
+		}
+		scriptPubKey, err := domainmessage.ReadVarBytes(r, 0,
+			uint32(len(serializedUndoData)), "scriptPubKey")
+		if err != nil {
+			return nil, err
+		}
+		serializedEntry, err := domainmessage.ReadVarBytes(r, 0,
+			uint32(len(serializedUndoData)), "serializedEntry")
+		if err != nil {
+			return nil, err
+		}
+		spentUTXOs[i] = &utxoIndexSpentUTXO{
+			outpointKey:     outpointKey,
+			scriptPubKey:    scriptPubKey,
+			serializedEntry: serializedEntry,
+		}
+	}
+	return &utxoIndexUndoData{
+		addedOutpoints: addedOutpoints,
+		spentUTXOs:     spentUTXOs,
+	}, nil
+}
+
+// serializeOutpointKeys serializes a list of serialized
+// outpoints as their number followed by the outpoints.
+func serializeOutpointKeys(w io.Writer, outpointKeys [][]byte) error {
+	err := domainmessage.WriteVarInt(w, uint64(len(outpointKeys)))
+	if err != nil {
+		return err
+	}
+	for _, outpointKey := range outpointKeys {
+		_, err := w.Write(outpointKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deserializeOutpointKeys(r io.Reader, maxSize int) ([][]byte, error) {
+	count, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(maxSize/utxoIndexOutpointSize) {
+		return nil, errors.Errorf("too many outpoints: %d", count)
+	}
+	outpointKeys := make([][]byte, count)
+	for i := range outpointKeys {
+		outpointKey := make([]byte, utxoIndexOutpointSize)
+		_, err := io.ReadFull(r, outpointKey)
+		if err != nil {
+			return nil, err
+		}
+		outpointKeys[i] = outpointKey
+	}
+	return outpointKeys, nil
+}
@@ -0,0 +1,208 @@
+package indexers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestUTXOIndexSerializationAndDeserialization(t *testing.T) {
+	txID, _ := daghash.NewTxIDFromStr("1111111111111111111111111111111111111111111111111111111111111111")
+	outpoint := domainmessage.NewOutpoint(txID, 0x01020304)
+	scriptPubKey := []byte{1, 2, 3}
+	entry := blockdag.NewUTXOEntry(&domainmessage.TxOut{ScriptPubKey: scriptPubKey, Value: 1234}, true, 5678)
+
+	deserializedOutpoint, err := deserializeUTXOIndexOutpoint(serializeUTXOIndexOutpoint(outpoint))
+	if err != nil {
+		t.Fatalf("TestUTXOIndexSerializationAndDeserialization: outpoint deserialization failed: %s", err)
+	}
+	if *deserializedOutpoint != *outpoint {
+		t.Fatalf("TestUTXOIndexSerializationAndDeserialization: original outpoint and "+
+			"deserialized outpoint aren't equal: %s != %s", outpoint, deserializedOutpoint)
+	}
+
+	serializedEntry, err := serializeUTXOIndexEntry(entry)
+	if err != nil {
+		t.Fatalf("TestUTXOIndexSerializationAndDeserialization: entry serialization failed: %s", err)
+	}
+	deserializedEntry, err := deserializeUTXOIndexEntry(serializedEntry, scriptPubKey)
+	if err != nil {
+		t.Fatalf("TestUTXOIndexSerializationAndDeserialization: entry deserialization failed: %s", err)
+	}
+	if !reflect.DeepEqual(entry, deserializedEntry) {
+		t.Fatalf("TestUTXOIndexSerializationAndDeserialization: original entry and " +
+			"deserialized entry aren't equal")
+	}
+}
+
+// TestUTXOIndexRecover tests that the UTXO index agrees with the DAG's
+// UTXO set, and that an index that's rebuilt from scratch is identical
+// to one that had been maintained while processing blocks.
+func TestUTXOIndexRecover(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	testFiles := []string{
+		"blk_0_to_4.dat",
+		"blk_3B.dat",
+	}
+
+	var blocks []*util.Block
+	for _, file := range testFiles {
+		blockTmp, err := blockdag.LoadBlocks(filepath.Join("../testdata/", file))
+		if err != nil {
+			t.Fatalf("Error loading file: %v\n", err)
+		}
+		blocks = append(blocks, blockTmp...)
+	}
+
+	dbPath, err := ioutil.TempDir("", "TestUTXOIndexRecover")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	databaseContext1, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+
+	utxoIndex1 := NewUTXOIndex()
+	dag1, teardown, err := blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{utxoIndex1}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext1,
+	})
+	if err != nil {
+		t.Fatalf("TestUTXOIndexRecover: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		isOrphan, isDelayed, err := dag1.ProcessBlock(blocks[i], blockdag.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+		if isDelayed {
+			t.Fatalf("ProcessBlock: block %d "+
+				"is too far in the future", i)
+		}
+		if isOrphan {
+			t.Fatalf("ProcessBlock incorrectly returned block %v "+
+				"is an orphan\n", i)
+		}
+	}
+
+	scriptPubKeys := make(map[string][]byte)
+	var outpoints []domainmessage.Outpoint
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			for i, txOut := range tx.MsgTx().TxOut {
+				scriptPubKeys[string(txOut.ScriptPubKey)] = txOut.ScriptPubKey
+				outpoints = append(outpoints, *domainmessage.NewOutpoint(tx.ID(), uint32(i)))
+			}
+		}
+	}
+
+	allUTXOs := make(map[domainmessage.Outpoint]*blockdag.UTXOEntry)
+	utxosByScriptPubKey := make(map[string]map[domainmessage.Outpoint]*blockdag.UTXOEntry)
+	for key, scriptPubKey := range scriptPubKeys {
+		utxos, err := utxoIndex1.UTXOsByScriptPubKey(scriptPubKey)
+		if err != nil {
+			t.Fatalf("UTXOsByScriptPubKey: %s", err)
+		}
+		for outpoint, entry := range utxos {
+			allUTXOs[outpoint] = entry
+		}
+		utxosByScriptPubKey[key] = utxos
+	}
+	if len(allUTXOs) == 0 {
+		t.Fatalf("the UTXO index is unexpectedly empty")
+	}
+
+	// The UTXO index contains the UTXOs accepted by the selected parent
+	// chain, while the DAG's UTXO set additionally contains the UTXOs
+	// accepted by the virtual. Apply the transactions accepted by the
+	// virtual on top of the index and make sure it matches the DAG.
+	virtualTxsAcceptanceData, err := dag1.TxsAcceptedByVirtual()
+	if err != nil {
+		t.Fatalf("TxsAcceptedByVirtual: %s", err)
+	}
+	for _, blockTxsAcceptanceData := range virtualTxsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if !txAcceptanceData.IsAccepted {
+				continue
+			}
+			tx := txAcceptanceData.Tx
+			if !tx.IsCoinBase() {
+				for _, txIn := range tx.MsgTx().TxIn {
+					delete(allUTXOs, txIn.PreviousOutpoint)
+				}
+			}
+			for i, txOut := range tx.MsgTx().TxOut {
+				outpoint := *domainmessage.NewOutpoint(tx.ID(), uint32(i))
+				allUTXOs[outpoint] = blockdag.NewUTXOEntry(txOut, tx.IsCoinBase(), dag1.VirtualBlueScore())
+			}
+		}
+	}
+	for _, outpoint := range outpoints {
+		expectedEntry, expectedOK := dag1.GetUTXOEntry(outpoint)
+		entry, ok := allUTXOs[outpoint]
+		if ok != expectedOK {
+			t.Fatalf("unexpected existence of outpoint %s in the UTXO index. "+
+				"Want: %t, got: %t", outpoint, expectedOK, ok)
+		}
+		if ok && !reflect.DeepEqual(entry, expectedEntry) {
+			t.Fatalf("the UTXO index entry of outpoint %s differs from the DAG's UTXO set entry", outpoint)
+		}
+	}
+
+	err = databaseContext1.Close()
+	if err != nil {
+		t.Fatalf("Error closing the database: %s", err)
+	}
+	databaseContext2, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext2.Close()
+
+	err = DropUTXOIndex(databaseContext2)
+	if err != nil {
+		t.Fatalf("DropUTXOIndex: %s", err)
+	}
+
+	utxoIndex2 := NewUTXOIndex()
+	_, teardown, err = blockdag.DAGSetup("", false, blockdag.Config{
+		IndexManager:    NewManager([]Indexer{utxoIndex2}),
+		DAGParams:       &params,
+		DatabaseContext: databaseContext2,
+	})
+	if err != nil {
+		t.Fatalf("TestUTXOIndexRecover: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for key, scriptPubKey := range scriptPubKeys {
+		utxos, err := utxoIndex2.UTXOsByScriptPubKey(scriptPubKey)
+		if err != nil {
+			t.Fatalf("UTXOsByScriptPubKey: %s", err)
+		}
+		if !reflect.DeepEqual(utxos, utxosByScriptPubKey[key]) {
+			t.Fatalf("recovery failed: the rebuilt UTXO index differs from the original one")
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package blockdag
+
+import (
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// pruningPoint returns the block whose past is pruned. It's the highest
+// block in the selected parent chain of the finality point that's at least
+// a whole finality interval below it, so that the data of the blocks that
+// were finalized last is kept for peers that are syncing with the DAG.
+// It returns nil if no block is that deep below the finality point yet.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) pruningPoint() *blockNode {
+	finalityPoint := dag.lastFinalityPoint
+	if finalityPoint.blueScore < dag.FinalityInterval() {
+		return nil
+	}
+
+	pruningPoint := finalityPoint
+	for pruningPoint.blueScore+dag.FinalityInterval() > finalityPoint.blueScore {
+		pruningPoint = pruningPoint.selectedParent
+	}
+	return pruningPoint
+}
+
+// pruneBlocks removes the data of the blocks in the past of the pruning
+// point from the database, along with the data of the indexes that isn't
+// needed for them anymore. The disk space of the pruned blocks is
+// reclaimed by compactBlockStore. If pruneBlocks fails, the blocks stay
+// unpruned, and are pruned once it's called again.
+//
+// This function MUST be called with the DAG state lock held (for writes).
+func (dag *BlockDAG) pruneBlocks() (err error) {
+	pruningPoint := dag.pruningPoint()
+	if pruningPoint == nil {
+		return nil
+	}
+	nodesToPrune := dag.nodesToPrune(pruningPoint)
+	if len(nodesToPrune) == 0 {
+		return nil
+	}
+
+	dbTx, err := dag.databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	blockHashes := make([]*daghash.Hash, len(nodesToPrune))
+	for i, node := range nodesToPrune {
+		err := dbaccess.RemoveBlock(dbTx, node.hash)
+		if err != nil {
+			return err
+		}
+		blockHashes[i] = node.hash
+	}
+
+	// Restore the status of the nodes if the transaction isn't
+	// committed, since nodesToPrune skips the nodes whose data
+	// isn't stored.
+	for _, node := range nodesToPrune {
+		dag.index.UnsetStatusFlags(node, statusDataStored)
+	}
+	defer func() {
+		if err != nil {
+			for _, node := range nodesToPrune {
+				dag.index.SetStatusFlags(node, statusDataStored)
+			}
+		}
+	}()
+
+	if dag.indexManager != nil {
+		err := dag.indexManager.PruneBlocks(dbTx, blockHashes)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = dag.index.flushToDB(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dbTx.Commit()
+	if err != nil {
+		return err
+	}
+	dag.index.clearDirtyEntries()
+
+	log.Infof("Pruned the data of %d blocks below block %s", len(nodesToPrune), pruningPoint.hash)
+	return nil
+}
+
+// pruneAndCompactBlocks prunes blocks and then compacts the block store,
+// and clears dag.isPruning once it's done. Errors aren't fatal, since
+// whatever wasn't pruned is retried on the next finality point.
+//
+// This function MUST NOT be called with the DAG state lock held.
+func (dag *BlockDAG) pruneAndCompactBlocks() {
+	defer func() {
+		dag.dagLock.Lock()
+		defer dag.dagLock.Unlock()
+
+		dag.isPruning = false
+	}()
+
+	err := func() error {
+		dag.dagLock.Lock()
+		defer dag.dagLock.Unlock()
+
+		return dag.pruneBlocks()
+	}()
+	if err != nil {
+		log.Errorf("Error pruning blocks: %s", err)
+		return
+	}
+	err = dag.compactBlockStore()
+	if err != nil {
+		log.Errorf("Error compacting the block store: %s", err)
+	}
+}
+
+// compactBlockStore reclaims the disk space of the pruned blocks. Since
+// blocks must not be stored while the block store is compacted, and the
+// compaction may take a while, the block store is compacted in bounded
+// steps, each of which holds the DAG lock, so that blocks are processed
+// between them.
+//
+// This function MUST NOT be called with the DAG state lock held.
+func (dag *BlockDAG) compactBlockStore() error {
+	for {
+		isDone, err := func() (bool, error) {
+			dag.dagLock.Lock()
+			defer dag.dagLock.Unlock()
+
+			return dbaccess.CompactBlockStoreStep(dag.databaseContext)
+		}()
+		if err != nil {
+			return err
+		}
+		if isDone {
+			return nil
+		}
+	}
+}
+
+// nodesToPrune returns the nodes in the past of the given pruning point
+// whose data is still stored. Since the past of every previous pruning
+// point is in the past of the current one, the search doesn't continue
+// below nodes whose data was already removed.
+func (dag *BlockDAG) nodesToPrune(pruningPoint *blockNode) []*blockNode {
+	var nodesToPrune []*blockNode
+	visited := newBlockSet()
+	queue := make([]*blockNode, 0, len(pruningPoint.parents))
+	for parent := range pruningPoint.parents {
+		queue = append(queue, parent)
+	}
+	for len(queue) > 0 {
+		var current *blockNode
+		current, queue = queue[0], queue[1:]
+		if visited.contains(current) {
+			continue
+		}
+		visited.add(current)
+
+		if dag.index.NodeStatus(current)&statusDataStored == 0 {
+			continue
+		}
+		nodesToPrune = append(nodesToPrune, current)
+		for parent := range current.parents {
+			queue = append(queue, parent)
+		}
+	}
+	return nodesToPrune
+}
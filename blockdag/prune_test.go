@@ -0,0 +1,134 @@
+package blockdag
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.FinalityDuration = 10 * params.TargetTimePerBlock
+	dag, teardownFunc, err := DAGSetup("TestPruneBlocks", true, Config{
+		DAGParams: &params,
+		Prune:     true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	// Start with two parallel blocks, so that there are blocks
+	// with multiple parents in the past of the pruning point.
+	var blocks []*domainmessage.MsgBlock
+	blockA := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blocks = append(blocks, params.GenesisBlock, blockA, blockB)
+	tips := []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}
+	for i := uint64(0); i < 4*dag.FinalityInterval(); i++ {
+		block := PrepareAndProcessBlockForTest(t, dag, tips, nil)
+		blocks = append(blocks, block)
+		tips = []*daghash.Hash{block.BlockHash()}
+	}
+
+	// Blocks are pruned in the background once the finality point
+	// changes, so prune them explicitly in order to wait for it.
+	dag.dagLock.Lock()
+	err = dag.pruneBlocks()
+	pruningPoint := dag.pruningPoint()
+	dag.dagLock.Unlock()
+	if err != nil {
+		t.Fatalf("pruneBlocks: %s", err)
+	}
+	err = dag.compactBlockStore()
+	if err != nil {
+		t.Fatalf("compactBlockStore: %s", err)
+	}
+	if pruningPoint == nil {
+		t.Fatalf("pruningPoint: expected a pruning point after %d blocks", len(blocks))
+	}
+	if pruningPoint.blueScore+dag.FinalityInterval() > dag.lastFinalityPoint.blueScore {
+		t.Fatalf("pruningPoint: expected the pruning point to be at least %d blocks "+
+			"below the finality point", dag.FinalityInterval())
+	}
+
+	for _, block := range blocks {
+		node, ok := dag.index.LookupNode(block.BlockHash())
+		if !ok {
+			t.Fatalf("block %s is unexpectedly missing from the block index", block.BlockHash())
+		}
+		_, err := dag.BlockByHash(block.BlockHash())
+		if node.blueScore < pruningPoint.blueScore {
+			if !IsNotInDAGErr(err) {
+				t.Fatalf("BlockByHash: expected an ErrNotInDAG for pruned block %s, but got: %v",
+					block.BlockHash(), err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("BlockByHash: unexpected error for unpruned block %s: %s", block.BlockHash(), err)
+		}
+	}
+
+	// A pruned database can't be used without pruning
+	_, err = New(&Config{
+		DAGParams:       &params,
+		DatabaseContext: dag.databaseContext,
+		TimeSource:      NewTimeSource(),
+		SigCache:        txscript.NewSigCache(1000),
+	})
+	if err == nil {
+		t.Fatalf("New: expected an error when loading a pruned DAG without pruning")
+	}
+
+	reloadedDAG, err := New(&Config{
+		DAGParams:       &params,
+		DatabaseContext: dag.databaseContext,
+		TimeSource:      NewTimeSource(),
+		SigCache:        txscript.NewSigCache(1000),
+		Prune:           true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to reload the pruned DAG: %s", err)
+	}
+	if !reloadedDAG.SelectedTipHash().IsEqual(dag.SelectedTipHash()) {
+		t.Fatalf("unexpected selected tip after reloading the pruned DAG. Want: %s, got: %s",
+			dag.SelectedTipHash(), reloadedDAG.SelectedTipHash())
+	}
+}
+
+// TestPruneBlocksWhilePruning checks that blocks aren't pruned in the
+// background while a previous pruning run is still in progress.
+func TestPruneBlocksWhilePruning(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.FinalityDuration = 10 * params.TargetTimePerBlock
+	dag, teardownFunc, err := DAGSetup("TestPruneBlocksWhilePruning", true, Config{
+		DAGParams: &params,
+		Prune:     true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG: %s", err)
+	}
+
+	dag.dagLock.Lock()
+	dag.isPruning = true
+	dag.dagLock.Unlock()
+
+	tipHash := params.GenesisHash
+	for i := uint64(0); i < 4*dag.FinalityInterval(); i++ {
+		block := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{tipHash}, nil)
+		tipHash = block.BlockHash()
+	}
+
+	// The teardown waits for all the background pruning runs
+	teardownFunc()
+	if dag.index.NodeStatus(dag.genesis)&statusDataStored == 0 {
+		t.Fatalf("the genesis was pruned while a previous pruning run was in progress")
+	}
+	if !dag.isPruning {
+		t.Fatalf("the in-progress pruning run was unexpectedly marked as done")
+	}
+}
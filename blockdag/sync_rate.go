@@ -36,10 +36,10 @@ func (dag *BlockDAG) recentBlockProcessingTimestampsRelevantWindow() []mstime.Ti
 	return dag.recentBlockProcessingTimestamps[windowStartIndex:]
 }
 
-// syncRate returns the rate of processed
-// blocks in the last syncRateWindowDuration
-// duration.
-func (dag *BlockDAG) syncRate() float64 {
+// SyncRate returns the rate of processed
+// blocks per second in the last
+// syncRateWindowDuration duration.
+func (dag *BlockDAG) SyncRate() float64 {
 	dag.RLock()
 	defer dag.RUnlock()
 	return float64(len(dag.recentBlockProcessingTimestampsRelevantWindow())) / syncRateWindowDuration.Seconds()
@@ -52,7 +52,7 @@ func (dag *BlockDAG) IsSyncRateBelowThreshold(maxDeviation float64) bool {
 		return false
 	}
 
-	return dag.syncRate() < 1/dag.Params.TargetTimePerBlock.Seconds()*maxDeviation
+	return dag.SyncRate() < 1/dag.Params.TargetTimePerBlock.Seconds()*maxDeviation
 }
 
 func (dag *BlockDAG) uptime() time.Duration {
@@ -231,7 +231,7 @@ func deserializeUTXOEntry(r io.Reader) (*UTXOEntry, error) {
 	}
 
 	entry.scriptPubKey = make([]byte, scriptPubKeyLen)
-	_, err = r.Read(entry.scriptPubKey)
+	_, err = io.ReadFull(r, entry.scriptPubKey)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -0,0 +1,837 @@
+package blockdag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/subnetworkid"
+	"github.com/pkg/errors"
+)
+
+// utxoSnapshotVersion is the version of the UTXO snapshot format.
+// A UTXO snapshot is serialized as follows:
+//  - The snapshot version (uint32)
+//  - The network of the DAG (uint32)
+//  - The headers of the snapshot block and of all the blocks in its past,
+//    in topological order, prefixed by their count (varint)
+//  - The snapshot block
+//  - The blocks in the anticone of the snapshot block, in topological
+//    order, prefixed by their count (varint). Each block is followed by
+//    the diff from the past UTXO set of the snapshot block to its own
+//    past UTXO set
+//  - The registered subnetworks, prefixed by their count (varint)
+//  - The past UTXO set of the snapshot block, prefixed by its size (varint)
+const utxoSnapshotVersion = 1
+
+// maxSubnetworkDataSize is the maximum size of the serialized
+// data of a subnetwork in a UTXO snapshot.
+const maxSubnetworkDataSize = 1024
+
+type utxoSnapshotSubnetwork struct {
+	id   *subnetworkid.SubnetworkID
+	data []byte
+}
+
+// utxoSnapshotAnticoneBlock is a block in the anticone of the snapshot
+// block of a UTXO snapshot, along with the diff from the past UTXO set of
+// the snapshot block to its own past UTXO set, and the multiset of its
+// past UTXO set.
+type utxoSnapshotAnticoneBlock struct {
+	block        *util.Block
+	pastUTXODiff *UTXODiff
+	multiset     *secp256k1.MultiSet
+}
+
+// ExportUTXOSnapshot writes a snapshot of the DAG to w, from which a new
+// node can be bootstrapped without processing all the blocks since the
+// genesis. The snapshot is taken at the last finality point: it holds the
+// headers of all the blocks in its past, its block, the registered
+// subnetworks, and its past UTXO set, whose ECMH multiset is committed to
+// in its header. It returns the hash of the snapshot block.
+//
+// Blocks in the anticone of the snapshot block are in the past of blocks
+// that will be added on top of it, but the past UTXO sets of such blocks
+// can't be restored from the snapshot block. Therefore, the snapshot holds
+// them in full, along with the diffs of their past UTXO sets.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) ExportUTXOSnapshot(w io.Writer) (*daghash.Hash, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	snapshotNode := dag.lastFinalityPoint
+	if snapshotNode.isGenesis() {
+		return nil, errors.New("the DAG has no finality point other than the genesis yet")
+	}
+
+	nodes, anticoneNodes, err := dag.utxoSnapshotNodes(snapshotNode)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := dag.fetchBlockByHash(snapshotNode.hash)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetworks, err := dag.registeredSubnetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	restoredPastUTXO, err := dag.restorePastUTXO(snapshotNode)
+	if err != nil {
+		return nil, err
+	}
+
+	// The past UTXO sets of all the blocks are restored relative to the
+	// virtual UTXO set, so the diffs between them can be taken directly.
+	anticoneBlocks := make([]*util.Block, len(anticoneNodes))
+	anticonePastUTXODiffs := make([]*UTXODiff, len(anticoneNodes))
+	for i, node := range anticoneNodes {
+		anticoneBlocks[i], err = dag.fetchBlockByHash(node.hash)
+		if err != nil {
+			return nil, err
+		}
+		nodePastUTXO, err := dag.restorePastUTXO(node)
+		if err != nil {
+			return nil, err
+		}
+		anticonePastUTXODiffs[i], err = restoredPastUTXO.diffFrom(nodePastUTXO)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pastUTXO := restoredPastUTXO.clone().(*DiffUTXOSet)
+	err = pastUTXO.meldToBase()
+	if err != nil {
+		return nil, err
+	}
+
+	err = binaryserializer.PutUint32(w, byteOrder, utxoSnapshotVersion)
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint32(w, byteOrder, uint32(dag.Params.Net))
+	if err != nil {
+		return nil, err
+	}
+
+	err = domainmessage.WriteVarInt(w, uint64(len(nodes)))
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		err := node.Header().Serialize(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = block.MsgBlock().Serialize(w)
+	if err != nil {
+		return nil, err
+	}
+
+	err = domainmessage.WriteVarInt(w, uint64(len(anticoneBlocks)))
+	if err != nil {
+		return nil, err
+	}
+	for i, anticoneBlock := range anticoneBlocks {
+		err := anticoneBlock.MsgBlock().Serialize(w)
+		if err != nil {
+			return nil, err
+		}
+		err = serializeUTXODiff(w, anticonePastUTXODiffs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = domainmessage.WriteVarInt(w, uint64(len(subnetworks)))
+	if err != nil {
+		return nil, err
+	}
+	for _, subnetwork := range subnetworks {
+		_, err := w.Write(subnetwork.id[:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		err = domainmessage.WriteVarBytes(w, 0, subnetwork.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = serializeUTXOCollection(w, pastUTXO.base.utxoCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshotNode.hash, nil
+}
+
+// utxoSnapshotNodes returns the nodes of the given snapshot block and of
+// all the blocks in its past, and separately the nodes of the blocks in
+// its anticone. Both are sorted by blue score, which is a topological
+// order.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) utxoSnapshotNodes(snapshotNode *blockNode) (
+	nodes []*blockNode, anticoneNodes []*blockNode, err error) {
+
+	dag.index.RLock()
+	defer dag.index.RUnlock()
+
+	nodes = []*blockNode{snapshotNode}
+	for _, node := range dag.index.index {
+		if node == snapshotNode || node.status.KnownInvalid() {
+			continue
+		}
+		isInPast, err := dag.isInPast(node, snapshotNode)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isInPast {
+			nodes = append(nodes, node)
+			continue
+		}
+		isInFuture, err := dag.isInPast(snapshotNode, node)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isInFuture {
+			anticoneNodes = append(anticoneNodes, node)
+		}
+	}
+
+	sortNodesTopologically(nodes)
+	sortNodesTopologically(anticoneNodes)
+	return nodes, anticoneNodes, nil
+}
+
+// sortNodesTopologically sorts the given nodes by blue score, which is a
+// topological order.
+func sortNodesTopologically(nodes []*blockNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].blueScore == nodes[j].blueScore {
+			return daghash.Less(nodes[i].hash, nodes[j].hash)
+		}
+		return nodes[i].blueScore < nodes[j].blueScore
+	})
+}
+
+func (dag *BlockDAG) registeredSubnetworks() ([]*utxoSnapshotSubnetwork, error) {
+	cursor, err := dbaccess.SubnetworkCursor(dag.databaseContext)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var subnetworks []*utxoSnapshotSubnetwork
+	for cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, err
+		}
+		id, err := subnetworkid.New(key.Suffix())
+		if err != nil {
+			return nil, err
+		}
+		data, err := cursor.Value()
+		if err != nil {
+			return nil, err
+		}
+		subnetworks = append(subnetworks, &utxoSnapshotSubnetwork{id: id, data: data})
+	}
+	return subnetworks, nil
+}
+
+// ImportUTXOSnapshot bootstraps the DAG from a snapshot that was written by
+// ExportUTXOSnapshot, so that it could continue syncing from the snapshot
+// block, which becomes its finality point. The snapshot block must be of
+// the given hash, since a snapshot is only as trustworthy as the source
+// of the hash of its snapshot block. The DAG must contain only the genesis.
+//
+// The headers in the snapshot are validated the same way as in IBD, and
+// the UTXO set is verified against the UTXO commitment of the snapshot
+// block. The blocks in the past of the snapshot block are added to the DAG
+// without their data, so they can't be served to other nodes. The blocks
+// in the anticone of the snapshot block are validated against their past
+// UTXO sets, which are verified against their UTXO commitments as well.
+//
+// The flags modify the behavior of this function as follows:
+//  - BFNoPoWCheck: The check to ensure the block hashes are less than the
+//    target difficulty is not performed.
+//
+// This function MUST be called before the DAG is used, and the DAG must
+// not be used if it returns an error.
+func (dag *BlockDAG) ImportUTXOSnapshot(r io.Reader, snapshotHash *daghash.Hash, flags BehaviorFlags) error {
+	if dag.BlockCount() != 1 {
+		return errors.New("a UTXO snapshot can only be imported into a DAG " +
+			"that contains only the genesis")
+	}
+
+	err := dag.readUTXOSnapshotPreamble(r)
+	if err != nil {
+		return err
+	}
+
+	validator := dag.NewHeaderValidator()
+	headers, err := dag.readUTXOSnapshotHeaders(r, validator, flags)
+	if err != nil {
+		return err
+	}
+	if headerHash := headers[len(headers)-1].BlockHash(); !headerHash.IsEqual(snapshotHash) {
+		return errors.Errorf("the UTXO snapshot is of block %s rather than of the expected block %s",
+			headerHash, snapshotHash)
+	}
+
+	var msgBlock domainmessage.MsgBlock
+	err = msgBlock.Deserialize(r)
+	if err != nil {
+		return err
+	}
+	block := util.NewBlock(&msgBlock)
+	if !block.Hash().IsEqual(snapshotHash) {
+		return errors.Errorf("expected the snapshot block %s, but got block %s",
+			snapshotHash, block.Hash())
+	}
+	_, err = dag.checkBlockSanity(block, flags)
+	if err != nil {
+		return err
+	}
+
+	anticoneBlocks, err := dag.readUTXOSnapshotAnticoneBlocks(r, validator, snapshotHash, flags)
+	if err != nil {
+		return err
+	}
+
+	subnetworks, err := readUTXOSnapshotSubnetworks(r)
+	if err != nil {
+		return err
+	}
+
+	pastUTXO, multiset, err := readUTXOSnapshotUTXOSet(r)
+	if err != nil {
+		return err
+	}
+	err = checkUTXOSnapshotCommitment(block, multiset)
+	if err != nil {
+		return err
+	}
+	for _, anticoneBlock := range anticoneBlocks {
+		anticoneBlock.multiset, err = utxoSnapshotDiffMultiset(pastUTXO, multiset, anticoneBlock.pastUTXODiff)
+		if err != nil {
+			return errors.Wrapf(err, "invalid past UTXO diff of block %s", anticoneBlock.block.Hash())
+		}
+		err = checkUTXOSnapshotCommitment(anticoneBlock.block, anticoneBlock.multiset)
+		if err != nil {
+			return err
+		}
+	}
+
+	dag.dagLock.Lock()
+	defer dag.dagLock.Unlock()
+
+	return dag.applyUTXOSnapshot(headers, block, anticoneBlocks, subnetworks, pastUTXO, multiset)
+}
+
+// checkUTXOSnapshotCommitment returns a ruleError if the given multiset of
+// the past UTXO set of the given block doesn't match its UTXO commitment.
+func checkUTXOSnapshotCommitment(block *util.Block, multiset *secp256k1.MultiSet) error {
+	utxoCommitment := daghash.Hash(*multiset.Finalize())
+	if !utxoCommitment.IsEqual(block.MsgBlock().Header.UTXOCommitment) {
+		str := fmt.Sprintf("the UTXO set in the snapshot doesn't match the UTXO "+
+			"commitment of block %s - block header indicates %s, but calculated "+
+			"value is %s", block.Hash(), block.MsgBlock().Header.UTXOCommitment, utxoCommitment)
+		return ruleError(ErrBadUTXOCommitment, str)
+	}
+	return nil
+}
+
+func (dag *BlockDAG) readUTXOSnapshotPreamble(r io.Reader) error {
+	version, err := binaryserializer.Uint32(r, byteOrder)
+	if err != nil {
+		return err
+	}
+	if version != utxoSnapshotVersion {
+		return errors.Errorf("unsupported UTXO snapshot version %d", version)
+	}
+
+	net, err := binaryserializer.Uint32(r, byteOrder)
+	if err != nil {
+		return err
+	}
+	if domainmessage.KaspaNet(net) != dag.Params.Net {
+		return errors.Errorf("the UTXO snapshot is of network %s rather than %s",
+			domainmessage.KaspaNet(net), dag.Params.Net)
+	}
+	return nil
+}
+
+// readUTXOSnapshotHeaders reads the headers in a UTXO snapshot and
+// validates them with the given validator. The last header is the header
+// of the snapshot block, and all the other headers must be of blocks in
+// its past.
+func (dag *BlockDAG) readUTXOSnapshotHeaders(r io.Reader, validator *HeaderValidator, flags BehaviorFlags) (
+	[]*domainmessage.BlockHeader, error) {
+
+	headerCount, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []*domainmessage.BlockHeader
+	for i := uint64(0); i < headerCount; i++ {
+		header := &domainmessage.BlockHeader{}
+		err := header.Deserialize(r)
+		if err != nil {
+			return nil, err
+		}
+		err = validator.ValidateHeader(header, flags)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	if len(headers) == 0 {
+		return nil, errors.New("the UTXO snapshot has no headers")
+	}
+
+	snapshotHash := headers[len(headers)-1].BlockHash()
+	snapshotNode, ok := validator.nodes[*snapshotHash]
+	if !ok {
+		return nil, errors.Errorf("the snapshot block %s is already in the DAG", snapshotHash)
+	}
+	snapshotPast := blockSetFromSlice(snapshotNode)
+	queue := []*blockNode{snapshotNode}
+	for len(queue) > 0 {
+		var current *blockNode
+		current, queue = queue[0], queue[1:]
+		for parent := range current.parents {
+			if _, ok := validator.nodes[*parent.hash]; !ok || snapshotPast.contains(parent) {
+				continue
+			}
+			snapshotPast.add(parent)
+			queue = append(queue, parent)
+		}
+	}
+	if len(snapshotPast) != len(validator.nodes) {
+		return nil, errors.Errorf("the UTXO snapshot has headers of blocks "+
+			"that are not in the past of the snapshot block %s", snapshotHash)
+	}
+
+	return headers, nil
+}
+
+// readUTXOSnapshotAnticoneBlocks reads the blocks in the anticone of the
+// snapshot block in a UTXO snapshot, along with the diffs of their past
+// UTXO sets, and validates their headers with the given validator, which
+// must have already validated the rest of the headers in the snapshot.
+func (dag *BlockDAG) readUTXOSnapshotAnticoneBlocks(r io.Reader, validator *HeaderValidator,
+	snapshotHash *daghash.Hash, flags BehaviorFlags) ([]*utxoSnapshotAnticoneBlock, error) {
+
+	blockCount, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var anticoneBlocks []*utxoSnapshotAnticoneBlock
+	for i := uint64(0); i < blockCount; i++ {
+		msgBlock := &domainmessage.MsgBlock{}
+		err := msgBlock.Deserialize(r)
+		if err != nil {
+			return nil, err
+		}
+		block := util.NewBlock(msgBlock)
+
+		// Blocks in the past of the snapshot block were already
+		// validated, so a known block can't be in its anticone.
+		// Since the blocks are ordered topologically, a block in
+		// the future of the snapshot block would have either the
+		// snapshot block or another such block as a parent.
+		if _, ok := validator.lookupNode(block.Hash()); ok {
+			return nil, errors.Errorf("block %s in the UTXO snapshot is not "+
+				"in the anticone of the snapshot block", block.Hash())
+		}
+		for _, parentHash := range msgBlock.Header.ParentHashes {
+			if parentHash.IsEqual(snapshotHash) {
+				return nil, errors.Errorf("block %s in the UTXO snapshot is in "+
+					"the future of the snapshot block", block.Hash())
+			}
+		}
+
+		err = validator.ValidateHeader(&msgBlock.Header, flags)
+		if err != nil {
+			return nil, err
+		}
+		_, err = dag.checkBlockSanity(block, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		pastUTXODiff, err := deserializeUTXODiff(r)
+		if err != nil {
+			return nil, err
+		}
+		anticoneBlocks = append(anticoneBlocks, &utxoSnapshotAnticoneBlock{
+			block:        block,
+			pastUTXODiff: pastUTXODiff,
+		})
+	}
+	return anticoneBlocks, nil
+}
+
+func readUTXOSnapshotSubnetworks(r io.Reader) ([]*utxoSnapshotSubnetwork, error) {
+	subnetworkCount, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnetworks []*utxoSnapshotSubnetwork
+	for i := uint64(0); i < subnetworkCount; i++ {
+		id := &subnetworkid.SubnetworkID{}
+		_, err := io.ReadFull(r, id[:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		data, err := domainmessage.ReadVarBytes(r, 0, maxSubnetworkDataSize, "subnetwork data")
+		if err != nil {
+			return nil, err
+		}
+		subnetworks = append(subnetworks, &utxoSnapshotSubnetwork{id: id, data: data})
+	}
+	return subnetworks, nil
+}
+
+// readUTXOSnapshotUTXOSet reads the UTXO set in a UTXO snapshot,
+// and returns it along with its multiset.
+func readUTXOSnapshotUTXOSet(r io.Reader) (utxoCollection, *secp256k1.MultiSet, error) {
+	utxoCount, err := domainmessage.ReadVarInt(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	collection := make(utxoCollection)
+	multiset := secp256k1.NewMultiset()
+	for i := uint64(0); i < utxoCount; i++ {
+		entry, outpoint, err := deserializeUTXO(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if collection.contains(*outpoint) {
+			return nil, nil, errors.Errorf("the UTXO snapshot has a duplicate entry for outpoint %s", outpoint)
+		}
+		collection.add(*outpoint, entry)
+
+		multiset, err = addUTXOToMultiset(multiset, entry, outpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return collection, multiset, nil
+}
+
+// utxoSnapshotDiffMultiset returns the multiset of the UTXO set that is
+// the result of applying the given diff to the given UTXO set, whose
+// multiset is given as well. It returns an error if the diff doesn't
+// apply to the UTXO set, since the multiset of the result would be
+// meaningless in that case.
+func utxoSnapshotDiffMultiset(utxoSet utxoCollection, multiset *secp256k1.MultiSet,
+	diff *UTXODiff) (*secp256k1.MultiSet, error) {
+
+	result := secp256k1.NewMultiset()
+	result.Combine(multiset)
+
+	var err error
+	for outpoint, entry := range diff.toRemove {
+		existingEntry, ok := utxoSet.get(outpoint)
+		if !ok || !utxoEntriesEqual(existingEntry, entry) {
+			return nil, errors.Errorf("outpoint %s is removed, but it's not in the UTXO set", outpoint)
+		}
+		result, err = removeUTXOFromMultiset(result, entry, &outpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for outpoint, entry := range diff.toAdd {
+		if utxoSet.contains(outpoint) && !diff.toRemove.contains(outpoint) {
+			return nil, errors.Errorf("outpoint %s is added, but it's already in the UTXO set", outpoint)
+		}
+		result, err = addUTXOToMultiset(result, entry, &outpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func utxoEntriesEqual(this *UTXOEntry, other *UTXOEntry) bool {
+	return this.amount == other.amount &&
+		bytes.Equal(this.scriptPubKey, other.scriptPubKey) &&
+		this.blockBlueScore == other.blockBlueScore &&
+		this.packedFlags == other.packedFlags
+}
+
+// applyUTXOSnapshot adds the blocks of the given headers and the blocks in
+// the anticone of the snapshot block to the DAG, and sets the snapshot
+// block as the finality point of the DAG. Only the data of the snapshot
+// block and of the blocks in its anticone is stored.
+//
+// This function MUST be called with the DAG state lock held (for writes).
+func (dag *BlockDAG) applyUTXOSnapshot(headers []*domainmessage.BlockHeader, block *util.Block,
+	anticoneBlocks []*utxoSnapshotAnticoneBlock, subnetworks []*utxoSnapshotSubnetwork,
+	pastUTXO utxoCollection, multiset *secp256k1.MultiSet) error {
+
+	var snapshotNode *blockNode
+	for _, header := range headers {
+		if dag.index.HaveBlock(header.BlockHash()) {
+			continue
+		}
+		node, err := dag.addUTXOSnapshotNode(header)
+		if err != nil {
+			return err
+		}
+		snapshotNode = node
+	}
+	dag.index.SetStatusFlags(snapshotNode, statusDataStored)
+
+	// The transactions of the snapshot block are validated against its
+	// past UTXO, which also yields the fee data of the snapshot block that
+	// is needed to validate the coinbase transactions of its children.
+	// The same goes for the blocks in its anticone, whose past UTXO sets
+	// are given as diffs from the past UTXO set of the snapshot block.
+	snapshotPastUTXO := NewDiffUTXOSet(&FullUTXOSet{utxoCollection: pastUTXO}, NewUTXODiff())
+	feeData, err := dag.checkConnectToPastUTXO(snapshotNode, snapshotPastUTXO, block.Transactions(), false)
+	if err != nil {
+		return err
+	}
+	blocks := []*util.Block{block}
+	blocksFeeData := []compactFeeData{feeData}
+	nodeBlocks := map[*blockNode]*util.Block{snapshotNode: block}
+	nodePastUTXOs := map[*blockNode]*DiffUTXOSet{snapshotNode: snapshotPastUTXO}
+	for _, anticoneBlock := range anticoneBlocks {
+		node, err := dag.addUTXOSnapshotNode(&anticoneBlock.block.MsgBlock().Header)
+		if err != nil {
+			return err
+		}
+		dag.index.SetStatusFlags(node, statusDataStored)
+		dag.multisetStore.setMultiset(node, anticoneBlock.multiset)
+
+		nodePastUTXO := NewDiffUTXOSet(snapshotPastUTXO.base, anticoneBlock.pastUTXODiff)
+		feeData, err := dag.checkConnectToPastUTXO(node, nodePastUTXO, anticoneBlock.block.Transactions(), false)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, anticoneBlock.block)
+		blocksFeeData = append(blocksFeeData, feeData)
+		nodeBlocks[node] = anticoneBlock.block
+		nodePastUTXOs[node] = nodePastUTXO
+	}
+
+	// The past UTXO of the snapshot block becomes the base of the virtual
+	// UTXO, so that its own diff is empty until the virtual UTXO is melded.
+	oldVirtualUTXO := dag.virtual.utxoSet.utxoCollection
+	dag.utxoLock.Lock()
+	dag.virtual.utxoSet = snapshotPastUTXO.base
+	dag.utxoLock.Unlock()
+	dag.multisetStore.setMultiset(snapshotNode, multiset)
+
+	// The diffs of the tips are from the virtual UTXO, and the diffs of the
+	// rest of the blocks are from the past UTXO of one of their children,
+	// which are all in the anticone of the snapshot block as well.
+	tips := newBlockSet()
+	for node := range nodePastUTXOs {
+		tips.add(node)
+	}
+	for node := range nodePastUTXOs {
+		for parent := range node.parents {
+			tips.remove(parent)
+		}
+	}
+	for node, nodePastUTXO := range nodePastUTXOs {
+		if tips.contains(node) {
+			err := dag.utxoDiffStore.setBlockDiff(node, nodePastUTXO.UTXODiff.clone())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		for child := range node.children {
+			diff, err := nodePastUTXOs[child].diffFrom(nodePastUTXO)
+			if err != nil {
+				return err
+			}
+			err = dag.utxoDiffStore.setBlockDiff(node, diff)
+			if err != nil {
+				return err
+			}
+			err = dag.utxoDiffStore.setBlockDiffChild(node, child)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	// A block in the anticone of the snapshot block might be selected
+	// over it until the blocks above the snapshot block are added, so
+	// the virtual might have blue blocks in the past of the snapshot
+	// block, whose data isn't in the snapshot.
+	dag.virtual.SetTips(tips)
+	blueBlocks := make([]*util.Block, len(dag.virtual.blues))
+	for i, blue := range dag.virtual.blues {
+		blueBlock, ok := nodeBlocks[blue]
+		if !ok {
+			return errors.Errorf("the UTXO snapshot doesn't have the data of "+
+				"block %s, which is a blue block of the virtual block", blue)
+		}
+		blueBlocks[i] = blueBlock
+	}
+	selectedParentPastUTXO := nodePastUTXOs[dag.virtual.selectedParent]
+	newVirtualUTXO, _, err := dag.virtual.applyBlueBlocks(selectedParentPastUTXO, blueBlocks)
+	if err != nil {
+		return err
+	}
+	err = updateTipsUTXO(dag, newVirtualUTXO)
+	if err != nil {
+		return err
+	}
+	err = dag.meldVirtualUTXO(newVirtualUTXO.(*DiffUTXOSet))
+	if err != nil {
+		return err
+	}
+	dag.lastFinalityPoint = snapshotNode
+
+	err = dag.saveUTXOSnapshot(blocks, blocksFeeData, subnetworks, oldVirtualUTXO)
+	if err != nil {
+		return err
+	}
+
+	dag.finalizeNodesBelowFinalityPoint(true)
+	return nil
+}
+
+// addUTXOSnapshotNode adds the block of the given header to the DAG as a
+// valid block, without validating it.
+//
+// This function MUST be called with the DAG state lock held (for writes).
+func (dag *BlockDAG) addUTXOSnapshotNode(header *domainmessage.BlockHeader) (*blockNode, error) {
+	parents := newBlockSet()
+	for _, parentHash := range header.ParentHashes {
+		parent, ok := dag.index.LookupNode(parentHash)
+		if !ok {
+			return nil, errors.Errorf("parent %s of block %s is not in the DAG",
+				parentHash, header.BlockHash())
+		}
+		parents.add(parent)
+	}
+
+	node, selectedParentAnticone := dag.newBlockNode(header, parents)
+	dag.index.AddNode(node)
+	err := dag.reachabilityTree.addBlock(node, selectedParentAnticone)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed adding block to the reachability tree")
+	}
+	node.updateParentsChildren()
+	dag.index.SetStatusFlags(node, statusValid)
+	dag.blockCount++
+	return node, nil
+}
+
+// saveUTXOSnapshot saves the imported DAG state, along with the given
+// blocks, which are the snapshot block and the blocks in its anticone,
+// and their fee data.
+func (dag *BlockDAG) saveUTXOSnapshot(blocks []*util.Block, blocksFeeData []compactFeeData,
+	subnetworks []*utxoSnapshotSubnetwork, oldVirtualUTXO utxoCollection) error {
+
+	dbTx, err := dag.databaseContext.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dag.index.flushToDB(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dag.utxoDiffStore.flushToDB(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dag.reachabilityTree.storeState(dbTx)
+	if err != nil {
+		return err
+	}
+
+	err = dag.multisetStore.flushToDB(dbTx)
+	if err != nil {
+		return err
+	}
+
+	state := &dagState{
+		TipHashes:         dag.TipHashes(),
+		LastFinalityPoint: dag.lastFinalityPoint.hash,
+		LocalSubnetworkID: dag.subnetworkID,
+		IsPruned:          dag.prune,
+	}
+	err = saveDAGState(dbTx, state)
+	if err != nil {
+		return err
+	}
+
+	// Replace the UTXO set of the genesis with the imported one
+	err = updateUTXOSet(dbTx, &UTXODiff{
+		toAdd:    dag.virtual.utxoSet.utxoCollection,
+		toRemove: oldVirtualUTXO,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, subnetwork := range subnetworks {
+		err := dbaccess.StoreSubnetwork(dbTx, subnetwork.id, subnetwork.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, block := range blocks {
+		err := storeBlock(dbTx, block)
+		if err != nil {
+			return err
+		}
+		err = registerSubnetworks(dbTx, block.Transactions())
+		if err != nil {
+			return err
+		}
+		err = dbaccess.StoreFeeData(dbTx, block.Hash(), blocksFeeData[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = dbTx.Commit()
+	if err != nil {
+		return err
+	}
+
+	dag.index.clearDirtyEntries()
+	dag.utxoDiffStore.clearDirtyEntries()
+	dag.reachabilityTree.store.clearDirtyEntries()
+	dag.multisetStore.clearNewEntries()
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package blockdag
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestUTXOSnapshot(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.FinalityDuration = 10 * params.TargetTimePerBlock
+	exporterDAG, teardownFunc, err := DAGSetup("TestUTXOSnapshotExporter", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup exporter DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	importerDAG, teardownFunc, err := DAGSetup("TestUTXOSnapshotImporter", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup importer DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	_, err = exporterDAG.ExportUTXOSnapshot(&bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("ExportUTXOSnapshot: expected an error before the DAG has a finality point")
+	}
+
+	// Start with two parallel blocks, so that there are blocks
+	// with multiple parents in the past of the snapshot block.
+	var blocks []*domainmessage.MsgBlock
+	blockA := PrepareAndProcessBlockForTest(t, exporterDAG, []*daghash.Hash{params.GenesisHash}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, exporterDAG, []*daghash.Hash{params.GenesisHash}, nil)
+	blocks = append(blocks, blockA, blockB)
+	tips := []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}
+	for i := uint64(0); i < 3*exporterDAG.FinalityInterval(); i++ {
+		block := PrepareAndProcessBlockForTest(t, exporterDAG, tips, nil)
+		blocks = append(blocks, block)
+		tips = []*daghash.Hash{block.BlockHash()}
+	}
+
+	snapshot := &bytes.Buffer{}
+	snapshotHash, err := exporterDAG.ExportUTXOSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("ExportUTXOSnapshot: %s", err)
+	}
+	if !snapshotHash.IsEqual(exporterDAG.LastFinalityPointHash()) {
+		t.Fatalf("ExportUTXOSnapshot: expected the snapshot block to be the finality point %s, but got %s",
+			exporterDAG.LastFinalityPointHash(), snapshotHash)
+	}
+
+	// A snapshot whose UTXO set doesn't match the UTXO commitment of the
+	// snapshot block is rejected. The last byte of the snapshot is the last
+	// byte of the script public key of the last UTXO entry.
+	tamperedSnapshot := append([]byte{}, snapshot.Bytes()...)
+	tamperedSnapshot[len(tamperedSnapshot)-1]++
+	err = importerDAG.ImportUTXOSnapshot(bytes.NewReader(tamperedSnapshot), snapshotHash, BFNoPoWCheck)
+	if err := checkRuleError(err, ruleError(ErrBadUTXOCommitment, "")); err != nil {
+		t.Fatalf("ImportUTXOSnapshot: %s", err)
+	}
+
+	// A snapshot of another block than the expected one is rejected
+	err = importerDAG.ImportUTXOSnapshot(bytes.NewReader(snapshot.Bytes()), blockA.BlockHash(), BFNoPoWCheck)
+	if err == nil {
+		t.Fatalf("ImportUTXOSnapshot: expected an error for a snapshot of an unexpected block")
+	}
+
+	err = importerDAG.ImportUTXOSnapshot(bytes.NewReader(snapshot.Bytes()), snapshotHash, BFNoPoWCheck)
+	if err != nil {
+		t.Fatalf("ImportUTXOSnapshot: %s", err)
+	}
+	if !importerDAG.SelectedTipHash().IsEqual(snapshotHash) {
+		t.Fatalf("ImportUTXOSnapshot: expected the selected tip to be %s, but got %s",
+			snapshotHash, importerDAG.SelectedTipHash())
+	}
+	if !importerDAG.LastFinalityPointHash().IsEqual(snapshotHash) {
+		t.Fatalf("ImportUTXOSnapshot: expected the finality point to be %s, but got %s",
+			snapshotHash, importerDAG.LastFinalityPointHash())
+	}
+	if _, err := importerDAG.BlockByHash(blockA.BlockHash()); !IsNotInDAGErr(err) {
+		t.Fatalf("BlockByHash: expected an ErrNotInDAG for a block below the snapshot block, but got: %v", err)
+	}
+	if _, err := importerDAG.BlockByHash(snapshotHash); err != nil {
+		t.Fatalf("BlockByHash: unexpected error for the snapshot block: %s", err)
+	}
+
+	err = importerDAG.ImportUTXOSnapshot(bytes.NewReader(snapshot.Bytes()), snapshotHash, BFNoPoWCheck)
+	if err == nil {
+		t.Fatalf("ImportUTXOSnapshot: expected an error when importing into a non-empty DAG")
+	}
+
+	// The imported DAG state is loaded from the database
+	// once the DAG is created again.
+	reloadedDAG, err := New(&Config{
+		DAGParams:       &params,
+		DatabaseContext: importerDAG.databaseContext,
+		TimeSource:      NewTimeSource(),
+		SigCache:        txscript.NewSigCache(1000),
+	})
+	if err != nil {
+		t.Fatalf("Failed to reload the importer DAG: %s", err)
+	}
+
+	// The blocks above the snapshot block are added normally
+	for _, block := range blocks {
+		if reloadedDAG.IsInDAG(block.BlockHash()) {
+			continue
+		}
+		isOrphan, isDelayed, err := reloadedDAG.ProcessBlock(util.NewBlock(block), BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlock: unexpected error for block %s: %s", block.BlockHash(), err)
+		}
+		if isOrphan || isDelayed {
+			t.Fatalf("ProcessBlock: block %s is unexpectedly orphan or delayed", block.BlockHash())
+		}
+	}
+
+	if !reflect.DeepEqual(reloadedDAG.TipHashes(), exporterDAG.TipHashes()) {
+		t.Errorf("unexpected tips. Want: %s, got: %s", exporterDAG.TipHashes(), reloadedDAG.TipHashes())
+	}
+	if !reflect.DeepEqual(reloadedDAG.UTXOSet().utxoCollection, exporterDAG.UTXOSet().utxoCollection) {
+		t.Errorf("the virtual UTXO of the importer DAG is different from the one of the exporter DAG")
+	}
+}
+
+// TestUTXOSnapshotAnticone tests a UTXO snapshot of a finality point that
+// has parallel blocks in its anticone, which are merged only after the
+// snapshot is taken.
+func TestUTXOSnapshotAnticone(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.FinalityDuration = 10 * params.TargetTimePerBlock
+	exporterDAG, teardownFunc, err := DAGSetup("TestUTXOSnapshotAnticoneExporter", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup exporter DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	importerDAG, teardownFunc, err := DAGSetup("TestUTXOSnapshotAnticoneImporter", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup importer DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	// Build a chain, with a parallel block and its child next to the
+	// chain block whose blue score is twice the finality interval, which
+	// becomes the finality point once the chain is long enough.
+	finalityInterval := exporterDAG.FinalityInterval()
+	var blocks []*domainmessage.MsgBlock
+	var finalityPointBlock, parallelBlock, parallelBlockChild *domainmessage.MsgBlock
+	tip := params.GenesisHash
+	for blueScore := uint64(1); blueScore <= 3*finalityInterval+finalityInterval/2; blueScore++ {
+		block := PrepareAndProcessBlockForTest(t, exporterDAG, []*daghash.Hash{tip}, nil)
+		blocks = append(blocks, block)
+		if blueScore == 2*finalityInterval {
+			finalityPointBlock = block
+			parallelBlock = PrepareAndProcessBlockForTest(t, exporterDAG, []*daghash.Hash{tip}, nil)
+			parallelBlockChild = PrepareAndProcessBlockForTest(t, exporterDAG,
+				[]*daghash.Hash{parallelBlock.BlockHash()}, nil)
+			blocks = append(blocks, parallelBlock, parallelBlockChild)
+		}
+		tip = block.BlockHash()
+	}
+	if !exporterDAG.LastFinalityPointHash().IsEqual(finalityPointBlock.BlockHash()) {
+		t.Fatalf("expected the finality point to be %s, but got %s",
+			finalityPointBlock.BlockHash(), exporterDAG.LastFinalityPointHash())
+	}
+
+	snapshot := &bytes.Buffer{}
+	snapshotHash, err := exporterDAG.ExportUTXOSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("ExportUTXOSnapshot: %s", err)
+	}
+	if !snapshotHash.IsEqual(finalityPointBlock.BlockHash()) {
+		t.Fatalf("ExportUTXOSnapshot: expected the snapshot block to be the finality point %s, but got %s",
+			finalityPointBlock.BlockHash(), snapshotHash)
+	}
+
+	// The parallel blocks are merged only after the snapshot is taken
+	mergingBlock := PrepareAndProcessBlockForTest(t, exporterDAG,
+		[]*daghash.Hash{tip, parallelBlockChild.BlockHash()}, nil)
+	blocks = append(blocks, mergingBlock)
+	tip = mergingBlock.BlockHash()
+	for i := uint64(0); i < finalityInterval; i++ {
+		block := PrepareAndProcessBlockForTest(t, exporterDAG, []*daghash.Hash{tip}, nil)
+		blocks = append(blocks, block)
+		tip = block.BlockHash()
+	}
+
+	err = importerDAG.ImportUTXOSnapshot(bytes.NewReader(snapshot.Bytes()), snapshotHash, BFNoPoWCheck)
+	if err != nil {
+		t.Fatalf("ImportUTXOSnapshot: %s", err)
+	}
+	for _, block := range []*domainmessage.MsgBlock{parallelBlock, parallelBlockChild} {
+		if _, err := importerDAG.BlockByHash(block.BlockHash()); err != nil {
+			t.Fatalf("BlockByHash: unexpected error for block %s in the anticone "+
+				"of the snapshot block: %s", block.BlockHash(), err)
+		}
+	}
+	expectedTips := []*daghash.Hash{snapshotHash, parallelBlockChild.BlockHash()}
+	sort.Slice(expectedTips, func(i, j int) bool {
+		return daghash.Less(expectedTips[i], expectedTips[j])
+	})
+	if !reflect.DeepEqual(importerDAG.TipHashes(), expectedTips) {
+		t.Fatalf("ImportUTXOSnapshot: expected the tips to be %s, but got %s",
+			expectedTips, importerDAG.TipHashes())
+	}
+
+	reloadedDAG, err := New(&Config{
+		DAGParams:       &params,
+		DatabaseContext: importerDAG.databaseContext,
+		TimeSource:      NewTimeSource(),
+		SigCache:        txscript.NewSigCache(1000),
+	})
+	if err != nil {
+		t.Fatalf("Failed to reload the importer DAG: %s", err)
+	}
+
+	for _, block := range blocks {
+		if reloadedDAG.IsInDAG(block.BlockHash()) {
+			continue
+		}
+		isOrphan, isDelayed, err := reloadedDAG.ProcessBlock(util.NewBlock(block), BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlock: unexpected error for block %s: %s", block.BlockHash(), err)
+		}
+		if isOrphan || isDelayed {
+			t.Fatalf("ProcessBlock: block %s is unexpectedly orphan or delayed", block.BlockHash())
+		}
+	}
+
+	if !reflect.DeepEqual(reloadedDAG.TipHashes(), exporterDAG.TipHashes()) {
+		t.Errorf("unexpected tips. Want: %s, got: %s", exporterDAG.TipHashes(), reloadedDAG.TipHashes())
+	}
+	if !reflect.DeepEqual(reloadedDAG.UTXOSet().utxoCollection, exporterDAG.UTXOSet().utxoCollection) {
+		t.Errorf("the virtual UTXO of the importer DAG is different from the one of the exporter DAG")
+	}
+}
@@ -673,6 +673,14 @@ func (dag *BlockDAG) validateDifficulty(header *domainmessage.BlockHeader, blues
 
 // validateParents validates that no parent is an ancestor of another parent, and no parent is finalized
 func (dag *BlockDAG) validateParents(blockHeader *domainmessage.BlockHeader, parents blockSet) error {
+	return validateParentsWithIsInPast(blockHeader, parents, dag.isInPast)
+}
+
+// validateParentsWithIsInPast validates the parents of a block as described
+// in validateParents, using isInPast to check the relations between them.
+func validateParentsWithIsInPast(blockHeader *domainmessage.BlockHeader, parents blockSet,
+	isInPast isInPastFunc) error {
+
 	for parentA := range parents {
 		// isFinalized might be false-negative because node finality status is
 		// updated in a separate goroutine. This is why later the block is
@@ -687,7 +695,7 @@ func (dag *BlockDAG) validateParents(blockHeader *domainmessage.BlockHeader, par
 				continue
 			}
 
-			isAncestorOf, err := dag.isInPast(parentA, parentB)
+			isAncestorOf, err := isInPast(parentA, parentB)
 			if err != nil {
 				return err
 			}
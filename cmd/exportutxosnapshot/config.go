@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+var (
+	kaspadHomeDir  = util.AppDataDir("kaspad", false)
+	defaultDataDir = filepath.Join(kaspadHomeDir, "data")
+)
+
+type configFlags struct {
+	DataDir string `short:"b" long:"datadir" description:"Location of the kaspad data directory"`
+	OutFile string `short:"o" long:"outfile" description:"The file to write the UTXO snapshot to" required:"true"`
+	config.NetworkFlags
+}
+
+func parseConfig() (*configFlags, error) {
+	cfg := &configFlags{
+		DataDir: defaultDataDir,
+	}
+	parser := flags.NewParser(cfg, flags.Default)
+	_, err := parser.Parse()
+	if err != nil {
+		var flagsErr *flags.Error
+		if ok := errors.As(err, &flagsErr); !ok || flagsErr.Type != flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		}
+		return nil, err
+	}
+
+	err = cfg.ResolveNetwork(parser)
+	if err != nil {
+		return nil, err
+	}
+
+	// The data directory of kaspad is namespaced per network
+	cfg.DataDir = filepath.Join(cfg.DataDir, cfg.NetParams().Name)
+	return cfg, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+const sigCacheMaxSize = 1000
+
+// exportutxosnapshot writes a UTXO snapshot of the last finality point of
+// the DAG in the database of a stopped kaspad node. A new node can then be
+// bootstrapped from the snapshot by running kaspad with --importutxosnapshot
+// and with --utxosnapshothash set to the printed hash of the snapshot block.
+func main() {
+	cfg, err := parseConfig()
+	if err != nil {
+		// The flags parser already printed the error
+		os.Exit(1)
+	}
+
+	snapshotHash, err := exportUTXOSnapshot(cfg)
+	if err != nil {
+		printErrorAndExit(err)
+	}
+	fmt.Printf("Exported the UTXO snapshot of block %s to %s\n", snapshotHash, cfg.OutFile)
+}
+
+func exportUTXOSnapshot(cfg *configFlags) (*daghash.Hash, error) {
+	databaseContext, err := dbaccess.New(filepath.Join(cfg.DataDir, "db"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the database. Make sure that kaspad is not running")
+	}
+	defer databaseContext.Close()
+
+	dag, err := blockdag.New(&blockdag.Config{
+		DAGParams:       cfg.NetParams(),
+		DatabaseContext: databaseContext,
+		TimeSource:      blockdag.NewTimeSource(),
+		SigCache:        txscript.NewSigCache(sigCacheMaxSize),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the DAG")
+	}
+
+	outFile, err := os.Create(cfg.OutFile)
+	if err != nil {
+		return nil, err
+	}
+	defer outFile.Close()
+
+	writer := bufio.NewWriter(outFile)
+	snapshotHash, err := dag.ExportUTXOSnapshot(writer)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return snapshotHash, nil
+}
+
+func printErrorAndExit(err error) {
+	fmt.Fprintf(os.Stderr, "%s\n", err)
+	os.Exit(1)
+}
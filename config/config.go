@@ -26,6 +26,7 @@ import (
 	"github.com/jessevdk/go-flags"
 	"github.com/kaspanet/kaspad/logger"
 	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
 	"github.com/kaspanet/kaspad/util/network"
 	"github.com/kaspanet/kaspad/util/subnetworkid"
 	"github.com/kaspanet/kaspad/version"
@@ -52,11 +53,16 @@ const (
 	blockMaxMassMax              = 10000000
 	defaultMinRelayTxFee         = 1e-5 // 1 sompi per byte
 	defaultMaxOrphanTransactions = 100
+	defaultMaxMempoolMass        = 300000000
+	defaultMempoolExpiry         = time.Hour * 24
 	//DefaultMaxOrphanTxSize is the default maximum size for an orphan transaction
 	DefaultMaxOrphanTxSize = 100000
 	defaultSigCacheMaxSize = 100000
 	sampleConfigFilename   = "sample-kaspad.conf"
 	defaultAcceptanceIndex = false
+	defaultUTXOIndex       = false
+	defaultTxIndex         = false
+	defaultAddrIndex       = false
 )
 
 var (
@@ -113,10 +119,14 @@ type Flags struct {
 	DbType               string        `long:"dbtype" description:"Database backend to use for the Block DAG"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
+	Metrics              string        `long:"metrics" description:"Enable an HTTP endpoint that exports Prometheus metrics at /metrics on given port -- NOTE port must be between 1024 and 65536"`
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in KAS/kB to be considered a non-zero fee."`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	MaxMempoolMass       uint64        `long:"maxmempoolmass" description:"Maximum total mass of the transactions to keep in the mempool -- Transactions with the lowest fee per mass are evicted once it's exceeded. 0 means the mempool is unbounded"`
+	MempoolExpiry        time.Duration `long:"mempoolexpiry" description:"How long to keep transactions that had not been accepted in the mempool. Valid time units are {s, m, h}. Minimum 1 minute, 0 disables expiry"`
+	NoPersistMempool     bool          `long:"nopersistmempool" description:"Don't save the mempool to the data directory on shutdown and don't load it on startup"`
 	BlockMaxMass         uint64        `long:"blockmaxmass" description:"Maximum transaction mass to be used when creating a block"`
 	UserAgentComments    []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
@@ -124,9 +134,19 @@ type Flags struct {
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
 	AcceptanceIndex      bool          `long:"acceptanceindex" description:"Maintain a full hash-based acceptance index which makes the getChainFromBlock RPC available"`
 	DropAcceptanceIndex  bool          `long:"dropacceptanceindex" description:"Deletes the hash-based acceptance index from the database on start up and then exits."`
+	UTXOIndex            bool          `long:"utxoindex" description:"Maintain a full address-based UTXO index which makes the getUTXOsByAddress RPC available"`
+	DropUTXOIndex        bool          `long:"droputxoindex" description:"Deletes the address-based UTXO index from the database on start up and then exits."`
+	TxIndex              bool          `long:"txindex" description:"Maintain a full ID-based transaction index which makes the getTransaction and getRawTransaction RPCs available for confirmed transactions"`
+	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the ID-based transaction index from the database on start up and then exits."`
+	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction history index which makes the getAddressTransactions RPC available"`
+	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction history index from the database on start up and then exits."`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	ResetDatabase        bool          `long:"reset-db" description:"Reset database before starting node. It's needed when switching between subnetworks."`
+	ImportUTXOSnapshot   string        `long:"importutxosnapshot" description:"Bootstrap an empty database from the given UTXO snapshot file, created by the exportutxosnapshot utility, instead of syncing the DAG from the genesis"`
+	UTXOSnapshotHash     string        `long:"utxosnapshothash" description:"The hash of the snapshot block of the UTXO snapshot given with --importutxosnapshot, as printed by the exportutxosnapshot utility. The snapshot is rejected if it's of another block"`
+	Prune                bool          `long:"prune" description:"Delete the data of blocks that are deep below the finality point to reduce disk usage. Pruned nodes can't serve the full DAG to syncing peers"`
+	CompactDB            bool          `long:"compactdb" description:"Reclaims the disk space of removed blocks by compacting the block store of the database on start up and then exits."`
 	NetworkFlags
 }
 
@@ -135,12 +155,13 @@ type Flags struct {
 // See loadConfig for details on the configuration load process.
 type Config struct {
 	*Flags
-	Lookup        func(string) ([]net.IP, error)
-	Dial          func(string, string, time.Duration) (net.Conn, error)
-	MiningAddrs   []util.Address
-	MinRelayTxFee util.Amount
-	Whitelists    []*net.IPNet
-	SubnetworkID  *subnetworkid.SubnetworkID // nil in full nodes
+	Lookup           func(string) ([]net.IP, error)
+	Dial             func(string, string, time.Duration) (net.Conn, error)
+	MiningAddrs      []util.Address
+	MinRelayTxFee    util.Amount
+	Whitelists       []*net.IPNet
+	SubnetworkID     *subnetworkid.SubnetworkID // nil in full nodes
+	UTXOSnapshotHash *daghash.Hash              // nil unless a UTXO snapshot is imported
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -189,9 +210,14 @@ func defaultFlags() *Flags {
 		RPCCert:              defaultRPCCertFile,
 		BlockMaxMass:         defaultBlockMaxMass,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxMempoolMass:       defaultMaxMempoolMass,
+		MempoolExpiry:        defaultMempoolExpiry,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		MinRelayTxFee:        defaultMinRelayTxFee,
 		AcceptanceIndex:      defaultAcceptanceIndex,
+		UTXOIndex:            defaultUTXOIndex,
+		TxIndex:              defaultTxIndex,
+		AddrIndex:            defaultAddrIndex,
 	}
 }
 
@@ -408,6 +434,18 @@ func LoadConfig() (cfg *Config, remainingArgs []string, err error) {
 		}
 	}
 
+	// Validate metrics port number
+	if cfg.Metrics != "" {
+		metricsPort, err := strconv.Atoi(cfg.Metrics)
+		if err != nil || metricsPort < 1024 || metricsPort > 65535 {
+			str := "%s: The metrics port must be between 1024 and 65535"
+			err := errors.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Don't allow ban durations that are too short.
 	if cfg.BanDuration < time.Second {
 		str := "%s: The banduration option may not be less than 1s -- parsed [%s]"
@@ -418,37 +456,54 @@ func LoadConfig() (cfg *Config, remainingArgs []string, err error) {
 	}
 
 	// Validate any given whitelisted IP addresses and networks.
-	if len(cfg.Whitelists) > 0 {
-		var ip net.IP
+	if len(cfg.Flags.Whitelists) > 0 {
 		cfg.Whitelists = make([]*net.IPNet, 0, len(cfg.Flags.Whitelists))
 
 		for _, addr := range cfg.Flags.Whitelists {
-			_, ipnet, err := net.ParseCIDR(addr)
+			ipnet, err := network.ParseIPNetwork(addr)
 			if err != nil {
-				ip = net.ParseIP(addr)
-				if ip == nil {
-					str := "%s: The whitelist value of '%s' is invalid"
-					err = errors.Errorf(str, funcName, addr)
-					fmt.Fprintln(os.Stderr, err)
-					fmt.Fprintln(os.Stderr, usageMessage)
-					return nil, nil, err
-				}
-				var bits int
-				if ip.To4() == nil {
-					// IPv6
-					bits = 128
-				} else {
-					bits = 32
-				}
-				ipnet = &net.IPNet{
-					IP:   ip,
-					Mask: net.CIDRMask(bits, bits),
-				}
+				str := "%s: The whitelist value of '%s' is invalid"
+				err = errors.Errorf(str, funcName, addr)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
 			}
 			cfg.Whitelists = append(cfg.Whitelists, ipnet)
 		}
 	}
 
+	// A UTXO snapshot is trusted only as much as the hash of its snapshot
+	// block, which must therefore be given along with it.
+	if cfg.ImportUTXOSnapshot != "" {
+		if cfg.Flags.UTXOSnapshotHash == "" {
+			str := "%s: the --importutxosnapshot option requires the --utxosnapshothash option"
+			err := errors.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.UTXOSnapshotHash, err = daghash.NewHashFromStr(cfg.Flags.UTXOSnapshotHash)
+		if err != nil {
+			str := "%s: The utxosnapshothash value of '%s' is invalid: %s"
+			err := errors.Errorf(str, funcName, cfg.Flags.UTXOSnapshotHash, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		// --importutxosnapshot and the index options do not mix, since
+		// the imported UTXO set bypasses the indexes, and the blocks
+		// below the snapshot block can't be replayed into them.
+		if cfg.AcceptanceIndex || cfg.UTXOIndex || cfg.TxIndex || cfg.AddrIndex {
+			str := "%s: the --importutxosnapshot option may not be activated together " +
+				"with the --acceptanceindex, --utxoindex, --txindex or --addrindex options"
+			err := errors.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// --addPeer and --connect do not mix.
 	if len(cfg.AddPeers) > 0 && len(cfg.ConnectPeers) > 0 {
 		str := "%s: the --addpeer and --connect options can not be " +
@@ -574,6 +629,27 @@ func LoadConfig() (cfg *Config, remainingArgs []string, err error) {
 		return nil, nil, err
 	}
 
+	// The mempool must be able to hold at least one block's worth
+	// of transactions. A value of 0 means the mempool is unbounded.
+	if cfg.MaxMempoolMass != 0 && cfg.MaxMempoolMass < cfg.BlockMaxMass {
+		str := "%s: The maxmempoolmass option may not be less than " +
+			"blockmaxmass (%d) -- parsed [%d]"
+		err := errors.Errorf(str, funcName, cfg.BlockMaxMass, cfg.MaxMempoolMass)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Don't expire transactions before they had a fair chance to be
+	// accepted. A value of 0 disables expiry altogether.
+	if cfg.MempoolExpiry != 0 && cfg.MempoolExpiry < time.Minute {
+		str := "%s: The mempoolexpiry option may not be less than 1m unless it's 0 -- parsed [%s]"
+		err := errors.Errorf(str, funcName, cfg.MempoolExpiry)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Look for illegal characters in the user agent comments.
 	for _, uaComment := range cfg.UserAgentComments {
 		if strings.ContainsAny(uaComment, "/:()") {
@@ -596,6 +672,47 @@ func LoadConfig() (cfg *Config, remainingArgs []string, err error) {
 		return nil, nil, err
 	}
 
+	// --utxoindex and --droputxoindex do not mix.
+	if cfg.UTXOIndex && cfg.DropUTXOIndex {
+		err := errors.Errorf("%s: the --utxoindex and --droputxoindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --txindex and --droptxindex do not mix.
+	if cfg.TxIndex && cfg.DropTxIndex {
+		err := errors.Errorf("%s: the --txindex and --droptxindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --prune and --txindex do not mix, since the transaction index
+	// fetches transactions from the stored block data.
+	if cfg.Prune && cfg.TxIndex {
+		err := errors.Errorf("%s: the --prune and --txindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --addrindex and --dropaddrindex do not mix.
+	if cfg.AddrIndex && cfg.DropAddrIndex {
+		err := errors.Errorf("%s: the --addrindex and --dropaddrindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Add default port to all listener addresses if needed and remove
 	// duplicate addresses.
 	cfg.Listeners, err = network.NormalizeAddresses(cfg.Listeners,
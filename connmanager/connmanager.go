@@ -1,6 +1,7 @@
 package connmanager
 
 import (
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -135,6 +136,22 @@ func (c *ConnectionManager) IsBanned(netConnection *netadapter.NetConnection) (b
 	return c.addressManager.IsBanned(netConnection.NetAddress())
 }
 
+// BanScore returns the current ban score of the IP of the given netConnection
+func (c *ConnectionManager) BanScore(netConnection *netadapter.NetConnection) uint32 {
+	return c.addressManager.BanScore(netConnection.NetAddress())
+}
+
+// DisconnectNetwork disconnects all the connections to peers in the given
+// IP network, except for whitelisted peers
+func (c *ConnectionManager) DisconnectNetwork(ipNetwork *net.IPNet) {
+	for _, connection := range c.netAdapter.Connections() {
+		ip := connection.NetAddress().IP
+		if ipNetwork.Contains(ip) && !c.addressManager.IsWhitelisted(ip) {
+			connection.Disconnect()
+		}
+	}
+}
+
 func (c *ConnectionManager) waitTillNextIteration() {
 	select {
 	case <-c.resetLoopChan:
@@ -1,8 +1,22 @@
 package connmanager
 
-// checkIncomingConnections makes sure there's no more than maxIncoming incoming connections
+// checkIncomingConnections disconnects incoming connections from banned networks, and
+// makes sure there's no more than maxIncoming incoming connections
 // if there are - it randomly disconnects enough to go below that number
 func (c *ConnectionManager) checkIncomingConnections(incomingConnectionSet connectionSet) {
+	for _, connection := range incomingConnectionSet {
+		isBanned, err := c.addressManager.IsBanned(connection.NetAddress())
+		if err != nil {
+			log.Infof("Couldn't resolve whether %s is banned: %s", connection, err)
+			continue
+		}
+		if isBanned {
+			log.Infof("Disconnecting %s since it is banned", connection)
+			connection.Disconnect()
+			incomingConnectionSet.remove(connection)
+		}
+	}
+
 	if len(incomingConnectionSet) <= c.maxIncoming {
 		return
 	}
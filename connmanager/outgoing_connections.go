@@ -39,17 +39,8 @@ func (c *ConnectionManager) checkOutgoingConnections(connSet connectionSet) {
 		netAddress := address.NetAddress()
 		tcpAddress := netAddress.TCPAddress()
 		addressString := tcpAddress.String()
-		isBanned, err := c.addressManager.IsBanned(netAddress)
-		if err != nil {
-			log.Infof("Couldn't resolve whether %s is banned: %s", addressString, err)
-			continue
-		}
-		if isBanned {
-			continue
-		}
-
 		c.addressManager.Attempt(netAddress)
-		err = c.initiateConnection(addressString)
+		err := c.initiateConnection(addressString)
 		if err != nil {
 			log.Infof("Couldn't connect to %s: %s", addressString, err)
 			continue
@@ -16,4 +16,18 @@ type Database interface {
 
 	// Close closes the database.
 	Close() error
+
+	// CompactStore reclaims the disk space of the data in the
+	// store defined by storeName that none of the location
+	// handles that are stored in locationsBucket point to
+	// anymore, and updates the location handles of the data
+	// that was moved. All the location handles of the store
+	// that are in use must be stored in locationsBucket. See
+	// AppendToStore for further details.
+	//
+	// CompactStore moves at most about maxRewriteSize bytes of data,
+	// so that it doesn't take too long, and returns whether the
+	// compaction is complete. It should be called repeatedly until
+	// it is.
+	CompactStore(storeName string, locationsBucket *Bucket, maxRewriteSize uint64) (isDone bool, err error)
 }
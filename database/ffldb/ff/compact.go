@@ -0,0 +1,150 @@
+package ff
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"sort"
+)
+
+// compactionMaxFileUsage is the maximum ratio between the size of the
+// live data in a flat file and the size of the file for the file to be
+// compacted. Compacting files that are used more than that would
+// rewrite a lot of data in order to reclaim little disk space.
+const compactionMaxFileUsage = 0.5
+
+// compact rewrites the live data of the sparse files of the store to the
+// end of the store, so that the sparse files can be removed once the new
+// locations of the live data are stored instead of the old ones. Sparse
+// files are the files that precede the current file, and whose live data
+// takes up at most compactionMaxFileUsage of their size.
+//
+// The live data is defined by the given locations. compact returns the
+// new locations of the live data, in the order of the given locations.
+// The locations of data that wasn't rewritten are returned as is.
+//
+// compact stops once it has rewritten maxRewriteSize bytes, so that a
+// single call doesn't take too long, and returns whether all the live
+// data of the sparse files was rewritten. At least one record is
+// rewritten by every call, so calling compact again, with the new
+// locations, until it's done always completes the compaction.
+//
+// In case of an error, the store is rolled back to where it was before
+// compact was called. Since the rollback would undo writes that happen
+// meanwhile as well, compact MUST NOT be called concurrently with writes
+// to the store.
+func (s *flatFileStore) compact(liveLocations []*flatFileLocation, maxRewriteSize uint64) (
+	newLocations []*flatFileLocation, isDone bool, err error) {
+
+	if s.isClosed {
+		return nil, false, errors.Errorf("cannot compact a closed store %s",
+			s.storeName)
+	}
+
+	previousLocation := s.currentLocation()
+	sparseFileNumbers, err := s.sparseFileNumbers(previousLocation, liveLocations)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(sparseFileNumbers) == 0 {
+		return liveLocations, true, nil
+	}
+
+	// Rewrite the live data in the order in which it's stored in
+	// the sparse files, so that the files are read sequentially.
+	var locationsToRewrite []*flatFileLocation
+	for _, location := range liveLocations {
+		if _, ok := sparseFileNumbers[location.fileNumber]; ok {
+			locationsToRewrite = append(locationsToRewrite, location)
+		}
+	}
+	sort.Slice(locationsToRewrite, func(i, j int) bool {
+		if locationsToRewrite[i].fileNumber != locationsToRewrite[j].fileNumber {
+			return locationsToRewrite[i].fileNumber < locationsToRewrite[j].fileNumber
+		}
+		return locationsToRewrite[i].fileOffset < locationsToRewrite[j].fileOffset
+	})
+
+	isDone = true
+	rewriteSize := uint64(0)
+	rewrittenLocations := make(map[flatFileLocation]*flatFileLocation)
+	for _, location := range locationsToRewrite {
+		if _, ok := rewrittenLocations[*location]; ok {
+			continue
+		}
+		if rewriteSize >= maxRewriteSize && len(rewrittenLocations) > 0 {
+			isDone = false
+			break
+		}
+		newLocation, err := s.rewrite(location)
+		if err != nil {
+			rollbackErr := s.rollback(previousLocation)
+			if rollbackErr != nil {
+				return nil, false, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
+			}
+			return nil, false, err
+		}
+		rewrittenLocations[*location] = newLocation
+		rewriteSize += uint64(location.dataLength)
+	}
+
+	newLocations = make([]*flatFileLocation, len(liveLocations))
+	for i, location := range liveLocations {
+		newLocation, ok := rewrittenLocations[*location]
+		if !ok {
+			newLocation = location
+		}
+		newLocations[i] = newLocation
+	}
+
+	log.Debugf("Rewrote %d records from %d sparse files of store '%s'",
+		len(rewrittenLocations), len(sparseFileNumbers), s.storeName)
+	return newLocations, isDone, nil
+}
+
+// sparseFileNumbers returns the numbers of the files that precede the
+// file of boundaryLocation, and whose live data, as defined by the
+// given locations, takes up at most compactionMaxFileUsage of their size.
+// Files without any live data aren't returned, since there's nothing to
+// rewrite in order to remove them.
+func (s *flatFileStore) sparseFileNumbers(boundaryLocation *flatFileLocation,
+	liveLocations []*flatFileLocation) (map[uint32]struct{}, error) {
+
+	// Locations that appear more than once are counted once
+	liveDataSizes := make(map[uint32]uint64)
+	countedLocations := make(map[flatFileLocation]struct{})
+	for _, location := range liveLocations {
+		if location.fileNumber >= boundaryLocation.fileNumber {
+			continue
+		}
+		if _, ok := countedLocations[*location]; ok {
+			continue
+		}
+		countedLocations[*location] = struct{}{}
+		liveDataSizes[location.fileNumber] += uint64(location.dataLength)
+	}
+
+	sparseFileNumbers := make(map[uint32]struct{})
+	for fileNumber, liveDataSize := range liveDataSizes {
+		filePath := flatFilePath(s.basePath, s.storeName, fileNumber)
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if float64(liveDataSize) <= compactionMaxFileUsage*float64(fileInfo.Size()) {
+			sparseFileNumbers[fileNumber] = struct{}{}
+		}
+	}
+	return sparseFileNumbers, nil
+}
+
+// rewrite reads the data at the given location and writes it to the end
+// of the store. It returns the location the data was written to.
+func (s *flatFileStore) rewrite(location *flatFileLocation) (*flatFileLocation, error) {
+	data, err := s.read(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the data in file %d, "+
+			"offset %d of store '%s' in order to rewrite it", location.fileNumber,
+			location.fileOffset, s.storeName)
+	}
+	return s.write(data)
+}
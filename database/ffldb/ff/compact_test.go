@@ -0,0 +1,237 @@
+package ff
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+)
+
+// prepareSparseStoreForTest writes eight 8 byte chunks to a store whose
+// files can contain two chunks each, and returns the locations of the
+// chunks that remain live:
+//   - File 0: chunk 0 is live, chunk 1 isn't -> sparse
+//   - File 1: chunks 2 and 3 are live -> not sparse
+//   - File 2: no live chunks -> unused
+//   - File 3: chunk 6 is live, chunk 7 isn't -> the current file
+func prepareSparseStoreForTest(t *testing.T, testName string) (store *flatFileStore,
+	liveLocations []*flatFileLocation, teardownFunc func()) {
+
+	store, teardownFunc = prepareStoreForTest(t, testName)
+
+	// Set the maxFileSize to 32 bytes so that every file
+	// contains two 8 byte chunks.
+	currentMaxFileSize := maxFileSize
+	maxFileSize = 32
+	storeTeardownFunc := teardownFunc
+	teardownFunc = func() {
+		maxFileSize = currentMaxFileSize
+		storeTeardownFunc()
+	}
+
+	locations := make([]*flatFileLocation, 8)
+	for i := byte(0); i < 8; i++ {
+		writeData := []byte{i, i, i, i, i, i, i, i}
+		var err error
+		locations[i], err = store.write(writeData)
+		if err != nil {
+			teardownFunc()
+			t.Fatalf("%s: write returned unexpected error: %s", testName, err)
+		}
+	}
+	liveLocations = []*flatFileLocation{locations[0], locations[2], locations[3], locations[6]}
+	return store, liveLocations, teardownFunc
+}
+
+func TestFlatFileCompact(t *testing.T) {
+	store, liveLocations, teardownFunc := prepareSparseStoreForTest(t, "TestFlatFileCompact")
+	defer teardownFunc()
+
+	liveData := make([][]byte, len(liveLocations))
+	for i, location := range liveLocations {
+		var err error
+		liveData[i], err = store.read(location)
+		if err != nil {
+			t.Fatalf("TestFlatFileCompact: read returned "+
+				"unexpected error: %s", err)
+		}
+	}
+
+	previousLocation := store.currentLocation()
+	newLocations, isDone, err := store.compact(liveLocations, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("TestFlatFileCompact: compact returned "+
+			"unexpected error: %s", err)
+	}
+	if !isDone {
+		t.Fatalf("TestFlatFileCompact: expected compact to be done")
+	}
+
+	// Only the live chunk of the sparse file is rewritten
+	if newLocations[0].fileNumber <= previousLocation.fileNumber {
+		t.Fatalf("TestFlatFileCompact: expected the live chunk of the sparse file "+
+			"to be rewritten after file %d, but it's in file %d",
+			previousLocation.fileNumber, newLocations[0].fileNumber)
+	}
+	for i := 1; i < len(liveLocations); i++ {
+		if *newLocations[i] != *liveLocations[i] {
+			t.Fatalf("TestFlatFileCompact: chunk %d was unexpectedly rewritten", i)
+		}
+	}
+
+	err = store.removeUnusedFiles(previousLocation, newLocations)
+	if err != nil {
+		t.Fatalf("TestFlatFileCompact: removeUnusedFiles returned "+
+			"unexpected error: %s", err)
+	}
+
+	// The sparse file and the unused file are removed,
+	// and all the live data is still available
+	for fileNumber := uint32(0); fileNumber <= 3; fileNumber++ {
+		filePath := flatFilePath(store.basePath, store.storeName, fileNumber)
+		_, err := os.Stat(filePath)
+		isRemoved := fileNumber == 0 || fileNumber == 2
+		if isRemoved != os.IsNotExist(err) {
+			t.Fatalf("TestFlatFileCompact: unexpected existence of file %d. "+
+				"Expected removed: %t, got error: %v", fileNumber, isRemoved, err)
+		}
+	}
+	for i, location := range newLocations {
+		data, err := store.read(location)
+		if err != nil {
+			t.Fatalf("TestFlatFileCompact: read of live chunk %d returned "+
+				"unexpected error: %s", i, err)
+		}
+		if !bytes.Equal(data, liveData[i]) {
+			t.Fatalf("TestFlatFileCompact: read returned unexpected data for "+
+				"live chunk %d. Want: %v, got: %v", i, liveData[i], data)
+		}
+	}
+}
+
+func TestFlatFileCompactInSteps(t *testing.T) {
+	store, liveLocations, teardownFunc := prepareSparseStoreForTest(t, "TestFlatFileCompactInSteps")
+	defer teardownFunc()
+
+	// Make file 1 sparse as well, and file 3 unused, so that
+	// there are two live chunks to rewrite
+	liveLocations = []*flatFileLocation{liveLocations[0], liveLocations[1]}
+
+	// Every step rewrites a single chunk, since the first
+	// chunk already takes up the whole step size
+	for i := 0; i < 2; i++ {
+		previousLocation := store.currentLocation()
+		newLocations, isDone, err := store.compact(liveLocations, 1)
+		if err != nil {
+			t.Fatalf("TestFlatFileCompactInSteps: compact returned "+
+				"unexpected error in step %d: %s", i, err)
+		}
+		expectedIsDone := i == 1
+		if isDone != expectedIsDone {
+			t.Fatalf("TestFlatFileCompactInSteps: unexpected isDone in step %d. "+
+				"Want: %t, got: %t", i, expectedIsDone, isDone)
+		}
+		if *newLocations[i] == *liveLocations[i] {
+			t.Fatalf("TestFlatFileCompactInSteps: expected chunk %d to be "+
+				"rewritten in step %d", i, i)
+		}
+		for j := range liveLocations {
+			if j != i && *newLocations[j] != *liveLocations[j] {
+				t.Fatalf("TestFlatFileCompactInSteps: chunk %d was unexpectedly "+
+					"rewritten in step %d", j, i)
+			}
+		}
+		err = store.removeUnusedFiles(previousLocation, newLocations)
+		if err != nil {
+			t.Fatalf("TestFlatFileCompactInSteps: removeUnusedFiles returned "+
+				"unexpected error: %s", err)
+		}
+		liveLocations = newLocations
+	}
+
+	// Once the compaction is done, there's nothing left to rewrite
+	newLocations, isDone, err := store.compact(liveLocations, 1)
+	if err != nil {
+		t.Fatalf("TestFlatFileCompactInSteps: compact returned "+
+			"unexpected error: %s", err)
+	}
+	if !isDone {
+		t.Fatalf("TestFlatFileCompactInSteps: expected compact to be done")
+	}
+	for i := range liveLocations {
+		if *newLocations[i] != *liveLocations[i] {
+			t.Fatalf("TestFlatFileCompactInSteps: chunk %d was unexpectedly "+
+				"rewritten after the compaction was done", i)
+		}
+	}
+}
+
+func TestFlatFileCompactRollback(t *testing.T) {
+	store, liveLocations, teardownFunc := prepareSparseStoreForTest(t, "TestFlatFileCompactRollback")
+	defer teardownFunc()
+
+	// Add a location with a bad checksum in the unused file, so that
+	// compact fails after the live chunk of the sparse file is rewritten.
+	badLocation := &flatFileLocation{fileNumber: 2, fileOffset: 0, dataLength: 8}
+	liveLocations = append(liveLocations, badLocation)
+
+	previousLocation := store.currentLocation()
+	_, _, err := store.compact(liveLocations, math.MaxUint64)
+	if err == nil {
+		t.Fatalf("TestFlatFileCompactRollback: compact unexpectedly succeeded")
+	}
+
+	// The rewritten data is rolled back
+	currentLocation := store.currentLocation()
+	if *currentLocation != *previousLocation {
+		t.Fatalf("TestFlatFileCompactRollback: unexpected current location "+
+			"after a failed compaction. Want: %v, got: %v", previousLocation, currentLocation)
+	}
+	filePath := flatFilePath(store.basePath, store.storeName, previousLocation.fileNumber+1)
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Fatalf("TestFlatFileCompactRollback: file "+
+			"unexpectedly still exists: %s", filePath)
+	}
+}
+
+func TestFlatFileCompactCrashRecovery(t *testing.T) {
+	store, liveLocations, teardownFunc := prepareSparseStoreForTest(t, "TestFlatFileCompactCrashRecovery")
+	defer teardownFunc()
+
+	previousLocation := store.currentLocation()
+	_, _, err := store.compact(liveLocations, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("TestFlatFileCompactCrashRecovery: compact returned "+
+			"unexpected error: %s", err)
+	}
+
+	// Simulate a crash before the new locations are stored, by
+	// reopening the store and rolling it back to the location that's
+	// still stored as its current location, as done when the
+	// database is repaired on start up.
+	reopenedStore, err := openFlatFileStore(store.basePath, store.storeName)
+	if err != nil {
+		t.Fatalf("TestFlatFileCompactCrashRecovery: openFlatFileStore "+
+			"unexpectedly failed: %s", err)
+	}
+	defer reopenedStore.Close()
+	err = reopenedStore.rollback(previousLocation)
+	if err != nil {
+		t.Fatalf("TestFlatFileCompactCrashRecovery: rollback returned "+
+			"unexpected error: %s", err)
+	}
+
+	// The old locations are still valid
+	for i, location := range liveLocations {
+		_, err := reopenedStore.read(location)
+		if err != nil {
+			t.Fatalf("TestFlatFileCompactCrashRecovery: read of live chunk %d "+
+				"returned unexpected error: %s", i, err)
+		}
+	}
+	currentLocation := reopenedStore.currentLocation()
+	if *currentLocation != *previousLocation {
+		t.Fatalf("TestFlatFileCompactCrashRecovery: unexpected current location "+
+			"after the rollback. Want: %v, got: %v", previousLocation, currentLocation)
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"hash/crc32"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -16,6 +20,9 @@ const (
 	// cache. Note that this does not include the current/write file, so there
 	// will typically be one more than this value open.
 	maxOpenFiles = 25
+
+	// flatFileExtension is the extension of the names of the flat files.
+	flatFileExtension = ".fdb"
 )
 
 var (
@@ -195,25 +202,21 @@ func (s *flatFileStore) currentLocation() *flatFileLocation {
 
 // findCurrentLocation searches the database directory for all flat files for a given
 // store to find the end of the most recent file. This position is considered
-// the current write cursor.
+// the current write cursor. Note that files that precede the most recent file
+// might have been removed, so the most recent file is the one with the highest
+// file number.
 func findCurrentLocation(dbPath string, storeName string) (fileNumber uint32, fileLength uint32, err error) {
-	currentFileNumber := uint32(0)
-	currentFileLength := uint32(0)
-	for {
-		currentFilePath := flatFilePath(dbPath, storeName, currentFileNumber)
-		stat, err := os.Stat(currentFilePath)
+	fileNumbers, err := flatFileNumbers(dbPath, storeName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(fileNumbers) > 0 {
+		fileNumber = fileNumbers[len(fileNumbers)-1]
+		stat, err := os.Stat(flatFilePath(dbPath, storeName, fileNumber))
 		if err != nil {
-			if !os.IsNotExist(err) {
-				return 0, 0, errors.WithStack(err)
-			}
-			if currentFileNumber > 0 {
-				fileNumber = currentFileNumber - 1
-			}
-			fileLength = currentFileLength
-			break
+			return 0, 0, errors.WithStack(err)
 		}
-		currentFileLength = uint32(stat.Size())
-		currentFileNumber++
+		fileLength = uint32(stat.Size())
 	}
 
 	log.Tracef("Scan for store '%s' found latest file #%d with length %d",
@@ -221,11 +224,47 @@ func findCurrentLocation(dbPath string, storeName string) (fileNumber uint32, fi
 	return fileNumber, fileLength, nil
 }
 
+// flatFileNumbers returns the numbers of all the existing flat files of the
+// given store, in ascending order.
+func flatFileNumbers(dbPath string, storeName string) ([]uint32, error) {
+	fileInfos, err := ioutil.ReadDir(dbPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	prefix := storeName + "-"
+	var fileNumbers []uint32
+	for _, fileInfo := range fileInfos {
+		fileName := fileInfo.Name()
+		if !strings.HasPrefix(fileName, prefix) || !strings.HasSuffix(fileName, flatFileExtension) {
+			continue
+		}
+		fileNumberString := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), flatFileExtension)
+		fileNumber, err := strconv.ParseUint(fileNumberString, 10, 32)
+		if err != nil {
+			continue
+		}
+		// Make sure that the file name is in the exact format of
+		// flatFilePath, e.g. that it has all of the leading zeros.
+		if filepath.Base(flatFilePath(dbPath, storeName, uint32(fileNumber))) != fileName {
+			continue
+		}
+		fileNumbers = append(fileNumbers, uint32(fileNumber))
+	}
+	sort.Slice(fileNumbers, func(i, j int) bool {
+		return fileNumbers[i] < fileNumbers[j]
+	})
+	return fileNumbers, nil
+}
+
 // flatFilePath return the file path for the provided store's flat file number.
 func flatFilePath(dbPath string, storeName string, fileNumber uint32) string {
 	// Choose 9 digits of precision for the filenames. 9 digits provide
 	// 10^9 files @ 512MiB each a total of ~476.84PiB.
 
-	fileName := fmt.Sprintf("%s-%09d.fdb", storeName, fileNumber)
+	fileName := fmt.Sprintf("%s-%09d%s", storeName, fileNumber, flatFileExtension)
 	return filepath.Join(dbPath, fileName)
 }
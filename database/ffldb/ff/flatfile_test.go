@@ -173,3 +173,87 @@ func TestFlatFileMultiFileRollback(t *testing.T) {
 		}
 	}
 }
+
+func TestFlatFileRemoveUnusedFiles(t *testing.T) {
+	store, teardownFunc := prepareStoreForTest(t, "TestFlatFileRemoveUnusedFiles")
+	defer teardownFunc()
+
+	// Set the maxFileSize to 16 bytes so that every 8 byte chunk
+	// is written to a file of its own.
+	currentMaxFileSize := maxFileSize
+	maxFileSize = 16
+	defer func() {
+		maxFileSize = currentMaxFileSize
+	}()
+
+	// Write six 8 byte chunks and keep their locations
+	locations := make([]*flatFileLocation, 6)
+	for i := byte(0); i < 6; i++ {
+		writeData := []byte{i, i, i, i, i, i, i, i}
+		var err error
+		locations[i], err = store.write(writeData)
+		if err != nil {
+			t.Fatalf("TestFlatFileRemoveUnusedFiles: write returned "+
+				"unexpected error: %s", err)
+		}
+	}
+
+	// Read the first chunk so that its file is open for reading
+	// when it's removed
+	_, err := store.read(locations[0])
+	if err != nil {
+		t.Fatalf("TestFlatFileRemoveUnusedFiles: read returned "+
+			"unexpected error: %s", err)
+	}
+
+	// Remove the files that precede the file of the fifth chunk,
+	// except for the file of the third chunk
+	currentLocation := store.currentLocation()
+	err = store.removeUnusedFiles(locations[4], []*flatFileLocation{locations[2]})
+	if err != nil {
+		t.Fatalf("TestFlatFileRemoveUnusedFiles: removeUnusedFiles returned "+
+			"unexpected error: %s", err)
+	}
+
+	for i, location := range locations {
+		data, err := store.read(location)
+		isRemoved := i < 4 && i != 2
+		if isRemoved {
+			if !database.IsNotFoundError(err) {
+				t.Fatalf("TestFlatFileRemoveUnusedFiles: read of removed "+
+					"chunk %d returned unexpected error: %v", i, err)
+			}
+			filePath := flatFilePath(store.basePath, store.storeName, location.fileNumber)
+			if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+				t.Fatalf("TestFlatFileRemoveUnusedFiles: file "+
+					"unexpectedly still exists: %s", filePath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestFlatFileRemoveUnusedFiles: read of chunk %d returned "+
+				"unexpected error: %s", i, err)
+		}
+		expectedData := []byte{byte(i), byte(i), byte(i), byte(i), byte(i), byte(i), byte(i), byte(i)}
+		if !bytes.Equal(data, expectedData) {
+			t.Fatalf("TestFlatFileRemoveUnusedFiles: read returned "+
+				"unexpected data for chunk %d. Want: %v, got: %v", i,
+				expectedData, data)
+		}
+	}
+
+	// Make sure that the current location is found after
+	// the store is reopened, even though the first files
+	// don't exist anymore
+	reopenedStore, err := openFlatFileStore(store.basePath, store.storeName)
+	if err != nil {
+		t.Fatalf("TestFlatFileRemoveUnusedFiles: openFlatFileStore "+
+			"unexpectedly failed: %s", err)
+	}
+	defer reopenedStore.Close()
+	reopenedLocation := reopenedStore.currentLocation()
+	if *reopenedLocation != *currentLocation {
+		t.Fatalf("TestFlatFileRemoveUnusedFiles: unexpected current location "+
+			"after reopening the store. Want: %v, got: %v", currentLocation, reopenedLocation)
+	}
+}
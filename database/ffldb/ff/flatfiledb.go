@@ -89,6 +89,63 @@ func (ffdb *FlatFileDB) Rollback(storeName string, serializedLocation []byte) er
 	return store.rollback(location)
 }
 
+// RemoveUnusedFiles removes the files of the flat-file store
+// defined by storeName that none of the given serialized
+// location handles point to. Only the files that precede the
+// file of the given boundary location handle are removed, so
+// that data that was written while the used location handles
+// were collected isn't removed. Usually, the boundary location
+// handle is the current location of the store as it was before
+// the used location handles were collected.
+func (ffdb *FlatFileDB) RemoveUnusedFiles(storeName string, serializedBoundaryLocation []byte,
+	serializedUsedLocations [][]byte) error {
+
+	store, err := ffdb.store(storeName)
+	if err != nil {
+		return err
+	}
+	boundaryLocation, err := deserializeLocation(serializedBoundaryLocation)
+	if err != nil {
+		return err
+	}
+	usedLocations, err := deserializeLocations(serializedUsedLocations)
+	if err != nil {
+		return err
+	}
+	return store.removeUnusedFiles(boundaryLocation, usedLocations)
+}
+
+// Compact rewrites the live data of the sparse files of the
+// flat-file store defined by storeName to the end of the store.
+// The live data is defined by the given serialized location
+// handles, and Compact returns their new serialized location
+// handles, in the same order. The sparse files should be removed
+// using RemoveUnusedFiles once the new location handles are stored.
+// Compact stops once it has rewritten maxRewriteSize bytes, and
+// returns whether all the live data of the sparse files was rewritten.
+// See flatFileStore.compact() for further details.
+func (ffdb *FlatFileDB) Compact(storeName string, serializedLiveLocations [][]byte,
+	maxRewriteSize uint64) (serializedNewLocations [][]byte, isDone bool, err error) {
+
+	store, err := ffdb.store(storeName)
+	if err != nil {
+		return nil, false, err
+	}
+	liveLocations, err := deserializeLocations(serializedLiveLocations)
+	if err != nil {
+		return nil, false, err
+	}
+	newLocations, isDone, err := store.compact(liveLocations, maxRewriteSize)
+	if err != nil {
+		return nil, false, err
+	}
+	serializedNewLocations = make([][]byte, len(newLocations))
+	for i, newLocation := range newLocations {
+		serializedNewLocations[i] = serializeLocation(newLocation)
+	}
+	return serializedNewLocations, isDone, nil
+}
+
 func (ffdb *FlatFileDB) store(storeName string) (*flatFileStore, error) {
 	store, ok := ffdb.flatFileStores[storeName]
 	if !ok {
@@ -42,3 +42,17 @@ func deserializeLocation(serializedLocation []byte) (*flatFileLocation, error) {
 	}
 	return location, nil
 }
+
+// deserializeLocations deserializes the passed serialized flat file
+// locations. See serializeLocation for further details.
+func deserializeLocations(serializedLocations [][]byte) ([]*flatFileLocation, error) {
+	locations := make([]*flatFileLocation, len(serializedLocations))
+	for i, serializedLocation := range serializedLocations {
+		location, err := deserializeLocation(serializedLocation)
+		if err != nil {
+			return nil, err
+		}
+		locations[i] = location
+	}
+	return locations, nil
+}
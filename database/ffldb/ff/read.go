@@ -28,9 +28,14 @@ func (s *flatFileStore) read(location *flatFileLocation) ([]byte, error) {
 		return nil, database.ErrNotFound
 	}
 
-	// Get the referenced flat file.
+	// Get the referenced flat file. Files that no data is referenced
+	// from anymore might have been removed, in which case the location
+	// doesn't exist either.
 	flatFile, err := s.flatFile(location.fileNumber)
 	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, database.ErrNotFound
+		}
 		return nil, err
 	}
 	flatFile.RLock()
@@ -0,0 +1,59 @@
+package ff
+
+import (
+	"github.com/pkg/errors"
+)
+
+// removeUnusedFiles removes the flat files that precede the file of
+// boundaryLocation and that don't contain the data of any of the given
+// used locations. The files from the file of boundaryLocation onwards are
+// never removed, since they might contain data that was written after the
+// used locations had been collected.
+//
+// This is used to reclaim the disk space of data that is no longer
+// referenced, such as the data of pruned blocks.
+func (s *flatFileStore) removeUnusedFiles(boundaryLocation *flatFileLocation,
+	usedLocations []*flatFileLocation) error {
+
+	if s.isClosed {
+		return errors.Errorf("cannot remove the files of a closed store %s",
+			s.storeName)
+	}
+
+	usedFileNumbers := make(map[uint32]struct{})
+	for _, location := range usedLocations {
+		usedFileNumbers[location.fileNumber] = struct{}{}
+	}
+
+	fileNumbers, err := flatFileNumbers(s.basePath, s.storeName)
+	if err != nil {
+		return err
+	}
+
+	// Grab the write cursor mutex to block readers from getting
+	// the files that are about to be removed. This follows the
+	// locking order of rollback.
+	s.writeCursor.Lock()
+	defer s.writeCursor.Unlock()
+	s.lruMutex.Lock()
+	defer s.lruMutex.Unlock()
+	s.openFilesMutex.Lock()
+	defer s.openFilesMutex.Unlock()
+
+	for _, fileNumber := range fileNumbers {
+		if fileNumber >= boundaryLocation.fileNumber || fileNumber >= s.writeCursor.currentFileNumber {
+			break
+		}
+		if _, ok := usedFileNumbers[fileNumber]; ok {
+			continue
+		}
+
+		err := s.deleteFile(fileNumber)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove unused file "+
+				"number %d in store '%s'", fileNumber, s.storeName)
+		}
+		log.Debugf("Removed unused file #%d of store '%s'", fileNumber, s.storeName)
+	}
+	return nil
+}
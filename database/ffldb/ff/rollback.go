@@ -114,10 +114,10 @@ func (s *flatFileStore) rollback(targetLocation *flatFileLocation) error {
 // This function MUST be called with the lruMutex and the openFilesMutex
 // held for writes.
 func (s *flatFileStore) deleteFile(fileNumber uint32) error {
-	// Cleanup the file before deleting it
+	// Cleanup the file before deleting it. Note that Close
+	// locks the file for writes, so that it isn't closed out
+	// from under any readers currently reading from it.
 	if file, ok := s.openFiles[fileNumber]; ok {
-		file.Lock()
-		defer file.Unlock()
 		err := file.Close()
 		if err != nil {
 			return err
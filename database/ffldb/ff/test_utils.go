@@ -0,0 +1,14 @@
+package ff
+
+// SetMaxFileSizeForTest sets the maximum size of the flat files, so that
+// tests can spread little data over several files. It returns a function
+// that restores the previous maximum size.
+//
+// It MUST NOT be called while a flat-file database is open.
+func SetMaxFileSizeForTest(size uint32) (restore func()) {
+	previousMaxFileSize := maxFileSize
+	maxFileSize = size
+	return func() {
+		maxFileSize = previousMaxFileSize
+	}
+}
@@ -1,6 +1,7 @@
 package ffldb
 
 import (
+	"bytes"
 	"github.com/kaspanet/kaspad/database"
 	"github.com/kaspanet/kaspad/database/ffldb/ff"
 	"github.com/kaspanet/kaspad/database/ffldb/ldb"
@@ -153,6 +154,130 @@ func (db *ffldb) RetrieveFromStore(storeName string, location []byte) ([]byte, e
 	return db.flatFileDB.Read(storeName, location)
 }
 
+// CompactStore reclaims the disk space of the data in the
+// flat-file store defined by storeName that none of the location
+// handles that are stored in locationsBucket point to anymore.
+// The live data of sparse files is rewritten to the end of the
+// store, the location handles are updated to point to it in a
+// single transaction, and then the files that don't contain any
+// live data are removed. All the location handles of the store
+// that are in use must be stored in locationsBucket. See
+// AppendToStore for further details.
+//
+// CompactStore stops rewriting once it has rewritten maxRewriteSize
+// bytes, and returns whether all the live data of the sparse files
+// was rewritten.
+//
+// CompactStore MUST NOT be called concurrently with transactions
+// that write to the store or to locationsBucket.
+// This method is part of the Database interface.
+func (db *ffldb) CompactStore(storeName string, locationsBucket *database.Bucket,
+	maxRewriteSize uint64) (isDone bool, err error) {
+
+	previousLocation, err := db.flatFileDB.CurrentLocation(storeName)
+	if err != nil {
+		return false, err
+	}
+
+	keys, locations, err := db.storeLocations(locationsBucket)
+	if err != nil {
+		return false, err
+	}
+
+	newLocations, isDone, err := db.flatFileDB.Compact(storeName, locations, maxRewriteSize)
+	if err != nil {
+		return false, err
+	}
+
+	// If the node shuts down before the new location handles are
+	// stored, the rewritten data is rolled back once the database
+	// is opened again, since the current location of the store
+	// isn't updated either. See tryRepair for further details.
+	err = db.storeCompactedLocations(storeName, keys, locations, newLocations)
+	if err != nil {
+		rollbackErr := db.flatFileDB.Rollback(storeName, previousLocation)
+		if rollbackErr != nil {
+			return false, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
+		}
+		return false, err
+	}
+
+	err = db.flatFileDB.RemoveUnusedFiles(storeName, previousLocation, newLocations)
+	if err != nil {
+		return false, err
+	}
+	return isDone, nil
+}
+
+// storeLocations returns the keys and the values of all the location
+// handles that are stored in locationsBucket.
+func (db *ffldb) storeLocations(locationsBucket *database.Bucket) (
+	keys []*database.Key, locations [][]byte, err error) {
+
+	cursor := db.levelDB.Cursor(locationsBucket)
+	defer func() {
+		err := cursor.Close()
+		if err != nil {
+			log.Warnf("cursor failed to close")
+		}
+	}()
+
+	for cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, nil, err
+		}
+		location, err := cursor.Value()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The contents of the key and the value may change on the
+		// next call to Next, so we copy them before collecting them.
+		suffix := make([]byte, len(key.Suffix()))
+		copy(suffix, key.Suffix())
+		keys = append(keys, locationsBucket.Key(suffix))
+		locationCopy := make([]byte, len(location))
+		copy(locationCopy, location)
+		locations = append(locations, locationCopy)
+	}
+	return keys, locations, nil
+}
+
+// storeCompactedLocations replaces the location handles of the data that
+// was rewritten by CompactStore with the new ones, and updates the
+// current location of the store, all in a single transaction.
+func (db *ffldb) storeCompactedLocations(storeName string, keys []*database.Key,
+	locations [][]byte, newLocations [][]byte) error {
+
+	dbTx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	for i, key := range keys {
+		if bytes.Equal(locations[i], newLocations[i]) {
+			continue
+		}
+		err := dbTx.Put(key, newLocations[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	currentLocation, err := db.flatFileDB.CurrentLocation(storeName)
+	if err != nil {
+		return err
+	}
+	err = setCurrentStoreLocation(dbTx, storeName, currentLocation)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
 // Cursor begins a new cursor over the given bucket.
 // This method is part of the DataAccessor interface.
 func (db *ffldb) Cursor(bucket *database.Bucket) (database.Cursor, error) {
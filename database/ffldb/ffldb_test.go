@@ -1,8 +1,12 @@
 package ffldb
 
 import (
+	"fmt"
 	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/database/ffldb/ff"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -129,3 +133,126 @@ func TestRepairFlatFiles(t *testing.T) {
 			"returned wrong error: %s", err)
 	}
 }
+
+func TestCompactStore(t *testing.T) {
+	// Set the maximum file size so that every file contains
+	// two 4 byte records, each 12 bytes long with its length
+	// and checksum
+	restoreMaxFileSize := ff.SetMaxFileSizeForTest(24)
+	defer restoreMaxFileSize()
+
+	// Create a temp db to run tests against
+	path, err := ioutil.TempDir("", "TestCompactStore")
+	if err != nil {
+		t.Fatalf("TestCompactStore: TempDir unexpectedly "+
+			"failed: %s", err)
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("TestCompactStore: Open unexpectedly "+
+			"failed: %s", err)
+	}
+	isOpen := true
+	defer func() {
+		if isOpen {
+			err := db.Close()
+			if err != nil {
+				t.Fatalf("TestCompactStore: Close unexpectedly "+
+					"failed: %s", err)
+			}
+		}
+	}()
+
+	// Append data to files 0 to 4 of the store and keep its
+	// location handles in a locations bucket, except for the
+	// location handles of removed data, so that every file
+	// is sparse
+	storeName := "test"
+	locationsBucket := database.MakeBucket([]byte("test-locations"))
+	liveData := make(map[string][]byte)
+	for i := 0; i < 10; i++ {
+		data := []byte{byte(i), byte(i), byte(i), byte(i)}
+		location, err := db.AppendToStore(storeName, data)
+		if err != nil {
+			t.Fatalf("TestCompactStore: AppendToStore unexpectedly "+
+				"failed: %s", err)
+		}
+		if i%2 == 0 {
+			continue
+		}
+		err = db.Put(locationsBucket.Key(data), location)
+		if err != nil {
+			t.Fatalf("TestCompactStore: Put unexpectedly "+
+				"failed: %s", err)
+		}
+		liveData[string(data)] = data
+	}
+
+	// Compact the store in steps of a single record. Every step
+	// rewrites the live record of the next sparse file, including
+	// file 4 once it stops being the current file, so it takes
+	// five steps to rewrite all the live records.
+	const expectedSteps = 5
+	for step := 1; ; step++ {
+		isDone, err := db.CompactStore(storeName, locationsBucket, 1)
+		if err != nil {
+			t.Fatalf("TestCompactStore: CompactStore unexpectedly "+
+				"failed: %s", err)
+		}
+		if isDone {
+			if step != expectedSteps {
+				t.Fatalf("TestCompactStore: unexpected number of compaction "+
+					"steps. Want: %d, got: %d", expectedSteps, step)
+			}
+			break
+		}
+		if step >= expectedSteps {
+			t.Fatalf("TestCompactStore: compaction isn't done "+
+				"after %d steps", step)
+		}
+	}
+
+	// The sparse files are removed, and the rewritten
+	// records are in files 5 to 7
+	for fileNumber := 0; fileNumber <= 7; fileNumber++ {
+		fileName := fmt.Sprintf("%s-%09d.fdb", storeName, fileNumber)
+		_, err := os.Stat(filepath.Join(path, fileName))
+		isRemoved := fileNumber < 5
+		if isRemoved != os.IsNotExist(err) {
+			t.Fatalf("TestCompactStore: unexpected existence of file %d. "+
+				"Expected removed: %t, got error: %v", fileNumber, isRemoved, err)
+		}
+	}
+
+	// Make sure that the live data is still available
+	// after the database is reopened and repaired
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("TestCompactStore: Close unexpectedly "+
+			"failed: %s", err)
+	}
+	isOpen = false
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("TestCompactStore: Open unexpectedly "+
+			"failed: %s", err)
+	}
+	isOpen = true
+
+	for _, data := range liveData {
+		location, err := db.Get(locationsBucket.Key(data))
+		if err != nil {
+			t.Fatalf("TestCompactStore: Get unexpectedly "+
+				"failed: %s", err)
+		}
+		retrievedData, err := db.RetrieveFromStore(storeName, location)
+		if err != nil {
+			t.Fatalf("TestCompactStore: RetrieveFromStore "+
+				"unexpectedly failed: %s", err)
+		}
+		if !reflect.DeepEqual(retrievedData, data) {
+			t.Fatalf("TestCompactStore: RetrieveFromStore returned "+
+				"unexpected data. Want: %v, got: %v", data, retrievedData)
+		}
+	}
+}
@@ -7,13 +7,18 @@ import (
 )
 
 var (
-	acceptanceIndexBucket = database.MakeBucket([]byte("acceptance-index"))
+	acceptanceIndexBucket                = database.MakeBucket([]byte("acceptance-index"))
+	acceptanceIndexAcceptingBlocksBucket = database.MakeBucket([]byte("acceptance-index-accepting-blocks"))
 )
 
 func acceptanceIndexKey(hash *daghash.Hash) *database.Key {
 	return acceptanceIndexBucket.Key(hash[:])
 }
 
+func acceptanceIndexAcceptingBlockKey(txID *daghash.TxID) *database.Key {
+	return acceptanceIndexAcceptingBlocksBucket.Key(txID[:])
+}
+
 // StoreAcceptanceData stores the given acceptanceData in the database.
 func StoreAcceptanceData(context Context, hash *daghash.Hash, acceptanceData []byte) error {
 	accessor, err := context.accessor()
@@ -58,7 +63,68 @@ func FetchAcceptanceData(context Context, hash *daghash.Hash) ([]byte, error) {
 	return acceptanceData, nil
 }
 
+// RemoveAcceptanceData removes the acceptanceData of the given hash.
+func RemoveAcceptanceData(context Context, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := acceptanceIndexKey(hash)
+	return accessor.Delete(key)
+}
+
+// StoreAcceptingBlockHash stores the hash of the selected parent
+// chain block that accepted the transaction with the given ID.
+func StoreAcceptingBlockHash(context Context, txID *daghash.TxID, acceptingBlockHash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := acceptanceIndexAcceptingBlockKey(txID)
+	return accessor.Put(key, acceptingBlockHash[:])
+}
+
+// FetchAcceptingBlockHash returns the hash of the selected parent chain
+// block that accepted the transaction with the given ID. Returns
+// ErrNotFound if no selected parent chain block accepted the transaction.
+func FetchAcceptingBlockHash(context Context, txID *daghash.TxID) (*daghash.Hash, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	key := acceptanceIndexAcceptingBlockKey(txID)
+	acceptingBlockHash, err := accessor.Get(key)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "accepting block not found for transaction %s", txID)
+		}
+		return nil, err
+	}
+
+	return daghash.NewHash(acceptingBlockHash)
+}
+
+// RemoveAcceptingBlockHash removes the accepting block hash of the
+// transaction with the given ID.
+func RemoveAcceptingBlockHash(context Context, txID *daghash.TxID) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := acceptanceIndexAcceptingBlockKey(txID)
+	return accessor.Delete(key)
+}
+
 // DropAcceptanceIndex completely removes all acceptanceData entries.
 func DropAcceptanceIndex(dbTx *TxContext) error {
-	return clearBucket(dbTx, acceptanceIndexBucket)
+	err := clearBucket(dbTx, acceptanceIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	return clearBucket(dbTx, acceptanceIndexAcceptingBlocksBucket)
 }
@@ -0,0 +1,240 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+var (
+	addrIndexBucket            = database.MakeBucket([]byte("addr-index"))
+	addrIndexOutputsBucket     = database.MakeBucket([]byte("addr-index-outputs"))
+	addrIndexChainBlocksBucket = database.MakeBucket([]byte("addr-index-chain-blocks"))
+	addrIndexUndoDataBucket    = database.MakeBucket([]byte("addr-index-undo-data"))
+)
+
+// addrIndexAddressBucket returns the sub-bucket that holds all the
+// entries of the given address. Addresses are hashed so that every
+// sub-bucket name has the same length, which prevents the prefix of
+// one sub-bucket from matching another.
+func addrIndexAddressBucket(address []byte) *database.Bucket {
+	return addrIndexBucket.Bucket(daghash.HashB(address))
+}
+
+func addrIndexOutputKey(outpointKey []byte) *database.Key {
+	return addrIndexOutputsBucket.Key(outpointKey)
+}
+
+func addrIndexChainBlockKey(hash *daghash.Hash) *database.Key {
+	return addrIndexChainBlocksBucket.Key(hash[:])
+}
+
+func addrIndexUndoDataKey(hash *daghash.Hash) *database.Key {
+	return addrIndexUndoDataBucket.Key(hash[:])
+}
+
+// AddrIndexEntryKey returns the database key of the address index
+// entry of the given address and entry key. It's meant to be used
+// for seeking address index cursors.
+func AddrIndexEntryKey(address []byte, entryKey []byte) *database.Key {
+	return addrIndexAddressBucket(address).Key(entryKey)
+}
+
+// StoreAddrIndexEntry stores the given address index entry
+// under the given address.
+func StoreAddrIndexEntry(context Context, address []byte, entryKey []byte, entry []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(AddrIndexEntryKey(address, entryKey), entry)
+}
+
+// FetchAddrIndexEntry returns the address index entry of the given
+// address and entry key. Returns ErrNotFound if the entry had not been
+// previously inserted into the database.
+func FetchAddrIndexEntry(context Context, address []byte, entryKey []byte) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := accessor.Get(AddrIndexEntryKey(address, entryKey))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "address index entry not found for address %s", address)
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// RemoveAddrIndexEntry removes the address index entry
+// of the given address and entry key.
+func RemoveAddrIndexEntry(context Context, address []byte, entryKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(AddrIndexEntryKey(address, entryKey))
+}
+
+// AddrIndexCursor opens a cursor over all the address
+// index entries of the given address.
+func AddrIndexCursor(context Context, address []byte) (database.Cursor, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessor.Cursor(addrIndexAddressBucket(address))
+}
+
+// StoreAddrIndexOutput stores the given serialized output under
+// the given outpoint. These outputs are later used to find the
+// addresses that spending transactions debit.
+func StoreAddrIndexOutput(context Context, outpointKey []byte, output []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(addrIndexOutputKey(outpointKey), output)
+}
+
+// FetchAddrIndexOutput returns the serialized output of the given
+// outpoint. Returns ErrNotFound if the output had not been previously
+// inserted into the database.
+func FetchAddrIndexOutput(context Context, outpointKey []byte) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := accessor.Get(addrIndexOutputKey(outpointKey))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "address index output not found")
+		}
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// RemoveAddrIndexOutput removes the output of the given outpoint.
+func RemoveAddrIndexOutput(context Context, outpointKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(addrIndexOutputKey(outpointKey))
+}
+
+// StoreAddrIndexChainBlockEntries stores the given serialized list of
+// the address index entries that were added by the given chain block.
+func StoreAddrIndexChainBlockEntries(context Context, hash *daghash.Hash, entries []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(addrIndexChainBlockKey(hash), entries)
+}
+
+// FetchAddrIndexChainBlockEntries returns the serialized list of the
+// address index entries that were added by the given chain block.
+// Returns ErrNotFound if the chain block had not been indexed.
+func FetchAddrIndexChainBlockEntries(context Context, hash *daghash.Hash) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := accessor.Get(addrIndexChainBlockKey(hash))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "address index entries not found for chain block %s", hash)
+		}
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// RemoveAddrIndexChainBlockEntries removes the list of the address
+// index entries that were added by the given chain block.
+func RemoveAddrIndexChainBlockEntries(context Context, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(addrIndexChainBlockKey(hash))
+}
+
+// StoreAddrIndexUndoData stores the given serialized data that's
+// required to revert the changes that the given chain block made to
+// the address index, in case it's removed from the selected parent chain.
+func StoreAddrIndexUndoData(context Context, hash *daghash.Hash, undoData []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(addrIndexUndoDataKey(hash), undoData)
+}
+
+// FetchAddrIndexUndoData returns the serialized undo data of the given
+// chain block. Returns ErrNotFound if the chain block had not been
+// applied to the address index.
+func FetchAddrIndexUndoData(context Context, hash *daghash.Hash) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	undoData, err := accessor.Get(addrIndexUndoDataKey(hash))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "address index undo data not found for chain block %s", hash)
+		}
+		return nil, err
+	}
+
+	return undoData, nil
+}
+
+// RemoveAddrIndexUndoData removes the undo data of the given chain block.
+func RemoveAddrIndexUndoData(context Context, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(addrIndexUndoDataKey(hash))
+}
+
+// DropAddrIndex completely removes all address index entries.
+func DropAddrIndex(dbTx *TxContext) error {
+	err := clearBucket(dbTx, addrIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	err = clearBucket(dbTx, addrIndexOutputsBucket)
+	if err != nil {
+		return err
+	}
+
+	err = clearBucket(dbTx, addrIndexChainBlocksBucket)
+	if err != nil {
+		return err
+	}
+
+	return clearBucket(dbTx, addrIndexUndoDataBucket)
+}
@@ -88,3 +88,46 @@ func FetchBlock(context Context, hash *daghash.Hash) ([]byte, error) {
 
 	return bytes, nil
 }
+
+// RemoveBlock removes the block of the given hash from the database.
+// Note that the block's bytes remain in the block store until
+// CompactBlockStore is called.
+func RemoveBlock(context *TxContext, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	blockLocationsKey := blockLocationKey(hash)
+	return accessor.Delete(blockLocationsKey)
+}
+
+// blockStoreCompactionStepSize is the maximum amount of block
+// data that a single step of the block store compaction moves.
+const blockStoreCompactionStepSize = 32 * 1024 * 1024
+
+// CompactBlockStore reclaims the disk space of removed blocks.
+// It MUST NOT be called concurrently with transactions that
+// store or remove blocks.
+func CompactBlockStore(databaseContext *DatabaseContext) error {
+	for {
+		isDone, err := CompactBlockStoreStep(databaseContext)
+		if err != nil {
+			return err
+		}
+		if isDone {
+			return nil
+		}
+	}
+}
+
+// CompactBlockStoreStep reclaims some of the disk space of removed
+// blocks, moving at most about blockStoreCompactionStepSize bytes
+// of block data. It returns whether the compaction is complete.
+// Blocks may be stored and removed between the steps of the
+// compaction, but CompactBlockStoreStep itself MUST NOT be called
+// concurrently with transactions that store or remove blocks.
+func CompactBlockStoreStep(databaseContext *DatabaseContext) (isDone bool, err error) {
+	return databaseContext.db.CompactStore(blockStoreName, blockLocationsBucket,
+		blockStoreCompactionStepSize)
+}
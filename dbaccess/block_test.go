@@ -81,4 +81,43 @@ func TestBlockStoreSanity(t *testing.T) {
 		t.Fatalf("TestBlockStoreSanity: just-inserted block is " +
 			"not equal to its database counterpart.")
 	}
+
+	// Remove the genesis block from the db
+	dbTx, err = databaseContext.NewTx()
+	if err != nil {
+		t.Fatalf("Failed to open database "+
+			"transaction: %s", err)
+	}
+	defer dbTx.RollbackUnlessClosed()
+	err = RemoveBlock(dbTx, genesisHash)
+	if err != nil {
+		t.Fatalf("TestBlockStoreSanity: RemoveBlock unexpectedly "+
+			"failed: %s", err)
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		t.Fatalf("Failed to commit database "+
+			"transaction: %s", err)
+	}
+	err = CompactBlockStore(databaseContext)
+	if err != nil {
+		t.Fatalf("TestBlockStoreSanity: CompactBlockStore unexpectedly "+
+			"failed: %s", err)
+	}
+
+	// Make sure the genesis block no longer exists in the db
+	exists, err = HasBlock(databaseContext, genesisHash)
+	if err != nil {
+		t.Fatalf("TestBlockStoreSanity: HasBlock unexpectedly "+
+			"failed: %s", err)
+	}
+	if exists {
+		t.Fatalf("TestBlockStoreSanity: just-removed block " +
+			"still exists in the database")
+	}
+	_, err = FetchBlock(databaseContext, genesisHash)
+	if !IsNotFoundError(err) {
+		t.Fatalf("TestBlockStoreSanity: FetchBlock returned "+
+			"unexpected error for a removed block: %v", err)
+	}
 }
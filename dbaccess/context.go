@@ -1,9 +1,17 @@
 package dbaccess
 
 import (
+	"time"
+
 	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/metrics"
 )
 
+// WriteDurations tracks the number and the durations
+// of database transaction commits.
+var WriteDurations = metrics.NewSummary("kaspad_database_write_duration_seconds",
+	"The durations of database transaction commits", "")
+
 // Context is an interface type representing the context in which queries run, currently relating to the
 // existence or non-existence of a database transaction
 // Call `.NoTx()` or `.NewTx()` to acquire a Context
@@ -39,6 +47,7 @@ func (ctx *TxContext) accessor() (database.DataAccessor, error) {
 
 // Commit commits the transaction attached to this TxContext
 func (ctx *TxContext) Commit() error {
+	defer WriteDurations.ObserveDuration("", time.Now())
 	return ctx.dbTransaction.Commit()
 }
 
@@ -0,0 +1,26 @@
+package dbaccess
+
+import "github.com/kaspanet/kaspad/database"
+
+var (
+	feeEstimatorKey = database.MakeBucket().Key([]byte("fee-estimator"))
+)
+
+// StoreFeeEstimator stores the serialized fee estimator in the database.
+func StoreFeeEstimator(context Context, feeEstimator []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	return accessor.Put(feeEstimatorKey, feeEstimator)
+}
+
+// FetchFeeEstimator retrieves the serialized fee estimator from the database.
+// Returns ErrNotFound if the fee estimator is missing from the database.
+func FetchFeeEstimator(context Context) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+	return accessor.Get(feeEstimatorKey)
+}
@@ -0,0 +1,106 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+var (
+	indexTipsBucket      = database.MakeBucket([]byte("index-tips"))
+	indexChainTipsBucket = database.MakeBucket([]byte("index-chain-tips"))
+)
+
+func indexTipsKey(indexKey []byte) *database.Key {
+	return indexTipsBucket.Key(indexKey)
+}
+
+func indexChainTipKey(indexKey []byte) *database.Key {
+	return indexChainTipsBucket.Key(indexKey)
+}
+
+// StoreIndexTips stores the given serialized tips of the index
+// with the given key. The tips of an index are the blocks that
+// it had processed that no other processed block points to.
+func StoreIndexTips(context Context, indexKey []byte, tips []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(indexTipsKey(indexKey), tips)
+}
+
+// FetchIndexTips returns the serialized tips of the index with the
+// given key. Returns ErrNotFound if the index had never processed
+// any blocks.
+func FetchIndexTips(context Context, indexKey []byte) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	tips, err := accessor.Get(indexTipsKey(indexKey))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "tips not found for index %s", indexKey)
+		}
+		return nil, err
+	}
+
+	return tips, nil
+}
+
+// RemoveIndexTips removes the tips of the index with the given key,
+// which marks the index as one that has to be rebuilt from scratch.
+func RemoveIndexTips(context Context, indexKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(indexTipsKey(indexKey))
+}
+
+// StoreIndexChainTip stores the hash of the last selected parent
+// chain block that was applied to the index with the given key.
+func StoreIndexChainTip(context Context, indexKey []byte, chainTip *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(indexChainTipKey(indexKey), chainTip[:])
+}
+
+// FetchIndexChainTip returns the hash of the last selected parent chain
+// block that was applied to the index with the given key. Returns
+// ErrNotFound if no chain blocks had ever been applied to the index.
+func FetchIndexChainTip(context Context, indexKey []byte) (*daghash.Hash, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	chainTip, err := accessor.Get(indexChainTipKey(indexKey))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "chain tip not found for index %s", indexKey)
+		}
+		return nil, err
+	}
+
+	return daghash.NewHash(chainTip)
+}
+
+// RemoveIndexChainTip removes the chain tip of the index with the given
+// key, which marks the index as one whose chain blocks have to be
+// applied from scratch.
+func RemoveIndexChainTip(context Context, indexKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(indexChainTipKey(indexKey))
+}
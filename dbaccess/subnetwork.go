@@ -22,6 +22,17 @@ func FetchSubnetworkData(context Context, subnetworkID *subnetworkid.SubnetworkI
 	return accessor.Get(key)
 }
 
+// SubnetworkCursor opens a cursor over all the
+// registered subnetworks.
+func SubnetworkCursor(context Context) (database.Cursor, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessor.Cursor(subnetworkBucket)
+}
+
 // StoreSubnetwork stores mappings from ID of the subnetwork to the subnetwork data.
 func StoreSubnetwork(context Context, subnetworkID *subnetworkid.SubnetworkID, subnetworkData []byte) error {
 	accessor, err := context.accessor()
@@ -0,0 +1,65 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+var (
+	txIndexBucket = database.MakeBucket([]byte("tx-index"))
+)
+
+func txIndexKey(txID *daghash.TxID) *database.Key {
+	return txIndexBucket.Key(txID[:])
+}
+
+// StoreTxIndexEntry stores the given tx index entry of the
+// transaction with the given ID in the database.
+func StoreTxIndexEntry(context Context, txID *daghash.TxID, txIndexEntry []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := txIndexKey(txID)
+	return accessor.Put(key, txIndexEntry)
+}
+
+// HasTxIndexEntry returns whether the tx index entry of the
+// given transaction ID has been previously inserted into the database.
+func HasTxIndexEntry(context Context, txID *daghash.TxID) (bool, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return false, err
+	}
+
+	key := txIndexKey(txID)
+	return accessor.Has(key)
+}
+
+// FetchTxIndexEntry returns the tx index entry of the given
+// transaction ID. Returns ErrNotFound if the entry had not been
+// previously inserted into the database.
+func FetchTxIndexEntry(context Context, txID *daghash.TxID) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	key := txIndexKey(txID)
+	txIndexEntry, err := accessor.Get(key)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "tx index entry not found for transaction %s", txID)
+		}
+		return nil, err
+	}
+
+	return txIndexEntry, nil
+}
+
+// DropTxIndex completely removes all tx index entries.
+func DropTxIndex(dbTx *TxContext) error {
+	return clearBucket(dbTx, txIndexBucket)
+}
@@ -0,0 +1,166 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+var (
+	utxoIndexBucket          = database.MakeBucket([]byte("utxo-index"))
+	utxoIndexOutpointsBucket = database.MakeBucket([]byte("utxo-index-outpoints"))
+	utxoIndexUndoDataBucket  = database.MakeBucket([]byte("utxo-index-undo-data"))
+)
+
+// utxoIndexScriptPubKeyBucket returns the sub-bucket that holds all the
+// outpoints paying to the given scriptPubKey. scriptPubKeys are hashed
+// so that every sub-bucket name has the same length, which prevents
+// the prefix of one sub-bucket from matching another.
+func utxoIndexScriptPubKeyBucket(scriptPubKey []byte) *database.Bucket {
+	return utxoIndexBucket.Bucket(daghash.HashB(scriptPubKey))
+}
+
+func utxoIndexOutpointKey(outpointKey []byte) *database.Key {
+	return utxoIndexOutpointsBucket.Key(outpointKey)
+}
+
+func utxoIndexUndoDataKey(chainBlockHash *daghash.Hash) *database.Key {
+	return utxoIndexUndoDataBucket.Key(chainBlockHash[:])
+}
+
+// AddToUTXOIndex adds the given outpoint-utxoEntry pair to the
+// UTXO index under the given scriptPubKey.
+func AddToUTXOIndex(context Context, scriptPubKey []byte, outpointKey []byte, utxoEntry []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := utxoIndexScriptPubKeyBucket(scriptPubKey).Key(outpointKey)
+	err = accessor.Put(key, utxoEntry)
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(utxoIndexOutpointKey(outpointKey), scriptPubKey)
+}
+
+// RemoveFromUTXOIndex removes the given outpoint from the UTXO index.
+// Removing an outpoint that is not in the index is a no-op.
+func RemoveFromUTXOIndex(context Context, outpointKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	outpointKeyInIndex := utxoIndexOutpointKey(outpointKey)
+	scriptPubKey, err := accessor.Get(outpointKeyInIndex)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	key := utxoIndexScriptPubKeyBucket(scriptPubKey).Key(outpointKey)
+	err = accessor.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(outpointKeyInIndex)
+}
+
+// UTXOIndexCursor opens a cursor over all the UTXO entries in the
+// UTXO index that pay to the given scriptPubKey.
+func UTXOIndexCursor(context Context, scriptPubKey []byte) (database.Cursor, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessor.Cursor(utxoIndexScriptPubKeyBucket(scriptPubKey))
+}
+
+// FetchFromUTXOIndex returns the scriptPubKey and the serialized UTXO
+// entry of the given outpoint. Returns ErrNotFound if the outpoint is
+// not in the UTXO index.
+func FetchFromUTXOIndex(context Context, outpointKey []byte) (scriptPubKey []byte, utxoEntry []byte, err error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scriptPubKey, err = accessor.Get(utxoIndexOutpointKey(outpointKey))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, nil, errors.Wrapf(err, "outpoint not found in the UTXO index")
+		}
+		return nil, nil, err
+	}
+
+	key := utxoIndexScriptPubKeyBucket(scriptPubKey).Key(outpointKey)
+	utxoEntry, err = accessor.Get(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scriptPubKey, utxoEntry, nil
+}
+
+// StoreUTXOIndexUndoData stores the given serialized data that's
+// required to revert the changes that the given chain block made to
+// the UTXO index, in case it's removed from the selected parent chain.
+func StoreUTXOIndexUndoData(context Context, chainBlockHash *daghash.Hash, undoData []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Put(utxoIndexUndoDataKey(chainBlockHash), undoData)
+}
+
+// FetchUTXOIndexUndoData returns the serialized undo data of the given
+// chain block. Returns ErrNotFound if the chain block had not been
+// applied to the UTXO index.
+func FetchUTXOIndexUndoData(context Context, chainBlockHash *daghash.Hash) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	undoData, err := accessor.Get(utxoIndexUndoDataKey(chainBlockHash))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "UTXO index undo data not found for chain block %s", chainBlockHash)
+		}
+		return nil, err
+	}
+
+	return undoData, nil
+}
+
+// RemoveUTXOIndexUndoData removes the undo data of the given chain block.
+func RemoveUTXOIndexUndoData(context Context, chainBlockHash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	return accessor.Delete(utxoIndexUndoDataKey(chainBlockHash))
+}
+
+// DropUTXOIndex completely removes all UTXO index entries.
+func DropUTXOIndex(dbTx *TxContext) error {
+	err := clearBucket(dbTx, utxoIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	err = clearBucket(dbTx, utxoIndexOutpointsBucket)
+	if err != nil {
+		return err
+	}
+
+	return clearBucket(dbTx, utxoIndexUndoDataBucket)
+}
@@ -47,6 +47,10 @@ const (
 	CmdRequestNextIBDBlocks
 	CmdDoneIBDBlocks
 	CmdTransactionNotFound
+	CmdRequestHeaders
+	CmdBlockHeaders
+	CmdRequestNextHeaders
+	CmdDoneHeaders
 )
 
 // MessageCommandToString maps all MessageCommands to their string representation
@@ -72,6 +76,10 @@ var MessageCommandToString = map[MessageCommand]string{
 	CmdRequestNextIBDBlocks: "RequestNextIBDBlocks",
 	CmdDoneIBDBlocks:        "DoneIBDBlocks",
 	CmdTransactionNotFound:  "TransactionNotFound",
+	CmdRequestHeaders:       "RequestHeaders",
+	CmdBlockHeaders:         "BlockHeaders",
+	CmdRequestNextHeaders:   "RequestNextHeaders",
+	CmdDoneHeaders:          "DoneHeaders",
 }
 
 // Message is an interface that describes a kaspa message. A type that
@@ -0,0 +1,27 @@
+package domainmessage
+
+// MaxBlockHeadersPerMsg is the maximum number of block headers allowed
+// per message.
+const MaxBlockHeadersPerMsg = 2000
+
+// MsgBlockHeaders implements the Message interface and represents a kaspa
+// BlockHeaders message. It is used to send a batch of the block headers
+// that were requested by a RequestHeaders message.
+type MsgBlockHeaders struct {
+	baseMessage
+	BlockHeaders []*BlockHeader
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgBlockHeaders) Command() MessageCommand {
+	return CmdBlockHeaders
+}
+
+// NewMsgBlockHeaders returns a new kaspa BlockHeaders message that conforms to
+// the Message interface. See MsgBlockHeaders for details.
+func NewMsgBlockHeaders(blockHeaders []*BlockHeader) *MsgBlockHeaders {
+	return &MsgBlockHeaders{
+		BlockHeaders: blockHeaders,
+	}
+}
@@ -0,0 +1,34 @@
+package domainmessage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// TestBlockHeaders tests the MsgBlockHeaders API.
+func TestBlockHeaders(t *testing.T) {
+	bits := uint32(0x1d00ffff)
+	blockHeaders := []*BlockHeader{
+		NewBlockHeader(1, []*daghash.Hash{mainnetGenesisHash}, mainnetGenesisMerkleRoot,
+			exampleAcceptedIDMerkleRoot, exampleUTXOCommitment, bits, 0),
+		NewBlockHeader(1, []*daghash.Hash{simnetGenesisHash}, mainnetGenesisMerkleRoot,
+			exampleAcceptedIDMerkleRoot, exampleUTXOCommitment, bits, 1),
+	}
+
+	// Ensure we get the same data back out.
+	msg := NewMsgBlockHeaders(blockHeaders)
+	if !reflect.DeepEqual(msg.BlockHeaders, blockHeaders) {
+		t.Errorf("NewMsgBlockHeaders: wrong block headers - got %v, want %v",
+			spew.Sprint(msg.BlockHeaders), spew.Sprint(blockHeaders))
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := MessageCommand(22)
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgBlockHeaders: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+}
@@ -0,0 +1,30 @@
+package domainmessage
+
+import (
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// MsgRequestHeaders implements the Message interface and represents a kaspa
+// RequestHeaders message. It is used to request the headers of the blocks
+// starting after the low hash and until the high hash.
+type MsgRequestHeaders struct {
+	baseMessage
+	LowHash  *daghash.Hash
+	HighHash *daghash.Hash
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgRequestHeaders) Command() MessageCommand {
+	return CmdRequestHeaders
+}
+
+// NewMsgRequestHeaders returns a new kaspa RequestHeaders message that conforms to the
+// Message interface using the passed parameters and defaults for the remaining
+// fields.
+func NewMsgRequestHeaders(lowHash, highHash *daghash.Hash) *MsgRequestHeaders {
+	return &MsgRequestHeaders{
+		LowHash:  lowHash,
+		HighHash: highHash,
+	}
+}
@@ -0,0 +1,40 @@
+package domainmessage
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// TestRequestHeaders tests the MsgRequestHeaders API.
+func TestRequestHeaders(t *testing.T) {
+	hashStr := "000000000002e7ad7b9eef9479e4aabc65cb831269cc20d2632c13684406dee0"
+	lowHash, err := daghash.NewHashFromStr(hashStr)
+	if err != nil {
+		t.Errorf("NewHashFromStr: %v", err)
+	}
+
+	hashStr = "3ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+	highHash, err := daghash.NewHashFromStr(hashStr)
+	if err != nil {
+		t.Errorf("NewHashFromStr: %v", err)
+	}
+
+	// Ensure we get the same data back out.
+	msg := NewMsgRequestHeaders(lowHash, highHash)
+	if !msg.LowHash.IsEqual(lowHash) {
+		t.Errorf("NewMsgRequestHeaders: wrong low hash - got %v, want %v",
+			msg.LowHash, lowHash)
+	}
+	if !msg.HighHash.IsEqual(highHash) {
+		t.Errorf("NewMsgRequestHeaders: wrong high hash - got %v, want %v",
+			msg.HighHash, highHash)
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := MessageCommand(21)
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgRequestHeaders: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -49,3 +50,77 @@ func TestIBD(t *testing.T) {
 		t.Errorf("Tips of syncer: '%s' and syncee '%s' are not equal", tip1.Hash, tip2.Hash)
 	}
 }
+
+func TestIBDWithMultiplePeers(t *testing.T) {
+	const numBlocks = 1000
+
+	syncer, syncee, helper, teardown := standardSetup(t)
+	defer teardown()
+
+	for i := 0; i < numBlocks; i++ {
+		mineNextBlock(t, syncer)
+	}
+
+	helperBlockAddedWG := sync.WaitGroup{}
+	helperBlockAddedWG.Add(numBlocks)
+	setOnBlockAddedHandler(t, helper, func(header *domainmessage.BlockHeader) {
+		helperBlockAddedWG.Done()
+	})
+
+	connect(t, syncer, helper)
+
+	select {
+	case <-time.After(defaultTimeout):
+		t.Fatalf("Timeout waiting for the helper to finish IBD")
+	case <-locks.ReceiveFromChanWhenDone(func() { helperBlockAddedWG.Wait() }):
+	}
+
+	blockAddedWG := sync.WaitGroup{}
+	blockAddedWG.Add(numBlocks)
+	receivedBlocks := uint32(0)
+	setOnBlockAddedHandler(t, syncee, func(header *domainmessage.BlockHeader) {
+		atomic.AddUint32(&receivedBlocks, 1)
+		blockAddedWG.Done()
+	})
+
+	// The syncee is connected to two synced peers, so both of
+	// them serve the segments of IBD blocks it requests.
+	connect(t, syncer, syncee)
+	connect(t, helper, syncee)
+
+	select {
+	case <-time.After(defaultTimeout):
+		t.Fatalf("Timeout waiting for IBD to finish. Received %d blocks out of %d",
+			atomic.LoadUint32(&receivedBlocks), numBlocks)
+	case <-locks.ReceiveFromChanWhenDone(func() { blockAddedWG.Wait() }):
+	}
+
+	syncerTip, err := syncer.rpcClient.GetSelectedTip()
+	if err != nil {
+		t.Fatalf("Error getting tip for syncer")
+	}
+	synceeTip, err := syncee.rpcClient.GetSelectedTip()
+	if err != nil {
+		t.Fatalf("Error getting tip for syncee")
+	}
+
+	if syncerTip.Hash != synceeTip.Hash {
+		t.Errorf("Tips of syncer: '%s' and syncee '%s' are not equal", syncerTip.Hash, synceeTip.Hash)
+	}
+
+	connectedPeerInfo, err := syncee.rpcClient.GetConnectedPeerInfo()
+	if err != nil {
+		t.Fatalf("Error getting connected peer info: %+v", err)
+	}
+	receivedIBDBlocks := make(map[string]uint64)
+	for _, connectedPeer := range connectedPeerInfo {
+		receivedIBDBlocks[connectedPeer.ID] = connectedPeer.MessagesReceived["IBDBlock"]
+	}
+	for _, peer := range []*appHarness{syncer, helper} {
+		peerID := peer.app.P2PNodeID().String()
+		if receivedIBDBlocks[peerID] == 0 {
+			t.Errorf("Expected the syncee to download IBD blocks from %s, but it didn't",
+				peer.p2pAddress)
+		}
+	}
+}
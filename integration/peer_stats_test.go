@@ -0,0 +1,36 @@
+package integration
+
+import (
+	"testing"
+)
+
+func TestPeerStats(t *testing.T) {
+	appHarness1, appHarness2, _, teardown := standardSetup(t)
+	defer teardown()
+
+	connect(t, appHarness1, appHarness2)
+
+	connectedPeerInfo, err := appHarness2.rpcClient.GetConnectedPeerInfo()
+	if err != nil {
+		t.Fatalf("Error getting connected peer info: %+v", err)
+	}
+	app1ID := appHarness1.app.P2PNodeID().String()
+	for _, connectedPeer := range connectedPeerInfo {
+		if connectedPeer.ID != app1ID {
+			continue
+		}
+		if connectedPeer.BytesSent == 0 || connectedPeer.BytesReceived == 0 {
+			t.Errorf("Expected traffic in both directions, but got %d bytes sent and %d bytes received",
+				connectedPeer.BytesSent, connectedPeer.BytesReceived)
+		}
+		if connectedPeer.LastSend == 0 || connectedPeer.LastReceive == 0 {
+			t.Errorf("Expected last send and receive times to be set")
+		}
+		if connectedPeer.MessagesSent["Version"] != 1 || connectedPeer.MessagesReceived["Version"] != 1 {
+			t.Errorf("Expected exactly one version message in each direction, but got %v sent and %v received",
+				connectedPeer.MessagesSent, connectedPeer.MessagesReceived)
+		}
+		return
+	}
+	t.Fatalf("Didn't find appHarness1 in the connected peers of appHarness2")
+}
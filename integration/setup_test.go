@@ -97,6 +97,12 @@ func setRPCClient(t *testing.T, harness *appHarness) {
 }
 
 func teardownHarness(t *testing.T, harness *appHarness) {
+	// The RPC client is shut down first, so that it wouldn't
+	// reconnect to the RPC server of a later test that listens
+	// on the same address.
+	harness.rpcClient.Shutdown()
+	harness.rpcClient.WaitForShutdown()
+
 	err := harness.app.Stop()
 	if err != nil {
 		t.Errorf("Error stopping App: %+v", err)
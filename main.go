@@ -123,10 +123,51 @@ func kaspadMain(startedChan chan<- struct{}) error {
 
 		return nil
 	}
+	if cfg.DropUTXOIndex {
+		if err := indexers.DropUTXOIndex(databaseContext); err != nil {
+			log.Errorf("%s", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropTxIndex {
+		if err := indexers.DropTxIndex(databaseContext); err != nil {
+			log.Errorf("%s", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropAddrIndex {
+		if err := indexers.DropAddrIndex(databaseContext); err != nil {
+			log.Errorf("%s", err)
+			return err
+		}
+
+		return nil
+	}
+
+	// Compact the block store and exit if requested.
+	if cfg.CompactDB {
+		log.Infof("Compacting the block store...")
+		if err := dbaccess.CompactBlockStore(databaseContext); err != nil {
+			log.Errorf("%s", err)
+			return err
+		}
+		log.Infof("Compacted the block store")
+
+		return nil
+	}
 
 	// Create app and start it.
 	app, err := app.New(cfg, databaseContext, interrupt)
 	if err != nil {
+		// Initialization, such as catching up indexes, stops
+		// early with an error when an interrupt signal is triggered.
+		if signal.InterruptRequested(interrupt) {
+			return nil
+		}
 		log.Errorf("Unable to start kaspad: %+v", err)
 		return err
 	}
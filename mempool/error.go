@@ -100,6 +100,24 @@ func dagRuleError(dagErr blockdag.RuleError) RuleError {
 	}
 }
 
+// PackageError identifies the transaction that caused a package of
+// transactions to be rejected. Err is the reason the transaction was
+// rejected.
+type PackageError struct {
+	TxIndex int
+	Err     error
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e PackageError) Error() string {
+	return fmt.Sprintf("transaction %d in package: %s", e.TxIndex, e.Err)
+}
+
+// Unwrap returns the reason the transaction was rejected.
+func (e PackageError) Unwrap() error {
+	return e.Err
+}
+
 // extractRejectCode attempts to return a relevant reject code for a given error
 // by examining the error for known types. It will return true if a code
 // was successfully extracted.
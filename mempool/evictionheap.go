@@ -0,0 +1,88 @@
+package mempool
+
+import (
+	"container/heap"
+)
+
+// baseEvictionHeap is a heap.Interface of the transactions in the pool,
+// ordered by the order in which they're evicted when the pool is full:
+// by ascending fee per mass, and among transactions with the same fee per
+// mass, the most recently added ones first.
+type baseEvictionHeap []*TxDesc
+
+func (h baseEvictionHeap) Len() int {
+	return len(h)
+}
+
+func (h baseEvictionHeap) Less(i, j int) bool {
+	if h[i].FeePerMegaGram != h[j].FeePerMegaGram {
+		return h[i].FeePerMegaGram < h[j].FeePerMegaGram
+	}
+	return h[i].Added.After(h[j].Added)
+}
+
+func (h baseEvictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].evictionHeapIndex = i
+	h[j].evictionHeapIndex = j
+}
+
+func (h *baseEvictionHeap) Push(x interface{}) {
+	txDesc := x.(*TxDesc)
+	txDesc.evictionHeapIndex = len(*h)
+	*h = append(*h, txDesc)
+}
+
+func (h *baseEvictionHeap) Pop() interface{} {
+	oldHeap := *h
+	oldLength := len(oldHeap)
+	popped := oldHeap[oldLength-1]
+	oldHeap[oldLength-1] = nil
+	popped.evictionHeapIndex = -1
+	*h = oldHeap[0 : oldLength-1]
+	return popped
+}
+
+// evictionHeap keeps the transactions in the pool in eviction order,
+// so that limitPoolMass doesn't have to sort the whole pool whenever
+// it's full.
+type evictionHeap struct {
+	impl *baseEvictionHeap
+}
+
+// newEvictionHeap initializes and returns a new evictionHeap
+func newEvictionHeap() evictionHeap {
+	h := evictionHeap{impl: &baseEvictionHeap{}}
+	heap.Init(h.impl)
+	return h
+}
+
+// push adds the transaction to the heap
+func (h evictionHeap) push(txDesc *TxDesc) {
+	heap.Push(h.impl, txDesc)
+}
+
+// remove removes the transaction from the heap. It does nothing if the
+// transaction isn't in the heap.
+func (h evictionHeap) remove(txDesc *TxDesc) {
+	index := txDesc.evictionHeapIndex
+	if index < 0 || index >= h.impl.Len() || (*h.impl)[index] != txDesc {
+		return
+	}
+	heap.Remove(h.impl, index)
+}
+
+// pop removes the transaction that should be evicted first from the
+// heap and returns it
+func (h evictionHeap) pop() *TxDesc {
+	return heap.Pop(h.impl).(*TxDesc)
+}
+
+// peek returns the transaction that should be evicted first without
+// removing it from the heap, or nil if the heap is empty.
+func (h evictionHeap) peek() *TxDesc {
+	if h.impl.Len() == 0 {
+		return nil
+	}
+	return (*h.impl)[0]
+}
@@ -0,0 +1,48 @@
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/mining"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// TestEvictionHeap ensures that the eviction heap keeps the transactions
+// ordered by ascending fee per mass and descending addition time, also
+// after transactions are removed from its middle.
+func TestEvictionHeap(t *testing.T) {
+	now := mstime.Now()
+	newTxDesc := func(feePerMegaGram uint64, added mstime.Time) *TxDesc {
+		return &TxDesc{TxDesc: mining.TxDesc{FeePerMegaGram: feePerMegaGram, Added: added}}
+	}
+	highFee := newTxDesc(3000, now)
+	lowFeeOld := newTxDesc(1000, now.Add(-time.Minute))
+	lowFeeNew := newTxDesc(1000, now)
+	midFee := newTxDesc(2000, now)
+	removed := newTxDesc(500, now)
+	notInHeap := newTxDesc(100, now)
+
+	h := newEvictionHeap()
+	if h.peek() != nil {
+		t.Fatalf("peek: expected nil for an empty heap")
+	}
+	for _, txDesc := range []*TxDesc{highFee, lowFeeOld, removed, midFee, lowFeeNew} {
+		h.push(txDesc)
+	}
+	h.remove(removed)
+	h.remove(notInHeap)
+
+	expectedOrder := []*TxDesc{lowFeeNew, lowFeeOld, midFee, highFee}
+	for i, expected := range expectedOrder {
+		txDesc := h.peek()
+		if txDesc != expected {
+			t.Fatalf("peek: expected transaction #%d to have fee per megagram %d, "+
+				"got %d", i, expected.FeePerMegaGram, txDesc.FeePerMegaGram)
+		}
+		h.remove(txDesc)
+	}
+	if h.peek() != nil {
+		t.Fatalf("peek: expected the heap to be empty")
+	}
+}
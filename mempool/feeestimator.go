@@ -0,0 +1,369 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FeeEstimatorMaxTarget is the maximum number of blue-score
+	// confirmations that the fee estimator is able to estimate a fee
+	// rate for.
+	FeeEstimatorMaxTarget = 64
+
+	// feeEstimatorMinBucketFeeRate is the lower boundary, in sompi per
+	// megagram, of the lowest fee rate bucket. Transactions that pay
+	// less than this are counted in the lowest bucket.
+	feeEstimatorMinBucketFeeRate = 1e3
+
+	// feeEstimatorMaxBucketFeeRate is the lower boundary, in sompi per
+	// megagram, above which no more fee rate buckets are created.
+	// Transactions that pay more than this are counted in the highest
+	// bucket.
+	feeEstimatorMaxBucketFeeRate = 1e13
+
+	// feeEstimatorBucketSpacing is the ratio between the lower boundaries
+	// of every two adjacent fee rate buckets.
+	feeEstimatorBucketSpacing = 1.2
+
+	// feeEstimatorDecay is the factor by which all the collected data is
+	// multiplied whenever a block is registered, so that the estimates
+	// follow recent changes in the fee market.
+	feeEstimatorDecay = 0.9995
+
+	// feeEstimatorSuccessThreshold is the minimal ratio of transactions
+	// that must have been included within the target number of blue-score
+	// confirmations for their fee rate to be considered sufficient.
+	feeEstimatorSuccessThreshold = 0.85
+
+	// feeEstimatorMinDataPoints is the minimal (decayed) number of
+	// transactions a group of fee rate buckets must contain for its
+	// success ratio to be trusted.
+	feeEstimatorMinDataPoints = 10
+
+	// feeEstimatorSerializationVersion is the version of the serialized
+	// fee estimator state. It must be bumped whenever the serialization
+	// format or the bucket layout changes.
+	feeEstimatorSerializationVersion = 1
+)
+
+// ErrNotEnoughFeeData is returned by EstimateFee when the fee estimator
+// had not yet observed enough transactions to estimate a fee rate for the
+// requested target.
+var ErrNotEnoughFeeData = errors.New("not enough transactions have been observed to estimate a fee")
+
+// feeEstimatorBuckets holds the lower boundaries, in sompi per megagram,
+// of all the fee rate buckets, in ascending order.
+var feeEstimatorBuckets = func() []float64 {
+	var buckets []float64
+	for feeRate := float64(feeEstimatorMinBucketFeeRate); feeRate <= feeEstimatorMaxBucketFeeRate; feeRate *= feeEstimatorBucketSpacing {
+		buckets = append(buckets, feeRate)
+	}
+	return buckets
+}()
+
+// observedTransaction is a transaction that had entered the mempool and
+// had not yet been included in a block.
+type observedTransaction struct {
+	bucket            int
+	observedBlueScore uint64
+}
+
+// FeeEstimator observes the fee rates of the transactions that enter the
+// mempool and the number of blue-score confirmations it takes until they
+// are included in blocks, and uses them to estimate the fee rate that a
+// transaction has to pay in order to be included within a given number of
+// blue-score confirmations.
+//
+// Fee rates are measured per transaction mass rather than per
+// serialized size, since mass is what limits the amount of transactions
+// a block may contain.
+type FeeEstimator struct {
+	mtx sync.RWMutex
+
+	// observed holds the transactions that had entered the mempool and
+	// had not yet been included in a block.
+	observed map[daghash.TxID]*observedTransaction
+
+	// confirmed[target-1][bucket] is the decayed number of transactions
+	// with a fee rate in the given bucket that were included within
+	// target blue-score confirmations.
+	confirmed [FeeEstimatorMaxTarget][]float64
+
+	// total[bucket] is the decayed number of transactions with a fee
+	// rate in the given bucket that were either included in a block or
+	// that had not been included within FeeEstimatorMaxTarget blue-score
+	// confirmations.
+	total []float64
+
+	// lastBlueScore is the highest blue score of all the registered
+	// blocks.
+	lastBlueScore uint64
+}
+
+// NewFeeEstimator returns a new fee estimator that had not observed any
+// transactions.
+func NewFeeEstimator() *FeeEstimator {
+	fe := &FeeEstimator{
+		observed: make(map[daghash.TxID]*observedTransaction),
+		total:    make([]float64, len(feeEstimatorBuckets)),
+	}
+	for i := range fe.confirmed {
+		fe.confirmed[i] = make([]float64, len(feeEstimatorBuckets))
+	}
+	return fe
+}
+
+// feeRateBucket returns the index of the bucket that the given fee rate,
+// in sompi per megagram, belongs to.
+func feeRateBucket(feeRate uint64) int {
+	bucket := sort.Search(len(feeEstimatorBuckets), func(i int) bool {
+		return feeEstimatorBuckets[i] > float64(feeRate)
+	}) - 1
+	if bucket < 0 {
+		return 0
+	}
+	return bucket
+}
+
+// ObserveTransaction starts tracking the given transaction, which had
+// just entered the mempool while the virtual had the given blue score.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) ObserveTransaction(txDesc *TxDesc, virtualBlueScore uint64) {
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	fe.observed[*txDesc.Tx.ID()] = &observedTransaction{
+		bucket:            feeRateBucket(txDesc.FeePerMegaGram),
+		observedBlueScore: virtualBlueScore,
+	}
+}
+
+// RemoveTransaction stops tracking the given transaction, without
+// counting it either as included or as not included. It's used for
+// transactions that leave the mempool without being included in a block,
+// such as double spends.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) RemoveTransaction(txID *daghash.TxID) {
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	delete(fe.observed, *txID)
+}
+
+// RegisterBlock records the number of blue-score confirmations it took
+// for every observed transaction in the given block, which has the given
+// blue score, to be included. Observed transactions that had not been
+// included within FeeEstimatorMaxTarget blue-score confirmations are
+// counted as not included and are no longer tracked.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) RegisterBlock(block *util.Block, blueScore uint64) {
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	fe.decay()
+
+	if blueScore > fe.lastBlueScore {
+		fe.lastBlueScore = blueScore
+	}
+
+	for _, tx := range block.Transactions()[util.CoinbaseTransactionIndex+1:] {
+		observedTx, ok := fe.observed[*tx.ID()]
+		if !ok {
+			continue
+		}
+		delete(fe.observed, *tx.ID())
+
+		// A transaction that's included in a block with the blue score
+		// of the virtual it had been observed in has one blue-score
+		// confirmation. Blocks with lower blue scores may still include
+		// it, since they might have been mined in parallel.
+		confirmations := uint64(1)
+		if blueScore > observedTx.observedBlueScore {
+			confirmations += blueScore - observedTx.observedBlueScore
+		}
+		for target := confirmations; target <= FeeEstimatorMaxTarget; target++ {
+			fe.confirmed[target-1][observedTx.bucket]++
+		}
+		fe.total[observedTx.bucket]++
+	}
+
+	for txID, observedTx := range fe.observed {
+		// Transactions are observed in the virtual, whose blue score
+		// might be higher than those of all the registered blocks.
+		// Such transactions haven't waited for any blocks yet.
+		if fe.lastBlueScore < observedTx.observedBlueScore ||
+			fe.lastBlueScore-observedTx.observedBlueScore < FeeEstimatorMaxTarget {
+			continue
+		}
+		delete(fe.observed, txID)
+		fe.total[observedTx.bucket]++
+	}
+}
+
+// decay multiplies all the collected data by feeEstimatorDecay.
+//
+// This function MUST be called with the fee estimator lock held (for writes).
+func (fe *FeeEstimator) decay() {
+	for bucket := range fe.total {
+		fe.total[bucket] *= feeEstimatorDecay
+	}
+	for _, confirmed := range fe.confirmed {
+		for bucket := range confirmed {
+			confirmed[bucket] *= feeEstimatorDecay
+		}
+	}
+}
+
+// EstimateFee returns the lowest fee rate, in sompi per 1000 grams of
+// transaction mass, for which enough of the observed transactions had
+// been included within the given number of blue-score confirmations.
+//
+// Fee rate buckets are grouped from the highest fee rate downwards until
+// every group contains enough transactions, and the estimate is the lower
+// boundary of the last group whose ratio of included transactions passes
+// feeEstimatorSuccessThreshold. ErrNotEnoughFeeData is returned if no
+// group passes it.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) EstimateFee(targetConfirmations uint64) (util.Amount, error) {
+	if targetConfirmations < 1 || targetConfirmations > FeeEstimatorMaxTarget {
+		return 0, errors.Errorf("target confirmations must be between 1 and %d, got %d",
+			FeeEstimatorMaxTarget, targetConfirmations)
+	}
+
+	fe.mtx.RLock()
+	defer fe.mtx.RUnlock()
+
+	confirmed := fe.confirmed[targetConfirmations-1]
+	estimatedBucket := -1
+	groupConfirmed, groupTotal := 0.0, 0.0
+	for bucket := len(feeEstimatorBuckets) - 1; bucket >= 0; bucket-- {
+		groupConfirmed += confirmed[bucket]
+		groupTotal += fe.total[bucket]
+		if groupTotal < feeEstimatorMinDataPoints {
+			continue
+		}
+		if groupConfirmed/groupTotal < feeEstimatorSuccessThreshold {
+			break
+		}
+		estimatedBucket = bucket
+		groupConfirmed, groupTotal = 0, 0
+	}
+	if estimatedBucket == -1 {
+		return 0, ErrNotEnoughFeeData
+	}
+
+	// Bucket boundaries are in sompi per megagram, while
+	// estimates are in sompi per 1000 grams.
+	return util.Amount(math.Ceil(feeEstimatorBuckets[estimatedBucket] / 1000)), nil
+}
+
+// Serialize returns the serialized data collected by the fee estimator,
+// so that it may be restored after a restart using RestoreFeeEstimator.
+// Observed transactions that had not yet been included in a block are
+// not serialized. The transactions that are restored to the mempool are
+// observed again when LoadFromFile runs them through ProcessTransaction.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) Serialize() ([]byte, error) {
+	fe.mtx.RLock()
+	defer fe.mtx.RUnlock()
+
+	w := &bytes.Buffer{}
+	err := binaryserializer.PutUint32(w, binary.LittleEndian, feeEstimatorSerializationVersion)
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint32(w, binary.LittleEndian, uint32(len(feeEstimatorBuckets)))
+	if err != nil {
+		return nil, err
+	}
+	err = binaryserializer.PutUint64(w, binary.LittleEndian, fe.lastBlueScore)
+	if err != nil {
+		return nil, err
+	}
+	err = putFloat64s(w, fe.total)
+	if err != nil {
+		return nil, err
+	}
+	for _, confirmed := range fe.confirmed {
+		err = putFloat64s(w, confirmed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
+
+// RestoreFeeEstimator returns a fee estimator with the data of the
+// given serialized fee estimator. An error is returned if the data was
+// serialized by an incompatible version.
+func RestoreFeeEstimator(serialized []byte) (*FeeEstimator, error) {
+	r := bytes.NewReader(serialized)
+	version, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	if version != feeEstimatorSerializationVersion {
+		return nil, errors.Errorf("unsupported fee estimator serialization version %d", version)
+	}
+	bucketCount, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	if int(bucketCount) != len(feeEstimatorBuckets) {
+		return nil, errors.Errorf("serialized fee estimator has %d fee rate buckets, expected %d",
+			bucketCount, len(feeEstimatorBuckets))
+	}
+
+	fe := NewFeeEstimator()
+	fe.lastBlueScore, err = binaryserializer.Uint64(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	err = readFloat64s(r, fe.total)
+	if err != nil {
+		return nil, err
+	}
+	for _, confirmed := range fe.confirmed {
+		err = readFloat64s(r, confirmed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fe, nil
+}
+
+func putFloat64s(w io.Writer, values []float64) error {
+	for _, value := range values {
+		err := binaryserializer.PutUint64(w, binary.LittleEndian, math.Float64bits(value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFloat64s(r io.Reader, values []float64) error {
+	for i := range values {
+		bits, err := binaryserializer.Uint64(r, binary.LittleEndian)
+		if err != nil {
+			return err
+		}
+		values[i] = math.Float64frombits(bits)
+	}
+	return nil
+}
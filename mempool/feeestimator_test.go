@@ -0,0 +1,157 @@
+package mempool
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/mining"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// TestFeeEstimator simulates a fee market in which high fee rate
+// transactions are included in the next block, low fee rate transactions
+// take lowFeeConfirmations blue-score confirmations to be included, and
+// stuck transactions are never included, and checks that the estimates
+// follow it and survive a serialization round-trip.
+func TestFeeEstimator(t *testing.T) {
+	const (
+		highFeeRate         = 1e7
+		lowFeeRate          = 1e5
+		stuckFeeRate        = 2e3
+		lowFeeConfirmations = 20
+		blockCount          = 300
+	)
+
+	fe := NewFeeEstimator()
+
+	_, err := fe.EstimateFee(1)
+	if !errors.Is(err, ErrNotEnoughFeeData) {
+		t.Fatalf("EstimateFee: expected ErrNotEnoughFeeData before observing "+
+			"any transactions, got %v", err)
+	}
+
+	lockTime := uint64(0)
+	newTxDesc := func(feeRate uint64) *TxDesc {
+		lockTime++
+		tx := util.NewTx(domainmessage.NewNativeMsgTxWithLocktime(1, nil, nil, lockTime))
+		return &TxDesc{TxDesc: mining.TxDesc{Tx: tx, FeePerMegaGram: feeRate}}
+	}
+
+	lowFeeTxsByBlueScore := make(map[uint64]*TxDesc)
+	for blueScore := uint64(1); blueScore <= blockCount; blueScore++ {
+		highFeeTx := newTxDesc(highFeeRate)
+		lowFeeTx := newTxDesc(lowFeeRate)
+		fe.ObserveTransaction(highFeeTx, blueScore)
+		fe.ObserveTransaction(lowFeeTx, blueScore)
+		fe.ObserveTransaction(newTxDesc(stuckFeeRate), blueScore)
+		lowFeeTxsByBlueScore[blueScore] = lowFeeTx
+
+		// The first transaction in a block is
+		// its coinbase, which is ignored.
+		msgBlock := domainmessage.NewMsgBlock(&domainmessage.BlockHeader{})
+		msgBlock.AddTransaction(domainmessage.NewNativeMsgTx(1, nil, nil))
+		msgBlock.AddTransaction(highFeeTx.Tx.MsgTx())
+		if includedLowFeeTx, ok := lowFeeTxsByBlueScore[blueScore-lowFeeConfirmations+1]; ok {
+			msgBlock.AddTransaction(includedLowFeeTx.Tx.MsgTx())
+		}
+		fe.RegisterBlock(util.NewBlock(msgBlock), blueScore)
+	}
+
+	bucketFeeRate := func(feeRate uint64) util.Amount {
+		return util.Amount(math.Ceil(feeEstimatorBuckets[feeRateBucket(feeRate)] / 1000))
+	}
+	tests := []struct {
+		targetConfirmations uint64
+		expectedFeeRate     util.Amount
+	}{
+		{targetConfirmations: 1, expectedFeeRate: bucketFeeRate(highFeeRate)},
+		{targetConfirmations: lowFeeConfirmations - 1, expectedFeeRate: bucketFeeRate(highFeeRate)},
+		{targetConfirmations: lowFeeConfirmations, expectedFeeRate: bucketFeeRate(lowFeeRate)},
+		{targetConfirmations: FeeEstimatorMaxTarget, expectedFeeRate: bucketFeeRate(lowFeeRate)},
+	}
+
+	serialized, err := fe.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	restored, err := RestoreFeeEstimator(serialized)
+	if err != nil {
+		t.Fatalf("RestoreFeeEstimator: %s", err)
+	}
+
+	for _, estimator := range []*FeeEstimator{fe, restored} {
+		for _, test := range tests {
+			feeRate, err := estimator.EstimateFee(test.targetConfirmations)
+			if err != nil {
+				t.Fatalf("EstimateFee(%d): %s", test.targetConfirmations, err)
+			}
+			if feeRate != test.expectedFeeRate {
+				t.Errorf("EstimateFee(%d): expected %d, got %d",
+					test.targetConfirmations, test.expectedFeeRate, feeRate)
+			}
+		}
+	}
+
+	if len(restored.observed) != 0 {
+		t.Errorf("RestoreFeeEstimator: expected no observed transactions, got %d",
+			len(restored.observed))
+	}
+	if restored.lastBlueScore != blockCount {
+		t.Errorf("RestoreFeeEstimator: expected last blue score %d, got %d",
+			blockCount, restored.lastBlueScore)
+	}
+
+	for _, targetConfirmations := range []uint64{0, FeeEstimatorMaxTarget + 1} {
+		_, err := fe.EstimateFee(targetConfirmations)
+		if err == nil {
+			t.Errorf("EstimateFee(%d): expected an error", targetConfirmations)
+		}
+	}
+
+	_, err = RestoreFeeEstimator(serialized[:len(serialized)-1])
+	if err == nil {
+		t.Errorf("RestoreFeeEstimator: expected an error for truncated data")
+	}
+}
+
+// TestFeeEstimatorBlockBelowObservedBlueScore checks that transactions that
+// were observed in a virtual with a higher blue score than that of a
+// registered block are neither counted as having waited for a huge number of
+// blocks when the block includes them, nor dropped as failures when it
+// doesn't.
+func TestFeeEstimatorBlockBelowObservedBlueScore(t *testing.T) {
+	const (
+		feeRate           = 1e5
+		observedBlueScore = 10
+		blockBlueScore    = 5
+	)
+
+	fe := NewFeeEstimator()
+
+	includedTx := util.NewTx(domainmessage.NewNativeMsgTxWithLocktime(1, nil, nil, 1))
+	pendingTx := util.NewTx(domainmessage.NewNativeMsgTxWithLocktime(1, nil, nil, 2))
+	for _, tx := range []*util.Tx{includedTx, pendingTx} {
+		fe.ObserveTransaction(&TxDesc{TxDesc: mining.TxDesc{Tx: tx, FeePerMegaGram: feeRate}},
+			observedBlueScore)
+	}
+
+	msgBlock := domainmessage.NewMsgBlock(&domainmessage.BlockHeader{})
+	msgBlock.AddTransaction(domainmessage.NewNativeMsgTx(1, nil, nil))
+	msgBlock.AddTransaction(includedTx.MsgTx())
+	fe.RegisterBlock(util.NewBlock(msgBlock), blockBlueScore)
+
+	bucket := feeRateBucket(feeRate)
+	if fe.confirmed[0][bucket] != 1 {
+		t.Errorf("RegisterBlock: expected the included transaction to be confirmed "+
+			"within a single blue-score confirmation, got %f", fe.confirmed[0][bucket])
+	}
+	if fe.total[bucket] != 1 {
+		t.Errorf("RegisterBlock: expected a single transaction to be counted, got %f",
+			fe.total[bucket])
+	}
+	if _, ok := fe.observed[*pendingTx.ID()]; !ok {
+		t.Errorf("RegisterBlock: the pending transaction was unexpectedly dropped")
+	}
+}
@@ -7,6 +7,7 @@ package mempool
 import (
 	"container/list"
 	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,6 +34,14 @@ const (
 	// orphanExpireScanInterval is the minimum amount of time in between
 	// scans of the orphan pool to evict expired transactions.
 	orphanExpireScanInterval = time.Minute * 5
+
+	// rollingMinFeeHalfLife is the amount of time it takes the rolling
+	// minimum fee rate to drop by half after it had last been raised.
+	rollingMinFeeHalfLife = time.Hour * 12
+
+	// poolExpireScanInterval is the minimum amount of time in between
+	// scans of the pool to remove expired transactions.
+	poolExpireScanInterval = time.Minute
 )
 
 // NewBlockMsg is the type that is used in NewBlockMsg to transfer
@@ -69,6 +78,10 @@ type Config struct {
 
 	// DAG is the BlockDAG we want to use (mainly for UTXO checks)
 	DAG *blockdag.BlockDAG
+
+	// FeeEstimator, if not nil, observes the transactions that enter the
+	// mempool and the blocks that include them.
+	FeeEstimator *FeeEstimator
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -96,6 +109,25 @@ type Policy struct {
 	// MinRelayTxFee defines the minimum transaction fee in KAS/kB to be
 	// considered a non-zero fee.
 	MinRelayTxFee util.Amount
+
+	// MaxPoolMass is the maximum total mass of the transactions in the
+	// pool. Once it's exceeded, the transactions with the lowest fee per
+	// mass are evicted. Since the mass of a transaction is never smaller
+	// than its serialized size, this also bounds the memory the pool uses.
+	// A value of 0 means the pool is unbounded.
+	MaxPoolMass uint64
+
+	// MempoolExpiry is the maximum amount of time a transaction may stay
+	// in the pool without being accepted. Expired transactions are
+	// removed along with the transactions that depend on them. A value of
+	// 0 means transactions never expire.
+	MempoolExpiry time.Duration
+
+	// MaxReplacementEvictions is the maximum number of transactions,
+	// including descendants, that a single replacement transaction may
+	// evict from the pool. A value of 0 disables replacements, in which
+	// case transactions that conflict with the pool are always rejected.
+	MaxReplacementEvictions int
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -107,6 +139,10 @@ type TxDesc struct {
 	// one that is accepted to pool, but cannot be mined in next block because it
 	// depends on outputs of accepted, but still not mined transaction
 	depCount int
+
+	// evictionHeapIndex is the index of the transaction in the
+	// pool's evictionHeap
+	evictionHeapIndex int
 }
 
 // orphanTx is normal transaction that references an ancestor transaction
@@ -140,7 +176,37 @@ type TxPool struct {
 	// to on an unconditional timer.
 	nextExpireScan mstime.Time
 
+	// nextPoolExpireScan is the time after which the pool will be
+	// scanned in order to remove expired transactions. Like
+	// nextExpireScan, it's not a hard deadline, as the scan only runs
+	// when a new block is handled.
+	nextPoolExpireScan mstime.Time
+
+	// totalMass is the sum of the masses of all the transactions in
+	// the pool, including the dependent ones.
+	totalMass uint64
+
+	// evictionHeap holds all the transactions in the pool, including
+	// the dependent ones, in the order in which they're evicted once
+	// totalMass exceeds Policy.MaxPoolMass.
+	evictionHeap evictionHeap
+
+	// rollingMinFeeRate is the minimum fee rate, in sompi per 1000 grams
+	// of mass, that transactions have to pay in order to enter the pool.
+	// It's raised whenever transactions are evicted from a full pool, and
+	// decays back as time passes since lastRollingMinFeeUpdate.
+	rollingMinFeeRate       float64
+	lastRollingMinFeeUpdate mstime.Time
+
 	mpUTXOSet blockdag.UTXOSet
+
+	notifications     []NotificationCallback
+	notificationsLock sync.RWMutex
+
+	// loadStatus describes the loading of the transactions that
+	// were saved before the last shutdown.
+	loadStatus     LoadStatus
+	loadStatusLock sync.RWMutex
 }
 
 // Ensure the TxPool type implements the mining.TxSource interface.
@@ -532,6 +598,12 @@ func (mp *TxPool) removeTransactionWithDiff(tx *util.Tx, diff *blockdag.UTXODiff
 	} else {
 		delete(mp.depends, *txID)
 	}
+	mp.totalMass -= txDesc.Mass
+	mp.evictionHeap.remove(txDesc)
+
+	if mp.cfg.FeeEstimator != nil {
+		mp.cfg.FeeEstimator.RemoveTransaction(txID)
+	}
 
 	mp.processRemovedTransactionDependencies(tx)
 
@@ -558,17 +630,7 @@ func (mp *TxPool) removeTransactionUTXOEntriesFromDiff(tx *util.Tx, diff *blockd
 func (mp *TxPool) markTransactionOutputsUnspent(tx *util.Tx, diff *blockdag.UTXODiff, restoreInputs bool) error {
 	for _, txIn := range tx.MsgTx().TxIn {
 		if restoreInputs {
-			if prevTxDesc, exists := mp.pool[txIn.PreviousOutpoint.TxID]; exists {
-				prevOut := prevTxDesc.Tx.MsgTx().TxOut[txIn.PreviousOutpoint.Index]
-				entry := blockdag.NewUTXOEntry(prevOut, false, blockdag.UnacceptedBlueScore)
-				err := diff.AddEntry(txIn.PreviousOutpoint, entry)
-				if err != nil {
-					return err
-				}
-			}
-			if prevTxDesc, exists := mp.depends[txIn.PreviousOutpoint.TxID]; exists {
-				prevOut := prevTxDesc.Tx.MsgTx().TxOut[txIn.PreviousOutpoint.Index]
-				entry := blockdag.NewUTXOEntry(prevOut, false, blockdag.UnacceptedBlueScore)
+			if entry, ok := mp.spentInputEntry(txIn.PreviousOutpoint); ok {
 				err := diff.AddEntry(txIn.PreviousOutpoint, entry)
 				if err != nil {
 					return err
@@ -580,6 +642,25 @@ func (mp *TxPool) markTransactionOutputsUnspent(tx *util.Tx, diff *blockdag.UTXO
 	return nil
 }
 
+// spentInputEntry returns the UTXO entry of an outpoint that is spent by a
+// transaction in the pool, so that it could be restored to the mempool UTXO
+// set once the spending transaction is removed. The outpoint may either be
+// an output of another transaction in the pool, or an output in the DAG's
+// UTXO set. The second return value is false if the outpoint isn't spent
+// anymore or if it was spent by the DAG in the meantime.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) spentInputEntry(outpoint domainmessage.Outpoint) (*blockdag.UTXOEntry, bool) {
+	if _, exists := mp.mpUTXOSet.Get(outpoint); exists {
+		return nil, false
+	}
+	if prevTxDesc, exists := mp.fetchTxDesc(&outpoint.TxID); exists {
+		prevOut := prevTxDesc.Tx.MsgTx().TxOut[outpoint.Index]
+		return blockdag.NewUTXOEntry(prevOut, false, blockdag.UnacceptedBlueScore), true
+	}
+	return mp.cfg.DAG.UTXOSet().Get(outpoint)
+}
+
 // processRemovedTransactionDependencies processes the dependencies of a
 // transaction tx that was just now removed from the mempool
 func (mp *TxPool) processRemovedTransactionDependencies(tx *util.Tx) {
@@ -659,23 +740,264 @@ func (mp *TxPool) removeDoubleSpends(tx *util.Tx) error {
 	return nil
 }
 
-// addTransaction adds the passed transaction to the memory pool. It should
-// not be called directly as it doesn't perform any validation. This is a
-// helper for maybeAcceptTransaction.
+// expireTransactions removes the transactions that have been in the pool
+// for longer than Policy.MempoolExpiry, along with the transactions that
+// depend on them, and notifies the subscribers about them. The pool is
+// scanned for expired transactions at most once per
+// poolExpireScanInterval.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) expireTransactions() error {
+	expiredTxs, err := func() ([]*util.Tx, error) {
+		mp.cfg.DAG.RLock()
+		defer mp.cfg.DAG.RUnlock()
+		mp.mtx.Lock()
+		defer mp.mtx.Unlock()
+		return mp.removeExpiredTransactions()
+	}()
+	if err != nil {
+		return err
+	}
+
+	// Notifications are sent after the locks are released,
+	// so that subscribers may call back into the mempool.
+	if len(expiredTxs) > 0 {
+		mp.sendNotification(NTTransactionsExpired,
+			&TransactionsExpiredNotificationData{Transactions: expiredTxs})
+	}
+	return nil
+}
+
+// removeExpiredTransactions is the internal function which implements
+// the removal part of expireTransactions. It returns all the removed
+// transactions.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeExpiredTransactions() ([]*util.Tx, error) {
+	expiry := mp.cfg.Policy.MempoolExpiry
+	now := mstime.Now()
+	if expiry == 0 || now.Before(mp.nextPoolExpireScan) {
+		return nil, nil
+	}
+	mp.nextPoolExpireScan = now.Add(poolExpireScanInterval)
+
+	var expiredTxDescs []*TxDesc
+	for _, txDescs := range []map[daghash.TxID]*TxDesc{mp.pool, mp.depends} {
+		for _, txDesc := range txDescs {
+			if now.Sub(txDesc.Added) > expiry {
+				expiredTxDescs = append(expiredTxDescs, txDesc)
+			}
+		}
+	}
+
+	var removedTxs []*util.Tx
+	for _, txDesc := range expiredTxDescs {
+		// The transaction might have already been removed
+		// as a dependant of a previously expired one.
+		if _, exists := mp.fetchTxDesc(txDesc.Tx.ID()); !exists {
+			continue
+		}
+		dependants := mp.collectRelatives(txDesc, mp.txChildren)
+		err := mp.removeTransaction(txDesc.Tx, true, true)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debugf("Expired transaction %s, which was added at %s, "+
+			"along with %d %s", txDesc.Tx.ID(), txDesc.Added, len(dependants),
+			logger.PickNoun(uint64(len(dependants)), "dependant", "dependants"))
+		removedTxs = append(removedTxs, txDesc.Tx)
+		for _, dependant := range dependants {
+			removedTxs = append(removedTxs, dependant.Tx)
+		}
+	}
+
+	if len(removedTxs) > 0 {
+		log.Infof("Removed %d expired %s from the mempool (remaining: %d)",
+			len(removedTxs), logger.PickNoun(uint64(len(removedTxs)), "transaction", "transactions"),
+			len(mp.pool)+len(mp.depends))
+	}
+	return removedTxs, nil
+}
+
+// limitPoolMass evicts the transactions with the lowest fee per mass,
+// along with the transactions that depend on them, until the total mass
+// of the pool no longer exceeds Policy.MaxPoolMass. Whenever a transaction
+// is evicted, the rolling minimum fee rate is raised above its fee rate.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) limitPoolMass() error {
+	maxPoolMass := mp.cfg.Policy.MaxPoolMass
+	if maxPoolMass == 0 {
+		return nil
+	}
+
+	for mp.totalMass > maxPoolMass {
+		// Removing the transaction removes it, along with
+		// its dependants, from the eviction heap as well.
+		txDesc := mp.evictionHeap.peek()
+		err := mp.removeTransaction(txDesc.Tx, true, true)
+		if err != nil {
+			return err
+		}
+		mp.raiseRollingMinFeeRate(txDesc.FeePerMegaGram)
+
+		log.Debugf("Evicted transaction %s from the full mempool "+
+			"(fee per megagram: %d, pool mass: %d)", txDesc.Tx.ID(),
+			txDesc.FeePerMegaGram, mp.totalMass)
+	}
+
+	return nil
+}
+
+// txsToEvict returns the transactions that limitPoolMass would evict if it
+// was called now, without evicting them.
+//
+// This function MUST be called with the mempool lock held (for writes),
+// since the eviction heap is modified while they're collected.
+func (mp *TxPool) txsToEvict() map[daghash.TxID]*TxDesc {
+	txsToEvict := make(map[daghash.TxID]*TxDesc)
+	maxPoolMass := mp.cfg.Policy.MaxPoolMass
+	if maxPoolMass == 0 {
+		return txsToEvict
+	}
+
+	totalMass := mp.totalMass
+	var poppedTxDescs []*TxDesc
+	for totalMass > maxPoolMass {
+		txDesc := mp.evictionHeap.pop()
+		poppedTxDescs = append(poppedTxDescs, txDesc)
+		if _, ok := txsToEvict[*txDesc.Tx.ID()]; ok {
+			continue
+		}
+		evicted := append([]*TxDesc{txDesc}, mp.collectRelatives(txDesc, mp.txChildren)...)
+		for _, evictedTxDesc := range evicted {
+			if _, ok := txsToEvict[*evictedTxDesc.Tx.ID()]; ok {
+				continue
+			}
+			txsToEvict[*evictedTxDesc.Tx.ID()] = evictedTxDesc
+			totalMass -= evictedTxDesc.Mass
+		}
+	}
+	for _, txDesc := range poppedTxDescs {
+		mp.evictionHeap.push(txDesc)
+	}
+	return txsToEvict
+}
+
+// isReplacementEvicted returns whether limitPoolMass would evict a
+// replacement transaction with the passed fee per megagram, mass and parents
+// in the pool, once it replaces the passed evicted transactions. Since the
+// replacement would be the newest transaction in the pool, it's evicted
+// before the transactions that have the same fee rate.
+//
+// This function MUST be called with the mempool lock held (for writes),
+// since the eviction heap is modified while the check runs.
+func (mp *TxPool) isReplacementEvicted(feePerMegaGram uint64, mass uint64,
+	parentsInPool []*domainmessage.Outpoint, evicted []*TxDesc) bool {
+
+	maxPoolMass := mp.cfg.Policy.MaxPoolMass
+	if maxPoolMass == 0 {
+		return false
+	}
+
+	removedTxIDs := make(map[daghash.TxID]struct{})
+	totalMass := mp.totalMass + mass
+	for _, txDesc := range evicted {
+		removedTxIDs[*txDesc.Tx.ID()] = struct{}{}
+		totalMass -= txDesc.Mass
+	}
+	parentTxIDs := make(map[daghash.TxID]struct{}, len(parentsInPool))
+	for _, parentOutpoint := range parentsInPool {
+		parentTxIDs[parentOutpoint.TxID] = struct{}{}
+	}
+
+	var poppedTxDescs []*TxDesc
+	defer func() {
+		for _, txDesc := range poppedTxDescs {
+			mp.evictionHeap.push(txDesc)
+		}
+	}()
+	for totalMass > maxPoolMass {
+		if mp.evictionHeap.peek() == nil {
+			return true
+		}
+		txDesc := mp.evictionHeap.pop()
+		poppedTxDescs = append(poppedTxDescs, txDesc)
+		if _, ok := removedTxIDs[*txDesc.Tx.ID()]; ok {
+			continue
+		}
+		if feePerMegaGram <= txDesc.FeePerMegaGram {
+			return true
+		}
+
+		// The replacement is evicted along with any of its parents.
+		for _, removedTxDesc := range append([]*TxDesc{txDesc}, mp.collectRelatives(txDesc, mp.txChildren)...) {
+			if _, ok := removedTxIDs[*removedTxDesc.Tx.ID()]; ok {
+				continue
+			}
+			if _, ok := parentTxIDs[*removedTxDesc.Tx.ID()]; ok {
+				return true
+			}
+			removedTxIDs[*removedTxDesc.Tx.ID()] = struct{}{}
+			totalMass -= removedTxDesc.Mass
+		}
+	}
+	return false
+}
+
+// raiseRollingMinFeeRate raises the rolling minimum fee rate above the
+// given fee rate of an evicted transaction. The minimum relay fee is added
+// on top of it, so that transactions that replace evicted ones also pay
+// for relaying the evicted ones.
 //
 // This function MUST be called with the mempool lock held (for writes).
-func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*domainmessage.Outpoint) (*TxDesc, error) {
+func (mp *TxPool) raiseRollingMinFeeRate(evictedFeePerMegaGram uint64) {
+	feeRate := float64(evictedFeePerMegaGram)/1000 + float64(mp.cfg.Policy.MinRelayTxFee)
+	if feeRate > mp.currentRollingMinFeeRate() {
+		mp.rollingMinFeeRate = feeRate
+		mp.lastRollingMinFeeUpdate = mstime.Now()
+	}
+}
+
+// currentRollingMinFeeRate returns the rolling minimum fee rate, in sompi
+// per 1000 grams of mass, after decaying it by the time that had passed
+// since it was last updated. Once it drops below half the minimum relay
+// fee it's reset to zero, since it no longer has any effect.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) currentRollingMinFeeRate() float64 {
+	if mp.rollingMinFeeRate == 0 {
+		return 0
+	}
+
+	now := mstime.Now()
+	halfLives := float64(now.Sub(mp.lastRollingMinFeeUpdate)) / float64(rollingMinFeeHalfLife)
+	mp.rollingMinFeeRate /= math.Pow(2, halfLives)
+	mp.lastRollingMinFeeUpdate = now
+
+	if mp.rollingMinFeeRate < float64(mp.cfg.Policy.MinRelayTxFee)/2 {
+		mp.rollingMinFeeRate = 0
+	}
+	return mp.rollingMinFeeRate
+}
+
+// addTransaction adds the passed transaction to the memory pool, as though it
+// was added at the passed time. It should not be called directly as it
+// doesn't perform any validation. This is a helper for maybeAcceptTransaction.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, mass uint64,
+	parentsInPool []*domainmessage.Outpoint, added mstime.Time) (*TxDesc, error) {
+
 	// Add the transaction to the pool and mark the referenced outpoints
 	// as spent by the pool.
-	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, mp.mpUTXOSet)
-	if err != nil {
-		return nil, err
-	}
 	txD := &TxDesc{
 		TxDesc: mining.TxDesc{
 			Tx:             tx,
-			Added:          mstime.Now(),
+			Added:          added,
 			Fee:            fee,
+			Mass:           mass,
 			FeePerMegaGram: fee * 1e6 / mass,
 		},
 		depCount: len(parentsInPool),
@@ -692,6 +1014,8 @@ func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*domai
 			mp.dependsByPrev[*previousOutpoint][*tx.ID()] = txD
 		}
 	}
+	mp.totalMass += mass
+	mp.evictionHeap.push(txD)
 
 	for _, txIn := range tx.MsgTx().TxIn {
 		mp.outpoints[txIn.PreviousOutpoint] = tx
@@ -708,23 +1032,158 @@ func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*domai
 
 // checkPoolDoubleSpend checks whether or not the passed transaction is
 // attempting to spend coins already spent by other transactions in the pool.
+// This is only allowed if replacements are enabled and all of the conflicting
+// transactions are replaceable, in which case they are returned.
 // Note it does not check for double spends against transactions already in the
 // DAG.
 //
 // This function MUST be called with the mempool lock held (for reads).
-func (mp *TxPool) checkPoolDoubleSpend(tx *util.Tx) error {
+func (mp *TxPool) checkPoolDoubleSpend(tx *util.Tx) ([]*TxDesc, error) {
+	var conflicts []*TxDesc
 	for _, txIn := range tx.MsgTx().TxIn {
-		if txR, exists := mp.outpoints[txIn.PreviousOutpoint]; exists {
+		txR, exists := mp.outpoints[txIn.PreviousOutpoint]
+		if !exists {
+			continue
+		}
+		txRDesc, _ := mp.fetchTxDesc(txR.ID())
+		if mp.cfg.Policy.MaxReplacementEvictions == 0 || !mp.isReplaceable(txRDesc) {
 			str := fmt.Sprintf("output %s already spent by "+
 				"transaction %s in the memory pool",
 				txIn.PreviousOutpoint, txR.ID())
-			return txRuleError(RejectDuplicate, str)
+			return nil, txRuleError(RejectDuplicate, str)
+		}
+		if !containsTxDesc(conflicts, txRDesc) {
+			conflicts = append(conflicts, txRDesc)
 		}
 	}
 
+	return conflicts, nil
+}
+
+// isReplaceable returns whether the passed transaction, or any of its
+// ancestors in the pool, signals that it may be replaced.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) isReplaceable(txDesc *TxDesc) bool {
+	if signalsReplacement(txDesc.Tx) {
+		return true
+	}
+	for _, ancestor := range mp.collectRelatives(txDesc, mp.txParents) {
+		if signalsReplacement(ancestor.Tx) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReplacement checks whether the passed transaction may replace the
+// passed conflicting transactions, and returns all the transactions that
+// the replacement would evict from the pool: the conflicting transactions
+// along with their descendants.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkReplacement(tx *util.Tx, conflicts []*TxDesc) ([]*TxDesc, error) {
+	evicted := make([]*TxDesc, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		for _, txDesc := range append([]*TxDesc{conflict}, mp.collectRelatives(conflict, mp.txChildren)...) {
+			if !containsTxDesc(evicted, txDesc) {
+				evicted = append(evicted, txDesc)
+			}
+		}
+	}
+	if len(evicted) > mp.cfg.Policy.MaxReplacementEvictions {
+		str := fmt.Sprintf("transaction %s would replace %d transactions, "+
+			"which is more than the maximum of %d", tx.ID(), len(evicted),
+			mp.cfg.Policy.MaxReplacementEvictions)
+		return nil, txRuleError(RejectNonstandard, str)
+	}
+
+	for _, txIn := range tx.MsgTx().TxIn {
+		if containsTxID(evicted, &txIn.PreviousOutpoint.TxID) {
+			str := fmt.Sprintf("transaction %s spends output %s of "+
+				"a transaction it replaces", tx.ID(), txIn.PreviousOutpoint)
+			return nil, txRuleError(RejectInvalid, str)
+		}
+	}
+
+	return evicted, nil
+}
+
+// checkReplacementFees checks that a replacement transaction, with the
+// passed fee and mass, pays a higher fee rate than every transaction it
+// conflicts with, and a higher fee than all of the transactions it evicts
+// combined, by at least minFee, so that it also pays for its own relay.
+func checkReplacementFees(tx *util.Tx, fee uint64, mass uint64, minFee uint64,
+	conflicts []*TxDesc, evicted []*TxDesc) error {
+
+	feePerMegaGram := fee * 1e6 / mass
+	for _, conflict := range conflicts {
+		if feePerMegaGram <= conflict.FeePerMegaGram {
+			str := fmt.Sprintf("transaction %s has a fee rate of %d sompi "+
+				"per megagram, which is not higher than the fee rate of %d of "+
+				"transaction %s, which it replaces", tx.ID(), feePerMegaGram,
+				conflict.FeePerMegaGram, conflict.Tx.ID())
+			return txRuleError(RejectInsufficientFee, str)
+		}
+	}
+
+	evictedFee := uint64(0)
+	for _, txDesc := range evicted {
+		evictedFee += txDesc.Fee
+	}
+	if fee <= evictedFee || fee-evictedFee < minFee {
+		str := fmt.Sprintf("transaction %s has %d fees, which is under the "+
+			"required amount of %d for replacing %d transactions that pay "+
+			"%d fees", tx.ID(), fee, evictedFee+minFee, len(evicted), evictedFee)
+		return txRuleError(RejectInsufficientFee, str)
+	}
 	return nil
 }
 
+// utxoSetWithoutTransactions returns the mempool UTXO set as it would be if
+// the passed transactions were removed from the pool. The transactions must
+// include all of their descendants in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) utxoSetWithoutTransactions(txDescs []*TxDesc) (blockdag.UTXOSet, error) {
+	diff := blockdag.NewUTXODiff()
+	for _, txDesc := range txDescs {
+		err := mp.removeTransactionUTXOEntriesFromDiff(txDesc.Tx, diff)
+		if err != nil {
+			return nil, err
+		}
+		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+			// Outputs of removed transactions aren't restored.
+			if containsTxID(txDescs, &txIn.PreviousOutpoint.TxID) {
+				continue
+			}
+			if entry, ok := mp.spentInputEntry(txIn.PreviousOutpoint); ok {
+				err := diff.AddEntry(txIn.PreviousOutpoint, entry)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return mp.mpUTXOSet.WithDiff(diff)
+}
+
+// containsTxDesc returns whether txDescs contains txDesc.
+func containsTxDesc(txDescs []*TxDesc, txDesc *TxDesc) bool {
+	return containsTxID(txDescs, txDesc.Tx.ID())
+}
+
+// containsTxID returns whether txDescs contains a transaction with the
+// passed ID.
+func containsTxID(txDescs []*TxDesc, txID *daghash.TxID) bool {
+	for _, other := range txDescs {
+		if other.Tx.ID().IsEqual(txID) {
+			return true
+		}
+	}
+	return false
+}
+
 // CheckSpend checks whether the passed outpoint is already spent by a
 // transaction in the mempool. If that's the case the spending transaction will
 // be returned, if not nil will be returned.
@@ -762,6 +1221,115 @@ func (mp *TxPool) FetchTxDesc(txID *daghash.TxID) (*TxDesc, bool) {
 	return nil, false
 }
 
+// TxFamily holds the relatives of a transaction in the transaction pool.
+// Relatives that aren't in the pool, such as transactions that had already
+// been accepted by the DAG, are not included.
+type TxFamily struct {
+	// Parents are the transactions whose outputs the transaction spends.
+	Parents []*TxDesc
+
+	// Children are the transactions that spend the outputs of the transaction.
+	Children []*TxDesc
+
+	// Ancestors are the parents of the transaction, their parents, and so on.
+	Ancestors []*TxDesc
+
+	// Descendants are the children of the transaction, their children, and so on.
+	Descendants []*TxDesc
+}
+
+// FetchTxFamily returns the requested TxDesc from the transaction pool along
+// with the relatives of the transaction in the pool. This only fetches from
+// the main transaction pool and does not include orphans.
+// returns false in the third return parameter if transaction was not found
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchTxFamily(txID *daghash.TxID) (*TxDesc, *TxFamily, bool) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	txDesc, exists := mp.fetchTxDesc(txID)
+	if !exists {
+		return nil, nil, false
+	}
+
+	return txDesc, &TxFamily{
+		Parents:     mp.txParents(txDesc),
+		Children:    mp.txChildren(txDesc),
+		Ancestors:   mp.collectRelatives(txDesc, mp.txParents),
+		Descendants: mp.collectRelatives(txDesc, mp.txChildren),
+	}, true
+}
+
+// txParents returns the transactions in the pool whose outputs the
+// given transaction spends.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txParents(txDesc *TxDesc) []*TxDesc {
+	var parents []*TxDesc
+	visited := make(map[daghash.TxID]struct{})
+	for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+		parentID := txIn.PreviousOutpoint.TxID
+		if _, ok := visited[parentID]; ok {
+			continue
+		}
+		visited[parentID] = struct{}{}
+		if parent, exists := mp.fetchTxDesc(&parentID); exists {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// txChildren returns the transactions in the pool that spend the
+// outputs of the given transaction.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txChildren(txDesc *TxDesc) []*TxDesc {
+	var children []*TxDesc
+	visited := make(map[daghash.TxID]struct{})
+	outpoint := domainmessage.Outpoint{TxID: *txDesc.Tx.ID()}
+	for i := range txDesc.Tx.MsgTx().TxOut {
+		outpoint.Index = uint32(i)
+		spendingTx, exists := mp.outpoints[outpoint]
+		if !exists {
+			continue
+		}
+		if _, ok := visited[*spendingTx.ID()]; ok {
+			continue
+		}
+		visited[*spendingTx.ID()] = struct{}{}
+		if child, exists := mp.fetchTxDesc(spendingTx.ID()); exists {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// collectRelatives returns all the transactions that are reachable from the
+// given transaction by repeatedly following the given relation, excluding the
+// transaction itself.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) collectRelatives(txDesc *TxDesc, relation func(*TxDesc) []*TxDesc) []*TxDesc {
+	var relatives []*TxDesc
+	visited := map[daghash.TxID]struct{}{*txDesc.Tx.ID(): {}}
+	queue := []*TxDesc{txDesc}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, relative := range relation(current) {
+			if _, ok := visited[*relative.Tx.ID()]; ok {
+				continue
+			}
+			visited[*relative.Tx.ID()] = struct{}{}
+			relatives = append(relatives, relative)
+			queue = append(queue, relative)
+		}
+	}
+	return relatives
+}
+
 // FetchTransaction returns the requested transaction from the transaction pool.
 // This only fetches from the main transaction pool and does not include
 // orphans.
@@ -791,18 +1359,33 @@ func checkTransactionMassSanity(tx *util.Tx) error {
 	return nil
 }
 
-// maybeAcceptTransaction is the main workhorse for handling insertion of new
-// free-standing transactions into a memory pool. It includes functionality
-// such as rejecting duplicate transactions, ensuring transactions follow all
-// rules, detecting orphan transactions, and insertion into the memory pool.
-//
-// If the transaction is an orphan (missing parent transactions), the
-// transaction is NOT added to the orphan pool, but each unknown referenced
-// parent is returned. Use ProcessTransaction instead if new orphans should
-// be added to the orphan pool.
+// acceptanceCheck describes a transaction that passed the checks of
+// checkTransactionAcceptance.
+type acceptanceCheck struct {
+	// missingParents are the unknown transactions that the transaction
+	// spends from. If it's not empty, none of the other fields are set.
+	missingParents []*daghash.TxID
+
+	fee                uint64
+	mass               uint64
+	parentsInPool      []*domainmessage.Outpoint
+	conflicts          []*TxDesc
+	nextBlockBlueScore uint64
+
+	// evicted are the transactions that the transaction replaces: its
+	// conflicts along with their descendants.
+	evicted []*TxDesc
+}
+
+// checkTransactionAcceptance runs all the checks that a transaction has to
+// pass in order to be accepted to the memory pool, without modifying the pool.
+// If the transaction is an orphan, the unknown referenced parents are returned
+// in missingParents. If checkFees is false, the fee of the transaction is only
+// required not to be zero, so that the fees of a package of transactions could
+// be checked as a whole.
 //
 // This function MUST be called with the mempool lock held (for writes).
-func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]*daghash.TxID, *TxDesc, error) {
+func (mp *TxPool) checkTransactionAcceptance(tx *util.Tx, rejectDupOrphans bool, checkFees bool) (*acceptanceCheck, error) {
 	txID := tx.ID()
 
 	// Don't accept the transaction if it already exists in the pool. This
@@ -813,7 +1396,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 		mp.isOrphanInPool(txID)) {
 
 		str := fmt.Sprintf("already have transaction %s", txID)
-		return nil, nil, txRuleError(RejectDuplicate, str)
+		return nil, txRuleError(RejectDuplicate, str)
 	}
 
 	// Don't accept the transaction if it's from an incompatible subnetwork.
@@ -821,20 +1404,20 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	if !tx.MsgTx().IsSubnetworkCompatible(subnetworkID) {
 		str := fmt.Sprintf("tx %s belongs to an invalid subnetwork %s, DAG subnetwork %s", tx.ID(),
 			tx.MsgTx().SubnetworkID, subnetworkID)
-		return nil, nil, txRuleError(RejectInvalid, str)
+		return nil, txRuleError(RejectInvalid, str)
 	}
 
 	// Disallow non-native/coinbase subnetworks in networks that don't allow them
 	if !mp.cfg.DAG.Params.EnableNonNativeSubnetworks {
 		if !(tx.MsgTx().SubnetworkID.IsEqual(subnetworkid.SubnetworkIDNative) ||
 			tx.MsgTx().SubnetworkID.IsEqual(subnetworkid.SubnetworkIDCoinbase)) {
-			return nil, nil, txRuleError(RejectInvalid, "non-native/coinbase subnetworks are not allowed")
+			return nil, txRuleError(RejectInvalid, "non-native/coinbase subnetworks are not allowed")
 		}
 	}
 
 	err := checkTransactionMassSanity(tx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Perform preliminary sanity checks on the transaction. This makes
@@ -844,9 +1427,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	if err != nil {
 		var ruleErr blockdag.RuleError
 		if ok := errors.As(err, &ruleErr); ok {
-			return nil, nil, dagRuleError(ruleErr)
+			return nil, dagRuleError(ruleErr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Check that transaction does not overuse GAS
@@ -854,12 +1437,12 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	if !msgTx.SubnetworkID.IsBuiltInOrNative() {
 		gasLimit, err := mp.cfg.DAG.GasLimit(&msgTx.SubnetworkID)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		if msgTx.Gas > gasLimit {
 			str := fmt.Sprintf("transaction wants more gas %d, than allowed %d",
 				msgTx.Gas, gasLimit)
-			return nil, nil, dagRuleError(blockdag.RuleError{
+			return nil, dagRuleError(blockdag.RuleError{
 				ErrorCode:   blockdag.ErrInvalidGas,
 				Description: str})
 		}
@@ -869,7 +1452,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	if tx.IsCoinBase() {
 		str := fmt.Sprintf("transaction %s is an individual coinbase transaction",
 			txID)
-		return nil, nil, txRuleError(RejectInvalid, str)
+		return nil, txRuleError(RejectInvalid, str)
 	}
 
 	// We take the blue score of the current virtual block to validate
@@ -893,7 +1476,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 			}
 			str := fmt.Sprintf("transaction %s is not standard: %s",
 				txID, err)
-			return nil, nil, txRuleError(rejectCode, str)
+			return nil, txRuleError(rejectCode, str)
 		}
 	}
 
@@ -905,9 +1488,25 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// at this point. There is a more in-depth check that happens later
 	// after fetching the referenced transaction inputs from the DAG
 	// which examines the actual spend data and prevents double spends.
-	err = mp.checkPoolDoubleSpend(tx)
+	conflicts, err := mp.checkPoolDoubleSpend(tx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	// A transaction that conflicts with replaceable transactions in the
+	// pool is validated against the mempool UTXO set as it would be once
+	// they, along with their descendants, are evicted.
+	utxoSet := mp.mpUTXOSet
+	var evicted []*TxDesc
+	if len(conflicts) > 0 {
+		evicted, err = mp.checkReplacement(tx, conflicts)
+		if err != nil {
+			return nil, err
+		}
+		utxoSet, err = mp.utxoSetWithoutTransactions(evicted)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Don't allow the transaction if it exists in the DAG and is
@@ -915,9 +1514,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	prevOut := domainmessage.Outpoint{TxID: *txID}
 	for txOutIdx := range tx.MsgTx().TxOut {
 		prevOut.Index = uint32(txOutIdx)
-		_, ok := mp.mpUTXOSet.Get(prevOut)
+		_, ok := utxoSet.Get(prevOut)
 		if ok {
-			return nil, nil, txRuleError(RejectDuplicate,
+			return nil, txRuleError(RejectDuplicate,
 				"transaction already exists")
 		}
 	}
@@ -929,7 +1528,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	var missingParents []*daghash.TxID
 	var parentsInPool []*domainmessage.Outpoint
 	for _, txIn := range tx.MsgTx().TxIn {
-		if _, ok := mp.mpUTXOSet.Get(txIn.PreviousOutpoint); !ok {
+		if _, ok := utxoSet.Get(txIn.PreviousOutpoint); !ok {
 			// Must make a copy of the hash here since the iterator
 			// is replaced and taking its address directly would
 			// result in all of the entries pointing to the same
@@ -942,35 +1541,35 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 		}
 	}
 	if len(missingParents) > 0 {
-		return missingParents, nil, nil
+		return &acceptanceCheck{missingParents: missingParents}, nil
 	}
 
 	// Don't allow the transaction into the mempool unless its sequence
 	// lock is active, meaning that it'll be allowed into the next block
 	// with respect to its defined relative lock times.
-	sequenceLock, err := mp.cfg.CalcSequenceLockNoLock(tx, mp.mpUTXOSet)
+	sequenceLock, err := mp.cfg.CalcSequenceLockNoLock(tx, utxoSet)
 	if err != nil {
 		var dagRuleErr blockdag.RuleError
 		if ok := errors.As(err, &dagRuleErr); ok {
-			return nil, nil, dagRuleError(dagRuleErr)
+			return nil, dagRuleError(dagRuleErr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 	if !blockdag.SequenceLockActive(sequenceLock, nextBlockBlueScore,
 		medianTimePast) {
-		return nil, nil, txRuleError(RejectNonstandard,
+		return nil, txRuleError(RejectNonstandard,
 			"transaction's sequence locks on inputs not met")
 	}
 
 	// Don't allow transactions that exceed the maximum allowed
 	// transaction mass.
-	err = blockdag.ValidateTxMass(tx, mp.mpUTXOSet)
+	err = blockdag.ValidateTxMass(tx, utxoSet)
 	if err != nil {
 		var ruleError blockdag.RuleError
 		if ok := errors.As(err, &ruleError); ok {
-			return nil, nil, dagRuleError(ruleError)
+			return nil, dagRuleError(ruleError)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Perform several checks on the transaction inputs using the invariant
@@ -978,19 +1577,19 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// Also returns the fees associated with the transaction which will be
 	// used later.
 	txFee, err := blockdag.CheckTransactionInputsAndCalulateFee(tx, nextBlockBlueScore,
-		mp.mpUTXOSet, mp.cfg.DAG.Params, false)
+		utxoSet, mp.cfg.DAG.Params, false)
 	if err != nil {
 		var dagRuleErr blockdag.RuleError
 		if ok := errors.As(err, &dagRuleErr); ok {
-			return nil, nil, dagRuleError(dagRuleErr)
+			return nil, dagRuleError(dagRuleErr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Don't allow transactions with non-standard inputs if the network
 	// parameters forbid their acceptance.
 	if !mp.cfg.Policy.AcceptNonStd {
-		err := checkInputsStandard(tx, mp.mpUTXOSet)
+		err := checkInputsStandard(tx, utxoSet)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained. When not possible, fall back to
@@ -1001,7 +1600,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 			}
 			str := fmt.Sprintf("transaction %s has a non-standard "+
 				"input: %s", txID, err)
-			return nil, nil, txRuleError(rejectCode, str)
+			return nil, txRuleError(rejectCode, str)
 		}
 	}
 
@@ -1009,12 +1608,65 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// you should add code here to check that the transaction does a
 	// reasonable number of ECDSA signature verifications.
 
-	// Don't allow transactions with 0 fees.
+	// Don't allow transactions with 0 fees, since they can't be
+	// included in blocks. This applies to the transactions of packages
+	// as well.
 	if txFee == 0 {
 		str := fmt.Sprintf("transaction %s has 0 fees", txID)
-		return nil, nil, txRuleError(RejectInsufficientFee, str)
+		return nil, txRuleError(RejectInsufficientFee, str)
+	}
+
+	serializedSize := int64(tx.MsgTx().SerializeSize())
+	minFee := uint64(calcMinRequiredTxRelayFee(serializedSize,
+		mp.cfg.Policy.MinRelayTxFee))
+	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, utxoSet)
+	if err != nil {
+		return nil, err
+	}
+	if checkFees {
+		err = mp.checkFees(fmt.Sprintf("transaction %s", txID), txFee, mass, serializedSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A replacement transaction must pay more than the transactions it
+	// evicts.
+	if len(conflicts) > 0 {
+		err = checkReplacementFees(tx, txFee, mass, minFee, conflicts, evicted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Verify crypto signatures for each input and reject the transaction if
+	// any don't verify.
+	err = blockdag.ValidateTransactionScripts(tx, utxoSet,
+		txscript.StandardVerifyFlags, mp.cfg.SigCache)
+	if err != nil {
+		var dagRuleErr blockdag.RuleError
+		if ok := errors.As(err, &dagRuleErr); ok {
+			return nil, dagRuleError(dagRuleErr)
+		}
+		return nil, err
 	}
 
+	return &acceptanceCheck{
+		fee:                txFee,
+		mass:               mass,
+		parentsInPool:      parentsInPool,
+		conflicts:          conflicts,
+		nextBlockBlueScore: nextBlockBlueScore,
+		evicted:            evicted,
+	}, nil
+}
+
+// checkFees checks that the passed fee is high enough for transactions of the
+// passed total mass and serialized size to be accepted to the memory pool.
+// description is used in order to describe the transactions in errors.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) checkFees(description string, fee uint64, mass uint64, serializedSize int64) error {
 	// Don't allow transactions with fees too low to get into a mined block.
 	//
 	// Most miners allow a free transaction area in blocks they mine to go
@@ -1026,34 +1678,93 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// which is more desirable. Therefore, as long as the size of the
 	// transaction does not exceeed 1000 less than the reserved space for
 	// high-priority transactions, don't require a fee for it.
-	serializedSize := int64(tx.MsgTx().SerializeSize())
 	minFee := uint64(calcMinRequiredTxRelayFee(serializedSize,
 		mp.cfg.Policy.MinRelayTxFee))
-	if txFee < minFee {
-		str := fmt.Sprintf("transaction %s has %d fees which is under "+
-			"the required amount of %d", txID, txFee,
+	if fee < minFee {
+		str := fmt.Sprintf("%s has %d fees which is under "+
+			"the required amount of %d", description, fee,
 			minFee)
-		return nil, nil, txRuleError(RejectInsufficientFee, str)
+		return txRuleError(RejectInsufficientFee, str)
 	}
 
-	// Verify crypto signatures for each input and reject the transaction if
-	// any don't verify.
-	err = blockdag.ValidateTransactionScripts(tx, mp.mpUTXOSet,
-		txscript.StandardVerifyFlags, mp.cfg.SigCache)
+	// Don't allow transactions with fee rates below the rolling minimum
+	// fee rate, which rises after transactions are evicted from a full
+	// pool.
+	minPoolFee := uint64(math.Ceil(float64(mass) * mp.currentRollingMinFeeRate() / 1000))
+	if fee < minPoolFee {
+		str := fmt.Sprintf("%s has %d fees which is under "+
+			"the mempool minimum fee of %d", description, fee, minPoolFee)
+		return txRuleError(RejectInsufficientFee, str)
+	}
+	return nil
+}
+
+// maybeAcceptTransaction is the main workhorse for handling insertion of new
+// free-standing transactions into a memory pool. It includes functionality
+// such as rejecting duplicate transactions, ensuring transactions follow all
+// rules, detecting orphan transactions, and insertion into the memory pool.
+//
+// If the transaction is an orphan (missing parent transactions), the
+// transaction is NOT added to the orphan pool, but each unknown referenced
+// parent is returned. Use ProcessTransaction instead if new orphans should
+// be added to the orphan pool. The transaction is added to the pool as
+// though it was added at the passed time.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool,
+	added mstime.Time) ([]*daghash.TxID, *TxDesc, error) {
+
+	txID := tx.ID()
+	check, err := mp.checkTransactionAcceptance(tx, rejectDupOrphans, true)
 	if err != nil {
-		var dagRuleErr blockdag.RuleError
-		if ok := errors.As(err, &dagRuleErr); ok {
-			return nil, nil, dagRuleError(dagRuleErr)
-		}
 		return nil, nil, err
 	}
+	if len(check.missingParents) > 0 {
+		return check.missingParents, nil, nil
+	}
+
+	// Once the transactions that the transaction replaces are evicted,
+	// they can't be restored, so a replacement is rejected in advance if
+	// it would be evicted right away since the pool is full.
+	if len(check.evicted) > 0 &&
+		mp.isReplacementEvicted(check.fee*1e6/check.mass, check.mass, check.parentsInPool, check.evicted) {
+
+		str := fmt.Sprintf("transaction %s would be evicted since the "+
+			"mempool is full and its fee rate is too low", txID)
+		return nil, nil, txRuleError(RejectInsufficientFee, str)
+	}
+
+	// Evict the transactions that the transaction replaces.
+	for _, conflict := range check.conflicts {
+		err := mp.removeTransaction(conflict.Tx, true, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Debugf("Replaced transaction %s with %s", conflict.Tx.ID(), txID)
+	}
 
 	// Add to transaction pool.
-	txD, err := mp.addTransaction(tx, txFee, parentsInPool)
+	txD, err := mp.addTransaction(tx, check.fee, check.mass, check.parentsInPool, added)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if mp.cfg.FeeEstimator != nil {
+		mp.cfg.FeeEstimator.ObserveTransaction(txD, check.nextBlockBlueScore)
+	}
+
+	// Make room for the transaction if the pool is full. The
+	// transaction itself is evicted if its fee rate is too low.
+	err = mp.limitPoolMass()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, exists := mp.fetchTxDesc(txID); !exists {
+		str := fmt.Sprintf("transaction %s was evicted since the "+
+			"mempool is full and its fee rate is too low", txID)
+		return nil, nil, txRuleError(RejectInsufficientFee, str)
+	}
+
 	log.Debugf("Accepted transaction %s (pool size: %d)", txID,
 		len(mp.pool))
 
@@ -1097,7 +1808,7 @@ func (mp *TxPool) processOrphans(acceptedTx *util.Tx) []*TxDesc {
 			// Potentially accept an orphan into the tx pool.
 			for _, tx := range orphans {
 				missing, txD, err := mp.maybeAcceptTransaction(
-					tx, false)
+					tx, false, mstime.Now())
 				if err != nil {
 					// The orphan is now invalid, so there
 					// is no way any other orphans which
@@ -1175,6 +1886,16 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *util.Tx) []*TxDesc {
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) ProcessTransaction(tx *util.Tx, allowOrphan bool, tag Tag) ([]*TxDesc, error) {
+	return mp.processTransaction(tx, allowOrphan, tag, mstime.Now())
+}
+
+// processTransaction is the internal function which implements the public
+// ProcessTransaction. The passed transaction, unlike the orphans accepted
+// as a result of it, is added to the pool as though it was added at the
+// passed time.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) processTransaction(tx *util.Tx, allowOrphan bool, tag Tag, added mstime.Time) ([]*TxDesc, error) {
 	log.Tracef("Processing transaction %s", tx.ID())
 
 	// Protect concurrent access.
@@ -1184,7 +1905,7 @@ func (mp *TxPool) ProcessTransaction(tx *util.Tx, allowOrphan bool, tag Tag) ([]
 	defer mp.mtx.Unlock()
 
 	// Potentially accept the transaction to the memory pool.
-	missingParents, txD, err := mp.maybeAcceptTransaction(tx, true)
+	missingParents, txD, err := mp.maybeAcceptTransaction(tx, true, added)
 	if err != nil {
 		return nil, err
 	}
@@ -1208,19 +1929,7 @@ func (mp *TxPool) ProcessTransaction(tx *util.Tx, allowOrphan bool, tag Tag) ([]
 	// The transaction is an orphan (has inputs missing). Reject
 	// it if the flag to allow orphans is not set.
 	if !allowOrphan {
-		// Only use the first missing parent transaction in
-		// the error message.
-		//
-		// NOTE: RejectDuplicate is really not an accurate
-		// reject code here, but it matches the reference
-		// implementation and there isn't a better choice due
-		// to the limited number of reject codes. Missing
-		// inputs is assumed to mean they are already spent
-		// which is not really always the case.
-		str := fmt.Sprintf("orphan transaction %s references "+
-			"outputs of unknown or fully-spent "+
-			"transaction %s", tx.ID(), missingParents[0])
-		return nil, txRuleError(RejectDuplicate, str)
+		return nil, orphanRejectionError(tx, missingParents)
 	}
 
 	// Potentially add the orphan transaction to the orphan pool.
@@ -1228,6 +1937,48 @@ func (mp *TxPool) ProcessTransaction(tx *util.Tx, allowOrphan bool, tag Tag) ([]
 	return nil, err
 }
 
+// orphanRejectionError returns the error for rejecting an orphan transaction
+// with the passed missing parents.
+func orphanRejectionError(tx *util.Tx, missingParents []*daghash.TxID) error {
+	// Only use the first missing parent transaction in
+	// the error message.
+	//
+	// NOTE: RejectDuplicate is really not an accurate
+	// reject code here, but it matches the reference
+	// implementation and there isn't a better choice due
+	// to the limited number of reject codes. Missing
+	// inputs is assumed to mean they are already spent
+	// which is not really always the case.
+	str := fmt.Sprintf("orphan transaction %s references "+
+		"outputs of unknown or fully-spent "+
+		"transaction %s", tx.ID(), missingParents[0])
+	return txRuleError(RejectDuplicate, str)
+}
+
+// CheckTransactionAcceptance runs all the checks that ProcessTransaction runs
+// before accepting the passed transaction to the memory pool, without adding
+// it to the pool, and returns the fee it pays and its mass. Orphan
+// transactions are rejected. Note that a transaction that passes the checks
+// may still be evicted right after being accepted, if the pool is full and
+// its fee rate is too low.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CheckTransactionAcceptance(tx *util.Tx) (fee uint64, mass uint64, err error) {
+	mp.cfg.DAG.RLock()
+	defer mp.cfg.DAG.RUnlock()
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	check, err := mp.checkTransactionAcceptance(tx, true, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(check.missingParents) > 0 {
+		return 0, 0, orphanRejectionError(tx, check.missingParents)
+	}
+	return check.fee, check.mass, nil
+}
+
 // Count returns the number of transactions in the main pool. It does not
 // include the orphan pool.
 //
@@ -1240,6 +1991,16 @@ func (mp *TxPool) Count() int {
 	return count
 }
 
+// OrphanCount returns the number of transactions in the orphan pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) OrphanCount() int {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	return len(mp.orphans)
+}
+
 // DepCount returns the number of dependent transactions in the main pool. It does not
 // include the orphan pool.
 //
@@ -1250,6 +2011,36 @@ func (mp *TxPool) DepCount() int {
 	return len(mp.depends)
 }
 
+// TotalMass returns the sum of the masses of all the transactions in the
+// main pool, including the dependent ones. It does not include the orphan
+// pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) TotalMass() uint64 {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+	return mp.totalMass
+}
+
+// MinFeeRate returns the minimum fee rate, in sompi per 1000 grams of mass,
+// that a transaction has to pay in order to enter the pool. It's the
+// rolling minimum fee rate if it had been raised above the minimum relay
+// fee by evictions from a full pool, and the minimum relay fee otherwise.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MinFeeRate() util.Amount {
+	// The rolling minimum fee rate decays when read, which requires
+	// a write lock.
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	rollingMinFeeRate := util.Amount(math.Ceil(mp.currentRollingMinFeeRate()))
+	if rollingMinFeeRate > mp.cfg.Policy.MinRelayTxFee {
+		return rollingMinFeeRate
+	}
+	return mp.cfg.Policy.MinRelayTxFee
+}
+
 // TxIDs returns a slice of IDs for all of the transactions in the memory
 // pool.
 //
@@ -1303,6 +2094,31 @@ func (mp *TxPool) MiningDescs() []*mining.TxDesc {
 	return descs
 }
 
+// DependantMiningDescs returns a slice of descriptors for all the transactions
+// in the pool that depend on other transactions in the pool, along with all of
+// their ancestors in the pool.
+//
+// This is part of the mining.TxSource interface implementation and is safe for
+// concurrent access as required by the interface contract.
+func (mp *TxPool) DependantMiningDescs() []*mining.DependantTxDesc {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+	descs := make([]*mining.DependantTxDesc, 0, len(mp.depends))
+	for _, desc := range mp.depends {
+		ancestors := mp.collectRelatives(desc, mp.txParents)
+		ancestorDescs := make([]*mining.TxDesc, len(ancestors))
+		for i, ancestor := range ancestors {
+			ancestorDescs[i] = &ancestor.TxDesc
+		}
+		descs = append(descs, &mining.DependantTxDesc{
+			TxDesc:    &desc.TxDesc,
+			Ancestors: ancestorDescs,
+		})
+	}
+
+	return descs
+}
+
 // LastUpdated returns the last time a transaction was added to or removed from
 // the main pool. It does not include the orphan pool.
 //
@@ -1346,14 +2162,42 @@ func (mp *TxPool) HandleNewBlockOld(block *util.Block, txChan chan NewBlockMsg)
 // HandleNewBlock removes all the transactions in the new block
 // from the mempool and the orphan pool, and it also removes
 // from the mempool transactions that double spend a
-// transaction that is already in the DAG
+// transaction that is already in the DAG. Finally, it removes
+// the transactions that have expired, if it's time to scan for
+// them.
 func (mp *TxPool) HandleNewBlock(block *util.Block) ([]*util.Tx, error) {
+	acceptedTxs, err := mp.handleNewBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mp.expireTransactions()
+	if err != nil {
+		return nil, err
+	}
+	return acceptedTxs, nil
+}
+
+// handleNewBlock is the internal function which implements the
+// block-related part of HandleNewBlock.
+func (mp *TxPool) handleNewBlock(block *util.Block) ([]*util.Tx, error) {
 	// Protect concurrent access.
 	mp.cfg.DAG.RLock()
 	defer mp.cfg.DAG.RUnlock()
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
 
+	// Let the fee estimator know how long it took for the
+	// transactions in the block to be included, before they
+	// are removed from the pool.
+	if mp.cfg.FeeEstimator != nil {
+		blueScore, err := mp.cfg.DAG.BlueScoreByBlockHash(block.Hash())
+		if err != nil {
+			return nil, err
+		}
+		mp.cfg.FeeEstimator.RegisterBlock(block, blueScore)
+	}
+
 	oldUTXOSet := mp.mpUTXOSet
 
 	// Remove all of the transactions (except the coinbase) in the
@@ -1389,14 +2233,16 @@ func New(cfg *Config) *TxPool {
 	virtualUTXO := cfg.DAG.UTXOSet()
 	mpUTXO := blockdag.NewDiffUTXOSet(virtualUTXO, blockdag.NewUTXODiff())
 	return &TxPool{
-		cfg:            *cfg,
-		pool:           make(map[daghash.TxID]*TxDesc),
-		depends:        make(map[daghash.TxID]*TxDesc),
-		dependsByPrev:  make(map[domainmessage.Outpoint]map[daghash.TxID]*TxDesc),
-		orphans:        make(map[daghash.TxID]*orphanTx),
-		orphansByPrev:  make(map[domainmessage.Outpoint]map[daghash.TxID]*util.Tx),
-		nextExpireScan: mstime.Now().Add(orphanExpireScanInterval),
-		outpoints:      make(map[domainmessage.Outpoint]*util.Tx),
-		mpUTXOSet:      mpUTXO,
+		cfg:                *cfg,
+		pool:               make(map[daghash.TxID]*TxDesc),
+		depends:            make(map[daghash.TxID]*TxDesc),
+		dependsByPrev:      make(map[domainmessage.Outpoint]map[daghash.TxID]*TxDesc),
+		orphans:            make(map[daghash.TxID]*orphanTx),
+		orphansByPrev:      make(map[domainmessage.Outpoint]map[daghash.TxID]*util.Tx),
+		nextExpireScan:     mstime.Now().Add(orphanExpireScanInterval),
+		nextPoolExpireScan: mstime.Now().Add(poolExpireScanInterval),
+		outpoints:          make(map[domainmessage.Outpoint]*util.Tx),
+		evictionHeap:       newEvictionHeap(),
+		mpUTXOSet:          mpUTXO,
 	}
 }
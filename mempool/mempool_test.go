@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/kaspanet/kaspad/util/mstime"
 	"github.com/pkg/errors"
@@ -906,6 +907,120 @@ func TestFetchTransaction(t *testing.T) {
 
 }
 
+// TestFetchTxFamily ensures that the parents, children, ancestors and
+// descendants of a transaction in the pool are found correctly. It creates
+// a transaction with two outputs, a transaction that spends each of them,
+// and a transaction that spends the outputs of both of these transactions,
+// and checks the family of each of the transactions.
+func TestFetchTxFamily(t *testing.T) {
+	tc, spendableOuts, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestFetchTxFamily")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	createAndProcessTx := func(inputs []spendableOutpoint, numOutputs uint32) *util.Tx {
+		tx, err := harness.CreateSignedTx(inputs, numOutputs)
+		if err != nil {
+			t.Fatalf("unable to create signed tx: %v", err)
+		}
+		_, err = harness.txPool.ProcessTransaction(tx, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+		return tx
+	}
+	root := createAndProcessTx([]spendableOutpoint{spendableOuts[0]}, 2)
+	left := createAndProcessTx([]spendableOutpoint{txOutToSpendableOutpoint(root, 0)}, 1)
+	right := createAndProcessTx([]spendableOutpoint{txOutToSpendableOutpoint(root, 1)}, 1)
+	leaf := createAndProcessTx([]spendableOutpoint{
+		txOutToSpendableOutpoint(left, 0),
+		txOutToSpendableOutpoint(right, 0),
+	}, 1)
+
+	txIDs := func(txDescs []*TxDesc) map[daghash.TxID]struct{} {
+		ids := make(map[daghash.TxID]struct{}, len(txDescs))
+		for _, txDesc := range txDescs {
+			ids[*txDesc.Tx.ID()] = struct{}{}
+		}
+		if len(ids) != len(txDescs) {
+			t.Fatalf("TestFetchTxFamily: got duplicate relatives")
+		}
+		return ids
+	}
+	expectedIDs := func(txs ...*util.Tx) map[daghash.TxID]struct{} {
+		ids := make(map[daghash.TxID]struct{}, len(txs))
+		for _, tx := range txs {
+			ids[*tx.ID()] = struct{}{}
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name                string
+		tx                  *util.Tx
+		expectedParents     map[daghash.TxID]struct{}
+		expectedChildren    map[daghash.TxID]struct{}
+		expectedAncestors   map[daghash.TxID]struct{}
+		expectedDescendants map[daghash.TxID]struct{}
+	}{
+		{
+			name:                "root",
+			tx:                  root,
+			expectedParents:     expectedIDs(),
+			expectedChildren:    expectedIDs(left, right),
+			expectedAncestors:   expectedIDs(),
+			expectedDescendants: expectedIDs(left, right, leaf),
+		},
+		{
+			name:                "left",
+			tx:                  left,
+			expectedParents:     expectedIDs(root),
+			expectedChildren:    expectedIDs(leaf),
+			expectedAncestors:   expectedIDs(root),
+			expectedDescendants: expectedIDs(leaf),
+		},
+		{
+			name:                "leaf",
+			tx:                  leaf,
+			expectedParents:     expectedIDs(left, right),
+			expectedChildren:    expectedIDs(),
+			expectedAncestors:   expectedIDs(root, left, right),
+			expectedDescendants: expectedIDs(),
+		},
+	}
+	for _, test := range tests {
+		txDesc, txFamily, ok := harness.txPool.FetchTxFamily(test.tx.ID())
+		if !ok {
+			t.Fatalf("FetchTxFamily: %s: transaction unexpectedly not found", test.name)
+		}
+		if !txDesc.Tx.ID().IsEqual(test.tx.ID()) {
+			t.Fatalf("FetchTxFamily: %s: returned the wrong transaction", test.name)
+		}
+		if txDesc.Mass == 0 {
+			t.Fatalf("FetchTxFamily: %s: transaction mass is unexpectedly zero", test.name)
+		}
+		if !reflect.DeepEqual(txIDs(txFamily.Parents), test.expectedParents) {
+			t.Errorf("FetchTxFamily: %s: unexpected parents", test.name)
+		}
+		if !reflect.DeepEqual(txIDs(txFamily.Children), test.expectedChildren) {
+			t.Errorf("FetchTxFamily: %s: unexpected children", test.name)
+		}
+		if !reflect.DeepEqual(txIDs(txFamily.Ancestors), test.expectedAncestors) {
+			t.Errorf("FetchTxFamily: %s: unexpected ancestors", test.name)
+		}
+		if !reflect.DeepEqual(txIDs(txFamily.Descendants), test.expectedDescendants) {
+			t.Errorf("FetchTxFamily: %s: unexpected descendants", test.name)
+		}
+	}
+
+	_, _, ok := harness.txPool.FetchTxFamily(&daghash.TxID{1})
+	if ok {
+		t.Errorf("FetchTxFamily: expected an ok=false for a transaction that isn't in the pool")
+	}
+}
+
 // TestSimpleOrphanChain ensures that a simple chain of orphans is handled
 // properly. In particular, it generates a chain of single input, single output
 // transactions and inserts them while skipping the first linking transaction so
@@ -1594,6 +1709,308 @@ func TestCount(t *testing.T) {
 	}
 }
 
+// TestPoolMassLimit ensures that exceeding MaxPoolMass evicts the
+// transactions with the lowest fee per mass along with their dependants,
+// that the outputs the evicted transactions spent become spendable again,
+// and that the rolling minimum fee rate rejects transactions that pay
+// less than the evicted ones.
+func TestPoolMassLimit(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 4, "TestPoolMassLimit")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	lowFeeTx, err := harness.createTx(outputs[0], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	lowFeeChildTx, err := harness.createTx(txOutToSpendableOutpoint(lowFeeTx, 0), 3000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	midFeeTx, err := harness.createTx(outputs[1], 5000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	for _, tx := range []*util.Tx{lowFeeTx, lowFeeChildTx, midFeeTx} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+	}
+
+	lowFeeTxFeePerMegaGram := harness.txPool.pool[*lowFeeTx.ID()].FeePerMegaGram
+
+	// Fill the pool up to its limit, so that adding
+	// another transaction requires an eviction.
+	harness.txPool.cfg.Policy.MaxPoolMass = harness.txPool.TotalMass()
+	if minFeeRate := harness.txPool.MinFeeRate(); minFeeRate != harness.txPool.cfg.Policy.MinRelayTxFee {
+		t.Fatalf("MinFeeRate: expected the minimum relay fee %d before any evictions, got %d",
+			harness.txPool.cfg.Policy.MinRelayTxFee, minFeeRate)
+	}
+
+	highFeeTx, err := harness.createTx(outputs[2], 10000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(highFeeTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+
+	testPoolMembership(tc, lowFeeTx, false, false, false)
+	testPoolMembership(tc, lowFeeChildTx, false, false, false)
+	testPoolMembership(tc, midFeeTx, false, true, false)
+	testPoolMembership(tc, highFeeTx, false, true, false)
+	if _, ok := harness.txPool.mpUTXOSet.Get(outputs[0].outpoint); !ok {
+		t.Fatalf("mpUTXOSet: expected the output spent by an evicted " +
+			"transaction to be unspent again")
+	}
+	if harness.txPool.TotalMass() > harness.txPool.cfg.Policy.MaxPoolMass {
+		t.Fatalf("TotalMass: expected at most %d, got %d",
+			harness.txPool.cfg.Policy.MaxPoolMass, harness.txPool.TotalMass())
+	}
+
+	expectedMinFeeRate := util.Amount(lowFeeTxFeePerMegaGram/1000) + harness.txPool.cfg.Policy.MinRelayTxFee
+	if minFeeRate := harness.txPool.MinFeeRate(); minFeeRate < expectedMinFeeRate {
+		t.Fatalf("MinFeeRate: expected at least %d after evicting a transaction, got %d",
+			expectedMinFeeRate, minFeeRate)
+	}
+
+	// A transaction that pays the fee of the evicted transaction
+	// no longer passes the rolling minimum fee rate.
+	lowFeeTx, err = harness.createTx(outputs[3], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(lowFeeTx, true, 0)
+	if err == nil {
+		t.Fatalf("ProcessTransaction: expected the transaction to be rejected")
+	}
+	if code, _ := extractRejectCode(err); code != RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got %s (%v)",
+			RejectInsufficientFee, code, err)
+	}
+	testPoolMembership(tc, lowFeeTx, false, false, false)
+}
+
+func TestMempoolExpiry(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestMempoolExpiry")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+	harness.txPool.cfg.Policy.MempoolExpiry = time.Hour
+
+	var notifications []*Notification
+	harness.txPool.Subscribe(func(notification *Notification) {
+		notifications = append(notifications, notification)
+	})
+
+	oldTx, err := harness.createTx(outputs[0], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	oldChildTx, err := harness.createTx(txOutToSpendableOutpoint(oldTx, 0), 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	newTx, err := harness.createTx(outputs[1], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	for _, tx := range []*util.Tx{oldTx, oldChildTx, newTx} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+	}
+
+	// Only the parent is old enough to expire,
+	// but its child has to be removed with it.
+	harness.txPool.pool[*oldTx.ID()].Added = mstime.Now().Add(-2 * time.Hour)
+
+	// The pool shouldn't be scanned before the scan interval passes.
+	err = harness.txPool.expireTransactions()
+	if err != nil {
+		t.Fatalf("expireTransactions: %v", err)
+	}
+	testPoolMembership(tc, oldTx, false, true, false)
+	if len(notifications) != 0 {
+		t.Fatalf("expireTransactions: expected no notifications before the "+
+			"scan interval passes, got %d", len(notifications))
+	}
+
+	harness.txPool.nextPoolExpireScan = mstime.Now()
+	err = harness.txPool.expireTransactions()
+	if err != nil {
+		t.Fatalf("expireTransactions: %v", err)
+	}
+	testPoolMembership(tc, oldTx, false, false, false)
+	testPoolMembership(tc, oldChildTx, false, false, false)
+	testPoolMembership(tc, newTx, false, true, false)
+
+	if len(notifications) != 1 {
+		t.Fatalf("expireTransactions: expected 1 notification, got %d", len(notifications))
+	}
+	if notifications[0].Type != NTTransactionsExpired {
+		t.Fatalf("expireTransactions: expected notification of type %s, got %s",
+			NTTransactionsExpired, notifications[0].Type)
+	}
+	expiredTxs := notifications[0].Data.(*TransactionsExpiredNotificationData).Transactions
+	if len(expiredTxs) != 2 || expiredTxs[0] != oldTx || expiredTxs[1] != oldChildTx {
+		t.Fatalf("expireTransactions: expected the notification to contain %s and %s",
+			oldTx.ID(), oldChildTx.ID())
+	}
+}
+
+func TestReplaceByFee(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestReplaceByFee")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = DefaultMaxReplacementEvictions
+
+	createTx := func(outpoint spendableOutpoint, fee uint64, sequence uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		msgTx := tx.MsgTx()
+		msgTx.TxIn[0].Sequence = sequence
+		return util.NewTx(msgTx)
+	}
+	expectRejection := func(tx *util.Tx, expectedCode RejectCode) {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err == nil {
+			t.Fatalf("ProcessTransaction: expected transaction %s to be rejected", tx.ID())
+		}
+		if code, _ := extractRejectCode(err); code != expectedCode {
+			t.Fatalf("ProcessTransaction: expected reject code %s, got %s (%v)",
+				expectedCode, code, err)
+		}
+	}
+
+	// A transaction that doesn't signal replaceability can't be replaced.
+	nonReplaceableTx := createTx(outputs[0], 2000, domainmessage.MaxTxInSequenceNum)
+	_, err = harness.txPool.ProcessTransaction(nonReplaceableTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+	expectRejection(createTx(outputs[0], 20000, MaxReplaceableSequenceNum), RejectDuplicate)
+	testPoolMembership(tc, nonReplaceableTx, false, true, false)
+
+	// A replaceable transaction, with a child that inherits its replaceability.
+	replaceableTx := createTx(outputs[1], 2000, MaxReplaceableSequenceNum)
+	replaceableChildTx := createTx(txOutToSpendableOutpoint(replaceableTx, 0), 2000,
+		domainmessage.MaxTxInSequenceNum)
+	for _, tx := range []*util.Tx{replaceableTx, replaceableChildTx} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+	}
+
+	// The replacement has to pay more than both the
+	// transaction it replaces and its child.
+	expectRejection(createTx(outputs[1], 3000, domainmessage.MaxTxInSequenceNum), RejectInsufficientFee)
+
+	// The replacement may not evict too many transactions.
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = 1
+	expectRejection(createTx(outputs[1], 20000, domainmessage.MaxTxInSequenceNum), RejectNonstandard)
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = DefaultMaxReplacementEvictions
+
+	// Replacements can be disabled altogether.
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = 0
+	expectRejection(createTx(outputs[1], 20000, domainmessage.MaxTxInSequenceNum), RejectDuplicate)
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = DefaultMaxReplacementEvictions
+
+	testPoolMembership(tc, replaceableTx, false, true, false)
+	testPoolMembership(tc, replaceableChildTx, false, true, true)
+
+	replacementTx := createTx(outputs[1], 20000, domainmessage.MaxTxInSequenceNum)
+	_, err = harness.txPool.ProcessTransaction(replacementTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+	testPoolMembership(tc, replaceableTx, false, false, false)
+	testPoolMembership(tc, replaceableChildTx, false, false, false)
+	testPoolMembership(tc, replacementTx, false, true, false)
+	if spender := harness.txPool.CheckSpend(outputs[1].outpoint); spender != replacementTx {
+		t.Fatalf("CheckSpend: expected %s to be spent by the replacement", outputs[1].outpoint)
+	}
+
+	expectedMass := harness.txPool.pool[*nonReplaceableTx.ID()].Mass + harness.txPool.pool[*replacementTx.ID()].Mass
+	if harness.txPool.TotalMass() != expectedMass {
+		t.Fatalf("TotalMass: expected %d, got %d", expectedMass, harness.txPool.TotalMass())
+	}
+}
+
+// TestReplaceByFeeInFullPool ensures that a replacement that would be
+// evicted from a full pool right away is rejected without evicting the
+// transactions it replaces.
+func TestReplaceByFeeInFullPool(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestReplaceByFeeInFullPool")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = DefaultMaxReplacementEvictions
+
+	createTx := func(outpoint spendableOutpoint, fee uint64, sequence uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		msgTx := tx.MsgTx()
+		msgTx.TxIn[0].Sequence = sequence
+		return util.NewTx(msgTx)
+	}
+
+	highFeeTx := createTx(outputs[0], 20000, domainmessage.MaxTxInSequenceNum)
+	replaceableTx := createTx(outputs[1], 2000, MaxReplaceableSequenceNum)
+	for _, tx := range []*util.Tx{highFeeTx, replaceableTx} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+	}
+	harness.txPool.cfg.Policy.MaxPoolMass = harness.txPool.TotalMass() - 1
+
+	// A replacement whose fee rate is the lowest in the pool would be
+	// evicted right away, so the transaction it replaces is kept.
+	lowFeeReplacementTx := createTx(outputs[1], 10000, domainmessage.MaxTxInSequenceNum)
+	_, err = harness.txPool.ProcessTransaction(lowFeeReplacementTx, true, 0)
+	if code, _ := extractRejectCode(err); code != RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got %v",
+			RejectInsufficientFee, err)
+	}
+	testPoolMembership(tc, lowFeeReplacementTx, false, false, false)
+	testPoolMembership(tc, replaceableTx, false, true, false)
+	testPoolMembership(tc, highFeeTx, false, true, false)
+	if spender := harness.txPool.CheckSpend(outputs[1].outpoint); spender != replaceableTx {
+		t.Fatalf("CheckSpend: expected %s to still be spent by the replaced "+
+			"transaction", outputs[1].outpoint)
+	}
+
+	// A replacement with a higher fee rate evicts the
+	// transaction with the lowest fee rate instead.
+	highFeeReplacementTx := createTx(outputs[1], 40000, domainmessage.MaxTxInSequenceNum)
+	_, err = harness.txPool.ProcessTransaction(highFeeReplacementTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+	testPoolMembership(tc, highFeeReplacementTx, false, true, false)
+	testPoolMembership(tc, replaceableTx, false, false, false)
+	testPoolMembership(tc, highFeeTx, false, false, false)
+}
+
 func TestExtractRejectCode(t *testing.T) {
 	tests := []struct {
 		blockdagRuleErrorCode blockdag.ErrorCode
@@ -1850,3 +2267,69 @@ func TestTransactionGas(t *testing.T) {
 		t.Error("ProcessTransaction did not return error, expecting ErrInvalidGas")
 	}
 }
+
+// TestCheckTransactionAcceptance checks that CheckTransactionAcceptance
+// reports the same result that ProcessTransaction would, without modifying
+// the pool.
+func TestCheckTransactionAcceptance(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestCheckTransactionAcceptance")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	tx, err := harness.createTx(outputs[0], uint64(txRelayFeeForTest), 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	fee, mass, err := harness.txPool.CheckTransactionAcceptance(tx)
+	if err != nil {
+		t.Fatalf("CheckTransactionAcceptance: %v", err)
+	}
+	testPoolMembership(tc, tx, false, false, false)
+
+	acceptedTxs, err := harness.txPool.ProcessTransaction(tx, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+	if acceptedTxs[0].Fee != fee || acceptedTxs[0].Mass != mass {
+		t.Errorf("CheckTransactionAcceptance: expected fee %d and mass %d, got %d and %d",
+			acceptedTxs[0].Fee, acceptedTxs[0].Mass, fee, mass)
+	}
+
+	zeroFeeTx, err := harness.createTx(outputs[1], 0, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	orphanTx, err := harness.createTx(spendableOutpoint{
+		amount:   util.Amount(5000000000),
+		outpoint: domainmessage.Outpoint{TxID: daghash.TxID{}, Index: 1},
+	}, uint64(txRelayFeeForTest), 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	tests := []struct {
+		name               string
+		tx                 *util.Tx
+		expectedRejectCode RejectCode
+	}{
+		{name: "duplicate", tx: tx, expectedRejectCode: RejectDuplicate},
+		{name: "zero fee", tx: zeroFeeTx, expectedRejectCode: RejectInsufficientFee},
+		{name: "orphan", tx: orphanTx, expectedRejectCode: RejectDuplicate},
+	}
+	for _, test := range tests {
+		_, _, err := harness.txPool.CheckTransactionAcceptance(test.tx)
+		if err == nil {
+			t.Errorf("%s: CheckTransactionAcceptance: expected an error", test.name)
+			continue
+		}
+		rejectCode, _ := ErrToRejectErr(err)
+		if rejectCode != test.expectedRejectCode {
+			t.Errorf("%s: CheckTransactionAcceptance: expected reject code %s, got %s",
+				test.name, test.expectedRejectCode, rejectCode)
+		}
+	}
+	testPoolMembership(tc, zeroFeeTx, false, false, false)
+	testPoolMembership(tc, orphanTx, false, false, false)
+}
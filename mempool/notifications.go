@@ -0,0 +1,77 @@
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/util"
+)
+
+// NotificationType represents the type of a notification message.
+type NotificationType int
+
+// NotificationCallback is used for a caller to provide a callback for
+// notifications about various mempool events.
+type NotificationCallback func(*Notification)
+
+// Constants for the type of a notification message.
+const (
+	// NTTransactionsExpired indicates that transactions were removed
+	// from the mempool since they had not been accepted for longer
+	// than the mempool expiry.
+	NTTransactionsExpired NotificationType = iota
+)
+
+// notificationTypeStrings is a map of notification types back to their constant
+// names for pretty printing.
+var notificationTypeStrings = map[NotificationType]string{
+	NTTransactionsExpired: "NTTransactionsExpired",
+}
+
+// String returns the NotificationType in human-readable form.
+func (n NotificationType) String() string {
+	if s, ok := notificationTypeStrings[n]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown Notification Type (%d)", int(n))
+}
+
+// Notification defines notification that is sent to the caller via the callback
+// function provided during the call to Subscribe and consists of a notification
+// type as well as associated data that depends on the type as follows:
+// 	- TransactionsExpired: *TransactionsExpiredNotificationData
+type Notification struct {
+	Type NotificationType
+	Data interface{}
+}
+
+// Subscribe to mempool notifications. Registers a callback to be executed
+// when various events take place. See the documentation on Notification and
+// NotificationType for details on the types and contents of notifications.
+//
+// Callbacks are executed without the mempool lock held, so they may call
+// back into the mempool.
+func (mp *TxPool) Subscribe(callback NotificationCallback) {
+	mp.notificationsLock.Lock()
+	defer mp.notificationsLock.Unlock()
+	mp.notifications = append(mp.notifications, callback)
+}
+
+// sendNotification sends a notification with the passed type and data to
+// all the subscribed callbacks.
+//
+// This function MUST NOT be called with the mempool lock held.
+func (mp *TxPool) sendNotification(typ NotificationType, data interface{}) {
+	n := Notification{Type: typ, Data: data}
+	mp.notificationsLock.RLock()
+	defer mp.notificationsLock.RUnlock()
+	for _, callback := range mp.notifications {
+		callback(&n)
+	}
+}
+
+// TransactionsExpiredNotificationData defines data to be sent along with a
+// TransactionsExpired notification. Transactions includes both the expired
+// transactions and the transactions that depended on them.
+type TransactionsExpiredNotificationData struct {
+	Transactions []*util.Tx
+}
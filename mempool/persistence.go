@@ -0,0 +1,356 @@
+package mempool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/logger"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/pkg/errors"
+)
+
+const (
+	// dumpFileName is the name of the file, in the data directory, that
+	// the mempool is saved to.
+	dumpFileName = "mempool.dat"
+
+	// dumpVersion is the version of the format of the mempool dump.
+	dumpVersion = 1
+
+	// unreadableDumpSuffix is appended to the path of a mempool dump that
+	// couldn't be read, so that it's kept aside instead of being loaded
+	// again on the next startup, or overwritten by the next save.
+	unreadableDumpSuffix = ".unreadable"
+)
+
+// DumpFilePath returns the path of the file that the mempool is saved to in
+// the passed data directory.
+func DumpFilePath(dataDir string) string {
+	return filepath.Join(dataDir, dumpFileName)
+}
+
+// LoadStatus describes the loading of the transactions that were saved by
+// SaveToFile.
+type LoadStatus struct {
+	// IsLoaded is set once all the saved transactions were processed, or
+	// once it's known that there are no saved transactions or that they
+	// can't be read.
+	IsLoaded bool
+
+	// Error describes why the saved transactions couldn't be read, if
+	// they couldn't.
+	Error string
+
+	// Total is the number of saved transactions, including orphans.
+	Total int
+
+	// Accepted is the number of saved transactions that were accepted
+	// to the pool or to the orphan pool.
+	Accepted int
+
+	// Rejected is the number of saved transactions that were rejected,
+	// usually since they had been included in blocks or double spent
+	// while the node was down.
+	Rejected int
+}
+
+// dumpEntry is a transaction saved in a mempool dump.
+type dumpEntry struct {
+	tx       *util.Tx
+	added    mstime.Time
+	tag      Tag
+	isOrphan bool
+}
+
+// SaveToFile saves all the transactions in the pool and in the orphan pool,
+// along with the time they were added to the pool and their tags, to the file
+// at the passed path, so that they could be loaded by LoadFromFile after a
+// restart. The file is replaced atomically.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) SaveToFile(path string) error {
+	tempPath := path + ".new"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	txCount, err := mp.dump(writer)
+	if err != nil {
+		return err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = file.Sync()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = file.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Infof("Saved %d mempool %s to %s", txCount,
+		logger.PickNoun(uint64(txCount), "transaction", "transactions"), path)
+	return nil
+}
+
+// dump writes all the transactions in the pool and in the orphan pool to w,
+// and returns their number. The transactions in the pool are written in
+// topological order, so that every transaction is loaded after its parents.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) dump(w io.Writer) (int, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	err := binaryserializer.PutUint32(w, binary.LittleEndian, dumpVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	txDescs := mp.txDescsInTopologicalOrder()
+	err = binaryserializer.PutUint32(w, binary.LittleEndian, uint32(len(txDescs)))
+	if err != nil {
+		return 0, err
+	}
+	for _, txDesc := range txDescs {
+		err = binaryserializer.PutUint64(w, binary.LittleEndian, uint64(txDesc.Added.UnixMilliseconds()))
+		if err != nil {
+			return 0, err
+		}
+		err = txDesc.Tx.MsgTx().Serialize(w)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = binaryserializer.PutUint32(w, binary.LittleEndian, uint32(len(mp.orphans)))
+	if err != nil {
+		return 0, err
+	}
+	for _, orphan := range mp.orphans {
+		err = binaryserializer.PutUint64(w, binary.LittleEndian, uint64(orphan.tag))
+		if err != nil {
+			return 0, err
+		}
+		err = orphan.tx.MsgTx().Serialize(w)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(txDescs) + len(mp.orphans), nil
+}
+
+// txDescsInTopologicalOrder returns all the transactions in the pool, such
+// that every transaction comes after all of its parents in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txDescsInTopologicalOrder() []*TxDesc {
+	ordered := make([]*TxDesc, 0, len(mp.pool)+len(mp.depends))
+	visited := make(map[daghash.TxID]bool, len(mp.pool)+len(mp.depends))
+	var visit func(txDesc *TxDesc)
+	visit = func(txDesc *TxDesc) {
+		if visited[*txDesc.Tx.ID()] {
+			return
+		}
+		visited[*txDesc.Tx.ID()] = true
+		for _, parent := range mp.txParents(txDesc) {
+			visit(parent)
+		}
+		ordered = append(ordered, txDesc)
+	}
+	for _, txDescs := range []map[daghash.TxID]*TxDesc{mp.pool, mp.depends} {
+		for _, txDesc := range txDescs {
+			visit(txDesc)
+		}
+	}
+	return ordered
+}
+
+// LoadFromFile loads the transactions that were saved by SaveToFile to the
+// file at the passed path. Every transaction is revalidated through
+// ProcessTransaction against the current UTXO set, and the transactions that
+// are accepted keep the time they were originally added to the pool. The
+// progress of the loading is reported by LoadStatus. It's not an error for the
+// file not to exist. If the file can't be read, it's renamed so that it's not
+// read again, and the loading is finished with the error recorded in the
+// LoadStatus, so that the mempool could still be saved.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) LoadFromFile(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		mp.setLoadStatus(func(status *LoadStatus) {
+			status.IsLoaded = true
+		})
+		return nil
+	}
+	if err != nil {
+		return mp.failLoading(path, errors.WithStack(err))
+	}
+	entries, err := readDump(bufio.NewReader(file))
+	file.Close()
+	if err != nil {
+		return mp.failLoading(path, errors.Wrapf(err, "failed to read the mempool dump %s", path))
+	}
+	mp.setLoadStatus(func(status *LoadStatus) {
+		status.Total = len(entries)
+	})
+
+	for _, entry := range entries {
+		err := mp.loadDumpEntry(entry)
+		if err != nil {
+			log.Debugf("Rejected saved transaction %s: %s", entry.tx.ID(), err)
+		}
+		mp.setLoadStatus(func(status *LoadStatus) {
+			if err != nil {
+				status.Rejected++
+			} else {
+				status.Accepted++
+			}
+		})
+	}
+
+	status := mp.setLoadStatus(func(status *LoadStatus) {
+		status.IsLoaded = true
+	})
+	log.Infof("Loaded %d of %d saved mempool %s from %s", status.Accepted, status.Total,
+		logger.PickNoun(uint64(status.Total), "transaction", "transactions"), path)
+	return nil
+}
+
+// failLoading finishes the loading of the mempool dump at the passed path
+// after it failed with the passed error, which is recorded in the LoadStatus
+// and returned. The dump is renamed, so that it's kept for inspection rather
+// than overwritten, and so that it isn't read again on the next startup.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) failLoading(path string, err error) error {
+	unreadablePath := path + unreadableDumpSuffix
+	renameErr := os.Rename(path, unreadablePath)
+	if renameErr != nil {
+		log.Warnf("Couldn't rename the unreadable mempool dump %s: %s", path, renameErr)
+	} else {
+		log.Warnf("Renamed the unreadable mempool dump %s to %s", path, unreadablePath)
+	}
+
+	mp.setLoadStatus(func(status *LoadStatus) {
+		status.IsLoaded = true
+		status.Error = err.Error()
+	})
+	return err
+}
+
+// readDump reads all the entries of a mempool dump from r.
+func readDump(r io.Reader) ([]*dumpEntry, error) {
+	version, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	if version != dumpVersion {
+		return nil, errors.Errorf("unsupported mempool dump version %d", version)
+	}
+
+	readTx := func() (*util.Tx, error) {
+		msgTx := &domainmessage.MsgTx{}
+		err := msgTx.Deserialize(r)
+		if err != nil {
+			return nil, err
+		}
+		return util.NewTx(msgTx), nil
+	}
+
+	txCount, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*dumpEntry
+	for i := uint32(0); i < txCount; i++ {
+		added, err := binaryserializer.Uint64(r, binary.LittleEndian)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := readTx()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &dumpEntry{
+			tx:    tx,
+			added: mstime.UnixMilliseconds(int64(added)),
+		})
+	}
+
+	orphanCount, err := binaryserializer.Uint32(r, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < orphanCount; i++ {
+		tag, err := binaryserializer.Uint64(r, binary.LittleEndian)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := readTx()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &dumpEntry{
+			tx:       tx,
+			tag:      Tag(tag),
+			isOrphan: true,
+		})
+	}
+	return entries, nil
+}
+
+// loadDumpEntry processes a transaction that was saved in a mempool dump.
+// Transactions that were in the pool aren't allowed to become orphans, since
+// their parents were saved before them, and they keep the time they were
+// originally added to the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) loadDumpEntry(entry *dumpEntry) error {
+	if entry.isOrphan {
+		_, err := mp.ProcessTransaction(entry.tx, true, entry.tag)
+		return err
+	}
+	_, err := mp.processTransaction(entry.tx, false, entry.tag, entry.added)
+	return err
+}
+
+// LoadStatus returns the status of loading the transactions that were saved
+// by SaveToFile.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) LoadStatus() LoadStatus {
+	mp.loadStatusLock.RLock()
+	defer mp.loadStatusLock.RUnlock()
+	return mp.loadStatus
+}
+
+// setLoadStatus updates the load status with the passed function and returns
+// the updated status.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) setLoadStatus(update func(status *LoadStatus)) LoadStatus {
+	mp.loadStatusLock.Lock()
+	defer mp.loadStatusLock.Unlock()
+	update(&mp.loadStatus)
+	return mp.loadStatus
+}
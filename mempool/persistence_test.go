@@ -0,0 +1,254 @@
+package mempool
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// TestMempoolPersistence saves a pool with a chain of transactions and an
+// orphan, and checks that they are loaded into a fresh pool with their original
+// added times and tags, and that saved transactions that no longer pass
+// validation are rejected.
+func TestMempoolPersistence(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestMempoolPersistence")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	dataDir, err := ioutil.TempDir("", "TestMempoolPersistence")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+	path := DumpFilePath(dataDir)
+
+	parentTx, err := harness.createTx(outputs[0], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	childTx, err := harness.createTx(txOutToSpendableOutpoint(parentTx, 0), 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	doubleSpentTx, err := harness.createTx(outputs[1], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	for _, tx := range []*util.Tx{parentTx, childTx, doubleSpentTx} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+	}
+	orphanTx, err := harness.createTx(spendableOutpoint{
+		amount:   util.Amount(5000000000),
+		outpoint: domainmessage.Outpoint{TxID: daghash.TxID{}, Index: 1},
+	}, 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	const orphanTag = Tag(7)
+	_, err = harness.txPool.ProcessTransaction(orphanTx, true, orphanTag)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+
+	parentAdded := mstime.Now().Add(-time.Hour)
+	harness.txPool.pool[*parentTx.ID()].Added = parentAdded
+
+	err = harness.txPool.SaveToFile(path)
+	if err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+
+	// Load the saved transactions into a fresh pool, in which the output
+	// spent by doubleSpentTx was already spent by another transaction.
+	harness.txPool = New(&harness.txPool.cfg)
+	conflictingTx, err := harness.createTx(outputs[1], 3000, 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(conflictingTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+
+	if harness.txPool.LoadStatus().IsLoaded {
+		t.Fatalf("LoadStatus: expected the pool not to be loaded before LoadFromFile")
+	}
+	err = harness.txPool.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+
+	expectedStatus := LoadStatus{IsLoaded: true, Total: 4, Accepted: 3, Rejected: 1}
+	if status := harness.txPool.LoadStatus(); status != expectedStatus {
+		t.Fatalf("LoadStatus: expected %+v, got %+v", expectedStatus, status)
+	}
+	testPoolMembership(tc, parentTx, false, true, false)
+	testPoolMembership(tc, childTx, false, true, true)
+	testPoolMembership(tc, doubleSpentTx, false, false, false)
+	testPoolMembership(tc, orphanTx, true, false, false)
+
+	if added := harness.txPool.pool[*parentTx.ID()].Added; added.UnixMilliseconds() != parentAdded.UnixMilliseconds() {
+		t.Errorf("LoadFromFile: expected the parent to be added at %s, got %s", parentAdded, added)
+	}
+	if tag := harness.txPool.orphans[*orphanTx.ID()].tag; tag != orphanTag {
+		t.Errorf("LoadFromFile: expected the orphan to have tag %d, got %d", orphanTag, tag)
+	}
+
+	// A missing file means there's nothing to load.
+	harness.txPool = New(&harness.txPool.cfg)
+	err = harness.txPool.LoadFromFile(filepath.Join(dataDir, "missing.dat"))
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	expectedStatus = LoadStatus{IsLoaded: true}
+	if status := harness.txPool.LoadStatus(); status != expectedStatus {
+		t.Fatalf("LoadStatus: expected %+v, got %+v", expectedStatus, status)
+	}
+}
+
+// TestMempoolPersistenceEvictionOrder checks that transactions loaded from
+// a mempool dump are evicted in the order of their original added times when
+// they have the same fee rate.
+func TestMempoolPersistenceEvictionOrder(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestMempoolPersistenceEvictionOrder")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	dataDir, err := ioutil.TempDir("", "TestMempoolPersistenceEvictionOrder")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+	path := DumpFilePath(dataDir)
+
+	// The parent and the child have the same fee rate, and the parent,
+	// which is loaded first, was added to the pool after the child.
+	parentTx, err := harness.createTx(outputs[0], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	childTx, err := harness.createTx(txOutToSpendableOutpoint(parentTx, 0), 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	for _, tx := range []*util.Tx{parentTx, childTx} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+	}
+	parentTxDesc := harness.txPool.pool[*parentTx.ID()]
+	childTxDesc := harness.txPool.depends[*childTx.ID()]
+	if parentTxDesc.FeePerMegaGram != childTxDesc.FeePerMegaGram {
+		t.Fatalf("expected the parent and the child to have the same fee rate, "+
+			"got %d and %d", parentTxDesc.FeePerMegaGram, childTxDesc.FeePerMegaGram)
+	}
+	parentTxDesc.Added = mstime.Now().Add(-time.Hour)
+	childTxDesc.Added = mstime.Now().Add(-2 * time.Hour)
+
+	err = harness.txPool.SaveToFile(path)
+	if err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	harness.txPool = New(&harness.txPool.cfg)
+	err = harness.txPool.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+
+	// Of the transactions with the same fee rate, the one that was
+	// added last is evicted first.
+	if txDesc := harness.txPool.evictionHeap.peek(); txDesc == nil || !txDesc.Tx.ID().IsEqual(parentTx.ID()) {
+		t.Fatalf("evictionHeap: expected %s to be evicted first", parentTx.ID())
+	}
+}
+
+// TestMempoolPersistenceTruncatedDump checks that a truncated mempool dump
+// finishes the loading with an error, is renamed so that it isn't loaded
+// again, and doesn't prevent the pool from being saved.
+func TestMempoolPersistenceTruncatedDump(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestMempoolPersistenceTruncatedDump")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	dataDir, err := ioutil.TempDir("", "TestMempoolPersistenceTruncatedDump")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+	path := DumpFilePath(dataDir)
+
+	tx, err := harness.createTx(outputs[0], 2000, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(tx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+	err = harness.txPool.SaveToFile(path)
+	if err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+
+	// Cut the dump in the middle of the transaction
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	err = os.Truncate(path, fileInfo.Size()/2)
+	if err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+
+	harness.txPool = New(&harness.txPool.cfg)
+	err = harness.txPool.LoadFromFile(path)
+	if err == nil {
+		t.Fatalf("LoadFromFile: expected an error for a truncated dump")
+	}
+	status := harness.txPool.LoadStatus()
+	if !status.IsLoaded || status.Error == "" || status.Total != 0 {
+		t.Fatalf("LoadStatus: expected the loading to finish with an error and "+
+			"no transactions, got %+v", status)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("LoadFromFile: expected the truncated dump to be renamed")
+	}
+	if _, err := os.Stat(path + unreadableDumpSuffix); err != nil {
+		t.Fatalf("LoadFromFile: expected the truncated dump to be kept: %v", err)
+	}
+
+	err = harness.txPool.SaveToFile(path)
+	if err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	harness.txPool = New(&harness.txPool.cfg)
+	err = harness.txPool.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	expectedStatus := LoadStatus{IsLoaded: true}
+	if status := harness.txPool.LoadStatus(); status != expectedStatus {
+		t.Fatalf("LoadStatus: expected %+v, got %+v", expectedStatus, status)
+	}
+}
@@ -49,8 +49,36 @@ const (
 	// considered dust and as a base for calculating minimum required fees
 	// for larger transactions. This value is in sompi/1000 bytes.
 	DefaultMinRelayTxFee = util.Amount(1000)
+
+	// MaxReplaceableSequenceNum is the maximum sequence number of an input
+	// for the transaction that contains it to signal that it may be
+	// replaced in the mempool by a conflicting transaction that pays a
+	// higher fee. A transaction is replaceable if it, or any of its
+	// unconfirmed ancestors, has at least one such input.
+	MaxReplaceableSequenceNum = domainmessage.MaxTxInSequenceNum - 2
+
+	// DefaultMaxReplacementEvictions is the default maximum number of
+	// transactions, including descendants, that a single replacement
+	// transaction may evict from the mempool.
+	DefaultMaxReplacementEvictions = 100
+
+	// MaxPackageTxs is the maximum number of transactions in a package
+	// that is submitted to the mempool as a unit.
+	MaxPackageTxs = 25
 )
 
+// signalsReplacement returns whether the passed transaction signals that it
+// may be replaced by a conflicting transaction that pays a higher fee. See
+// MaxReplaceableSequenceNum for details.
+func signalsReplacement(tx *util.Tx) bool {
+	for _, txIn := range tx.MsgTx().TxIn {
+		if txIn.Sequence <= MaxReplaceableSequenceNum {
+			return true
+		}
+	}
+	return false
+}
+
 // calcMinRequiredTxRelayFee returns the minimum transaction fee required for a
 // transaction with the passed serialized size to be accepted into the memory
 // pool and relayed.
@@ -0,0 +1,202 @@
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// ProcessPackage handles the insertion of a package of transactions into the
+// memory pool as a unit: either all of the transactions are accepted, or none
+// of them are. The transactions must be ordered so that every transaction
+// comes after its parents in the package, and none of them may be an orphan
+// once its preceding transactions are accepted. Since replaced transactions
+// can't be restored if a later transaction in the package is rejected, the
+// transactions may not conflict with transactions in the pool.
+//
+// The minimum fees are checked against the package as a whole, so a
+// transaction that pays too little may be accepted along with a child that
+// pays for it. Room is made for the package in a full pool only once all of
+// it was validated, and only if none of its transactions would be evicted.
+//
+// It returns a slice of transactions added to the mempool, starting with the
+// transactions of the package in their order, followed by any orphans that
+// were accepted as a result. If one of the transactions is rejected, the
+// returned error is a PackageError that identifies it.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ProcessPackage(txs []*util.Tx) ([]*TxDesc, error) {
+	log.Tracef("Processing a package of %d transactions", len(txs))
+
+	err := checkPackageSanity(txs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Protect concurrent access.
+	mp.cfg.DAG.RLock()
+	defer mp.cfg.DAG.RUnlock()
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	// Every transaction is validated against the pool with its preceding
+	// transactions in the package added to it. Since nothing else is
+	// removed from the pool until the whole package is validated, adding
+	// the transactions is undone exactly if the package is rejected.
+	packageTxDescs := make([]*TxDesc, 0, len(txs))
+	var nextBlockBlueScore uint64
+	for i, tx := range txs {
+		check, err := mp.checkPackageTransactionAcceptance(tx)
+		if err != nil {
+			removeErr := mp.removePackageTransactions(packageTxDescs)
+			if removeErr != nil {
+				return nil, removeErr
+			}
+			return nil, PackageError{TxIndex: i, Err: err}
+		}
+		txD, err := mp.addTransaction(tx, check.fee, check.mass, check.parentsInPool, mstime.Now())
+		if err != nil {
+			// addTransaction may fail after the transaction was
+			// already added to the pool, in which case it's removed
+			// along with the preceding transactions.
+			if txD, exists := mp.fetchTxDesc(tx.ID()); exists {
+				packageTxDescs = append(packageTxDescs, txD)
+			}
+			removeErr := mp.removePackageTransactions(packageTxDescs)
+			if removeErr != nil {
+				return nil, removeErr
+			}
+			return nil, err
+		}
+		packageTxDescs = append(packageTxDescs, txD)
+		nextBlockBlueScore = check.nextBlockBlueScore
+	}
+
+	err = mp.checkPackageFees(packageTxDescs)
+	if err != nil {
+		removeErr := mp.removePackageTransactions(packageTxDescs)
+		if removeErr != nil {
+			return nil, removeErr
+		}
+		return nil, err
+	}
+
+	err = mp.limitPoolMass()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txD := range packageTxDescs {
+		if mp.cfg.FeeEstimator != nil {
+			mp.cfg.FeeEstimator.ObserveTransaction(txD, nextBlockBlueScore)
+		}
+		log.Debugf("Accepted transaction %s of a package (pool size: %d)",
+			txD.Tx.ID(), len(mp.pool))
+	}
+
+	acceptedTxs := append([]*TxDesc{}, packageTxDescs...)
+	for _, txD := range packageTxDescs {
+		acceptedTxs = append(acceptedTxs, mp.processOrphans(txD.Tx)...)
+	}
+	return acceptedTxs, nil
+}
+
+// checkPackageSanity performs the checks on a package of transactions that
+// don't depend on the state of the memory pool.
+func checkPackageSanity(txs []*util.Tx) error {
+	if len(txs) == 0 {
+		return txRuleError(RejectInvalid, "package has no transactions")
+	}
+	if len(txs) > MaxPackageTxs {
+		str := fmt.Sprintf("package has %d transactions, which is more "+
+			"than the maximum of %d", len(txs), MaxPackageTxs)
+		return txRuleError(RejectNonstandard, str)
+	}
+
+	txIDs := make(map[daghash.TxID]struct{}, len(txs))
+	for i, tx := range txs {
+		if _, exists := txIDs[*tx.ID()]; exists {
+			str := fmt.Sprintf("transaction %s appears more than "+
+				"once in the package", tx.ID())
+			return PackageError{TxIndex: i, Err: txRuleError(RejectInvalid, str)}
+		}
+		txIDs[*tx.ID()] = struct{}{}
+	}
+	return nil
+}
+
+// checkPackageTransactionAcceptance runs the checks that a single transaction
+// of a package has to pass in order to be added to the memory pool, except for
+// the minimum fee checks, which apply to the package as a whole. Unlike
+// checkTransactionAcceptance, it rejects transactions that would replace
+// transactions in the pool and orphan transactions.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) checkPackageTransactionAcceptance(tx *util.Tx) (*acceptanceCheck, error) {
+	for _, txIn := range tx.MsgTx().TxIn {
+		if txR, exists := mp.outpoints[txIn.PreviousOutpoint]; exists {
+			str := fmt.Sprintf("output %s already spent by "+
+				"transaction %s in the memory pool",
+				txIn.PreviousOutpoint, txR.ID())
+			return nil, txRuleError(RejectDuplicate, str)
+		}
+	}
+
+	check, err := mp.checkTransactionAcceptance(tx, true, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(check.missingParents) > 0 {
+		return nil, orphanRejectionError(tx, check.missingParents)
+	}
+	return check, nil
+}
+
+// checkPackageFees checks that the transactions of a package, which were
+// added to the pool, pay enough fees as a whole, and that room could be made
+// for them in the pool without evicting any of them.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) checkPackageFees(packageTxDescs []*TxDesc) error {
+	fee, mass, serializedSize := uint64(0), uint64(0), int64(0)
+	for _, txD := range packageTxDescs {
+		fee += txD.Fee
+		mass += txD.Mass
+		serializedSize += int64(txD.Tx.MsgTx().SerializeSize())
+	}
+	err := mp.checkFees("package", fee, mass, serializedSize)
+	if err != nil {
+		return err
+	}
+
+	txsToEvict := mp.txsToEvict()
+	for i, txD := range packageTxDescs {
+		if _, ok := txsToEvict[*txD.Tx.ID()]; ok {
+			str := fmt.Sprintf("transaction %s would be evicted since the "+
+				"mempool is full and its fee rate is too low", txD.Tx.ID())
+			return PackageError{TxIndex: i, Err: txRuleError(RejectInsufficientFee, str)}
+		}
+	}
+	return nil
+}
+
+// removePackageTransactions removes the passed transactions of a rejected
+// package from the memory pool, children before their parents, so that the
+// outputs the package spent are restored.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removePackageTransactions(txDescs []*TxDesc) error {
+	for i := len(txDescs) - 1; i >= 0; i-- {
+		tx := txDescs[i].Tx
+		if _, exists := mp.fetchTxDesc(tx.ID()); !exists {
+			continue
+		}
+		err := mp.removeTransaction(tx, true, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TestProcessPackage checks that packages are accepted to the pool as a
+// unit, and that the transactions of rejected packages are removed from it.
+func TestProcessPackage(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestProcessPackage")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	createTx := func(outpoint spendableOutpoint, fee uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		return tx
+	}
+	parentTx := createTx(outputs[0], uint64(txRelayFeeForTest))
+	childTx := createTx(txOutToSpendableOutpoint(parentTx, 0), uint64(txRelayFeeForTest))
+	zeroFeeChildTx := createTx(txOutToSpendableOutpoint(parentTx, 0), 0)
+
+	tests := []struct {
+		name               string
+		txs                []*util.Tx
+		expectedTxIndex    int
+		expectedRejectCode RejectCode
+	}{
+		{
+			name:               "no transactions",
+			txs:                nil,
+			expectedTxIndex:    -1,
+			expectedRejectCode: RejectInvalid,
+		},
+		{
+			name:               "duplicate transaction",
+			txs:                []*util.Tx{parentTx, parentTx},
+			expectedTxIndex:    1,
+			expectedRejectCode: RejectInvalid,
+		},
+		{
+			name:               "child before parent",
+			txs:                []*util.Tx{childTx, parentTx},
+			expectedTxIndex:    0,
+			expectedRejectCode: RejectDuplicate,
+		},
+		{
+			name:               "rejected child",
+			txs:                []*util.Tx{parentTx, zeroFeeChildTx},
+			expectedTxIndex:    1,
+			expectedRejectCode: RejectInsufficientFee,
+		},
+	}
+	for _, test := range tests {
+		_, err := harness.txPool.ProcessPackage(test.txs)
+		if err == nil {
+			t.Fatalf("%s: ProcessPackage: expected an error", test.name)
+		}
+		var packageErr PackageError
+		isPackageErr := errors.As(err, &packageErr)
+		if test.expectedTxIndex < 0 && isPackageErr {
+			t.Errorf("%s: ProcessPackage: expected an error about the whole "+
+				"package, got an error about transaction %d", test.name, packageErr.TxIndex)
+		}
+		if test.expectedTxIndex >= 0 && (!isPackageErr || packageErr.TxIndex != test.expectedTxIndex) {
+			t.Errorf("%s: ProcessPackage: expected an error about transaction %d, got: %v",
+				test.name, test.expectedTxIndex, err)
+		}
+		rejectCode, _ := ErrToRejectErr(err)
+		if rejectCode != test.expectedRejectCode {
+			t.Errorf("%s: ProcessPackage: expected reject code %s, got %s",
+				test.name, test.expectedRejectCode, rejectCode)
+		}
+		for _, tx := range test.txs {
+			testPoolMembership(tc, tx, false, false, false)
+		}
+	}
+
+	// The outputs spent by the rejected packages were
+	// restored, so the package can now be accepted.
+	acceptedTxs, err := harness.txPool.ProcessPackage([]*util.Tx{parentTx, childTx})
+	if err != nil {
+		t.Fatalf("ProcessPackage: %v", err)
+	}
+	if len(acceptedTxs) != 2 {
+		t.Fatalf("ProcessPackage: expected 2 accepted transactions, got %d", len(acceptedTxs))
+	}
+	testPoolMembership(tc, parentTx, false, true, false)
+	testPoolMembership(tc, childTx, false, true, true)
+
+	// A package may not conflict with transactions in the pool.
+	conflictingTx := createTx(txOutToSpendableOutpoint(parentTx, 0), 2*uint64(txRelayFeeForTest))
+	_, err = harness.txPool.ProcessPackage([]*util.Tx{conflictingTx})
+	rejectCode, _ := ErrToRejectErr(err)
+	if rejectCode != RejectDuplicate {
+		t.Errorf("ProcessPackage: expected reject code %s for a conflicting "+
+			"package, got %s", RejectDuplicate, rejectCode)
+	}
+}
+
+// TestProcessPackageFees checks that the minimum fees apply to packages as a
+// whole, and that packages that wouldn't fit in a full pool are rejected
+// without evicting any other transactions.
+func TestProcessPackageFees(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 7, "TestProcessPackageFees")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	createTx := func(outpoint spendableOutpoint, fee uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		return tx
+	}
+
+	// A parent that pays less than the minimum relay fee is
+	// rejected on its own, but accepted along with a child that
+	// pays for it.
+	lowFeeParentTx := createTx(outputs[0], 10)
+	highFeeChildTx := createTx(txOutToSpendableOutpoint(lowFeeParentTx, 0), 10*uint64(txRelayFeeForTest))
+	_, err = harness.txPool.ProcessTransaction(lowFeeParentTx, false, 0)
+	if code, _ := extractRejectCode(err); code != RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s for a low fee "+
+			"transaction, got %v", RejectInsufficientFee, err)
+	}
+	_, err = harness.txPool.ProcessPackage([]*util.Tx{lowFeeParentTx, highFeeChildTx})
+	if err != nil {
+		t.Fatalf("ProcessPackage: %v", err)
+	}
+	testPoolMembership(tc, lowFeeParentTx, false, true, false)
+	testPoolMembership(tc, highFeeChildTx, false, true, true)
+
+	// Fill the pool up to its limit with transactions whose fee
+	// rates are higher than the one of lowFeeParentTx.
+	var fillerTxs []*util.Tx
+	for i := 1; i <= 4; i++ {
+		fillerTx := createTx(outputs[i], uint64(txRelayFeeForTest))
+		_, err := harness.txPool.ProcessTransaction(fillerTx, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %v", err)
+		}
+		fillerTxs = append(fillerTxs, fillerTx)
+	}
+	harness.txPool.cfg.Policy.MaxPoolMass = harness.txPool.TotalMass()
+
+	// A package whose parent has the lowest fee rate in the pool would
+	// be evicted right away, so it's rejected without evicting anything.
+	anotherLowFeeParentTx := createTx(outputs[5], 5)
+	anotherHighFeeChildTx := createTx(txOutToSpendableOutpoint(anotherLowFeeParentTx, 0), 10*uint64(txRelayFeeForTest))
+	_, err = harness.txPool.ProcessPackage([]*util.Tx{anotherLowFeeParentTx, anotherHighFeeChildTx})
+	var packageErr PackageError
+	if !errors.As(err, &packageErr) || packageErr.TxIndex != 0 {
+		t.Fatalf("ProcessPackage: expected an error about transaction 0, got: %v", err)
+	}
+	if code, _ := extractRejectCode(err); code != RejectInsufficientFee {
+		t.Fatalf("ProcessPackage: expected reject code %s, got %s", RejectInsufficientFee, code)
+	}
+	testPoolMembership(tc, anotherLowFeeParentTx, false, false, false)
+	testPoolMembership(tc, anotherHighFeeChildTx, false, false, false)
+	testPoolMembership(tc, lowFeeParentTx, false, true, false)
+	testPoolMembership(tc, highFeeChildTx, false, true, true)
+	for _, fillerTx := range fillerTxs {
+		testPoolMembership(tc, fillerTx, false, true, false)
+	}
+	if minFeeRate := harness.txPool.MinFeeRate(); minFeeRate != harness.txPool.cfg.Policy.MinRelayTxFee {
+		t.Fatalf("MinFeeRate: expected the minimum relay fee %d after a rejected "+
+			"package, got %d", harness.txPool.cfg.Policy.MinRelayTxFee, minFeeRate)
+	}
+
+	// A package whose transactions all pay more than the rest of the
+	// pool evicts the transactions with the lowest fee rates once.
+	highFeeParentTx := createTx(outputs[6], 10*uint64(txRelayFeeForTest))
+	highFeeChildTx = createTx(txOutToSpendableOutpoint(highFeeParentTx, 0), 10*uint64(txRelayFeeForTest))
+	_, err = harness.txPool.ProcessPackage([]*util.Tx{highFeeParentTx, highFeeChildTx})
+	if err != nil {
+		t.Fatalf("ProcessPackage: %v", err)
+	}
+	testPoolMembership(tc, highFeeParentTx, false, true, false)
+	testPoolMembership(tc, highFeeChildTx, false, true, true)
+	testPoolMembership(tc, lowFeeParentTx, false, false, false)
+	if harness.txPool.TotalMass() > harness.txPool.cfg.Policy.MaxPoolMass {
+		t.Fatalf("TotalMass: expected at most %d, got %d",
+			harness.txPool.cfg.Policy.MaxPoolMass, harness.txPool.TotalMass())
+	}
+	if minFeeRate := harness.txPool.MinFeeRate(); minFeeRate <= harness.txPool.cfg.Policy.MinRelayTxFee {
+		t.Fatalf("MinFeeRate: expected more than the minimum relay fee %d after "+
+			"evicting transactions, got %d", harness.txPool.cfg.Policy.MinRelayTxFee, minFeeRate)
+	}
+}
+
+// failingAddTxUTXOSet is a UTXOSet that fails to add the transaction with
+// the given ID.
+type failingAddTxUTXOSet struct {
+	blockdag.UTXOSet
+	failingTxID *daghash.TxID
+}
+
+func (s *failingAddTxUTXOSet) AddTx(tx *domainmessage.MsgTx, blockBlueScore uint64) (bool, error) {
+	if tx.TxID().IsEqual(s.failingTxID) {
+		return false, errors.Errorf("failed to add transaction %s", s.failingTxID)
+	}
+	return s.UTXOSet.AddTx(tx, blockBlueScore)
+}
+
+// TestProcessPackageAddFailure checks that if adding a transaction of a
+// package to the pool fails, the transactions of the package that were
+// already added are removed from it.
+func TestProcessPackageAddFailure(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestProcessPackageAddFailure")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	parentTx, err := harness.createTx(outputs[0], uint64(txRelayFeeForTest), 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	childTx, err := harness.createTx(txOutToSpendableOutpoint(parentTx, 0), uint64(txRelayFeeForTest), 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+
+	harness.txPool.mpUTXOSet = &failingAddTxUTXOSet{
+		UTXOSet:     harness.txPool.mpUTXOSet,
+		failingTxID: childTx.ID(),
+	}
+	_, err = harness.txPool.ProcessPackage([]*util.Tx{parentTx, childTx})
+	if err == nil {
+		t.Fatalf("ProcessPackage: expected an error")
+	}
+	testPoolMembership(tc, parentTx, false, false, false)
+	testPoolMembership(tc, childTx, false, false, false)
+	if _, ok := harness.txPool.mpUTXOSet.Get(outputs[0].outpoint); !ok {
+		t.Fatalf("mpUTXOSet: expected the output spent by the " +
+			"rejected package to be restored")
+	}
+
+	if failingUTXOSet, ok := harness.txPool.mpUTXOSet.(*failingAddTxUTXOSet); ok {
+		harness.txPool.mpUTXOSet = failingUTXOSet.UTXOSet
+	}
+	_, err = harness.txPool.ProcessPackage([]*util.Tx{parentTx, childTx})
+	if err != nil {
+		t.Fatalf("ProcessPackage: %v", err)
+	}
+	testPoolMembership(tc, parentTx, false, true, false)
+	testPoolMembership(tc, childTx, false, true, true)
+}
@@ -35,10 +35,25 @@ type TxDesc struct {
 	// Fee is the total fee the transaction associated with the entry pays.
 	Fee uint64
 
+	// Mass is the mass of the transaction associated with the entry.
+	Mass uint64
+
 	// FeePerMegaGram is the fee the transaction pays in sompi per million gram.
 	FeePerMegaGram uint64
 }
 
+// DependantTxDesc is a descriptor about a transaction in a transaction source
+// that spends outputs of other transactions in the source, and therefore can't
+// be included in a block before they are included in previous blocks.
+type DependantTxDesc struct {
+	// TxDesc describes the dependant transaction itself.
+	TxDesc *TxDesc
+
+	// Ancestors describes all the transactions in the source that the
+	// dependant transaction spends from, directly or indirectly.
+	Ancestors []*TxDesc
+}
+
 // TxSource represents a source of transactions to consider for inclusion in
 // new blocks.
 //
@@ -50,9 +65,15 @@ type TxSource interface {
 	LastUpdated() mstime.Time
 
 	// MiningDescs returns a slice of mining descriptors for all the
-	// transactions in the source pool.
+	// transactions in the source pool that don't depend on other
+	// transactions in it.
 	MiningDescs() []*TxDesc
 
+	// DependantMiningDescs returns a slice of descriptors for all the
+	// transactions in the source pool that depend on other transactions
+	// in it, along with their ancestors.
+	DependantMiningDescs() []*DependantTxDesc
+
 	// HaveTransaction returns whether or not the passed transaction hash
 	// exists in the source pool.
 	HaveTransaction(txID *daghash.TxID) bool
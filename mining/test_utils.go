@@ -26,6 +26,10 @@ func (txs *fakeTxSource) MiningDescs() []*TxDesc {
 	return txs.txDescs
 }
 
+func (txs *fakeTxSource) DependantMiningDescs() []*DependantTxDesc {
+	return nil
+}
+
 func (txs *fakeTxSource) HaveTransaction(txID *daghash.TxID) bool {
 	for _, desc := range txs.txDescs {
 		if *desc.Tx.ID() == *txID {
@@ -4,9 +4,11 @@ import (
 	"math"
 	"math/rand"
 	"sort"
+	"strings"
 
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
 	"github.com/kaspanet/kaspad/util/subnetworkid"
 )
 
@@ -27,13 +29,34 @@ const (
 	rebalanceThreshold = 0.95
 )
 
-type candidateTx struct {
-	txDesc  *TxDesc
-	txValue float64
+// randomFloat64 draws the random numbers that candidates are selected by.
+// Tests replace it with a seeded source in order to make the selection
+// reproducible.
+var randomFloat64 = rand.Float64
+
+// selectableTx is a source transaction that may be included in the next
+// block.
+type selectableTx struct {
+	txDesc *TxDesc
 
 	txMass   uint64
 	gasLimit uint64
 
+	isSelected bool
+}
+
+// candidateTx is a candidate for inclusion in the next block. It's either
+// a single selectableTx, or a package of the selectableTxs that a dependant
+// transaction spends from, directly or through other dependant
+// transactions. Since a block can't contain a transaction along with the
+// transactions it spends from, the dependant transaction itself can only
+// be included in a later block, but it lends its fee to the package so
+// that the package's transactions are selected atomically and the
+// dependant is unblocked (child-pays-for-parent).
+type candidateTx struct {
+	txs     []*selectableTx
+	txValue float64
+
 	p     float64
 	start float64
 	end   float64
@@ -51,13 +74,18 @@ type txsForBlockTemplate struct {
 
 // selectTxs implements a probabilistic transaction selection algorithm.
 // The algorithm, roughly, is as follows:
-// 1. We assign a probability to each transaction equal to:
+// 1. We assign a probability to each candidate equal to:
 //    (candidateTx.Value^alpha) / Σ(tx.Value^alpha)
-//    Where the sum of the probabilities of all txs is 1.
-// 2. We draw a random number in [0,1) and select a transaction accordingly.
-// 3. If it's valid, add it to the selectedTxs and remove it from the candidates.
+//    Where the sum of the probabilities of all candidates is 1.
+//    A candidate is either a single transaction or a package of the
+//    transactions a dependant transaction spends from, in which case its
+//    value is derived from the fee rate of the dependant along with all
+//    of its ancestors.
+// 2. We draw a random number in [0,1) and select a candidate accordingly.
+// 3. If all of its transactions that weren't selected yet fit in the block,
+//    add them to the selectedTxs and remove the candidate from the candidates.
 // 4. Continue iterating the above until we have either selected all
-//    available transactions or ran out of gas/block space.
+//    available candidates or ran out of gas/block space.
 //
 // Note that we make two optimizations here:
 // * Draw a number in [0,Σ(tx.Value^alpha)) to avoid normalization
@@ -68,6 +96,7 @@ type txsForBlockTemplate struct {
 func (g *BlkTmplGenerator) selectTxs(payToAddress util.Address, extraNonce uint64) (*txsForBlockTemplate, error) {
 	// Fetch the source transactions.
 	sourceTxs := g.txSource.MiningDescs()
+	dependantTxs := g.txSource.DependantMiningDescs()
 
 	// Create a new txsForBlockTemplate struct, onto which all selectedTxs
 	// will be appended.
@@ -78,9 +107,9 @@ func (g *BlkTmplGenerator) selectTxs(payToAddress util.Address, extraNonce uint6
 
 	// Collect candidateTxs while excluding txs that will certainly not
 	// be selected.
-	candidateTxs := g.collectCandidatesTxs(sourceTxs)
+	candidateTxs := g.collectCandidatesTxs(sourceTxs, dependantTxs)
 
-	log.Debugf("Considering %d transactions for inclusion to new block",
+	log.Debugf("Considering %d candidates for inclusion to new block",
 		len(candidateTxs))
 
 	// Choose which transactions make it into the block.
@@ -127,11 +156,15 @@ func (g *BlkTmplGenerator) newTxsForBlockTemplate(payToAddress util.Address, ext
 }
 
 // collectCandidateTxs goes over the sourceTxs and collects only the ones that
-// may be included in the next block.
-func (g *BlkTmplGenerator) collectCandidatesTxs(sourceTxs []*TxDesc) []*candidateTx {
+// may be included in the next block, each as a candidate of its own. It then
+// goes over the dependantTxs and collects a package candidate for every
+// distinct set of selectable transactions that a dependant transaction spends
+// from.
+func (g *BlkTmplGenerator) collectCandidatesTxs(sourceTxs []*TxDesc, dependantTxs []*DependantTxDesc) []*candidateTx {
 	nextBlockBlueScore := g.dag.VirtualBlueScore()
 
 	candidateTxs := make([]*candidateTx, 0, len(sourceTxs))
+	selectableTxs := make(map[daghash.TxID]*selectableTx, len(sourceTxs))
 	for _, txDesc := range sourceTxs {
 		tx := txDesc.Tx
 
@@ -167,51 +200,134 @@ func (g *BlkTmplGenerator) collectCandidatesTxs(sourceTxs []*TxDesc) []*candidat
 		}
 
 		// Calculate the tx value
-		txValue, err := g.calcTxValue(tx, txDesc.Fee)
+		txValue, err := g.calcTxValue([]*TxDesc{txDesc}, txMass)
 		if err != nil {
 			log.Warnf("Skipping tx %s due to error in "+
 				"calcTxValue: %s", tx.ID(), err)
 			continue
 		}
 
-		candidateTxs = append(candidateTxs, &candidateTx{
+		selectable := &selectableTx{
 			txDesc:   txDesc,
-			txValue:  txValue,
 			txMass:   txMass,
 			gasLimit: gasLimit,
+		}
+		selectableTxs[*tx.ID()] = selectable
+		candidateTxs = append(candidateTxs, &candidateTx{
+			txs:     []*selectableTx{selectable},
+			txValue: txValue,
 		})
 	}
 
-	// Sort the candidate txs by subnetworkID.
-	sort.Slice(candidateTxs, func(i, j int) bool {
-		return subnetworkid.Less(&candidateTxs[i].txDesc.Tx.MsgTx().SubnetworkID,
-			&candidateTxs[j].txDesc.Tx.MsgTx().SubnetworkID)
-	})
+	isDependant := make(map[daghash.TxID]bool, len(dependantTxs))
+	for _, dependantTx := range dependantTxs {
+		isDependant[*dependantTx.TxDesc.Tx.ID()] = true
+	}
+
+	// Dependant transactions that spend from the same selectable
+	// transactions share a single package candidate, which takes
+	// the highest value among them.
+	packageCandidates := make(map[string]*candidateTx)
+	for _, dependantTx := range dependantTxs {
+		packageTxs, packageTxDescs, ok := packageOf(dependantTx, selectableTxs, isDependant)
+		if !ok {
+			continue
+		}
+
+		packageMass := uint64(0)
+		for _, packageTx := range packageTxs {
+			packageMass += packageTx.txMass
+		}
+		for _, txDesc := range packageTxDescs {
+			if _, ok := selectableTxs[*txDesc.Tx.ID()]; !ok {
+				packageMass += txDesc.Mass
+			}
+		}
+		txValue, err := g.calcTxValue(packageTxDescs, packageMass)
+		if err != nil {
+			log.Warnf("Skipping the package of tx %s due to error in "+
+				"calcTxValue: %s", dependantTx.TxDesc.Tx.ID(), err)
+			continue
+		}
+
+		key := packageKey(packageTxs)
+		if candidate, ok := packageCandidates[key]; ok {
+			if txValue > candidate.txValue {
+				candidate.txValue = txValue
+			}
+			continue
+		}
+		candidate := &candidateTx{
+			txs:     packageTxs,
+			txValue: txValue,
+		}
+		packageCandidates[key] = candidate
+		candidateTxs = append(candidateTxs, candidate)
+	}
 
 	return candidateTxs
 }
 
-// calcTxValue calculates a value to be used in transaction selection.
-// The higher the number the more likely it is that the transaction will be
-// included in the block.
-func (g *BlkTmplGenerator) calcTxValue(tx *util.Tx, fee uint64) (float64, error) {
-	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, g.dag.UTXOSet())
-	if err != nil {
-		return 0, err
+// packageOf returns the selectable transactions that the passed dependant
+// transaction spends from, directly or through other dependant
+// transactions, along with the descriptors of the dependant and all of its
+// ancestors. It returns false if some of the ancestors can't be included in
+// a block, in which case neither can the dependant.
+func packageOf(dependantTx *DependantTxDesc, selectableTxs map[daghash.TxID]*selectableTx,
+	isDependant map[daghash.TxID]bool) ([]*selectableTx, []*TxDesc, bool) {
+
+	packageTxs := make([]*selectableTx, 0, len(dependantTx.Ancestors))
+	packageTxDescs := make([]*TxDesc, 0, len(dependantTx.Ancestors)+1)
+	packageTxDescs = append(packageTxDescs, dependantTx.TxDesc)
+	for _, ancestor := range dependantTx.Ancestors {
+		ancestorID := ancestor.Tx.ID()
+		if selectable, ok := selectableTxs[*ancestorID]; ok {
+			packageTxs = append(packageTxs, selectable)
+		} else if !isDependant[*ancestorID] {
+			return nil, nil, false
+		}
+		packageTxDescs = append(packageTxDescs, ancestor)
 	}
-	massLimit := g.policy.BlockMaxMass
+	if len(packageTxs) == 0 {
+		return nil, nil, false
+	}
+	return packageTxs, packageTxDescs, true
+}
 
-	msgTx := tx.MsgTx()
-	if msgTx.SubnetworkID.IsBuiltInOrNative() {
-		return float64(fee) / (float64(mass) / float64(massLimit)), nil
+// packageKey returns a key that uniquely identifies the set of the passed
+// selectable transactions.
+func packageKey(packageTxs []*selectableTx) string {
+	txIDs := make([]string, len(packageTxs))
+	for i, packageTx := range packageTxs {
+		txIDs[i] = packageTx.txDesc.Tx.ID().String()
 	}
+	sort.Strings(txIDs)
+	return strings.Join(txIDs, ",")
+}
 
-	gas := msgTx.Gas
-	gasLimit, err := g.dag.GasLimit(&msgTx.SubnetworkID)
-	if err != nil {
-		return 0, err
+// calcTxValue calculates a value to be used in transaction selection for
+// the passed transactions, whose total mass is the passed mass.
+// The higher the number the more likely it is that the transactions will be
+// included in the block.
+func (g *BlkTmplGenerator) calcTxValue(txDescs []*TxDesc, mass uint64) (float64, error) {
+	massLimit := g.policy.BlockMaxMass
+
+	fee := uint64(0)
+	gasUsage := 0.0
+	for _, txDesc := range txDescs {
+		fee += txDesc.Fee
+
+		msgTx := txDesc.Tx.MsgTx()
+		if msgTx.SubnetworkID.IsBuiltInOrNative() {
+			continue
+		}
+		gasLimit, err := g.dag.GasLimit(&msgTx.SubnetworkID)
+		if err != nil {
+			return 0, err
+		}
+		gasUsage += float64(msgTx.Gas) / float64(gasLimit)
 	}
-	return float64(fee) / (float64(mass)/float64(massLimit) + float64(gas)/float64(gasLimit)), nil
+	return float64(fee) / (float64(mass)/float64(massLimit) + gasUsage), nil
 }
 
 // populateTemplateFromCandidates loops over the candidate transactions
@@ -229,7 +345,7 @@ func (g *BlkTmplGenerator) populateTemplateFromCandidates(candidateTxs []*candid
 		usedP += candidateTx.p
 	}
 
-	selectedTxs := make([]*candidateTx, 0)
+	selectedTxs := make([]*selectableTx, 0)
 	for len(candidateTxs)-usedCount > 0 {
 		// Rebalance the candidates if it's required
 		if usedP >= rebalanceThreshold*totalP {
@@ -243,68 +359,71 @@ func (g *BlkTmplGenerator) populateTemplateFromCandidates(candidateTxs []*candid
 		}
 
 		// Select a candidate tx at random
-		r := rand.Float64()
+		r := randomFloat64()
 		r *= totalP
-		selectedTx := findTx(candidateTxs, r)
+		selectedCandidate := findTx(candidateTxs, r)
 
 		// If isMarkedForDeletion is set, it means we got a collision.
 		// Ignore and select another Tx.
-		if selectedTx.isMarkedForDeletion {
+		if selectedCandidate.isMarkedForDeletion {
+			continue
+		}
+
+		// Only the transactions that weren't already selected as
+		// part of other candidates are added to the block.
+		txsToAdd := make([]*selectableTx, 0, len(selectedCandidate.txs))
+		for _, tx := range selectedCandidate.txs {
+			if !tx.isSelected {
+				txsToAdd = append(txsToAdd, tx)
+			}
+		}
+		if len(txsToAdd) == 0 {
+			markCandidateTxForDeletion(selectedCandidate)
 			continue
 		}
-		tx := selectedTx.txDesc.Tx
+		firstTxID := txsToAdd[0].txDesc.Tx.ID()
 
 		// Enforce maximum transaction mass per block. Also check
 		// for overflow.
-		if txsForBlockTemplate.totalMass+selectedTx.txMass < txsForBlockTemplate.totalMass ||
-			txsForBlockTemplate.totalMass+selectedTx.txMass > g.policy.BlockMaxMass {
-			log.Tracef("Tx %s would exceed the max block mass. "+
-				"As such, stopping.", tx.ID())
-			break
+		candidateMass := uint64(0)
+		for _, tx := range txsToAdd {
+			candidateMass += tx.txMass
+		}
+		if txsForBlockTemplate.totalMass+candidateMass < txsForBlockTemplate.totalMass ||
+			txsForBlockTemplate.totalMass+candidateMass > g.policy.BlockMaxMass {
+			log.Tracef("Candidate of tx %s (%d txs) would exceed the max "+
+				"block mass. As such, skipping it.", firstTxID, len(txsToAdd))
+			markCandidateTxForDeletion(selectedCandidate)
+			continue
 		}
 
 		// Enforce maximum gas per subnetwork per block. Also check
 		// for overflow.
-		if !tx.MsgTx().SubnetworkID.IsBuiltInOrNative() {
-			subnetworkID := tx.MsgTx().SubnetworkID
-			gasUsage, ok := gasUsageMap[subnetworkID]
-			if !ok {
-				gasUsage = 0
-			}
-			txGas := tx.MsgTx().Gas
-			if gasUsage+txGas < gasUsage ||
-				gasUsage+txGas > selectedTx.gasLimit {
-				log.Tracef("Tx %s would exceed the gas limit in "+
-					"subnetwork %s. Removing all remaining txs from this "+
-					"subnetwork.",
-					tx.MsgTx().TxID(), subnetworkID)
-				for _, candidateTx := range candidateTxs {
-					// candidateTxs are ordered by subnetwork, so we can safely assume
-					// that transactions after subnetworkID will not be relevant.
-					if subnetworkid.Less(&subnetworkID, &candidateTx.txDesc.Tx.MsgTx().SubnetworkID) {
-						break
-					}
-
-					if candidateTx.txDesc.Tx.MsgTx().SubnetworkID.IsEqual(&subnetworkID) {
-						markCandidateTxForDeletion(candidateTx)
-					}
-				}
-				continue
-			}
-			gasUsageMap[subnetworkID] = gasUsage + txGas
+		candidateGasUsageMap, ok := candidateGasUsage(txsToAdd, gasUsageMap)
+		if !ok {
+			log.Tracef("Candidate of tx %s (%d txs) would exceed the gas "+
+				"limit of its subnetwork. As such, skipping it.", firstTxID, len(txsToAdd))
+			markCandidateTxForDeletion(selectedCandidate)
+			continue
+		}
+		for subnetworkID, gasUsage := range candidateGasUsageMap {
+			gasUsageMap[subnetworkID] = gasUsage
 		}
 
-		// Add the transaction to the result, increment counters, and
+		// Add the transactions to the result, increment counters, and
 		// save the masses, fees, and signature operation counts to the
 		// result.
-		selectedTxs = append(selectedTxs, selectedTx)
-		txsForBlockTemplate.totalMass += selectedTx.txMass
-		txsForBlockTemplate.totalFees += selectedTx.txDesc.Fee
-
-		log.Tracef("Adding tx %s (feePerMegaGram %d)",
-			tx.ID(), selectedTx.txDesc.FeePerMegaGram)
+		for _, tx := range txsToAdd {
+			tx.isSelected = true
+			selectedTxs = append(selectedTxs, tx)
+			txsForBlockTemplate.totalMass += tx.txMass
+			txsForBlockTemplate.totalFees += tx.txDesc.Fee
+
+			log.Tracef("Adding tx %s (feePerMegaGram %d)",
+				tx.txDesc.Tx.ID(), tx.txDesc.FeePerMegaGram)
+		}
 
-		markCandidateTxForDeletion(selectedTx)
+		markCandidateTxForDeletion(selectedCandidate)
 	}
 
 	sort.Slice(selectedTxs, func(i, j int) bool {
@@ -318,6 +437,32 @@ func (g *BlkTmplGenerator) populateTemplateFromCandidates(candidateTxs []*candid
 	}
 }
 
+// candidateGasUsage returns the gas usage of every subnetwork that the
+// passed transactions belong to, as it would be after adding them to the
+// block, given the current gasUsageMap. It returns false if the gas limit of
+// any of these subnetworks would be exceeded.
+func candidateGasUsage(txs []*selectableTx, gasUsageMap map[subnetworkid.SubnetworkID]uint64) (
+	map[subnetworkid.SubnetworkID]uint64, bool) {
+
+	candidateGasUsageMap := make(map[subnetworkid.SubnetworkID]uint64)
+	for _, tx := range txs {
+		msgTx := tx.txDesc.Tx.MsgTx()
+		if msgTx.SubnetworkID.IsBuiltInOrNative() {
+			continue
+		}
+		gasUsage, ok := candidateGasUsageMap[msgTx.SubnetworkID]
+		if !ok {
+			gasUsage = gasUsageMap[msgTx.SubnetworkID]
+		}
+		if gasUsage+msgTx.Gas < gasUsage ||
+			gasUsage+msgTx.Gas > tx.gasLimit {
+			return nil, false
+		}
+		candidateGasUsageMap[msgTx.SubnetworkID] = gasUsage + msgTx.Gas
+	}
+	return candidateGasUsageMap, true
+}
+
 func rebalanceCandidates(oldCandidateTxs []*candidateTx, isFirstRun bool) (
 	candidateTxs []*candidateTx, totalP float64) {
 
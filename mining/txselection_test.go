@@ -0,0 +1,257 @@
+package mining
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// packageTxSource is an implementation of TxSource that derives the
+// dependencies between its transactions from their inputs. If
+// ignoreDependants is set, it doesn't report dependant transactions,
+// which makes the selection consider every transaction on its own.
+type packageTxSource struct {
+	txDescs          []*TxDesc
+	ignoreDependants bool
+}
+
+func (s *packageTxSource) LastUpdated() mstime.Time {
+	return mstime.UnixMilliseconds(0)
+}
+
+func (s *packageTxSource) parents(txDesc *TxDesc) []*TxDesc {
+	var parents []*TxDesc
+	for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+		for _, other := range s.txDescs {
+			if *other.Tx.ID() == txIn.PreviousOutpoint.TxID {
+				parents = append(parents, other)
+			}
+		}
+	}
+	return parents
+}
+
+func (s *packageTxSource) MiningDescs() []*TxDesc {
+	var descs []*TxDesc
+	for _, txDesc := range s.txDescs {
+		if len(s.parents(txDesc)) == 0 {
+			descs = append(descs, txDesc)
+		}
+	}
+	return descs
+}
+
+func (s *packageTxSource) DependantMiningDescs() []*DependantTxDesc {
+	if s.ignoreDependants {
+		return nil
+	}
+	var descs []*DependantTxDesc
+	for _, txDesc := range s.txDescs {
+		ancestors := s.parents(txDesc)
+		if len(ancestors) == 0 {
+			continue
+		}
+		for i := 0; i < len(ancestors); i++ {
+			ancestors = append(ancestors, s.parents(ancestors[i])...)
+		}
+		descs = append(descs, &DependantTxDesc{TxDesc: txDesc, Ancestors: ancestors})
+	}
+	return descs
+}
+
+func (s *packageTxSource) HaveTransaction(txID *daghash.TxID) bool {
+	for _, txDesc := range s.txDescs {
+		if *txDesc.Tx.ID() == *txID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *packageTxSource) remove(txs []*domainmessage.MsgTx) {
+	remaining := make([]*TxDesc, 0, len(s.txDescs))
+	for _, txDesc := range s.txDescs {
+		isRemoved := false
+		for _, tx := range txs {
+			if *tx.TxID() == *txDesc.Tx.ID() {
+				isRemoved = true
+				break
+			}
+		}
+		if !isRemoved {
+			remaining = append(remaining, txDesc)
+		}
+	}
+	s.txDescs = remaining
+}
+
+// TestSelectTxsPackages checks that a low-fee parent whose child pays a high
+// fee is preferred over a transaction that pays a medium fee, so that the
+// child is unblocked, and that it makes the fees of two consecutive block
+// templates higher than they are when every transaction is selected on its
+// own.
+func TestSelectTxsPackages(t *testing.T) {
+	// The selection is probabilistic, so a seeded source is used in
+	// order for the collected fees to be the same on every run.
+	defer func(originalRandomFloat64 func() float64) {
+		randomFloat64 = originalRandomFloat64
+	}(randomFloat64)
+
+	const (
+		lowFee      = 1000
+		mediumFee   = 100000
+		highFee     = 10000000
+		packageSize = 4
+	)
+
+	collectFees := func(ignoreDependants bool) uint64 {
+		randomFloat64 = rand.New(rand.NewSource(0)).Float64
+
+		params := dagconfig.SimnetParams
+		params.BlockCoinbaseMaturity = 0
+		dag, teardownFunc, err := blockdag.DAGSetup("TestSelectTxsPackages", true, blockdag.Config{
+			DAGParams: &params,
+		})
+		if err != nil {
+			t.Fatalf("Failed to setup dag instance: %v", err)
+		}
+		defer teardownFunc()
+
+		processBlock := func(msgBlock *domainmessage.MsgBlock) {
+			isOrphan, isDelayed, err := dag.ProcessBlock(util.NewBlock(msgBlock), blockdag.BFNoPoWCheck)
+			if err != nil {
+				t.Fatalf("ProcessBlock: %v", err)
+			}
+			if isOrphan || isDelayed {
+				t.Fatalf("ProcessBlock: block is unexpectedly an orphan or delayed")
+			}
+		}
+
+		block1, err := PrepareBlockForTest(dag, []*daghash.Hash{params.GenesisHash}, nil, false)
+		if err != nil {
+			t.Fatalf("PrepareBlockForTest: %v", err)
+		}
+		processBlock(block1)
+
+		signatureScript, err := txscript.PayToScriptHashSignatureScript(blockdag.OpTrueScript, nil)
+		if err != nil {
+			t.Fatalf("Failed to build signature script: %s", err)
+		}
+		scriptPubKey, err := txscript.PayToScriptHashScript(blockdag.OpTrueScript)
+		if err != nil {
+			t.Fatalf("Failed to build public key script: %s", err)
+		}
+		createTx := func(outpoint domainmessage.Outpoint, amount uint64, fee uint64, numOutputs int) *domainmessage.MsgTx {
+			txIn := &domainmessage.TxIn{
+				PreviousOutpoint: outpoint,
+				SignatureScript:  signatureScript,
+				Sequence:         domainmessage.MaxTxInSequenceNum,
+			}
+			txOuts := make([]*domainmessage.TxOut, numOutputs)
+			for i := range txOuts {
+				txOuts[i] = &domainmessage.TxOut{
+					ScriptPubKey: scriptPubKey,
+					Value:        (amount - fee) / uint64(numOutputs),
+				}
+			}
+			return domainmessage.NewNativeMsgTx(domainmessage.TxVersion, []*domainmessage.TxIn{txIn}, txOuts)
+		}
+
+		// Split a coinbase output to fund packageSize parents with
+		// children, and packageSize transactions without children.
+		cbTx := block1.Transactions[util.CoinbaseTransactionIndex]
+		fundingTx := createTx(domainmessage.Outpoint{TxID: *cbTx.TxID(), Index: 0},
+			cbTx.TxOut[0].Value, 1, 2*packageSize)
+		block2, err := PrepareBlockForTest(dag, []*daghash.Hash{block1.BlockHash()},
+			[]*domainmessage.MsgTx{fundingTx}, false)
+		if err != nil {
+			t.Fatalf("PrepareBlockForTest: %v", err)
+		}
+		processBlock(block2)
+
+		txSource := &packageTxSource{ignoreDependants: ignoreDependants}
+		addTx := func(msgTx *domainmessage.MsgTx, fee uint64, mass uint64) {
+			txSource.txDescs = append(txSource.txDescs, &TxDesc{
+				Tx:             util.NewTx(msgTx),
+				Fee:            fee,
+				Mass:           mass,
+				FeePerMegaGram: fee * 1e6 / mass,
+			})
+		}
+		fundingAmount := fundingTx.TxOut[0].Value
+		txMass := uint64(0)
+		for i := 0; i < packageSize; i++ {
+			parentTx := createTx(domainmessage.Outpoint{TxID: *fundingTx.TxID(), Index: uint32(i)},
+				fundingAmount, lowFee, 1)
+			txMass, err = blockdag.CalcTxMassFromUTXOSet(util.NewTx(parentTx), dag.UTXOSet())
+			if err != nil {
+				t.Fatalf("CalcTxMassFromUTXOSet: %v", err)
+			}
+			addTx(parentTx, lowFee, txMass)
+
+			childTx := createTx(domainmessage.Outpoint{TxID: *parentTx.TxID(), Index: 0},
+				parentTx.TxOut[0].Value, highFee, 1)
+			addTx(childTx, highFee, txMass)
+
+			mediumFeeTx := createTx(domainmessage.Outpoint{TxID: *fundingTx.TxID(), Index: uint32(packageSize + i)},
+				fundingAmount, mediumFee, 1)
+			addTx(mediumFeeTx, mediumFee, txMass)
+		}
+
+		policy := &Policy{}
+		generator := NewBlkTmplGenerator(policy, txSource, dag, txscript.NewSigCache(100000))
+		payToAddress, err := OpTrueAddress(params.Prefix)
+		if err != nil {
+			t.Fatalf("OpTrueAddress: %v", err)
+		}
+
+		// Every template has room for packageSize transactions.
+		totalFees := uint64(0)
+		for i := 0; i < 2; i++ {
+			policy.BlockMaxMass = 0
+			generator.txSource = &fakeTxSource{}
+			emptyTemplate, err := generator.NewBlockTemplate(payToAddress, GenerateDeterministicExtraNonceForTest())
+			if err != nil {
+				t.Fatalf("NewBlockTemplate: %v", err)
+			}
+			policy.BlockMaxMass = emptyTemplate.TxMasses[0] + packageSize*txMass + txMass/2
+			generator.txSource = txSource
+
+			template, err := generator.NewBlockTemplate(payToAddress, GenerateDeterministicExtraNonceForTest())
+			if err != nil {
+				t.Fatalf("NewBlockTemplate: %v", err)
+			}
+			if len(template.Block.Transactions) != packageSize+1 {
+				t.Fatalf("NewBlockTemplate: expected %d transactions, got %d",
+					packageSize+1, len(template.Block.Transactions))
+			}
+			for _, fee := range template.Fees[1:] {
+				totalFees += fee
+			}
+			txSource.remove(template.Block.Transactions)
+			processBlock(template.Block)
+		}
+		return totalFees
+	}
+
+	packageAwareFees := collectFees(false)
+	expectedPackageAwareFees := uint64(packageSize * (lowFee + highFee))
+	if packageAwareFees != expectedPackageAwareFees {
+		t.Errorf("expected the package-aware templates to collect %d in fees, got %d",
+			expectedPackageAwareFees, packageAwareFees)
+	}
+
+	perTxFees := collectFees(true)
+	if perTxFees >= packageAwareFees {
+		t.Errorf("expected the package-aware templates to collect more than the %d in fees "+
+			"of the templates that consider every transaction on its own, got %d",
+			perTxFees, packageAwareFees)
+	}
+}
@@ -76,6 +76,11 @@ func (c *NetConnection) NetAddress() *domainmessage.NetAddress {
 	return domainmessage.NewNetAddress(c.connection.Address(), 0)
 }
 
+// Stats returns the traffic statistics of this connection
+func (c *NetConnection) Stats() *server.ConnectionStats {
+	return c.connection.Stats()
+}
+
 // SetOnInvalidMessageHandler sets a handler function
 // for invalid messages
 func (c *NetConnection) SetOnInvalidMessageHandler(onInvalidMessageHandler server.OnInvalidMessageHandler) {
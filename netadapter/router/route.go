@@ -17,11 +17,11 @@ const (
 
 var (
 	// ErrTimeout signifies that one of the router functions had a timeout.
-	ErrTimeout = protocolerrors.New(false, "timeout expired")
+	ErrTimeout = protocolerrors.New(protocolerrors.BanScoreNone, "timeout expired")
 
 	// ErrRouteClosed indicates that a route was closed while reading/writing.
 	// TODO(libp2p): Remove protocol error here
-	ErrRouteClosed = protocolerrors.New(false, "route is closed")
+	ErrRouteClosed = protocolerrors.New(protocolerrors.BanScoreNone, "route is closed")
 )
 
 // onCapacityReachedHandler is a function that is to be
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/golang/protobuf/proto"
 	"github.com/kaspanet/kaspad/logger"
 
 	"github.com/kaspanet/kaspad/netadapter/server/grpcserver/protowire"
@@ -56,7 +57,7 @@ func (c *gRPCConnection) sendLoop() error {
 		if err != nil {
 			return err
 		}
-
+		c.stats.RecordSent(message.Command(), proto.Size(messageProto))
 	}
 	return nil
 }
@@ -77,6 +78,8 @@ func (c *gRPCConnection) receiveLoop() error {
 			return err
 		}
 
+		c.stats.RecordReceived(message.Command(), proto.Size(protoMessage))
+
 		messageNumber++
 		message.SetMessageNumber(messageNumber)
 		message.SetReceivedAt(time.Now())
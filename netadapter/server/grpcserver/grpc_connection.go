@@ -17,6 +17,7 @@ type gRPCConnection struct {
 	isOutbound bool
 	stream     grpcStream
 	router     *router.Router
+	stats      *server.StatsTracker
 
 	stopChan                chan struct{}
 	clientConn              grpc.ClientConn
@@ -26,12 +27,13 @@ type gRPCConnection struct {
 	isConnected uint32
 }
 
-func newConnection(server *gRPCServer, address *net.TCPAddr, isOutbound bool, stream grpcStream) *gRPCConnection {
+func newConnection(grpcServer *gRPCServer, address *net.TCPAddr, isOutbound bool, stream grpcStream) *gRPCConnection {
 	connection := &gRPCConnection{
-		server:      server,
+		server:      grpcServer,
 		address:     address,
 		isOutbound:  isOutbound,
 		stream:      stream,
+		stats:       server.NewStatsTracker(),
 		stopChan:    make(chan struct{}),
 		isConnected: 1,
 	}
@@ -97,3 +99,10 @@ func (c *gRPCConnection) Disconnect() {
 func (c *gRPCConnection) Address() *net.TCPAddr {
 	return c.address
 }
+
+// Stats returns the traffic statistics of the connection
+//
+// This is part of the Connection interface
+func (c *gRPCConnection) Stats() *server.ConnectionStats {
+	return c.stats.Stats()
+}
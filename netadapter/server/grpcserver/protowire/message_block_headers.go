@@ -0,0 +1,40 @@
+package protowire
+
+import (
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/pkg/errors"
+)
+
+func (x *KaspadMessage_BlockHeaders) toDomainMessage() (domainmessage.Message, error) {
+	if len(x.BlockHeaders.BlockHeaders) > domainmessage.MaxBlockHeadersPerMsg {
+		return nil, errors.Errorf("too many block headers for message "+
+			"[count %d, max %d]", len(x.BlockHeaders.BlockHeaders), domainmessage.MaxBlockHeadersPerMsg)
+	}
+	blockHeaders := make([]*domainmessage.BlockHeader, len(x.BlockHeaders.BlockHeaders))
+	for i, protoBlockHeader := range x.BlockHeaders.BlockHeaders {
+		if protoBlockHeader == nil {
+			return nil, errors.New("block header field cannot be nil")
+		}
+		blockHeader, err := protoBlockHeader.toWire()
+		if err != nil {
+			return nil, err
+		}
+		blockHeaders[i] = blockHeader
+	}
+	return &domainmessage.MsgBlockHeaders{BlockHeaders: blockHeaders}, nil
+}
+
+func (x *KaspadMessage_BlockHeaders) fromDomainMessage(msgBlockHeaders *domainmessage.MsgBlockHeaders) error {
+	if len(msgBlockHeaders.BlockHeaders) > domainmessage.MaxBlockHeadersPerMsg {
+		return errors.Errorf("too many block headers for message "+
+			"[count %d, max %d]", len(msgBlockHeaders.BlockHeaders), domainmessage.MaxBlockHeadersPerMsg)
+	}
+	protoBlockHeaders := make([]*BlockHeader, len(msgBlockHeaders.BlockHeaders))
+	for i, blockHeader := range msgBlockHeaders.BlockHeaders {
+		protoBlockHeaders[i] = wireBlockHeaderToProto(blockHeader)
+	}
+	x.BlockHeaders = &BlockHeadersMessage{
+		BlockHeaders: protoBlockHeaders,
+	}
+	return nil
+}
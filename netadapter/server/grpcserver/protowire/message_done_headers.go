@@ -0,0 +1,11 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/domainmessage"
+
+func (x *KaspadMessage_DoneHeaders) toDomainMessage() (domainmessage.Message, error) {
+	return &domainmessage.MsgDoneHeaders{}, nil
+}
+
+func (x *KaspadMessage_DoneHeaders) fromDomainMessage(_ *domainmessage.MsgDoneHeaders) error {
+	return nil
+}
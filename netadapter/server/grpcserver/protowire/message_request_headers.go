@@ -0,0 +1,28 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/domainmessage"
+
+func (x *KaspadMessage_RequestHeaders) toDomainMessage() (domainmessage.Message, error) {
+	lowHash, err := x.RequestHeaders.LowHash.toWire()
+	if err != nil {
+		return nil, err
+	}
+
+	highHash, err := x.RequestHeaders.HighHash.toWire()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domainmessage.MsgRequestHeaders{
+		LowHash:  lowHash,
+		HighHash: highHash,
+	}, nil
+}
+
+func (x *KaspadMessage_RequestHeaders) fromDomainMessage(msgRequestHeaders *domainmessage.MsgRequestHeaders) error {
+	x.RequestHeaders = &RequestHeadersMessage{
+		LowHash:  wireHashToProto(msgRequestHeaders.LowHash),
+		HighHash: wireHashToProto(msgRequestHeaders.HighHash),
+	}
+	return nil
+}
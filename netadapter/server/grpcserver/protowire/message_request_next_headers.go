@@ -0,0 +1,11 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/domainmessage"
+
+func (x *KaspadMessage_RequestNextHeaders) toDomainMessage() (domainmessage.Message, error) {
+	return &domainmessage.MsgRequestNextHeaders{}, nil
+}
+
+func (x *KaspadMessage_RequestNextHeaders) fromDomainMessage(_ *domainmessage.MsgRequestNextHeaders) error {
+	return nil
+}
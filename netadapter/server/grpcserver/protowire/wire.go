@@ -174,6 +174,34 @@ func toPayload(message domainmessage.Message) (isKaspadMessage_Payload, error) {
 			return nil, err
 		}
 		return payload, nil
+	case *domainmessage.MsgRequestHeaders:
+		payload := new(KaspadMessage_RequestHeaders)
+		err := payload.fromDomainMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *domainmessage.MsgBlockHeaders:
+		payload := new(KaspadMessage_BlockHeaders)
+		err := payload.fromDomainMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *domainmessage.MsgRequestNextHeaders:
+		payload := new(KaspadMessage_RequestNextHeaders)
+		err := payload.fromDomainMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case *domainmessage.MsgDoneHeaders:
+		payload := new(KaspadMessage_DoneHeaders)
+		err := payload.fromDomainMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
 	default:
 		return nil, errors.Errorf("unknown message type %T", message)
 	}
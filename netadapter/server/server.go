@@ -40,6 +40,7 @@ type Connection interface {
 	SetOnDisconnectedHandler(onDisconnectedHandler OnDisconnectedHandler)
 	SetOnInvalidMessageHandler(onInvalidMessageHandler OnInvalidMessageHandler)
 	Address() *net.TCPAddr
+	Stats() *ConnectionStats
 }
 
 // ErrNetwork is an error related to the internals of the connection, and not an error that
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// ConnectionStats holds the traffic statistics of a Connection.
+type ConnectionStats struct {
+	BytesSent        uint64
+	BytesReceived    uint64
+	MessagesSent     map[domainmessage.MessageCommand]uint64
+	MessagesReceived map[domainmessage.MessageCommand]uint64
+	LastSend         mstime.Time
+	LastReceive      mstime.Time
+}
+
+// StatsTracker tracks the traffic statistics of a Connection.
+// It is safe for concurrent access.
+type StatsTracker struct {
+	lock  sync.Mutex
+	stats ConnectionStats
+}
+
+// NewStatsTracker returns a new StatsTracker with no recorded traffic.
+func NewStatsTracker() *StatsTracker {
+	return &StatsTracker{
+		stats: ConnectionStats{
+			MessagesSent:     make(map[domainmessage.MessageCommand]uint64),
+			MessagesReceived: make(map[domainmessage.MessageCommand]uint64),
+		},
+	}
+}
+
+// RecordSent records that a message with the given command and
+// serialized size was sent.
+func (t *StatsTracker) RecordSent(command domainmessage.MessageCommand, size int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.stats.BytesSent += uint64(size)
+	t.stats.MessagesSent[command]++
+	t.stats.LastSend = mstime.Now()
+}
+
+// RecordReceived records that a message with the given command and
+// serialized size was received.
+func (t *StatsTracker) RecordReceived(command domainmessage.MessageCommand, size int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.stats.BytesReceived += uint64(size)
+	t.stats.MessagesReceived[command]++
+	t.stats.LastReceive = mstime.Now()
+}
+
+// Stats returns a copy of the statistics recorded so far.
+func (t *StatsTracker) Stats() *ConnectionStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats := t.stats
+	stats.MessagesSent = make(map[domainmessage.MessageCommand]uint64, len(t.stats.MessagesSent))
+	for command, count := range t.stats.MessagesSent {
+		stats.MessagesSent[command] = count
+	}
+	stats.MessagesReceived = make(map[domainmessage.MessageCommand]uint64, len(t.stats.MessagesReceived))
+	for command, count := range t.stats.MessagesReceived {
+		stats.MessagesReceived[command] = count
+	}
+	return &stats
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"sync/atomic"
 
+	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/netadapter/router"
+	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
 
 	"github.com/kaspanet/kaspad/protocol/protocolerrors"
 )
@@ -29,3 +31,39 @@ func (*FlowContext) HandleError(err error, flowName string, isStopping *uint32,
 		errChan <- err
 	}
 }
+
+// AddBanScore adds the passed score to the ban score of the peer, for
+// misbehavior that doesn't require disconnecting from the peer by itself.
+// The ban score is kept per IP, so it survives reconnects of the peer.
+// Once the ban score of the peer reaches the ban threshold, it returns a
+// ProtocolError that requires banning the peer, which the calling flow
+// should return in order to get the peer banned.
+func (f *FlowContext) AddBanScore(peer *peerpkg.Peer, score uint32, reason string) error {
+	return f.addBanScore(peer.Connection().NetAddress(), peer.String(), score, reason)
+}
+
+// addBanScore adds the passed score to the ban score of the IP of the
+// given address. peerString is used for logging only.
+func (f *FlowContext) addBanScore(address *domainmessage.NetAddress, peerString string, score uint32, reason string) error {
+	banScore := f.addressManager.AddBanScore(address, score)
+	log.Debugf("Increased the ban score of %s by %d to %d (reason: %s)", peerString, score, banScore, reason)
+	if f.cfg.DisableBanning || banScore < f.cfg.BanThreshold {
+		return nil
+	}
+	return protocolerrors.BanErrorf("ban score reached %d (reason: %s)", banScore, reason)
+}
+
+// HandleProtocolError adds the ban score of the passed ProtocolError, which
+// terminated the flows of a peer, to the ban score of the IP of the given
+// address. The IP is banned if the error requires it, or if its ban score
+// reached the ban threshold. peerString is used for logging only.
+func (f *FlowContext) HandleProtocolError(address *domainmessage.NetAddress, peerString string,
+	protocolErr *protocolerrors.ProtocolError) error {
+
+	banScore := f.addressManager.AddBanScore(address, protocolErr.BanScore)
+	if f.cfg.DisableBanning || (!protocolErr.ShouldBan && banScore < f.cfg.BanThreshold) {
+		return nil
+	}
+	log.Warnf("Banning %s with ban score %d (reason: %s)", peerString, banScore, protocolErr.Cause)
+	return f.addressManager.Ban(address)
+}
@@ -0,0 +1,126 @@
+package flowcontext
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/addressmanager"
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/protocol/protocolerrors"
+)
+
+func TestAddBanScore(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "TestAddBanScore")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dbPath)
+	databaseContext, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.BanThreshold = 100
+	f := &FlowContext{
+		cfg:            cfg,
+		addressManager: addressmanager.New(cfg, databaseContext),
+	}
+
+	address := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 16111, 0)
+	reconnectedAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 16112, 0)
+
+	err = f.addBanScore(address, "peer", protocolerrors.BanScoreModerate, "test")
+	if err != nil {
+		t.Fatalf("addBanScore: expected no error below the ban threshold, got: %s", err)
+	}
+
+	// The ban score should survive reconnecting, so misbehaving again
+	// from another connection of the same IP reaches the threshold.
+	err = f.addBanScore(reconnectedAddress, "peer", protocolerrors.BanScoreModerate, "test")
+	protocolErr := &protocolerrors.ProtocolError{}
+	if !errors.As(err, &protocolErr) {
+		t.Fatalf("addBanScore: expected a ProtocolError once the ban threshold "+
+			"is reached, got: %v", err)
+	}
+	if protocolErr.BanScore != protocolerrors.BanScoreNone {
+		t.Fatalf("addBanScore: expected the returned error not to add to the ban "+
+			"score, got ban score %d", protocolErr.BanScore)
+	}
+	if !protocolErr.ShouldBan {
+		t.Fatalf("addBanScore: expected the returned error to require banning the peer")
+	}
+
+	// No error should be returned once the ban threshold is reached if
+	// banning is disabled.
+	cfg.DisableBanning = true
+	err = f.addBanScore(address, "peer", protocolerrors.BanScoreModerate, "test")
+	if err != nil {
+		t.Fatalf("addBanScore: expected no error when banning is disabled, got: %s", err)
+	}
+}
+
+func TestHandleProtocolError(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "TestHandleProtocolError")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dbPath)
+	databaseContext, err := dbaccess.New(dbPath)
+	if err != nil {
+		t.Fatalf("error creating db: %s", err)
+	}
+	defer databaseContext.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.BanThreshold = 100
+	cfg.BanDuration = time.Hour
+	f := &FlowContext{
+		cfg:            cfg,
+		addressManager: addressmanager.New(cfg, databaseContext),
+	}
+
+	// The ban score decays between reaching the ban threshold and
+	// handling the returned error, so the peer should be banned even
+	// though its ban score is slightly below the threshold by then.
+	address := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 16111, 0)
+	err = f.addBanScore(address, "peer", cfg.BanThreshold, "test")
+	protocolErr := &protocolerrors.ProtocolError{}
+	if !errors.As(err, &protocolErr) {
+		t.Fatalf("addBanScore: expected a ProtocolError once the ban threshold "+
+			"is reached, got: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	err = f.HandleProtocolError(address, "peer", protocolErr)
+	if err != nil {
+		t.Fatalf("HandleProtocolError: %s", err)
+	}
+	bannedNetworks := f.addressManager.BannedNetworks()
+	if len(bannedNetworks) != 1 || !bannedNetworks[0].Network.Contains(address.IP) {
+		t.Fatalf("HandleProtocolError: expected %s to be banned, got banned "+
+			"networks: %v", address.IP, bannedNetworks)
+	}
+
+	// An error below the ban threshold only adds to the ban score.
+	otherAddress := domainmessage.NewNetAddressIPPort(net.ParseIP("173.194.115.67"), 16111, 0)
+	err = f.HandleProtocolError(otherAddress, "other peer",
+		&protocolerrors.ProtocolError{BanScore: protocolerrors.BanScoreModerate})
+	if err != nil {
+		t.Fatalf("HandleProtocolError: %s", err)
+	}
+	if isBanned, _ := f.addressManager.IsBanned(otherAddress); isBanned {
+		t.Fatalf("HandleProtocolError: expected %s not to be banned below the "+
+			"ban threshold", otherAddress.IP)
+	}
+	if banScore := f.addressManager.BanScore(otherAddress); banScore != protocolerrors.BanScoreModerate {
+		t.Fatalf("HandleProtocolError: expected ban score %d, got %d",
+			protocolerrors.BanScoreModerate, banScore)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/kaspanet/kaspad/netadapter"
 	"github.com/kaspanet/kaspad/netadapter/id"
 	"github.com/kaspanet/kaspad/protocol/flows/blockrelay"
+	"github.com/kaspanet/kaspad/protocol/flows/ibd"
 	"github.com/kaspanet/kaspad/protocol/flows/relaytransactions"
 	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
 	"github.com/kaspanet/kaspad/util"
@@ -39,6 +40,12 @@ type FlowContext struct {
 	startIBDMutex sync.Mutex
 	ibdPeer       *peerpkg.Peer
 
+	ibdBlockDownloadSchedulerMutex sync.RWMutex
+	ibdBlockDownloadScheduler      *ibd.BlockDownloadScheduler
+
+	ibdResumeHashMutex sync.RWMutex
+	ibdResumeHash      *daghash.Hash
+
 	peers      map[*id.ID]*peerpkg.Peer
 	peersMutex sync.RWMutex
 }
@@ -48,7 +55,7 @@ func New(cfg *config.Config, dag *blockdag.BlockDAG, addressManager *addressmana
 	txPool *mempool.TxPool, netAdapter *netadapter.NetAdapter,
 	connectionManager *connmanager.ConnectionManager) *FlowContext {
 
-	return &FlowContext{
+	flowContext := &FlowContext{
 		cfg:                         cfg,
 		netAdapter:                  netAdapter,
 		dag:                         dag,
@@ -60,4 +67,6 @@ func New(cfg *config.Config, dag *blockdag.BlockDAG, addressManager *addressmana
 		peers:                       make(map[*id.ID]*peerpkg.Peer),
 		transactionsToRebroadcast:   make(map[daghash.TxID]*util.Tx),
 	}
+	txPool.Subscribe(flowContext.handleMempoolNotification)
+	return flowContext
 }
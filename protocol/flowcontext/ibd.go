@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/protocol/flows/ibd"
 	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
+	"github.com/kaspanet/kaspad/util/daghash"
 )
 
 // StartIBDIfRequired selects a peer and starts IBD against it
@@ -15,6 +17,9 @@ func (f *FlowContext) StartIBDIfRequired() {
 	defer f.startIBDMutex.Unlock()
 
 	if f.IsInIBD() {
+		// Peers that connect during IBD may help
+		// downloading the blocks of the current round
+		f.requestIBDBlockDownloadHelp()
 		return
 	}
 
@@ -35,15 +40,24 @@ func (f *FlowContext) IsInIBD() bool {
 }
 
 // selectPeerForIBD returns the first peer whose selected tip
-// hash is not in our DAG
+// hash is not in our DAG. Peers that were deprioritized for IBD,
+// as well as pruned peers that might not have the blocks we're
+// missing, are selected only if there's no other such peer.
 func (f *FlowContext) selectPeerForIBD(dag *blockdag.BlockDAG) *peerpkg.Peer {
+	var fallbackPeer *peerpkg.Peer
 	for _, peer := range f.peers {
 		peerSelectedTipHash := peer.SelectedTipHash()
-		if !dag.IsInDAG(peerSelectedTipHash) {
+		if dag.IsInDAG(peerSelectedTipHash) {
+			continue
+		}
+		if !peer.IsDeprioritizedForIBD() && !peer.IsPruned() {
 			return peer
 		}
+		if fallbackPeer == nil {
+			fallbackPeer = peer
+		}
 	}
-	return nil
+	return fallbackPeer
 }
 
 func (f *FlowContext) requestSelectedTipsIfRequired() {
@@ -64,8 +78,66 @@ func (f *FlowContext) requestSelectedTips() {
 	}
 }
 
+// StartIBDBlockDownload sets the scheduler of the blocks that are
+// downloaded in the current IBD round, and requests all the other
+// peers whose selected tip isn't in our DAG to help downloading them.
+func (f *FlowContext) StartIBDBlockDownload(scheduler *ibd.BlockDownloadScheduler) {
+	f.setIBDBlockDownloadScheduler(scheduler)
+	f.requestIBDBlockDownloadHelp()
+}
+
+func (f *FlowContext) requestIBDBlockDownloadHelp() {
+	if f.IBDBlockDownloadScheduler() == nil {
+		return
+	}
+	for _, peer := range f.Peers() {
+		if peer == f.ibdPeer || f.dag.IsInDAG(peer.SelectedTipHash()) {
+			continue
+		}
+		peer.RequestIBDBlockDownload()
+	}
+}
+
+// IBDBlockDownloadScheduler returns the scheduler of the blocks that are
+// downloaded in the current IBD round.
+// Returns nil if we aren't currently downloading IBD blocks
+func (f *FlowContext) IBDBlockDownloadScheduler() *ibd.BlockDownloadScheduler {
+	f.ibdBlockDownloadSchedulerMutex.RLock()
+	defer f.ibdBlockDownloadSchedulerMutex.RUnlock()
+
+	return f.ibdBlockDownloadScheduler
+}
+
+func (f *FlowContext) setIBDBlockDownloadScheduler(scheduler *ibd.BlockDownloadScheduler) {
+	f.ibdBlockDownloadSchedulerMutex.Lock()
+	defer f.ibdBlockDownloadSchedulerMutex.Unlock()
+
+	f.ibdBlockDownloadScheduler = scheduler
+}
+
+// IBDResumeHash returns the hash of the highest block that was shared
+// with the last IBD peer, from which the search for the highest block
+// that is shared with the next IBD peer starts.
+// Returns nil if there was no IBD yet
+func (f *FlowContext) IBDResumeHash() *daghash.Hash {
+	f.ibdResumeHashMutex.RLock()
+	defer f.ibdResumeHashMutex.RUnlock()
+
+	return f.ibdResumeHash
+}
+
+// SetIBDResumeHash sets the hash of the highest block that is shared
+// with the current IBD peer.
+func (f *FlowContext) SetIBDResumeHash(hash *daghash.Hash) {
+	f.ibdResumeHashMutex.Lock()
+	defer f.ibdResumeHashMutex.Unlock()
+
+	f.ibdResumeHash = hash
+}
+
 // FinishIBD finishes the current IBD flow and starts a new one if required.
 func (f *FlowContext) FinishIBD() {
+	f.setIBDBlockDownloadScheduler(nil)
 	f.ibdPeer = nil
 
 	atomic.StoreUint32(&f.isInIBD, 0)
@@ -0,0 +1,75 @@
+package flowcontext
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/netadapter"
+	"github.com/kaspanet/kaspad/netadapter/id"
+	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+func TestSelectPeerForIBD(t *testing.T) {
+	params := dagconfig.SimnetParams
+	dag, teardownFunc, err := blockdag.DAGSetup("TestSelectPeerForIBD", true, blockdag.Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG: %s", err)
+	}
+	defer teardownFunc()
+
+	unknownTipHash := &daghash.Hash{1}
+	newPeer := func(selectedTipHash *daghash.Hash, services domainmessage.ServiceFlag) *peerpkg.Peer {
+		peer := peerpkg.New(&netadapter.NetConnection{})
+		peer.UpdateFieldsFromMsgVersion(&domainmessage.MsgVersion{
+			Services:        services,
+			SelectedTipHash: selectedTipHash,
+			Timestamp:       mstime.Now(),
+		})
+		return peer
+	}
+	syncedPeer := newPeer(params.GenesisHash, domainmessage.SFNodeNetwork)
+	prunedPeer := newPeer(unknownTipHash, 0)
+	deprioritizedPeer := newPeer(unknownTipHash, domainmessage.SFNodeNetwork)
+	deprioritizedPeer.DeprioritizeForIBD()
+	preferredPeer := newPeer(unknownTipHash, domainmessage.SFNodeNetwork)
+
+	flowContextWithPeers := func(peers ...*peerpkg.Peer) *FlowContext {
+		f := &FlowContext{peers: make(map[*id.ID]*peerpkg.Peer)}
+		for _, peer := range peers {
+			peerID, err := id.GenerateID()
+			if err != nil {
+				t.Fatalf("GenerateID: %s", err)
+			}
+			f.peers[peerID] = peer
+		}
+		return f
+	}
+
+	// Peers that stalled IBD and pruned peers are
+	// selected only if there's no other peer
+	f := flowContextWithPeers(syncedPeer, prunedPeer, deprioritizedPeer, preferredPeer)
+	selectedPeer := f.selectPeerForIBD(dag)
+	if selectedPeer != preferredPeer {
+		t.Fatalf("selectPeerForIBD: expected the peer that wasn't deprioritized to be selected")
+	}
+
+	f = flowContextWithPeers(syncedPeer, prunedPeer, deprioritizedPeer)
+	selectedPeer = f.selectPeerForIBD(dag)
+	if selectedPeer != prunedPeer && selectedPeer != deprioritizedPeer {
+		t.Fatalf("selectPeerForIBD: expected a deprioritized peer to be selected " +
+			"when there's no other peer to select")
+	}
+
+	// Peers whose selected tip is already in the DAG are never selected
+	f = flowContextWithPeers(syncedPeer)
+	selectedPeer = f.selectPeerForIBD(dag)
+	if selectedPeer != nil {
+		t.Fatalf("selectPeerForIBD: expected no peer to be selected when all the peers are synced")
+	}
+}
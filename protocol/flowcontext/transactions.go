@@ -29,6 +29,31 @@ func (f *FlowContext) AddTransaction(tx *util.Tx) error {
 	return f.Broadcast(inv)
 }
 
+// AddPackage adds a package of transactions to the mempool as a unit and
+// propagates them.
+func (f *FlowContext) AddPackage(txs []*util.Tx) error {
+	f.transactionsToRebroadcastLock.Lock()
+	defer f.transactionsToRebroadcastLock.Unlock()
+
+	transactionsAcceptedToMempool, err := f.txPool.ProcessPackage(txs)
+	if err != nil {
+		return err
+	}
+
+	// Orphans that were accepted thanks to the package
+	// are announced along with it, but only the package
+	// itself is rebroadcast.
+	for _, tx := range txs {
+		f.transactionsToRebroadcast[*tx.ID()] = tx
+	}
+	txIDs := make([]*daghash.TxID, len(transactionsAcceptedToMempool))
+	for i, txDesc := range transactionsAcceptedToMempool {
+		txIDs[i] = txDesc.Tx.ID()
+	}
+	inv := domainmessage.NewMsgInvTransaction(txIDs)
+	return f.Broadcast(inv)
+}
+
 func (f *FlowContext) updateTransactionsToRebroadcast(block *util.Block) {
 	f.transactionsToRebroadcastLock.Lock()
 	defer f.transactionsToRebroadcastLock.Unlock()
@@ -40,6 +65,25 @@ func (f *FlowContext) updateTransactionsToRebroadcast(block *util.Block) {
 	}
 }
 
+// handleMempoolNotification stops rebroadcasting transactions
+// that were removed from the mempool since they had expired.
+func (f *FlowContext) handleMempoolNotification(notification *mempool.Notification) {
+	if notification.Type != mempool.NTTransactionsExpired {
+		return
+	}
+	data, ok := notification.Data.(*mempool.TransactionsExpiredNotificationData)
+	if !ok {
+		log.Warnf("Transactions expired notification data is of wrong type.")
+		return
+	}
+
+	f.transactionsToRebroadcastLock.Lock()
+	defer f.transactionsToRebroadcastLock.Unlock()
+	for _, tx := range data.Transactions {
+		delete(f.transactionsToRebroadcast, *tx.ID())
+	}
+}
+
 func (f *FlowContext) shouldRebroadcastTransactions() bool {
 	const rebroadcastInterval = 30 * time.Second
 	return time.Since(f.lastRebroadcastTime) > rebroadcastInterval
@@ -49,11 +93,15 @@ func (f *FlowContext) txIDsToRebroadcast() []*daghash.TxID {
 	f.transactionsToRebroadcastLock.Lock()
 	defer f.transactionsToRebroadcastLock.Unlock()
 
-	txIDs := make([]*daghash.TxID, len(f.transactionsToRebroadcast))
-	i := 0
-	for _, tx := range f.transactionsToRebroadcast {
-		txIDs[i] = tx.ID()
-		i++
+	txIDs := make([]*daghash.TxID, 0, len(f.transactionsToRebroadcast))
+	for txID, tx := range f.transactionsToRebroadcast {
+		// Transactions that were replaced by conflicting
+		// transactions are no longer in the mempool.
+		if !f.txPool.HaveTransaction(tx.ID()) {
+			delete(f.transactionsToRebroadcast, txID)
+			continue
+		}
+		txIDs = append(txIDs, tx.ID())
 	}
 	return txIDs
 }
@@ -29,7 +29,16 @@ func HandleRelayBlockRequests(context RelayBlockRequestsContext, incomingRoute *
 			// Fetch the block from the database.
 			block, err := context.DAG().BlockByHash(hash)
 			if blockdag.IsNotInDAGErr(err) {
-				return protocolerrors.Errorf(true, "block %s not found", hash)
+				// Blocks that were pruned, as well as blocks that were
+				// imported from a UTXO snapshot, are in the DAG without
+				// their data. Peers may legitimately request them, so
+				// they're skipped rather than punished for.
+				if context.DAG().IsInDAG(hash) {
+					log.Debugf("Skipping the relay of block %s to %s, since its data "+
+						"is not stored", hash, peer)
+					continue
+				}
+				return protocolerrors.Errorf(protocolerrors.BanScoreMinor, "block %s not found", hash)
 			} else if err != nil {
 				return errors.Wrapf(err, "unable to fetch requested block hash %s", hash)
 			}
@@ -57,7 +57,7 @@ func (flow *handleRelayInvsFlow) start() error {
 
 		if flow.DAG().IsKnownBlock(inv.Hash) {
 			if flow.DAG().IsKnownInvalid(inv.Hash) {
-				return protocolerrors.Errorf(true, "sent inv of an invalid block %s",
+				return protocolerrors.Errorf(protocolerrors.BanScoreSevere, "sent inv of an invalid block %s",
 					inv.Hash)
 			}
 			continue
@@ -96,7 +96,7 @@ func (flow *handleRelayInvsFlow) readInv() (*domainmessage.MsgInvRelayBlock, err
 
 	inv, ok := msg.(*domainmessage.MsgInvRelayBlock)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "unexpected %s message in the block relay handleRelayInvsFlow while "+
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "unexpected %s message in the block relay handleRelayInvsFlow while "+
 			"expecting an inv message", msg.Command())
 	}
 	return inv, nil
@@ -143,7 +143,7 @@ func (flow *handleRelayInvsFlow) requestBlocks(requestQueue *hashesQueueSet) err
 		blockHash := block.Hash()
 
 		if _, ok := pendingBlocks[*blockHash]; !ok {
-			return protocolerrors.Errorf(true, "got unrequested block %s", block.Hash())
+			return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "got unrequested block %s", block.Hash())
 		}
 
 		err = flow.processAndRelayBlock(requestQueue, block)
@@ -190,7 +190,7 @@ func (flow *handleRelayInvsFlow) processAndRelayBlock(requestQueue *hashesQueueS
 		}
 		log.Infof("Rejected block %s from %s: %s", blockHash, flow.peer, err)
 
-		return protocolerrors.Wrap(true, err, "got invalid block")
+		return protocolerrors.Wrap(protocolerrors.BanScoreSevere, err, "got invalid block")
 	}
 
 	if isDelayed {
@@ -200,7 +200,7 @@ func (flow *handleRelayInvsFlow) processAndRelayBlock(requestQueue *hashesQueueS
 	if isOrphan {
 		blueScore, err := block.BlueScore()
 		if err != nil {
-			return protocolerrors.Errorf(true, "received an orphan "+
+			return protocolerrors.Errorf(protocolerrors.BanScoreSevere, "received an orphan "+
 				"block %s with malformed blue score", blockHash)
 		}
 
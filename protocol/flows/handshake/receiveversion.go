@@ -15,8 +15,9 @@ var (
 	allowSelfConnections bool
 
 	// minAcceptableProtocolVersion is the lowest protocol version that a
-	// connected peer may support.
-	minAcceptableProtocolVersion = domainmessage.ProtocolVersion
+	// connected peer may support. Peers that don't support headers-first
+	// IBD are still accepted, and synced from without headers.
+	minAcceptableProtocolVersion uint32 = 1
 )
 
 type receiveVersionFlow struct {
@@ -48,16 +49,16 @@ func (flow *receiveVersionFlow) start() (*domainmessage.NetAddress, error) {
 
 	msgVersion, ok := message.(*domainmessage.MsgVersion)
 	if !ok {
-		return nil, protocolerrors.New(true, "a version message must precede all others")
+		return nil, protocolerrors.New(protocolerrors.BanScoreModerate, "a version message must precede all others")
 	}
 
 	if !allowSelfConnections && flow.NetAdapter().ID().IsEqual(msgVersion.ID) {
-		return nil, protocolerrors.New(true, "connected to self")
+		return nil, protocolerrors.New(protocolerrors.BanScoreSevere, "connected to self")
 	}
 
 	// Disconnect and ban peers from a different network
 	if msgVersion.Network != flow.Config().ActiveNetParams.Name {
-		return nil, protocolerrors.Errorf(true, "wrong network")
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreSevere, "wrong network")
 	}
 
 	// Notify and disconnect clients that have a protocol version that is
@@ -68,13 +69,13 @@ func (flow *receiveVersionFlow) start() (*domainmessage.NetAddress, error) {
 	// disconnecting.
 	if msgVersion.ProtocolVersion < minAcceptableProtocolVersion {
 		//TODO(libp2p) create error type for disconnect but don't ban
-		return nil, protocolerrors.Errorf(false, "protocol version must be %d or greater",
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreNone, "protocol version must be %d or greater",
 			minAcceptableProtocolVersion)
 	}
 
 	// Disconnect from partial nodes in networks that don't allow them
 	if !flow.DAG().Params.EnableNonNativeSubnetworks && msgVersion.SubnetworkID != nil {
-		return nil, protocolerrors.New(true, "partial nodes are not allowed")
+		return nil, protocolerrors.New(protocolerrors.BanScoreSevere, "partial nodes are not allowed")
 	}
 
 	// TODO(libp2p)
@@ -20,6 +20,10 @@ var (
 	// the server.
 	defaultServices = domainmessage.SFNodeNetwork | domainmessage.SFNodeBloom | domainmessage.SFNodeCF
 
+	// prunedServices describes the services that are supported by the
+	// server when it runs with --prune.
+	prunedServices = defaultServices&^domainmessage.SFNodeNetwork | domainmessage.SFNodeNetworkLimited
+
 	// defaultRequiredServices describes the default services that are
 	// required to be supported by outbound peers.
 	defaultRequiredServices = domainmessage.SFNodeNetwork
@@ -55,6 +59,9 @@ func (flow *sendVersionFlow) start() error {
 
 	// Advertise the services flag
 	msg.Services = defaultServices
+	if flow.Config().Prune {
+		msg.Services = prunedServices
+	}
 
 	// Advertise our max supported protocol version.
 	msg.ProtocolVersion = domainmessage.ProtocolVersion
@@ -0,0 +1,291 @@
+package ibd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// segmentStallTimeout is the duration after which a segment whose
+// download hasn't progressed is reassigned to another peer.
+//
+// NOTE: This is a var rather than a const for testing purposes.
+var segmentStallTimeout = 10 * time.Second
+
+// segment is a range of blocks that is downloaded from a single peer
+// during IBD: all the blocks in the past of highHash (including itself)
+// that are not in the past of lowHash (including itself). Both hashes
+// are on the selected parent chain of the IBD peer's selected tip, so
+// segments of consecutive ranges of that chain are in topological order.
+type segment struct {
+	index             int
+	lowHash, highHash *daghash.Hash
+
+	assignedPeer     *peerpkg.Peer
+	lastProgressTime time.Time
+	unavailablePeers map[*peerpkg.Peer]struct{}
+
+	isDownloaded   bool
+	downloadedFrom *peerpkg.Peer
+	blocks         []*domainmessage.MsgIBDBlock
+}
+
+func newSegment(index int, lowHash, highHash *daghash.Hash) *segment {
+	return &segment{
+		index:            index,
+		lowHash:          lowHash,
+		highHash:         highHash,
+		unavailablePeers: make(map[*peerpkg.Peer]struct{}),
+	}
+}
+
+// BlockDownloadScheduler assigns the segments of an IBD round to the
+// peers that download them, reassigns the segments of stalled peers,
+// and hands the downloaded segments over in order, so that their blocks
+// can be added to the DAG in topological order.
+type BlockDownloadScheduler struct {
+	lock     sync.Mutex
+	cond     *sync.Cond
+	segments []*segment
+
+	// peerErrors holds the errors that caused the segments downloaded
+	// from a peer to be rejected. They're returned to the download flow
+	// of that peer, so that it would be disconnected.
+	peerErrors map[*peerpkg.Peer]error
+
+	progress *progressTracker
+
+	isClosed bool
+	err      error
+}
+
+func newBlockDownloadScheduler(segments []*segment) *BlockDownloadScheduler {
+	scheduler := &BlockDownloadScheduler{
+		segments:   segments,
+		peerErrors: make(map[*peerpkg.Peer]error),
+		progress:   newProgressTracker(),
+	}
+	scheduler.cond = sync.NewCond(&scheduler.lock)
+	return scheduler
+}
+
+// nextSegment blocks until there's a segment for the given peer to
+// download and assigns it to the peer. It returns nil once the scheduler
+// is closed, or an error if a segment the peer had downloaded was
+// rejected.
+func (s *BlockDownloadScheduler) nextSegment(peer *peerpkg.Peer) (*segment, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for {
+		if s.isClosed {
+			return nil, nil
+		}
+		if err, ok := s.peerErrors[peer]; ok {
+			return nil, err
+		}
+
+		segment, nextStallTime := s.segmentToAssign(peer)
+		if segment != nil {
+			if segment.assignedPeer != nil {
+				log.Debugf("Reassigning IBD segment %d from stalled peer %s to %s",
+					segment.index, segment.assignedPeer, peer)
+			}
+			segment.assignedPeer = peer
+			segment.lastProgressTime = time.Now()
+			return segment, nil
+		}
+
+		s.waitUntil(nextStallTime)
+	}
+}
+
+// segmentToAssign returns the lowest segment that the given peer may
+// download: a segment that isn't assigned to any peer, or otherwise a
+// segment whose assigned peer has stalled. If there's no such segment,
+// it returns the time in which the earliest assigned segment will be
+// considered stalled, or the zero time if there are no assigned segments.
+//
+// This function MUST be called with the scheduler lock held.
+func (s *BlockDownloadScheduler) segmentToAssign(peer *peerpkg.Peer) (*segment, time.Time) {
+	var stalledSegment *segment
+	var nextStallTime time.Time
+	now := time.Now()
+	for _, segment := range s.segments {
+		if segment.isDownloaded || segment.assignedPeer == peer {
+			continue
+		}
+		if _, ok := segment.unavailablePeers[peer]; ok {
+			continue
+		}
+		if segment.assignedPeer == nil {
+			return segment, time.Time{}
+		}
+		stallTime := segment.lastProgressTime.Add(segmentStallTimeout)
+		if !stallTime.After(now) {
+			if stalledSegment == nil {
+				stalledSegment = segment
+			}
+			continue
+		}
+		if nextStallTime.IsZero() || stallTime.Before(nextStallTime) {
+			nextStallTime = stallTime
+		}
+	}
+	return stalledSegment, nextStallTime
+}
+
+// waitUntil waits for the scheduler state to change, or until the
+// given time if it's not zero.
+//
+// This function MUST be called with the scheduler lock held.
+func (s *BlockDownloadScheduler) waitUntil(wakeUpTime time.Time) {
+	if wakeUpTime.IsZero() {
+		s.cond.Wait()
+		return
+	}
+	timer := time.AfterFunc(time.Until(wakeUpTime), s.wakeUp)
+	s.cond.Wait()
+	timer.Stop()
+}
+
+func (s *BlockDownloadScheduler) wakeUp() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.cond.Broadcast()
+}
+
+// reportProgress marks that a block of the given segment was received
+// from the given peer, so that the segment wouldn't be considered
+// stalled.
+func (s *BlockDownloadScheduler) reportProgress(segment *segment, peer *peerpkg.Peer) {
+	s.progress.blockReceived()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if segment.assignedPeer == peer {
+		segment.lastProgressTime = time.Now()
+	}
+}
+
+// segmentDownloaded stores the blocks of the given segment that were
+// downloaded from the given peer. If the segment was already downloaded
+// from another peer after it had been reassigned, the blocks are dropped.
+func (s *BlockDownloadScheduler) segmentDownloaded(segment *segment, peer *peerpkg.Peer,
+	blocks []*domainmessage.MsgIBDBlock) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if segment.isDownloaded {
+		return
+	}
+	log.Debugf("Downloaded IBD segment %d with %d blocks from %s", segment.index, len(blocks), peer)
+	segment.isDownloaded = true
+	segment.downloadedFrom = peer
+	segment.assignedPeer = nil
+	segment.blocks = blocks
+	s.cond.Broadcast()
+}
+
+// segmentUnavailable marks that the given peer doesn't have the blocks
+// of the given segment, so that it would be downloaded from other peers.
+func (s *BlockDownloadScheduler) segmentUnavailable(segment *segment, peer *peerpkg.Peer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	segment.unavailablePeers[peer] = struct{}{}
+	if segment.assignedPeer == peer {
+		segment.assignedPeer = nil
+	}
+	s.cond.Broadcast()
+}
+
+// releaseSegments unassigns the segments that are assigned to the given
+// peer, so that they would be downloaded from other peers. It's called
+// once the download flow of the peer stops due to an error.
+func (s *BlockDownloadScheduler) releaseSegments(peer *peerpkg.Peer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, segment := range s.segments {
+		if segment.assignedPeer == peer {
+			segment.assignedPeer = nil
+		}
+		segment.unavailablePeers[peer] = struct{}{}
+	}
+	s.cond.Broadcast()
+}
+
+// rejectSegment drops the blocks of the given segment, which failed to
+// be added to the DAG with the given error, so that it would be
+// downloaded again from another peer. The error is returned to the
+// download flow of the peer the segment was downloaded from.
+func (s *BlockDownloadScheduler) rejectSegment(segment *segment, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	peer := segment.downloadedFrom
+	segment.unavailablePeers[peer] = struct{}{}
+	segment.isDownloaded = false
+	segment.downloadedFrom = nil
+	segment.blocks = nil
+	s.peerErrors[peer] = err
+	s.cond.Broadcast()
+}
+
+// stopPeer makes the download flow of the given peer return the given
+// error.
+func (s *BlockDownloadScheduler) stopPeer(peer *peerpkg.Peer, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.peerErrors[peer] = err
+	s.cond.Broadcast()
+}
+
+// waitForSegment blocks until the segment with the given index is
+// downloaded and returns it. It returns nil if there's no such segment,
+// or an error if the scheduler was canceled.
+func (s *BlockDownloadScheduler) waitForSegment(index int) (*segment, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if index >= len(s.segments) {
+		return nil, nil
+	}
+	segment := s.segments[index]
+	for !segment.isDownloaded {
+		if s.err != nil {
+			return nil, s.err
+		}
+		s.cond.Wait()
+	}
+	return segment, nil
+}
+
+// cancel stops the scheduler due to the given error, which is returned
+// from waitForSegment.
+func (s *BlockDownloadScheduler) cancel(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.err = err
+	s.isClosed = true
+	s.cond.Broadcast()
+}
+
+// close stops the scheduler, which makes all the download flows that
+// wait for segments return.
+func (s *BlockDownloadScheduler) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.isClosed = true
+	s.cond.Broadcast()
+}
@@ -0,0 +1,300 @@
+package ibd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// waitTimeout is the time the tests wait for calls that are expected
+// to return, and for calls that are expected to block before deciding
+// that they do.
+const waitTimeout = 200 * time.Millisecond
+
+func newSchedulerForTest(numSegments int) *BlockDownloadScheduler {
+	segments := make([]*segment, numSegments)
+	for i := range segments {
+		segments[i] = newSegment(i, &daghash.Hash{byte(i)}, &daghash.Hash{byte(i + 1)})
+	}
+	return newBlockDownloadScheduler(segments)
+}
+
+// newFakePeer returns a peer without a connection. The scheduler only
+// uses peers as identities, so it doesn't need a connection.
+func newFakePeer() *peerpkg.Peer {
+	return peerpkg.New(nil)
+}
+
+type nextSegmentResult struct {
+	segment *segment
+	err     error
+}
+
+// nextSegmentAsync calls nextSegment for the given peer in the
+// background, since it blocks until there's a segment to assign.
+func nextSegmentAsync(scheduler *BlockDownloadScheduler, peer *peerpkg.Peer) <-chan *nextSegmentResult {
+	resultChan := make(chan *nextSegmentResult, 1)
+	go func() {
+		segment, err := scheduler.nextSegment(peer)
+		resultChan <- &nextSegmentResult{segment: segment, err: err}
+	}()
+	return resultChan
+}
+
+func receiveNextSegment(t *testing.T, resultChan <-chan *nextSegmentResult) *nextSegmentResult {
+	select {
+	case result := <-resultChan:
+		return result
+	case <-time.After(waitTimeout):
+		t.Fatalf("nextSegment: timeout waiting for a segment")
+	}
+	return nil
+}
+
+func expectNextSegmentToBlock(t *testing.T, resultChan <-chan *nextSegmentResult) {
+	select {
+	case result := <-resultChan:
+		t.Fatalf("nextSegment: expected to block, but returned segment %v and error %v",
+			result.segment, result.err)
+	case <-time.After(waitTimeout):
+	}
+}
+
+func assignSegment(t *testing.T, scheduler *BlockDownloadScheduler, peer *peerpkg.Peer,
+	expectedIndex int) *segment {
+
+	result := receiveNextSegment(t, nextSegmentAsync(scheduler, peer))
+	if result.err != nil {
+		t.Fatalf("nextSegment: unexpected error: %s", result.err)
+	}
+	if result.segment == nil || result.segment.index != expectedIndex {
+		t.Fatalf("nextSegment: expected segment %d, got %v", expectedIndex, result.segment)
+	}
+	return result.segment
+}
+
+// blocksForTest returns the downloaded blocks of a segment. The
+// scheduler doesn't look into the blocks, so they're empty.
+func blocksForTest() []*domainmessage.MsgIBDBlock {
+	return []*domainmessage.MsgIBDBlock{{}}
+}
+
+func TestNextSegment(t *testing.T) {
+	scheduler := newSchedulerForTest(2)
+	peerA := newFakePeer()
+	peerB := newFakePeer()
+	peerC := newFakePeer()
+
+	// Unassigned segments are assigned in order
+	assignSegment(t, scheduler, peerA, 0)
+	assignSegment(t, scheduler, peerB, 1)
+
+	// Once all the segments are assigned, nextSegment blocks
+	// until the scheduler is closed
+	resultChan := nextSegmentAsync(scheduler, peerC)
+	expectNextSegmentToBlock(t, resultChan)
+	scheduler.close()
+	result := receiveNextSegment(t, resultChan)
+	if result.segment != nil || result.err != nil {
+		t.Fatalf("nextSegment: expected neither a segment nor an error once the scheduler "+
+			"is closed, got segment %v and error %v", result.segment, result.err)
+	}
+}
+
+func TestNextSegmentReassignsStalledSegments(t *testing.T) {
+	currentSegmentStallTimeout := segmentStallTimeout
+	segmentStallTimeout = 2 * waitTimeout
+	defer func() {
+		segmentStallTimeout = currentSegmentStallTimeout
+	}()
+
+	scheduler := newSchedulerForTest(1)
+	peerA := newFakePeer()
+	peerB := newFakePeer()
+
+	segment := assignSegment(t, scheduler, peerA, 0)
+
+	// The segment isn't reassigned as long as its download
+	// progresses
+	resultChan := nextSegmentAsync(scheduler, peerB)
+	expectNextSegmentToBlock(t, resultChan)
+	scheduler.reportProgress(segment, peerA)
+	expectNextSegmentToBlock(t, resultChan)
+
+	// Once it stalls, it's reassigned to the waiting peer
+	select {
+	case result := <-resultChan:
+		if result.err != nil || result.segment != segment {
+			t.Fatalf("nextSegment: expected the stalled segment to be reassigned, "+
+				"got segment %v and error %v", result.segment, result.err)
+		}
+	case <-time.After(2 * segmentStallTimeout):
+		t.Fatalf("nextSegment: timeout waiting for the stalled segment to be reassigned")
+	}
+	if segment.assignedPeer != peerB {
+		t.Fatalf("nextSegment: expected the stalled segment to be assigned to the waiting peer")
+	}
+
+	// Progress of the stalled peer doesn't count anymore, but if it
+	// still finishes first, its blocks are kept and the blocks of
+	// the new peer are dropped
+	scheduler.reportProgress(segment, peerA)
+	blocksA := blocksForTest()
+	scheduler.segmentDownloaded(segment, peerA, blocksA)
+	scheduler.segmentDownloaded(segment, peerB, blocksForTest())
+	if segment.downloadedFrom != peerA || segment.blocks[0] != blocksA[0] {
+		t.Fatalf("segmentDownloaded: expected the segment to be downloaded from the peer " +
+			"that finished first")
+	}
+}
+
+func TestSegmentUnavailable(t *testing.T) {
+	scheduler := newSchedulerForTest(2)
+	peerA := newFakePeer()
+	peerB := newFakePeer()
+
+	segment0 := assignSegment(t, scheduler, peerA, 0)
+	scheduler.segmentUnavailable(segment0, peerA)
+	if segment0.assignedPeer != nil {
+		t.Fatalf("segmentUnavailable: expected the segment to be unassigned")
+	}
+
+	// The peer that doesn't have segment 0 skips it, and
+	// segment 0 is assigned to another peer
+	assignSegment(t, scheduler, peerA, 1)
+	assignSegment(t, scheduler, peerB, 0)
+
+	// A segment that's unavailable from all the peers isn't
+	// assigned to any of them
+	scheduler.segmentUnavailable(segment0, peerB)
+	resultChan := nextSegmentAsync(scheduler, peerB)
+	expectNextSegmentToBlock(t, resultChan)
+	scheduler.close()
+	receiveNextSegment(t, resultChan)
+}
+
+func TestRejectSegment(t *testing.T) {
+	scheduler := newSchedulerForTest(1)
+	peerA := newFakePeer()
+	peerB := newFakePeer()
+
+	segment := assignSegment(t, scheduler, peerA, 0)
+	scheduler.segmentDownloaded(segment, peerA, blocksForTest())
+
+	// The download flow of peerA waits for more segments
+	resultChanA := nextSegmentAsync(scheduler, peerA)
+	expectNextSegmentToBlock(t, resultChanA)
+
+	// Once the segment is rejected, peerA gets the rejection
+	// error, and the segment is downloaded again from peerB
+	rejectionErr := errors.New("rejected")
+	scheduler.rejectSegment(segment, rejectionErr)
+	result := receiveNextSegment(t, resultChanA)
+	if !errors.Is(result.err, rejectionErr) {
+		t.Fatalf("nextSegment: expected the rejection error, got: %v", result.err)
+	}
+	if segment.isDownloaded || segment.blocks != nil || segment.downloadedFrom != nil {
+		t.Fatalf("rejectSegment: expected the blocks of the segment to be dropped")
+	}
+	assignSegment(t, scheduler, peerB, 0)
+
+	// The error is returned to peerA from now on
+	result = receiveNextSegment(t, nextSegmentAsync(scheduler, peerA))
+	if !errors.Is(result.err, rejectionErr) {
+		t.Fatalf("nextSegment: expected the rejection error, got: %v", result.err)
+	}
+}
+
+func TestStopPeer(t *testing.T) {
+	scheduler := newSchedulerForTest(1)
+	peerA := newFakePeer()
+	peerB := newFakePeer()
+
+	assignSegment(t, scheduler, peerA, 0)
+	resultChanB := nextSegmentAsync(scheduler, peerB)
+	expectNextSegmentToBlock(t, resultChanB)
+
+	stopErr := errors.New("stopped")
+	scheduler.stopPeer(peerB, stopErr)
+	result := receiveNextSegment(t, resultChanB)
+	if !errors.Is(result.err, stopErr) {
+		t.Fatalf("nextSegment: expected the stop error, got: %v", result.err)
+	}
+
+	// Other peers aren't affected
+	resultChanA := nextSegmentAsync(scheduler, peerA)
+	expectNextSegmentToBlock(t, resultChanA)
+	scheduler.close()
+	result = receiveNextSegment(t, resultChanA)
+	if result.err != nil {
+		t.Fatalf("nextSegment: unexpected error for a peer that wasn't stopped: %s", result.err)
+	}
+}
+
+func TestWaitForSegment(t *testing.T) {
+	scheduler := newSchedulerForTest(2)
+	peerA := newFakePeer()
+	peerB := newFakePeer()
+
+	segment0 := assignSegment(t, scheduler, peerA, 0)
+	segment1 := assignSegment(t, scheduler, peerB, 1)
+
+	type waitForSegmentResult struct {
+		segment *segment
+		err     error
+	}
+	waitForSegmentAsync := func(index int) <-chan *waitForSegmentResult {
+		resultChan := make(chan *waitForSegmentResult, 1)
+		go func() {
+			segment, err := scheduler.waitForSegment(index)
+			resultChan <- &waitForSegmentResult{segment: segment, err: err}
+		}()
+		return resultChan
+	}
+	receiveSegment := func(resultChan <-chan *waitForSegmentResult, expected *segment) {
+		select {
+		case result := <-resultChan:
+			if result.err != nil || result.segment != expected {
+				t.Fatalf("waitForSegment: expected segment %v, got segment %v and error %v",
+					expected, result.segment, result.err)
+			}
+		case <-time.After(waitTimeout):
+			t.Fatalf("waitForSegment: timeout waiting for segment %v", expected)
+		}
+	}
+
+	// Segment 1 is downloaded first, but segment 0 is still
+	// waited for
+	resultChan := waitForSegmentAsync(0)
+	scheduler.segmentDownloaded(segment1, peerB, blocksForTest())
+	select {
+	case result := <-resultChan:
+		t.Fatalf("waitForSegment: expected to wait for segment 0, got segment %v and error %v",
+			result.segment, result.err)
+	case <-time.After(waitTimeout):
+	}
+
+	scheduler.segmentDownloaded(segment0, peerA, blocksForTest())
+	receiveSegment(resultChan, segment0)
+	receiveSegment(waitForSegmentAsync(1), segment1)
+	receiveSegment(waitForSegmentAsync(2), nil)
+
+	// Waiting for a segment that isn't downloaded
+	// returns the error the scheduler was canceled with
+	scheduler.rejectSegment(segment1, errors.New("rejected"))
+	resultChan = waitForSegmentAsync(1)
+	cancelErr := errors.New("canceled")
+	scheduler.cancel(cancelErr)
+	select {
+	case result := <-resultChan:
+		if !errors.Is(result.err, cancelErr) {
+			t.Fatalf("waitForSegment: expected the cancel error, got: %v", result.err)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatalf("waitForSegment: timeout waiting for the scheduler to be canceled")
+	}
+}
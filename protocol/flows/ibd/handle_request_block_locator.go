@@ -38,8 +38,15 @@ func (flow *handleRequestBlockLocatorFlow) start() error {
 		}
 
 		locator, err := flow.DAG().BlockLocatorFromHashes(highHash, lowHash)
+		if err != nil && flow.DAG().IsInDAG(highHash) {
+			// Peers that resume IBD with us from the highest block they
+			// shared with another peer don't know whether it's in our
+			// selected parent chain. If it isn't, we send them a locator
+			// down to the genesis instead.
+			locator, err = flow.DAG().BlockLocatorFromHashes(highHash, flow.DAG().Params.GenesisHash)
+		}
 		if err != nil || len(locator) == 0 {
-			return protocolerrors.Errorf(true, "couldn't build a block "+
+			return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "couldn't build a block "+
 				"locator between blocks %s and %s", lowHash, highHash)
 		}
 
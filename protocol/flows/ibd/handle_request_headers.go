@@ -0,0 +1,117 @@
+package ibd
+
+import (
+	"errors"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/netadapter/router"
+	"github.com/kaspanet/kaspad/protocol/protocolerrors"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// RequestHeadersContext is the interface for the context needed for the HandleRequestHeaders flow.
+type RequestHeadersContext interface {
+	DAG() *blockdag.BlockDAG
+}
+
+type handleRequestHeadersFlow struct {
+	RequestHeadersContext
+	incomingRoute, outgoingRoute *router.Route
+}
+
+// HandleRequestHeaders handles RequestHeaders messages
+func HandleRequestHeaders(context RequestHeadersContext, incomingRoute *router.Route,
+	outgoingRoute *router.Route) error {
+
+	flow := &handleRequestHeadersFlow{
+		RequestHeadersContext: context,
+		incomingRoute:         incomingRoute,
+		outgoingRoute:         outgoingRoute,
+	}
+	return flow.start()
+}
+
+func (flow *handleRequestHeadersFlow) start() error {
+	for {
+		lowHash, highHash, err := flow.receiveRequestHeaders()
+		if err != nil {
+			return err
+		}
+
+		blockHeaders, err := flow.buildBlockHeaders(lowHash, highHash)
+		if err != nil {
+			return err
+		}
+
+		for offset := 0; offset < len(blockHeaders); offset += domainmessage.MaxBlockHeadersPerMsg {
+			end := offset + domainmessage.MaxBlockHeadersPerMsg
+			if end > len(blockHeaders) {
+				end = len(blockHeaders)
+			}
+
+			headersToSend := blockHeaders[offset:end]
+			err = flow.outgoingRoute.Enqueue(domainmessage.NewMsgBlockHeaders(headersToSend))
+			if err != nil {
+				return err
+			}
+
+			// Exit the loop and don't wait for the RequestNextHeaders message if the last batch was
+			// less than MaxBlockHeadersPerMsg.
+			if len(headersToSend) < domainmessage.MaxBlockHeadersPerMsg {
+				break
+			}
+
+			message, err := flow.incomingRoute.Dequeue()
+			if err != nil {
+				return err
+			}
+
+			if _, ok := message.(*domainmessage.MsgRequestNextHeaders); !ok {
+				return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received unexpected message type. "+
+					"expected: %s, got: %s", domainmessage.CmdRequestNextHeaders, message.Command())
+			}
+		}
+		err = flow.outgoingRoute.Enqueue(domainmessage.NewMsgDoneHeaders())
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (flow *handleRequestHeadersFlow) receiveRequestHeaders() (lowHash *daghash.Hash,
+	highHash *daghash.Hash, err error) {
+
+	message, err := flow.incomingRoute.Dequeue()
+	if err != nil {
+		return nil, nil, err
+	}
+	msgRequestHeaders, ok := message.(*domainmessage.MsgRequestHeaders)
+	if !ok {
+		return nil, nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received unexpected message type. "+
+			"expected: %s, got: %s", domainmessage.CmdRequestHeaders, message.Command())
+	}
+
+	return msgRequestHeaders.LowHash, msgRequestHeaders.HighHash, nil
+}
+
+func (flow *handleRequestHeadersFlow) buildBlockHeaders(lowHash *daghash.Hash,
+	highHash *daghash.Hash) ([]*domainmessage.BlockHeader, error) {
+
+	// The peer that requested the headers finds out that they're
+	// missing once it doesn't receive the header of the high hash.
+	if !flow.DAG().IsInDAG(lowHash) || !flow.DAG().IsInDAG(highHash) {
+		return nil, nil
+	}
+
+	const maxHeaders = domainmessage.MaxInvPerMsg
+	blockHeaders, err := flow.DAG().AntiPastHeadersBetween(lowHash, highHash, maxHeaders)
+	if err != nil {
+		if errors.Is(err, blockdag.ErrInvalidParameter) {
+			return nil, protocolerrors.Wrapf(protocolerrors.BanScoreModerate, err, "could not get antiPast "+
+				"headers between %s and %s", lowHash, highHash)
+		}
+		return nil, err
+	}
+	return blockHeaders, nil
+}
@@ -67,7 +67,7 @@ func (flow *handleRequestBlocksFlow) start() error {
 			}
 
 			if _, ok := message.(*domainmessage.MsgRequestNextIBDBlocks); !ok {
-				return protocolerrors.Errorf(true, "received unexpected message type. "+
+				return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received unexpected message type. "+
 					"expected: %s, got: %s", domainmessage.CmdRequestNextIBDBlocks, message.Command())
 			}
 		}
@@ -93,11 +93,18 @@ func receiveRequestIBDBlocks(incomingRoute *router.Route) (lowHash *daghash.Hash
 func (flow *handleRequestBlocksFlow) buildMsgIBDBlocks(lowHash *daghash.Hash,
 	highHash *daghash.Hash) ([]*domainmessage.MsgIBDBlock, error) {
 
+	// Peers request segments of IBD blocks from all of their synced
+	// peers, some of which might not have them. In that case, no blocks
+	// are sent.
+	if !flow.DAG().IsInDAG(lowHash) || !flow.DAG().IsInDAG(highHash) {
+		return nil, nil
+	}
+
 	const maxHashesInMsgIBDBlocks = domainmessage.MaxInvPerMsg
 	blockHashes, err := flow.DAG().AntiPastHashesBetween(lowHash, highHash, maxHashesInMsgIBDBlocks)
 	if err != nil {
 		if errors.Is(err, blockdag.ErrInvalidParameter) {
-			return nil, protocolerrors.Wrapf(true, err, "could not get antiPast between "+
+			return nil, protocolerrors.Wrapf(protocolerrors.BanScoreModerate, err, "could not get antiPast between "+
 				"%s and %s", lowHash, highHash)
 		}
 		return nil, err
@@ -106,6 +113,12 @@ func (flow *handleRequestBlocksFlow) buildMsgIBDBlocks(lowHash *daghash.Hash,
 	msgIBDBlocks := make([]*domainmessage.MsgIBDBlock, len(blockHashes))
 	for i, blockHash := range blockHashes {
 		block, err := flow.DAG().BlockByHash(blockHash)
+		if blockdag.IsNotInDAGErr(err) {
+			// Blocks that were imported from a UTXO snapshot, as well
+			// as blocks that were pruned, are in the DAG without their
+			// data, so they can't be sent.
+			return nil, nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,103 @@
+package ibd
+
+import (
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/protocol/common"
+	"github.com/kaspanet/kaspad/protocol/protocolerrors"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// downloadHeaders downloads the headers of the blocks between lowHash and
+// highHash from the IBD peer, and validates them before any of the blocks
+// themselves are downloaded, so that a peer that sends an invalid chain
+// is rejected before bandwidth is spent on its blocks. It returns the
+// hashes of the validated headers.
+func (flow *handleIBDFlow) downloadHeaders(lowHash *daghash.Hash,
+	highHash *daghash.Hash) (map[daghash.Hash]struct{}, error) {
+
+	err := flow.outgoingRoute.Enqueue(domainmessage.NewMsgRequestHeaders(lowHash, highHash))
+	if err != nil {
+		return nil, err
+	}
+
+	validator := flow.DAG().NewHeaderValidator()
+	headerHashes := make(map[daghash.Hash]struct{})
+	var lastHeaderHash *daghash.Hash
+	for {
+		msgBlockHeaders, doneHeaders, err := flow.receiveHeaders()
+		if err != nil {
+			return nil, err
+		}
+		if doneHeaders {
+			break
+		}
+
+		for _, header := range msgBlockHeaders.BlockHeaders {
+			err := flow.validateHeader(validator, header)
+			if err != nil {
+				return nil, err
+			}
+			lastHeaderHash = header.BlockHash()
+			headerHashes[*lastHeaderHash] = struct{}{}
+		}
+
+		if len(msgBlockHeaders.BlockHeaders) == domainmessage.MaxBlockHeadersPerMsg {
+			err = flow.outgoingRoute.Enqueue(domainmessage.NewMsgRequestNextHeaders())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The high hash is the last block in the past of itself, so the
+	// IBD peer must send its header last.
+	if lastHeaderHash == nil || !lastHeaderHash.IsEqual(highHash) {
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received "+
+			"the headers between %s and %s without the high header", lowHash, highHash)
+	}
+	log.Debugf("Validated %d headers between %s and %s from %s",
+		len(headerHashes), lowHash, highHash, flow.peer)
+	return headerHashes, nil
+}
+
+func (flow *handleIBDFlow) validateHeader(validator *blockdag.HeaderValidator,
+	header *domainmessage.BlockHeader) error {
+
+	err := validator.ValidateHeader(header, blockdag.BFNone)
+	if err == nil {
+		return nil
+	}
+	ruleErr := &blockdag.RuleError{}
+	if !errors.As(err, ruleErr) {
+		return errors.Wrapf(err, "failed to validate header %s", header.BlockHash())
+	}
+	// Headers that are too far in the future might be the result of
+	// clock differences, similarly to delayed blocks.
+	if ruleErr.ErrorCode == blockdag.ErrTimeTooNew {
+		return protocolerrors.Wrapf(protocolerrors.BanScoreNone, err, "received header %s "+
+			"that is too far in the future during IBD", header.BlockHash())
+	}
+	return protocolerrors.Wrapf(protocolerrors.BanScoreSevere, err, "received invalid header %s "+
+		"during IBD", header.BlockHash())
+}
+
+func (flow *handleIBDFlow) receiveHeaders() (msgBlockHeaders *domainmessage.MsgBlockHeaders,
+	doneHeaders bool, err error) {
+
+	message, err := flow.incomingRoute.DequeueWithTimeout(common.DefaultTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	switch message := message.(type) {
+	case *domainmessage.MsgBlockHeaders:
+		return message, false, nil
+	case *domainmessage.MsgDoneHeaders:
+		return nil, true, nil
+	default:
+		return nil, false,
+			protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received unexpected message type. "+
+				"expected: %s, got: %s", domainmessage.CmdBlockHeaders, message.Command())
+	}
+}
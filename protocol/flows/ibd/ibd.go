@@ -1,6 +1,8 @@
 package ibd
 
 import (
+	"time"
+
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/netadapter/router"
@@ -9,6 +11,7 @@ import (
 	"github.com/kaspanet/kaspad/protocol/protocolerrors"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
 )
 
 // HandleIBDContext is the interface for the context needed for the HandleIBD flow.
@@ -17,12 +20,18 @@ type HandleIBDContext interface {
 	OnNewBlock(block *util.Block) error
 	StartIBDIfRequired()
 	FinishIBD()
+	StartIBDBlockDownload(scheduler *BlockDownloadScheduler)
+	IBDBlockDownloadScheduler() *BlockDownloadScheduler
+	IBDResumeHash() *daghash.Hash
+	SetIBDResumeHash(hash *daghash.Hash)
+	AddBanScore(peer *peerpkg.Peer, score uint32, reason string) error
 }
 
 type handleIBDFlow struct {
 	HandleIBDContext
 	incomingRoute, outgoingRoute *router.Route
 	peer                         *peerpkg.Peer
+	isIBDFinished                bool
 }
 
 // HandleIBD waits for IBD start and handles it when IBD is triggered for this peer
@@ -48,8 +57,12 @@ func (flow *handleIBDFlow) start() error {
 }
 
 func (flow *handleIBDFlow) runIBD() error {
-	flow.peer.WaitForIBDStart()
-	defer flow.FinishIBD()
+	isIBDPeer := flow.peer.WaitForIBDStart()
+	if !isIBDPeer {
+		return flow.helpDownloadBlocks()
+	}
+	flow.isIBDFinished = false
+	defer flow.finishIBD()
 
 	peerSelectedTipHash := flow.peer.SelectedTipHash()
 	highestSharedBlockHash, err := flow.findHighestSharedBlockHash(peerSelectedTipHash)
@@ -57,18 +70,58 @@ func (flow *handleIBDFlow) runIBD() error {
 		return err
 	}
 	if flow.DAG().IsKnownFinalizedBlock(highestSharedBlockHash) {
-		return protocolerrors.Errorf(false, "cannot initiate "+
+		return protocolerrors.Errorf(protocolerrors.BanScoreNone, "cannot initiate "+
 			"IBD with peer %s because the highest shared chain block (%s) is "+
 			"below the finality point", flow.peer, highestSharedBlockHash)
 	}
 
-	return flow.downloadBlocks(highestSharedBlockHash, peerSelectedTipHash)
+	flow.SetIBDResumeHash(highestSharedBlockHash)
+
+	segments, err := flow.buildSegments(highestSharedBlockHash, peerSelectedTipHash)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	// Peers that don't support headers-first IBD
+	// only send the blocks themselves.
+	if flow.peer.ProtocolVersion() < domainmessage.HeadersFirstIBDVersion {
+		log.Debugf("IBD peer %s doesn't support headers-first IBD. Downloading "+
+			"the blocks without their headers", flow.peer)
+		return flow.downloadBlocks(segments, nil)
+	}
+
+	roundHighHash := segments[len(segments)-1].highHash
+	headerHashes, err := flow.downloadHeaders(highestSharedBlockHash, roundHighHash)
+	if err != nil {
+		return err
+	}
+	return flow.downloadBlocks(segments, headerHashes)
+}
+
+// finishIBD finishes the IBD round of this peer, unless it was
+// already finished because the round stalled.
+func (flow *handleIBDFlow) finishIBD() {
+	if flow.isIBDFinished {
+		return
+	}
+	flow.isIBDFinished = true
+	flow.FinishIBD()
 }
 
 func (flow *handleIBDFlow) findHighestSharedBlockHash(peerSelectedTipHash *daghash.Hash) (lowHash *daghash.Hash,
 	err error) {
 
-	lowHash = flow.DAG().Params.GenesisHash
+	// The search starts from the highest block that was shared with the
+	// last IBD peer, rather than from the genesis, in case this peer
+	// took over a stalled IBD. If it's not in the selected parent chain
+	// of this peer, it sends a locator down to the genesis instead.
+	lowHash = flow.IBDResumeHash()
+	if lowHash == nil {
+		lowHash = flow.DAG().Params.GenesisHash
+	}
 	highHash := peerSelectedTipHash
 
 	for {
@@ -108,50 +161,176 @@ func (flow *handleIBDFlow) receiveBlockLocator() (blockLocatorHashes []*daghash.
 	msgBlockLocator, ok := message.(*domainmessage.MsgBlockLocator)
 	if !ok {
 		return nil,
-			protocolerrors.Errorf(true, "received unexpected message type. "+
+			protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received unexpected message type. "+
 				"expected: %s, got: %s", domainmessage.CmdBlockLocator, message.Command())
 	}
 	return msgBlockLocator.BlockLocatorHashes, nil
 }
 
-func (flow *handleIBDFlow) downloadBlocks(highestSharedBlockHash *daghash.Hash,
-	peerSelectedTipHash *daghash.Hash) error {
+// downloadBlocks downloads the blocks of the given segments from the IBD
+// peer and from all the other peers that help downloading them, and adds
+// them to the DAG in order. Only the blocks of the given validated headers
+// are accepted, unless headerHashes is nil, in which case the blocks are
+// validated only once they're added to the DAG.
+func (flow *handleIBDFlow) downloadBlocks(segments []*segment, headerHashes map[daghash.Hash]struct{}) error {
+	scheduler := newBlockDownloadScheduler(segments)
+	log.Debugf("Downloading %d segments of IBD blocks from %s and other synced peers",
+		len(segments), flow.peer)
+	flow.StartIBDBlockDownload(scheduler)
 
-	err := flow.sendGetBlocks(highestSharedBlockHash, peerSelectedTipHash)
-	if err != nil {
-		return err
+	downloadErrChan := make(chan error, 1)
+	spawn("handleIBDFlow-downloadSegments", func() {
+		err := flow.downloadSegments(scheduler, true)
+		if err != nil {
+			scheduler.cancel(err)
+		}
+		downloadErrChan <- err
+	})
+
+	stopMonitoringChan := make(chan struct{})
+	spawn("handleIBDFlow-monitorProgress", func() {
+		flow.monitorProgress(scheduler, stopMonitoringChan)
+	})
+
+	err := flow.processSegments(scheduler, headerHashes)
+	close(stopMonitoringChan)
+	scheduler.close()
+
+	if errors.Is(err, errIBDStalled) {
+		log.Infof("IBD with %s stalled. Resuming it with another peer", flow.peer)
+		flow.peer.DeprioritizeForIBD()
+
+		// Another peer may take over right away, while the
+		// download of this peer stops.
+		flow.finishIBD()
+		err = nil
+	}
+
+	// Wait for the download of the IBD peer to stop, so that the
+	// messages of the current round wouldn't reach the next one.
+	downloadErr := <-downloadErrChan
+	if downloadErr != nil {
+		return downloadErr
 	}
+	return err
+}
+
+// monitorProgress checks the progress of the IBD round every
+// ibdProgressInterval, and cancels the round once it stalls.
+func (flow *handleIBDFlow) monitorProgress(scheduler *BlockDownloadScheduler, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(ibdProgressInterval)
+	defer ticker.Stop()
 
-	blocksReceived := 0
 	for {
-		msgIBDBlock, doneIBD, err := flow.receiveIBDBlock()
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		interval := scheduler.progress.endInterval()
+		log.Debugf("IBD with %s: received %d blocks and added %d blocks (%.2f blocks per second) "+
+			"in the last %s. %d of the received blocks were already known", flow.peer,
+			interval.receivedBlocks, interval.addedBlocks, interval.addedBlocksPerSecond(),
+			interval.duration.Round(time.Second), interval.knownBlocks)
+		if interval.isStalled() {
+			scheduler.cancel(errIBDStalled)
+			return
+		}
+	}
+}
+
+// helpDownloadBlocks downloads segments of the blocks of the IBD that
+// runs against another peer.
+func (flow *handleIBDFlow) helpDownloadBlocks() error {
+	scheduler := flow.IBDBlockDownloadScheduler()
+	if scheduler == nil {
+		return nil
+	}
+	return flow.downloadSegments(scheduler, false)
+}
+
+// downloadSegments downloads the segments that the scheduler assigns to
+// the peer until the scheduler is closed.
+func (flow *handleIBDFlow) downloadSegments(scheduler *BlockDownloadScheduler, isIBDPeer bool) error {
+	for {
+		segment, err := scheduler.nextSegment(flow.peer)
 		if err != nil {
 			return err
 		}
-
-		if doneIBD {
+		if segment == nil {
 			return nil
 		}
 
-		err = flow.processIBDBlock(msgIBDBlock)
+		blocks, err := flow.downloadSegment(scheduler, segment)
 		if err != nil {
+			scheduler.releaseSegments(flow.peer)
 			return err
 		}
+		if len(blocks) == 0 {
+			if isIBDPeer && flow.peer.IsPruned() {
+				// Pruned peers legitimately don't have the
+				// blocks that are deep below their finality point
+				flow.peer.DeprioritizeForIBD()
+				return protocolerrors.Errorf(protocolerrors.BanScoreNone, "pruned IBD peer %s "+
+					"doesn't have the blocks between %s and %s",
+					flow.peer, segment.lowHash, segment.highHash)
+			}
+			if isIBDPeer {
+				return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "IBD peer %s "+
+					"doesn't have the blocks between %s and %s on its selected parent chain",
+					flow.peer, segment.lowHash, segment.highHash)
+			}
+			scheduler.segmentUnavailable(segment, flow.peer)
+			continue
+		}
+		scheduler.segmentDownloaded(segment, flow.peer, blocks)
+	}
+}
+
+// downloadSegment requests the blocks of the given segment from the peer
+// and receives them. It returns no blocks if the peer doesn't have them.
+func (flow *handleIBDFlow) downloadSegment(scheduler *BlockDownloadScheduler,
+	segment *segment) ([]*domainmessage.MsgIBDBlock, error) {
+
+	err := flow.sendGetBlocks(segment.lowHash, segment.highHash)
+	if err != nil {
+		return nil, err
+	}
 
-		blocksReceived++
-		if blocksReceived%ibdBatchSize == 0 {
+	var blocks []*domainmessage.MsgIBDBlock
+	for {
+		msgIBDBlock, doneIBD, err := flow.receiveIBDBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		if doneIBD {
+			break
+		}
+
+		blocks = append(blocks, msgIBDBlock)
+		scheduler.reportProgress(segment, flow.peer)
+
+		if len(blocks)%ibdBatchSize == 0 {
 			err = flow.outgoingRoute.Enqueue(domainmessage.NewMsgRequestNextIBDBlocks())
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
-}
 
-func (flow *handleIBDFlow) sendGetBlocks(highestSharedBlockHash *daghash.Hash,
-	peerSelectedTipHash *daghash.Hash) error {
+	// The high hash of the segment is the last block in its past, so
+	// a peer that has the segment must send it last.
+	if len(blocks) > 0 && !blocks[len(blocks)-1].BlockHash().IsEqual(segment.highHash) {
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received "+
+			"the blocks between %s and %s without the high block", segment.lowHash, segment.highHash)
+	}
+	return blocks, nil
+}
 
-	msgGetBlockInvs := domainmessage.NewMsgRequstIBDBlocks(highestSharedBlockHash, peerSelectedTipHash)
+func (flow *handleIBDFlow) sendGetBlocks(lowHash *daghash.Hash, highHash *daghash.Hash) error {
+	msgGetBlockInvs := domainmessage.NewMsgRequstIBDBlocks(lowHash, highHash)
 	return flow.outgoingRoute.Enqueue(msgGetBlockInvs)
 }
 
@@ -167,32 +346,117 @@ func (flow *handleIBDFlow) receiveIBDBlock() (msgIBDBlock *domainmessage.MsgIBDB
 		return nil, true, nil
 	default:
 		return nil, false,
-			protocolerrors.Errorf(true, "received unexpected message type. "+
+			protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received unexpected message type. "+
 				"expected: %s, got: %s", domainmessage.CmdIBDBlock, message.Command())
 	}
 }
 
-func (flow *handleIBDFlow) processIBDBlock(msgIBDBlock *domainmessage.MsgIBDBlock) error {
+// processSegments adds the blocks of the segments to the DAG, segment by
+// segment, as soon as they're downloaded. If the blocks of a segment
+// that was downloaded from another peer are rejected, the segment is
+// downloaded again and that peer is disconnected.
+func (flow *handleIBDFlow) processSegments(scheduler *BlockDownloadScheduler,
+	headerHashes map[daghash.Hash]struct{}) error {
+
+	for index := 0; ; {
+		segment, err := scheduler.waitForSegment(index)
+		if err != nil {
+			return err
+		}
+		if segment == nil {
+			return nil
+		}
+
+		err = flow.processSegment(scheduler, segment, headerHashes)
+		if err != nil {
+			protocolErr := &protocolerrors.ProtocolError{}
+			if segment.downloadedFrom == flow.peer || !errors.As(err, &protocolErr) {
+				return err
+			}
+			log.Infof("Rejected IBD blocks between %s and %s from %s: %s",
+				segment.lowHash, segment.highHash, segment.downloadedFrom, err)
+			scheduler.rejectSegment(segment, err)
+			continue
+		}
+		flow.SetIBDResumeHash(segment.highHash)
+		index++
+	}
+}
+
+func (flow *handleIBDFlow) processSegment(scheduler *BlockDownloadScheduler, segment *segment,
+	headerHashes map[daghash.Hash]struct{}) error {
+
+	addedBlocks := 0
+	for _, msgIBDBlock := range segment.blocks {
+		blockHash := msgIBDBlock.BlockHash()
+		if _, ok := headerHashes[*blockHash]; headerHashes != nil && !ok {
+			return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received IBD block %s "+
+				"whose header wasn't validated", blockHash)
+		}
+		isKnown, err := flow.processIBDBlock(msgIBDBlock)
+		if err != nil {
+			return err
+		}
+		scheduler.progress.blockProcessed(isKnown)
+		if !isKnown {
+			addedBlocks++
+		}
+	}
+	if addedBlocks == 0 {
+		return flow.handleKnownSegment(scheduler, segment)
+	}
+	return nil
+}
+
+// handleKnownSegment handles a segment whose blocks were all already in
+// the DAG. The high block of every segment is a block of the IBD peer's
+// selected parent chain that wasn't in the DAG when the segments were
+// built, so a peer that sends such segments is deprioritized for IBD, and
+// disconnected once it keeps doing it.
+func (flow *handleIBDFlow) handleKnownSegment(scheduler *BlockDownloadScheduler, segment *segment) error {
+	peer := segment.downloadedFrom
+	log.Debugf("All the blocks between %s and %s from %s were already known",
+		segment.lowHash, segment.highHash, peer)
+	peer.DeprioritizeForIBD()
+
+	err := flow.AddBanScore(peer, protocolerrors.BanScoreMinor, "sent only known IBD blocks")
+	if err == nil {
+		return nil
+	}
+	if peer == flow.peer {
+		return err
+	}
+	scheduler.stopPeer(peer, err)
+	return nil
+}
+
+// processIBDBlock adds the given block to the DAG. It returns
+// whether the block was already in the DAG.
+func (flow *handleIBDFlow) processIBDBlock(msgIBDBlock *domainmessage.MsgIBDBlock) (isKnown bool, err error) {
 
 	block := util.NewBlock(msgIBDBlock.MsgBlock)
 	if flow.DAG().IsInDAG(block.Hash()) {
-		return nil
+		return true, nil
 	}
 	isOrphan, isDelayed, err := flow.DAG().ProcessBlock(block, blockdag.BFNone)
 	if err != nil {
-		return err
+		if !errors.As(err, &blockdag.RuleError{}) {
+			return false, errors.Wrapf(err, "failed to process block %s", block.Hash())
+		}
+		return false, protocolerrors.Wrapf(protocolerrors.BanScoreSevere, err, "received invalid block %s "+
+			"during IBD", block.Hash())
 	}
 	if isOrphan {
-		return protocolerrors.Errorf(true, "received orphan block %s "+
+		return false, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received orphan block %s "+
 			"during IBD", block.Hash())
 	}
 	if isDelayed {
-		return protocolerrors.Errorf(false, "received delayed block %s "+
+		return false, protocolerrors.Errorf(protocolerrors.BanScoreNone, "received delayed block %s "+
 			"during IBD", block.Hash())
 	}
 	err = flow.OnNewBlock(block)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return false, nil
 }
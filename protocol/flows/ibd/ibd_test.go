@@ -0,0 +1,143 @@
+package ibd
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/netadapter/router"
+	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// fakeIBDContext is a HandleIBDContext and a RequestBlockLocatorContext
+// that only provides a DAG and an IBD resume hash.
+type fakeIBDContext struct {
+	dag           *blockdag.BlockDAG
+	ibdResumeHash *daghash.Hash
+}
+
+func (c *fakeIBDContext) DAG() *blockdag.BlockDAG                            { return c.dag }
+func (c *fakeIBDContext) OnNewBlock(block *util.Block) error                 { return nil }
+func (c *fakeIBDContext) StartIBDIfRequired()                                {}
+func (c *fakeIBDContext) FinishIBD()                                         {}
+func (c *fakeIBDContext) StartIBDBlockDownload(*BlockDownloadScheduler)      {}
+func (c *fakeIBDContext) IBDBlockDownloadScheduler() *BlockDownloadScheduler { return nil }
+func (c *fakeIBDContext) IBDResumeHash() *daghash.Hash                       { return c.ibdResumeHash }
+func (c *fakeIBDContext) SetIBDResumeHash(hash *daghash.Hash)                { c.ibdResumeHash = hash }
+func (c *fakeIBDContext) AddBanScore(*peerpkg.Peer, uint32, string) error    { return nil }
+
+// TestFindHighestSharedBlockHash checks that IBD that's resumed with a
+// different peer finds the highest shared block, also when the block it
+// resumes from isn't in the selected parent chain of the new peer, or
+// isn't known to it at all.
+func TestFindHighestSharedBlockHash(t *testing.T) {
+	params := dagconfig.SimnetParams
+	peerDAG, teardownPeerDAG, err := blockdag.DAGSetup("TestFindHighestSharedBlockHash-peer", true,
+		blockdag.Config{DAGParams: &params})
+	if err != nil {
+		t.Fatalf("Failed to setup the DAG of the peer: %s", err)
+	}
+	defer teardownPeerDAG()
+	dag, teardownDAG, err := blockdag.DAGSetup("TestFindHighestSharedBlockHash", true,
+		blockdag.Config{DAGParams: &params})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG: %s", err)
+	}
+	defer teardownDAG()
+
+	processBlock := func(msgBlock *domainmessage.MsgBlock) {
+		isOrphan, isDelayed, err := dag.ProcessBlock(util.NewBlock(msgBlock), blockdag.BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlock: %s", err)
+		}
+		if isOrphan || isDelayed {
+			t.Fatalf("ProcessBlock: block %s is unexpectedly an orphan or delayed", msgBlock.BlockHash())
+		}
+	}
+
+	// The peer has a chain of 10 blocks on top of the genesis, and a
+	// block next to it that isn't in its selected parent chain. We
+	// have the first 5 blocks of the chain and the side block.
+	const chainLength = 10
+	const sharedChainLength = 5
+	chain := make([]*domainmessage.MsgBlock, chainLength)
+	parentHash := params.GenesisHash
+	for i := range chain {
+		chain[i] = blockdag.PrepareAndProcessBlockForTest(t, peerDAG, []*daghash.Hash{parentHash}, nil)
+		parentHash = chain[i].BlockHash()
+		if i < sharedChainLength {
+			processBlock(chain[i])
+		}
+	}
+	sideBlock := blockdag.PrepareAndProcessBlockForTest(t, peerDAG, []*daghash.Hash{chain[0].BlockHash()}, nil)
+	processBlock(sideBlock)
+
+	// The peer doesn't know a block that we got from the
+	// previous IBD peer
+	unknownBlock := blockdag.PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{sideBlock.BlockHash()}, nil)
+
+	peerSelectedTipHash := chain[chainLength-1].BlockHash()
+	if !peerDAG.SelectedTipHash().IsEqual(peerSelectedTipHash) {
+		t.Fatalf("expected the selected tip of the peer to be the top of the chain")
+	}
+	expectedHighestSharedBlockHash := chain[sharedChainLength-1].BlockHash()
+
+	tests := []struct {
+		name          string
+		ibdResumeHash *daghash.Hash
+	}{
+		{
+			name: "no resume hash",
+		},
+		{
+			name:          "resume hash in the selected parent chain of the peer",
+			ibdResumeHash: chain[1].BlockHash(),
+		},
+		{
+			name:          "resume hash outside the selected parent chain of the peer",
+			ibdResumeHash: sideBlock.BlockHash(),
+		},
+		{
+			name:          "resume hash unknown to the peer",
+			ibdResumeHash: unknownBlock.BlockHash(),
+		},
+	}
+
+	for _, test := range tests {
+		// Connect the flow to a block locator flow of the peer
+		requestRoute := router.NewRoute()
+		responseRoute := router.NewRoute()
+		peerErrChan := make(chan error, 1)
+		go func() {
+			peerErrChan <- HandleRequestBlockLocator(&fakeIBDContext{dag: peerDAG},
+				requestRoute, responseRoute)
+		}()
+
+		flow := &handleIBDFlow{
+			HandleIBDContext: &fakeIBDContext{dag: dag, ibdResumeHash: test.ibdResumeHash},
+			incomingRoute:    responseRoute,
+			outgoingRoute:    requestRoute,
+		}
+		highestSharedBlockHash, err := flow.findHighestSharedBlockHash(peerSelectedTipHash)
+		requestRoute.Close()
+		if err != nil {
+			t.Fatalf("%s: findHighestSharedBlockHash: unexpected error: %s", test.name, err)
+		}
+		if !highestSharedBlockHash.IsEqual(expectedHighestSharedBlockHash) {
+			t.Fatalf("%s: findHighestSharedBlockHash: unexpected highest shared block. "+
+				"Want: %s, got: %s", test.name, expectedHighestSharedBlockHash, highestSharedBlockHash)
+		}
+
+		// The block locator flow of the peer only stops
+		// because its route was closed
+		peerErr := <-peerErrChan
+		if !errors.Is(peerErr, router.ErrRouteClosed) {
+			t.Fatalf("%s: HandleRequestBlockLocator: expected the route to be closed, "+
+				"got: %v", test.name, peerErr)
+		}
+	}
+}
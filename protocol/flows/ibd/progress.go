@@ -0,0 +1,92 @@
+package ibd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ibdProgressInterval is the interval in which the progress of an
+	// IBD round is checked.
+	ibdProgressInterval = 15 * time.Second
+
+	// ibdMinBlocksPerInterval is the minimum number of blocks that have
+	// to be either received or added to the DAG in every
+	// ibdProgressInterval. An IBD round that progresses slower than that
+	// is considered stalled.
+	ibdMinBlocksPerInterval = 10
+)
+
+// errIBDStalled is the error with which an IBD round is canceled once
+// it's considered stalled.
+var errIBDStalled = errors.New("IBD stalled")
+
+// progressTracker counts the blocks that are received and added to the
+// DAG during an IBD round, in intervals of ibdProgressInterval.
+type progressTracker struct {
+	lock              sync.Mutex
+	intervalStartTime time.Time
+	receivedBlocks    uint64
+	addedBlocks       uint64
+	knownBlocks       uint64
+}
+
+// progressInterval is the progress of an IBD round in a single interval.
+type progressInterval struct {
+	duration       time.Duration
+	receivedBlocks uint64
+	addedBlocks    uint64
+	knownBlocks    uint64
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{intervalStartTime: time.Now()}
+}
+
+func (pt *progressTracker) blockReceived() {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	pt.receivedBlocks++
+}
+
+func (pt *progressTracker) blockProcessed(isKnown bool) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	if isKnown {
+		pt.knownBlocks++
+		return
+	}
+	pt.addedBlocks++
+}
+
+// endInterval returns the progress in the current interval and starts
+// a new one.
+func (pt *progressTracker) endInterval() *progressInterval {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	now := time.Now()
+	interval := &progressInterval{
+		duration:       now.Sub(pt.intervalStartTime),
+		receivedBlocks: pt.receivedBlocks,
+		addedBlocks:    pt.addedBlocks,
+		knownBlocks:    pt.knownBlocks,
+	}
+	pt.intervalStartTime = now
+	pt.receivedBlocks = 0
+	pt.addedBlocks = 0
+	pt.knownBlocks = 0
+	return interval
+}
+
+func (interval *progressInterval) isStalled() bool {
+	return interval.receivedBlocks+interval.addedBlocks < ibdMinBlocksPerInterval
+}
+
+func (interval *progressInterval) addedBlocksPerSecond() float64 {
+	return float64(interval.addedBlocks) / interval.duration.Seconds()
+}
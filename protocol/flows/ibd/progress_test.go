@@ -0,0 +1,92 @@
+package ibd
+
+import (
+	"testing"
+)
+
+func TestProgressIntervalIsStalled(t *testing.T) {
+	tests := []struct {
+		name              string
+		receivedBlocks    uint64
+		addedBlocks       uint64
+		knownBlocks       uint64
+		expectedIsStalled bool
+	}{
+		{
+			name:              "no progress",
+			expectedIsStalled: true,
+		},
+		{
+			name:              "too few blocks",
+			receivedBlocks:    ibdMinBlocksPerInterval / 2,
+			addedBlocks:       ibdMinBlocksPerInterval/2 - 1,
+			expectedIsStalled: true,
+		},
+		{
+			name:              "known blocks don't count",
+			receivedBlocks:    ibdMinBlocksPerInterval - 1,
+			knownBlocks:       ibdMinBlocksPerInterval,
+			expectedIsStalled: true,
+		},
+		{
+			name:              "enough received blocks",
+			receivedBlocks:    ibdMinBlocksPerInterval,
+			expectedIsStalled: false,
+		},
+		{
+			name:              "enough added blocks",
+			addedBlocks:       ibdMinBlocksPerInterval,
+			expectedIsStalled: false,
+		},
+		{
+			name:              "enough received and added blocks together",
+			receivedBlocks:    ibdMinBlocksPerInterval / 2,
+			addedBlocks:       ibdMinBlocksPerInterval - ibdMinBlocksPerInterval/2,
+			expectedIsStalled: false,
+		},
+	}
+
+	for _, test := range tests {
+		interval := &progressInterval{
+			receivedBlocks: test.receivedBlocks,
+			addedBlocks:    test.addedBlocks,
+			knownBlocks:    test.knownBlocks,
+		}
+		isStalled := interval.isStalled()
+		if isStalled != test.expectedIsStalled {
+			t.Errorf("%s: unexpected isStalled. Want: %t, got: %t",
+				test.name, test.expectedIsStalled, isStalled)
+		}
+	}
+}
+
+func TestProgressTrackerEndInterval(t *testing.T) {
+	tracker := newProgressTracker()
+	for i := 0; i < ibdMinBlocksPerInterval; i++ {
+		tracker.blockReceived()
+		tracker.blockProcessed(i%2 == 0)
+	}
+
+	interval := tracker.endInterval()
+	if interval.receivedBlocks != ibdMinBlocksPerInterval ||
+		interval.addedBlocks != ibdMinBlocksPerInterval/2 ||
+		interval.knownBlocks != ibdMinBlocksPerInterval/2 {
+
+		t.Fatalf("endInterval: unexpected progress. Received: %d, added: %d, known: %d",
+			interval.receivedBlocks, interval.addedBlocks, interval.knownBlocks)
+	}
+	if interval.isStalled() {
+		t.Fatalf("isStalled: expected an interval with %d received blocks not to be stalled",
+			interval.receivedBlocks)
+	}
+
+	// The counts start over in the next interval, so an interval
+	// without any blocks is stalled
+	interval = tracker.endInterval()
+	if interval.receivedBlocks != 0 || interval.addedBlocks != 0 || interval.knownBlocks != 0 {
+		t.Fatalf("endInterval: expected the counts to start over in a new interval")
+	}
+	if !interval.isStalled() {
+		t.Fatalf("isStalled: expected an interval without blocks to be stalled")
+	}
+}
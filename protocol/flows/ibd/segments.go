@@ -0,0 +1,145 @@
+package ibd
+
+import (
+	"math"
+
+	"github.com/kaspanet/kaspad/protocol/protocolerrors"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+const (
+	// ibdSegmentSize is the approximate number of blocks, measured in
+	// blue score, in a single segment of IBD blocks.
+	ibdSegmentSize = 4 * ibdBatchSize
+
+	// ibdMaxRoundSize is the approximate maximum number of blocks,
+	// measured in blue score, that are downloaded in a single IBD round.
+	// The rest of the blocks are downloaded in the following rounds.
+	ibdMaxRoundSize = 1 << 13
+
+	// ibdMaxRangeSplits is the maximum number of chain ranges that are
+	// split while building the segments of a single IBD round. It's far
+	// more than an honest peer requires, since the sizes of the ranges
+	// split by its block locators shrink exponentially.
+	ibdMaxRangeSplits = 1 << 8
+)
+
+// chainRange is a range of the selected parent chain of the IBD peer,
+// along with an approximation of the difference between the blue scores
+// of its ends.
+type chainRange struct {
+	lowHash, highHash *daghash.Hash
+	size              uint64
+}
+
+// buildSegments splits the selected parent chain of the IBD peer, from
+// the highest shared block up to the peer's selected tip, into segments
+// of about ibdSegmentSize blocks each.
+//
+// Since we don't know the blocks of the peer yet, the chain is split
+// using block locators, which contain chain blocks whose distances from
+// the high hash grow exponentially. Ranges of the chain that are too
+// large are split again with a block locator of their own, starting from
+// the lowest one, until the round is large enough.
+func (flow *handleIBDFlow) buildSegments(highestSharedBlockHash *daghash.Hash,
+	peerSelectedTipHash *daghash.Hash) ([]*segment, error) {
+
+	pendingRanges := []*chainRange{{
+		lowHash:  highestSharedBlockHash,
+		highHash: peerSelectedTipHash,
+		size:     math.MaxUint64,
+	}}
+	var segments []*segment
+	var currentRange *chainRange
+	roundSize := uint64(0)
+	splitCount := 0
+	for len(pendingRanges) > 0 && roundSize < ibdMaxRoundSize {
+		lowestRange := pendingRanges[0]
+		if lowestRange.size > ibdSegmentSize {
+			if splitCount == ibdMaxRangeSplits {
+				return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "the chain "+
+					"between %s and %s was still not split into segments after %d block locators",
+					highestSharedBlockHash, peerSelectedTipHash, ibdMaxRangeSplits)
+			}
+			splitCount++
+			subRanges, err := flow.splitChainRange(lowestRange)
+			if err != nil {
+				return nil, err
+			}
+			pendingRanges = append(subRanges, pendingRanges[1:]...)
+			continue
+		}
+		pendingRanges = pendingRanges[1:]
+		roundSize += lowestRange.size
+
+		if currentRange != nil && currentRange.size+lowestRange.size > ibdSegmentSize {
+			segments = append(segments, newSegment(len(segments), currentRange.lowHash, currentRange.highHash))
+			currentRange = nil
+		}
+		if currentRange == nil {
+			currentRange = &chainRange{lowHash: lowestRange.lowHash}
+		}
+		currentRange.highHash = lowestRange.highHash
+		currentRange.size += lowestRange.size
+	}
+	if currentRange != nil {
+		segments = append(segments, newSegment(len(segments), currentRange.lowHash, currentRange.highHash))
+	}
+	return segments, nil
+}
+
+// splitChainRange splits the given range using a block locator from the
+// IBD peer. The locator starts with the selected parent of the high hash
+// and ends with the low hash, and the distance of its i-th hash from the
+// high hash is about 2^i, so the sizes of the sub-ranges are derived
+// from their positions in the locator.
+func (flow *handleIBDFlow) splitChainRange(rangeToSplit *chainRange) ([]*chainRange, error) {
+	err := flow.sendGetBlockLocator(rangeToSplit.lowHash, rangeToSplit.highHash)
+	if err != nil {
+		return nil, err
+	}
+	blockLocatorHashes, err := flow.receiveBlockLocator()
+	if err != nil {
+		return nil, err
+	}
+	if len(blockLocatorHashes) == 0 ||
+		!blockLocatorHashes[len(blockLocatorHashes)-1].IsEqual(rangeToSplit.lowHash) {
+
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received a block "+
+			"locator between %s and %s that doesn't end with the low hash",
+			rangeToSplit.lowHash, rangeToSplit.highHash)
+	}
+
+	// Since the distances between the locator hashes double, a locator
+	// of a range of blue scores, which are uint64s, can't be any longer.
+	// Moreover, the lowest sub-range of a locator can't be larger than
+	// the range that it splits.
+	const maxBlockLocatorLength = 65
+	if len(blockLocatorHashes) > maxBlockLocatorLength ||
+		(len(blockLocatorHashes) > 1 && 1<<uint(len(blockLocatorHashes)-2) > rangeToSplit.size) {
+
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "received a block "+
+			"locator with %d hashes, which is too long for the range between %s and %s",
+			len(blockLocatorHashes), rangeToSplit.lowHash, rangeToSplit.highHash)
+	}
+
+	// The sub-ranges are ordered from the lowest to the highest
+	subRanges := make([]*chainRange, len(blockLocatorHashes))
+	for i := len(blockLocatorHashes) - 1; i >= 1; i-- {
+		size := uint64(1) << uint(i-1)
+		if size > rangeToSplit.size {
+			size = rangeToSplit.size
+		}
+		subRanges[len(blockLocatorHashes)-1-i] = &chainRange{
+			lowHash:  blockLocatorHashes[i],
+			highHash: blockLocatorHashes[i-1],
+			size:     size,
+		}
+	}
+	subRanges[len(blockLocatorHashes)-1] = &chainRange{
+		lowHash:  blockLocatorHashes[0],
+		highHash: rangeToSplit.highHash,
+		size:     1,
+	}
+	return subRanges, nil
+}
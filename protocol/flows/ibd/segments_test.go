@@ -0,0 +1,90 @@
+package ibd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/netadapter/router"
+	"github.com/kaspanet/kaspad/protocol/protocolerrors"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TestBuildSegmentsRejectsOversizedLocators checks that block locators
+// that are too long for the ranges that they split are rejected, and that
+// building the segments fails with a protocol error instead of splitting
+// ranges forever when the peer never lets them shrink.
+func TestBuildSegmentsRejectsOversizedLocators(t *testing.T) {
+	tests := []struct {
+		name string
+		// locatorLengths are the lengths of the block locators that the
+		// peer responds with. The last one is repeated indefinitely.
+		locatorLengths []int
+		expectedErr    string
+	}{
+		{
+			name:           "locator longer than any range",
+			locatorLengths: []int{66},
+			expectedErr:    "which is too long for the range",
+		},
+		{
+			name:           "locator longer than the split range",
+			locatorLengths: []int{12, 13},
+			expectedErr:    "which is too long for the range",
+		},
+		{
+			name:           "ranges that never shrink",
+			locatorLengths: []int{12},
+			expectedErr:    "was still not split into segments",
+		},
+	}
+
+	hashCounter := byte(0)
+	newHash := func() *daghash.Hash {
+		hashCounter++
+		return &daghash.Hash{hashCounter, hashCounter + 1}
+	}
+
+	for _, test := range tests {
+		requestRoute := router.NewRoute()
+		responseRoute := router.NewRoute()
+		go func(locatorLengths []int) {
+			for i := 0; ; i++ {
+				message, err := requestRoute.Dequeue()
+				if err != nil {
+					return
+				}
+				request := message.(*domainmessage.MsgRequestBlockLocator)
+				locatorLength := locatorLengths[len(locatorLengths)-1]
+				if i < len(locatorLengths) {
+					locatorLength = locatorLengths[i]
+				}
+				locator := make([]*daghash.Hash, locatorLength)
+				for j := range locator[:locatorLength-1] {
+					locator[j] = newHash()
+				}
+				locator[locatorLength-1] = request.LowHash
+				err = responseRoute.Enqueue(domainmessage.NewMsgBlockLocator(locator))
+				if err != nil {
+					return
+				}
+			}
+		}(test.locatorLengths)
+
+		flow := &handleIBDFlow{
+			incomingRoute: responseRoute,
+			outgoingRoute: requestRoute,
+		}
+		_, err := flow.buildSegments(newHash(), newHash())
+		requestRoute.Close()
+		var protocolErr *protocolerrors.ProtocolError
+		if !errors.As(err, &protocolErr) {
+			t.Fatalf("%s: buildSegments: expected a protocol error, got: %v", test.name, err)
+		}
+		if !strings.Contains(err.Error(), test.expectedErr) {
+			t.Fatalf("%s: buildSegments: expected an error containing %q, got: %s",
+				test.name, test.expectedErr, err)
+		}
+	}
+}
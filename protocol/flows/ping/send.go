@@ -58,7 +58,7 @@ func (flow *sendPingsFlow) start() error {
 		}
 		pongMessage := message.(*domainmessage.MsgPong)
 		if pongMessage.Nonce != pingMessage.Nonce {
-			return protocolerrors.New(true, "nonce mismatch between ping and pong")
+			return protocolerrors.New(protocolerrors.BanScoreModerate, "nonce mismatch between ping and pong")
 		}
 		flow.peer.SetPingIdle()
 	}
@@ -1,12 +1,15 @@
 package relaytransactions
 
 import (
+	"fmt"
+
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/mempool"
 	"github.com/kaspanet/kaspad/netadapter"
 	"github.com/kaspanet/kaspad/netadapter/router"
 	"github.com/kaspanet/kaspad/protocol/common"
+	peerpkg "github.com/kaspanet/kaspad/protocol/peer"
 	"github.com/kaspanet/kaspad/protocol/protocolerrors"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/daghash"
@@ -21,21 +24,26 @@ type TransactionsRelayContext interface {
 	SharedRequestedTransactions() *SharedRequestedTransactions
 	TxPool() *mempool.TxPool
 	Broadcast(message domainmessage.Message) error
+	AddBanScore(peer *peerpkg.Peer, score uint32, reason string) error
 }
 
 type handleRelayedTransactionsFlow struct {
 	TransactionsRelayContext
 	incomingRoute, outgoingRoute *router.Route
+	peer                         *peerpkg.Peer
 	invsQueue                    []*domainmessage.MsgInvTransaction
 }
 
 // HandleRelayedTransactions listens to domainmessage.MsgInvTransaction messages, requests their corresponding transactions if they
 // are missing, adds them to the mempool and propagates them to the rest of the network.
-func HandleRelayedTransactions(context TransactionsRelayContext, incomingRoute *router.Route, outgoingRoute *router.Route) error {
+func HandleRelayedTransactions(context TransactionsRelayContext, incomingRoute *router.Route, outgoingRoute *router.Route,
+	peer *peerpkg.Peer) error {
+
 	flow := &handleRelayedTransactionsFlow{
 		TransactionsRelayContext: context,
 		incomingRoute:            incomingRoute,
 		outgoingRoute:            outgoingRoute,
+		peer:                     peer,
 		invsQueue:                make([]*domainmessage.MsgInvTransaction, 0),
 	}
 	return flow.start()
@@ -128,7 +136,7 @@ func (flow *handleRelayedTransactionsFlow) readInv() (*domainmessage.MsgInvTrans
 
 	inv, ok := msg.(*domainmessage.MsgInvTransaction)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "unexpected %s message in the block relay flow while "+
+		return nil, protocolerrors.Errorf(protocolerrors.BanScoreModerate, "unexpected %s message in the block relay flow while "+
 			"expecting an inv message", msg.Command())
 	}
 	return inv, nil
@@ -182,7 +190,7 @@ func (flow *handleRelayedTransactionsFlow) receiveTransactions(requestedTransact
 		}
 		if msgTxNotFound != nil {
 			if !msgTxNotFound.ID.IsEqual(expectedID) {
-				return protocolerrors.Errorf(true, "expected transaction %s, but got %s",
+				return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "expected transaction %s, but got %s",
 					expectedID, msgTxNotFound.ID)
 			}
 
@@ -190,7 +198,7 @@ func (flow *handleRelayedTransactionsFlow) receiveTransactions(requestedTransact
 		}
 		tx := util.NewTx(msgTx)
 		if !tx.ID().IsEqual(expectedID) {
-			return protocolerrors.Errorf(true, "expected transaction %s, but got %s",
+			return protocolerrors.Errorf(protocolerrors.BanScoreModerate, "expected transaction %s, but got %s",
 				expectedID, tx.ID())
 		}
 
@@ -201,20 +209,25 @@ func (flow *handleRelayedTransactionsFlow) receiveTransactions(requestedTransact
 				return errors.Wrapf(err, "failed to process transaction %s", tx.ID())
 			}
 
-			shouldBan := false
+			isInvalid := false
 			if txRuleErr := (&mempool.TxRuleError{}); errors.As(ruleErr.Err, txRuleErr) {
 				if txRuleErr.RejectCode == mempool.RejectInvalid {
-					shouldBan = true
+					isInvalid = true
 				}
 			} else if dagRuleErr := (&blockdag.RuleError{}); errors.As(ruleErr.Err, dagRuleErr) {
-				shouldBan = true
+				isInvalid = true
 			}
 
-			if !shouldBan {
+			if !isInvalid {
 				continue
 			}
 
-			return protocolerrors.Errorf(true, "rejected transaction %s", tx.ID())
+			err = flow.AddBanScore(flow.peer, protocolerrors.BanScoreModerate,
+				fmt.Sprintf("rejected transaction %s: %s", tx.ID(), err))
+			if err != nil {
+				return err
+			}
+			continue
 		}
 		err = flow.broadcastAcceptedTransactions(acceptedTxs)
 		if err != nil {
@@ -57,6 +57,12 @@ func (m *Manager) AddTransaction(tx *util.Tx) error {
 	return m.context.AddTransaction(tx)
 }
 
+// AddPackage adds a package of transactions to the mempool as a unit and
+// propagates them.
+func (m *Manager) AddPackage(txs []*util.Tx) error {
+	return m.context.AddPackage(txs)
+}
+
 // AddBlock adds the given block to the DAG and propagates it.
 func (m *Manager) AddBlock(block *util.Block, flags blockdag.BehaviorFlags) error {
 	return m.context.AddBlock(block, flags)
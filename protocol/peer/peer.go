@@ -40,16 +40,27 @@ type Peer struct {
 	selectedTipRequestChan chan struct{}
 	lastSelectedTipRequest mstime.Time
 
-	ibdStartChan chan struct{}
+	ibdStartChan                chan struct{}
+	ibdBlockDownloadRequestChan chan struct{}
+
+	ibdDeprioritizationLock sync.Mutex
+	ibdDeprioritizedTime    time.Time
 }
 
+// ibdDeprioritizationDuration is the time during which a peer that
+// stalled IBD or sent useless IBD blocks is selected for IBD only if
+// there's no other peer to select.
+const ibdDeprioritizationDuration = 10 * time.Minute
+
 // New returns a new Peer
 func New(connection *netadapter.NetConnection) *Peer {
 	return &Peer{
-		connection:             connection,
-		selectedTipRequestChan: make(chan struct{}),
-		ibdStartChan:           make(chan struct{}),
-		connectionStarted:      time.Now(),
+		connection:                  connection,
+		selectedTipRequestChan:      make(chan struct{}),
+		ibdStartChan:                make(chan struct{}),
+		ibdBlockDownloadRequestChan: make(chan struct{}, 1),
+		protocolVersion:             domainmessage.ProtocolVersion,
+		connectionStarted:           time.Now(),
 	}
 }
 
@@ -93,11 +104,28 @@ func (p *Peer) UserAgent() string {
 	return p.userAgent
 }
 
+// Services returns the services that the peer advertised.
+func (p *Peer) Services() domainmessage.ServiceFlag {
+	return p.services
+}
+
+// IsPruned returns whether the peer is a pruned node, which doesn't
+// serve the blocks that are deep below its finality point.
+func (p *Peer) IsPruned() bool {
+	return p.services&domainmessage.SFNodeNetwork == 0
+}
+
 // AdvertisedProtocolVersion returns the peer's advertised protocol version.
 func (p *Peer) AdvertisedProtocolVersion() uint32 {
 	return p.advertisedProtocolVerion
 }
 
+// ProtocolVersion returns the protocol version that was negotiated
+// with the peer.
+func (p *Peer) ProtocolVersion() uint32 {
+	return p.protocolVersion
+}
+
 // TimeConnected returns the time since the connection to this been has been started.
 func (p *Peer) TimeConnected() time.Duration {
 	return time.Since(p.connectionStarted)
@@ -186,10 +214,46 @@ func (p *Peer) StartIBD() {
 	p.ibdStartChan <- struct{}{}
 }
 
-// WaitForIBDStart blocks the current thread until
-// IBD start is requested from this peer
-func (p *Peer) WaitForIBDStart() {
-	<-p.ibdStartChan
+// RequestIBDBlockDownload requests this peer to help downloading
+// the blocks of an IBD that runs against another peer. The request
+// is dropped if there's already a pending request.
+func (p *Peer) RequestIBDBlockDownload() {
+	select {
+	case p.ibdBlockDownloadRequestChan <- struct{}{}:
+	default:
+	}
+}
+
+// WaitForIBDStart blocks the current thread until IBD start is
+// requested from this peer, or until this peer is requested to
+// help downloading the blocks of another peer's IBD. It returns
+// true in the former case.
+func (p *Peer) WaitForIBDStart() (isIBDPeer bool) {
+	select {
+	case <-p.ibdStartChan:
+		return true
+	case <-p.ibdBlockDownloadRequestChan:
+		return false
+	}
+}
+
+// DeprioritizeForIBD makes this peer be selected for IBD only if
+// there's no other peer to select, for ibdDeprioritizationDuration.
+func (p *Peer) DeprioritizeForIBD() {
+	p.ibdDeprioritizationLock.Lock()
+	defer p.ibdDeprioritizationLock.Unlock()
+
+	p.ibdDeprioritizedTime = time.Now()
+}
+
+// IsDeprioritizedForIBD returns whether this peer was deprioritized
+// for IBD in the last ibdDeprioritizationDuration.
+func (p *Peer) IsDeprioritizedForIBD() bool {
+	p.ibdDeprioritizationLock.Lock()
+	defer p.ibdDeprioritizationLock.Unlock()
+
+	return !p.ibdDeprioritizedTime.IsZero() &&
+		time.Since(p.ibdDeprioritizedTime) < ibdDeprioritizationDuration
 }
 
 // Address returns the address associated with this connection
@@ -205,3 +269,15 @@ func (p *Peer) LastPingDuration() time.Duration {
 
 	return p.lastPingDuration
 }
+
+// PingWait returns how long the pending ping to this peer
+// has been waiting for a pong, or 0 if there's no pending ping
+func (p *Peer) PingWait() time.Duration {
+	p.pingLock.Lock()
+	defer p.pingLock.Unlock()
+
+	if p.lastPingNonce == 0 {
+		return 0
+	}
+	return time.Since(p.lastPingTime)
+}
@@ -51,7 +51,7 @@ func (m *Manager) routerInitializer(router *routerpkg.Router, netConnection *net
 
 		netConnection.SetOnInvalidMessageHandler(func(err error) {
 			if atomic.AddUint32(&isStopping, 1) == 1 {
-				errChan <- protocolerrors.Wrap(true, err, "received bad message")
+				errChan <- protocolerrors.Wrap(protocolerrors.BanScoreModerate, err, "received bad message")
 			}
 		})
 
@@ -72,14 +72,13 @@ func (m *Manager) routerInitializer(router *routerpkg.Router, netConnection *net
 	})
 }
 
+// handleError handles an error that terminated the flows of the passed
+// connection.
 func (m *Manager) handleError(err error, netConnection *netadapter.NetConnection) {
 	if protocolErr := &(protocolerrors.ProtocolError{}); errors.As(err, &protocolErr) {
-		if !m.context.Config().DisableBanning && protocolErr.ShouldBan {
-			log.Warnf("Banning %s (reason: %s)", netConnection, protocolErr.Cause)
-			err := m.context.ConnectionManager().Ban(netConnection)
-			if err != nil && !errors.Is(err, addressmanager.ErrAddressNotFound) {
-				panic(err)
-			}
+		err := m.context.HandleProtocolError(netConnection.NetAddress(), netConnection.String(), protocolErr)
+		if err != nil && !errors.Is(err, addressmanager.ErrAddressNotFound) {
+			panic(err)
 		}
 		netConnection.Disconnect()
 		return
@@ -165,7 +164,7 @@ func (m *Manager) registerIBDFlows(router *routerpkg.Router, isStopping *uint32,
 
 	return []*flow{
 		m.registerFlow("HandleIBD", router, []domainmessage.MessageCommand{domainmessage.CmdBlockLocator, domainmessage.CmdIBDBlock,
-			domainmessage.CmdDoneIBDBlocks}, isStopping, errChan,
+			domainmessage.CmdDoneIBDBlocks, domainmessage.CmdBlockHeaders, domainmessage.CmdDoneHeaders}, isStopping, errChan,
 			func(incomingRoute *routerpkg.Route, peer *peerpkg.Peer) error {
 				return ibd.HandleIBD(m.context, incomingRoute, outgoingRoute, peer)
 			},
@@ -194,6 +193,12 @@ func (m *Manager) registerIBDFlows(router *routerpkg.Router, isStopping *uint32,
 				return ibd.HandleRequestIBDBlocks(m.context, incomingRoute, outgoingRoute)
 			},
 		),
+
+		m.registerFlow("HandleRequestHeaders", router, []domainmessage.MessageCommand{domainmessage.CmdRequestHeaders, domainmessage.CmdRequestNextHeaders}, isStopping, errChan,
+			func(incomingRoute *routerpkg.Route, peer *peerpkg.Peer) error {
+				return ibd.HandleRequestHeaders(m.context, incomingRoute, outgoingRoute)
+			},
+		),
 	}
 }
 
@@ -204,7 +209,7 @@ func (m *Manager) registerTransactionRelayFlow(router *routerpkg.Router, isStopp
 		m.registerFlow("HandleRelayedTransactions", router,
 			[]domainmessage.MessageCommand{domainmessage.CmdInvTransaction, domainmessage.CmdTx, domainmessage.CmdTransactionNotFound}, isStopping, errChan,
 			func(incomingRoute *routerpkg.Route, peer *peerpkg.Peer) error {
-				return relaytransactions.HandleRelayedTransactions(m.context, incomingRoute, outgoingRoute)
+				return relaytransactions.HandleRelayedTransactions(m.context, incomingRoute, outgoingRoute, peer)
 			},
 		),
 		m.registerFlow("HandleRequestTransactions", router,
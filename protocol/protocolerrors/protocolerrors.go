@@ -2,11 +2,37 @@ package protocolerrors
 
 import "github.com/pkg/errors"
 
+// Ban scores of the various kinds of misbehavior. A peer is banned once its
+// accumulated ban score, which decays over time, reaches the ban threshold
+// (100 by default).
+const (
+	// BanScoreNone is the ban score of errors that aren't caused by
+	// misbehavior, such as timeouts.
+	BanScoreNone uint32 = 0
+
+	// BanScoreMinor is the ban score of misbehavior that honest peers may
+	// occasionally exhibit, for example due to races.
+	BanScoreMinor uint32 = 10
+
+	// BanScoreModerate is the ban score of protocol violations that honest
+	// peers are unlikely to commit, such as malformed or unexpected
+	// messages.
+	BanScoreModerate uint32 = 50
+
+	// BanScoreSevere is the ban score of misbehavior that only malicious
+	// peers exhibit, such as relaying invalid blocks.
+	BanScoreSevere uint32 = 100
+)
+
 // ProtocolError is an error that signifies a violation
 // of the peer-to-peer protocol
 type ProtocolError struct {
+	BanScore uint32
+	Cause    error
+
+	// ShouldBan is set if the peer has to be banned regardless of
+	// BanScore, since its ban score already reached the ban threshold.
 	ShouldBan bool
-	Cause     error
 }
 
 func (e *ProtocolError) Error() string {
@@ -20,36 +46,47 @@ func (e *ProtocolError) Unwrap() error {
 // Errorf formats according to a format specifier and returns the string
 // as a value that satisfies error.
 // Errorf also records the stack trace at the point it was called.
-func Errorf(shouldBan bool, format string, args ...interface{}) error {
+func Errorf(banScore uint32, format string, args ...interface{}) error {
+	return &ProtocolError{
+		BanScore: banScore,
+		Cause:    errors.Errorf(format, args...),
+	}
+}
+
+// BanErrorf formats according to a format specifier and returns an error
+// that signifies that the peer has to be banned.
+// BanErrorf also records the stack trace at the point it was called.
+func BanErrorf(format string, args ...interface{}) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
+		BanScore:  BanScoreNone,
 		Cause:     errors.Errorf(format, args...),
+		ShouldBan: true,
 	}
 }
 
 // New returns an error with the supplied message.
 // New also records the stack trace at the point it was called.
-func New(shouldBan bool, message string) error {
+func New(banScore uint32, message string) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.New(message),
+		BanScore: banScore,
+		Cause:    errors.New(message),
 	}
 }
 
 // Wrap returns an error annotating err with a stack trace
 // at the point Wrap is called, and the supplied message.
-func Wrap(shouldBan bool, err error, message string) error {
+func Wrap(banScore uint32, err error, message string) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.Wrap(err, message),
+		BanScore: banScore,
+		Cause:    errors.Wrap(err, message),
 	}
 }
 
 // Wrapf returns an error annotating err with a stack trace
 // at the point Wrapf is called, and the format specifier.
-func Wrapf(shouldBan bool, err error, format string, args ...interface{}) error {
+func Wrapf(banScore uint32, err error, format string, args ...interface{}) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.Wrapf(err, format, args...),
+		BanScore: banScore,
+		Cause:    errors.Wrapf(err, format, args...),
 	}
 }
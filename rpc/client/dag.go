@@ -15,6 +15,7 @@ import (
 
 	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/daghash"
 )
 
@@ -526,6 +527,108 @@ func (c *Client) GetMempoolEntry(txHash string) (*model.GetMempoolEntryResult, e
 	return c.GetMempoolEntryAsync(txHash).Receive()
 }
 
+// FutureEstimateFeeResult is a future promise to deliver the result of an
+// EstimateFeeAsync RPC invocation (or an applicable error).
+type FutureEstimateFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// estimated fee rate.
+func (r FutureEstimateFeeResult) Receive() (*model.EstimateFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var estimateFeeResult model.EstimateFeeResult
+	err = json.Unmarshal(res, &estimateFeeResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode estimateFee response")
+	}
+
+	return &estimateFeeResult, nil
+}
+
+// EstimateFeeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See EstimateFee for the blocking version and more details.
+func (c *Client) EstimateFeeAsync(targetBlueScores uint64) FutureEstimateFeeResult {
+	cmd := model.NewEstimateFeeCmd(targetBlueScores)
+	return c.sendCmd(cmd)
+}
+
+// EstimateFee returns the fee rate, in KAS per 1000 grams of transaction
+// mass, that a transaction has to pay in order to be included in a block
+// within the given number of blue-score confirmations.
+func (c *Client) EstimateFee(targetBlueScores uint64) (*model.EstimateFeeResult, error) {
+	return c.EstimateFeeAsync(targetBlueScores).Receive()
+}
+
+// FutureSaveMempoolResult is a future promise to deliver the result of a
+// SaveMempoolAsync RPC invocation (or an applicable error).
+type FutureSaveMempoolResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the mempool couldn't be saved.
+func (r FutureSaveMempoolResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SaveMempoolAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SaveMempool for the blocking version and more details.
+func (c *Client) SaveMempoolAsync() FutureSaveMempoolResult {
+	cmd := model.NewSaveMempoolCmd()
+	return c.sendCmd(cmd)
+}
+
+// SaveMempool saves the transactions in the memory pool to the data
+// directory of the server, from which they are loaded on startup.
+func (c *Client) SaveMempool() error {
+	return c.SaveMempoolAsync().Receive()
+}
+
+// FutureGetMempoolLoadStatusResult is a future promise to deliver the result
+// of a GetMempoolLoadStatusAsync RPC invocation (or an applicable error).
+type FutureGetMempoolLoadStatusResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// status of loading the saved memory pool transactions.
+func (r FutureGetMempoolLoadStatusResult) Receive() (*model.GetMempoolLoadStatusResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var loadStatusResult model.GetMempoolLoadStatusResult
+	err = json.Unmarshal(res, &loadStatusResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getMempoolLoadStatus response")
+	}
+
+	return &loadStatusResult, nil
+}
+
+// GetMempoolLoadStatusAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetMempoolLoadStatus for the blocking version and more details.
+func (c *Client) GetMempoolLoadStatusAsync() FutureGetMempoolLoadStatusResult {
+	cmd := model.NewGetMempoolLoadStatusCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetMempoolLoadStatus returns the status of loading the memory pool
+// transactions that the server saved before its last shutdown.
+func (c *Client) GetMempoolLoadStatus() (*model.GetMempoolLoadStatusResult, error) {
+	return c.GetMempoolLoadStatusAsync().Receive()
+}
+
 // FutureGetRawMempoolResult is a future promise to deliver the result of a
 // GetRawMempoolAsync RPC invocation (or an applicable error).
 type FutureGetRawMempoolResult chan *response
@@ -704,6 +807,165 @@ func (c *Client) GetTxOut(txHash *daghash.Hash, index uint32, mempool bool) (*mo
 	return c.GetTxOutAsync(txHash, index, mempool).Receive()
 }
 
+// FutureGetUTXOsByAddressResult is a future promise to deliver the result of a
+// GetUTXOsByAddressAsync RPC invocation (or an applicable error).
+type FutureGetUTXOsByAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// unspent transaction outputs that pay to the requested address.
+func (r FutureGetUTXOsByAddressResult) Receive() (*model.GetUTXOsByAddressResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getUTXOsByAddress result object.
+	var getUTXOsByAddressResult *model.GetUTXOsByAddressResult
+	err = json.Unmarshal(res, &getUTXOsByAddressResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getUTXOsByAddress response")
+	}
+
+	return getUTXOsByAddressResult, nil
+}
+
+// GetUTXOsByAddressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetUTXOsByAddress for the blocking version and more details.
+func (c *Client) GetUTXOsByAddressAsync(address util.Address) FutureGetUTXOsByAddressResult {
+	cmd := model.NewGetUTXOsByAddressCmd(address.EncodeAddress())
+	return c.sendCmd(cmd)
+}
+
+// GetUTXOsByAddress returns all the unspent transaction outputs that pay
+// to the given address. It requires the node to run with --utxoindex.
+func (c *Client) GetUTXOsByAddress(address util.Address) (*model.GetUTXOsByAddressResult, error) {
+	return c.GetUTXOsByAddressAsync(address).Receive()
+}
+
+// FutureGetAddressTransactionsResult is a future promise to deliver the result of a
+// GetAddressTransactionsAsync RPC invocation (or an applicable error).
+type FutureGetAddressTransactionsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// transactions that credit or debit the requested address.
+func (r FutureGetAddressTransactionsResult) Receive() (*model.GetAddressTransactionsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getAddressTransactions result object.
+	var getAddressTransactionsResult *model.GetAddressTransactionsResult
+	err = json.Unmarshal(res, &getAddressTransactionsResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getAddressTransactions response")
+	}
+
+	return getAddressTransactionsResult, nil
+}
+
+// GetAddressTransactionsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetAddressTransactions for the blocking version and more details.
+func (c *Client) GetAddressTransactionsAsync(address util.Address, limit uint64,
+	cursor *string) FutureGetAddressTransactionsResult {
+
+	cmd := model.NewGetAddressTransactionsCmd(address.EncodeAddress(), &limit, cursor)
+	return c.sendCmd(cmd)
+}
+
+// GetAddressTransactions returns up to limit transactions that credit or debit
+// the given address, starting from the given cursor. A nil cursor starts from
+// the address's oldest transaction. It requires the node to run with --addrindex.
+func (c *Client) GetAddressTransactions(address util.Address, limit uint64,
+	cursor *string) (*model.GetAddressTransactionsResult, error) {
+
+	return c.GetAddressTransactionsAsync(address, limit, cursor).Receive()
+}
+
+// FutureGetRawTransactionResult is a future promise to deliver the result of a
+// GetRawTransactionAsync RPC invocation (or an applicable error).
+type FutureGetRawTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// requested hex-encoded transaction along with its acceptance data.
+func (r FutureGetRawTransactionResult) Receive() (*model.GetRawTransactionResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getRawTransaction result object.
+	var getRawTransactionResult *model.GetRawTransactionResult
+	err = json.Unmarshal(res, &getRawTransactionResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getRawTransaction response")
+	}
+
+	return getRawTransactionResult, nil
+}
+
+// GetRawTransactionAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetRawTransaction for the blocking version and more details.
+func (c *Client) GetRawTransactionAsync(txID *daghash.TxID) FutureGetRawTransactionResult {
+	cmd := model.NewGetRawTransactionCmd(txID.String())
+	return c.sendCmd(cmd)
+}
+
+// GetRawTransaction returns the hex-encoded transaction of the given ID.
+// Transactions that are not in the mempool require the node to run with
+// --txindex.
+func (c *Client) GetRawTransaction(txID *daghash.TxID) (*model.GetRawTransactionResult, error) {
+	return c.GetRawTransactionAsync(txID).Receive()
+}
+
+// FutureGetTransactionResult is a future promise to deliver the result of a
+// GetTransactionAsync RPC invocation (or an applicable error).
+type FutureGetTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns
+// information about the requested transaction.
+func (r FutureGetTransactionResult) Receive() (*model.TxRawResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getTransaction result object.
+	var txRawResult *model.TxRawResult
+	err = json.Unmarshal(res, &txRawResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getTransaction response")
+	}
+
+	return txRawResult, nil
+}
+
+// GetTransactionAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetTransaction for the blocking version and more details.
+func (c *Client) GetTransactionAsync(txID *daghash.TxID) FutureGetTransactionResult {
+	cmd := model.NewGetTransactionCmd(txID.String())
+	return c.sendCmd(cmd)
+}
+
+// GetTransaction returns information about the transaction of the given ID.
+// Transactions that are not in the mempool require the node to run with
+// --txindex.
+func (c *Client) GetTransaction(txID *daghash.TxID) (*model.TxRawResult, error) {
+	return c.GetTransactionAsync(txID).Receive()
+}
+
 // FutureRescanBlocksResult is a future promise to deliver the result of a
 // RescanBlocksAsync RPC invocation (or an applicable error).
 type FutureRescanBlocksResult chan *response
@@ -265,6 +265,9 @@ func (c *Client) trackRegisteredNtfns(cmd interface{}) {
 			c.ntfnState.notifyNewTx = true
 		}
 		c.ntfnState.notifyNewTxSubnetworkID = bcmd.Subnetwork
+
+	case *model.NotifyAddressTransactionsCmd:
+		c.ntfnState.notifyAddressTxs = bcmd.Addresses
 	}
 }
 
@@ -542,6 +545,17 @@ func (c *Client) reregisterNtfns() error {
 		}
 	}
 
+	// Reregister notifyaddresstransactions if needed.
+	if stateCopy.notifyAddressTxs != nil {
+		log.Debugf("Reregistering [notifyaddresstransactions] (%d addresses)",
+			len(stateCopy.notifyAddressTxs))
+		cmd := model.NewNotifyAddressTransactionsCmd(stateCopy.notifyAddressTxs)
+		_, err := receiveFuture(c.sendCmd(cmd))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -186,6 +186,97 @@ func (c *Client) GetPeerAddresses() (*model.GetPeerAddressesResult, error) {
 	return c.GetPeerAddressesAsync().Receive()
 }
 
+// FutureListBannedResult is a future promise to deliver the result of a
+// ListBannedAsync RPC invocation (or an applicable error).
+type FutureListBannedResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// banned IP addresses and subnets.
+func (r FutureListBannedResult) Receive() ([]model.ListBannedResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of listBanned result objects.
+	var bans []model.ListBannedResult
+	err = json.Unmarshal(res, &bans)
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// ListBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ListBanned for the blocking version and more details.
+func (c *Client) ListBannedAsync() FutureListBannedResult {
+	cmd := model.NewListBannedCmd()
+	return c.sendCmd(cmd)
+}
+
+// ListBanned returns the banned IP addresses and subnets.
+func (c *Client) ListBanned() ([]model.ListBannedResult, error) {
+	return c.ListBannedAsync().Receive()
+}
+
+// FutureSetBanResult is a future promise to deliver the result of a
+// SetBanAsync RPC invocation (or an applicable error).
+type FutureSetBanResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the ban couldn't be set.
+func (r FutureSetBanResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SetBanAsync returns an instance of a type that can be used to get the result
+// of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SetBan for the blocking version and more details.
+func (c *Client) SetBanAsync(address string, subCmd model.SetBanSubCmd, banTime *uint64) FutureSetBanResult {
+	cmd := model.NewSetBanCmd(address, subCmd, banTime)
+	return c.sendCmd(cmd)
+}
+
+// SetBan bans the given IP address or subnet for banTime seconds, or lifts
+// its ban, according to subCmd. A nil or zero banTime uses the ban duration
+// the server is configured with.
+func (c *Client) SetBan(address string, subCmd model.SetBanSubCmd, banTime *uint64) error {
+	return c.SetBanAsync(address, subCmd, banTime).Receive()
+}
+
+// FutureClearBannedResult is a future promise to deliver the result of a
+// ClearBannedAsync RPC invocation (or an applicable error).
+type FutureClearBannedResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the bans couldn't be cleared.
+func (r FutureClearBannedResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ClearBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ClearBanned for the blocking version and more details.
+func (c *Client) ClearBannedAsync() FutureClearBannedResult {
+	cmd := model.NewClearBannedCmd()
+	return c.sendCmd(cmd)
+}
+
+// ClearBanned lifts the bans of all the banned IP addresses and subnets.
+func (c *Client) ClearBanned() error {
+	return c.ClearBannedAsync().Receive()
+}
+
 // FutureGetNetTotalsResult is a future promise to deliver the result of a
 // GetNetTotalsAsync RPC invocation (or an applicable error).
 type FutureGetNetTotalsResult chan *response
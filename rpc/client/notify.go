@@ -37,6 +37,7 @@ type notificationState struct {
 	notifyNewTx             bool
 	notifyNewTxVerbose      bool
 	notifyNewTxSubnetworkID *string
+	notifyAddressTxs        []string
 }
 
 // Copy returns a deep copy of the receiver.
@@ -47,6 +48,10 @@ func (s *notificationState) Copy() *notificationState {
 	stateCopy.notifyNewTx = s.notifyNewTx
 	stateCopy.notifyNewTxVerbose = s.notifyNewTxVerbose
 	stateCopy.notifyNewTxSubnetworkID = s.notifyNewTxSubnetworkID
+	if s.notifyAddressTxs != nil {
+		stateCopy.notifyAddressTxs = make([]string, len(s.notifyAddressTxs))
+		copy(stateCopy.notifyAddressTxs, s.notifyAddressTxs)
+	}
 
 	return &stateCopy
 }
@@ -114,6 +119,20 @@ type NotificationHandlers struct {
 	// made to register for the notification and the function is non-nil.
 	OnTxAcceptedVerbose func(txDetails *model.TxRawResult)
 
+	// OnTxExpired is invoked when a transaction is removed from the
+	// memory pool since it had not been accepted for too long. It will
+	// only be invoked if a preceding call to NotifyNewTransactions has
+	// been made to register for the notification and the function is
+	// non-nil.
+	OnTxExpired func(txID *daghash.TxID)
+
+	// OnAddressTransaction is invoked when a transaction that credits or
+	// debits a watched address is accepted by the selected parent chain.
+	// It will only be invoked if a preceding call to NotifyAddressTransactions
+	// has been made to register for the notification and the function is
+	// non-nil.
+	OnAddressTransaction func(address string, transaction *model.AddressTransactionResult)
+
 	// OnUnknownNotification is invoked when an unrecognized notification
 	// is received. This typically means the notification handling code
 	// for this package needs to be updated for a new notification type or
@@ -222,6 +241,40 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnTxAcceptedVerbose(rawTx)
 
+	// OnTxExpired
+	case model.TxExpiredNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxExpired == nil {
+			return
+		}
+
+		txID, err := parseTxExpiredNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid tx expired "+
+				"notification: %s", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxExpired(txID)
+
+	// OnAddressTransaction
+	case model.AddressTransactionNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnAddressTransaction == nil {
+			return
+		}
+
+		address, transaction, err := parseAddressTransactionNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid address transaction "+
+				"notification: %s", err)
+			return
+		}
+
+		c.ntfnHandlers.OnAddressTransaction(address, transaction)
+
 	// OnUnknownNotification
 	default:
 		if c.ntfnHandlers.OnUnknownNotification == nil {
@@ -423,6 +476,23 @@ func parseTxAcceptedNtfnParams(params []json.RawMessage) (*daghash.Hash,
 	return txHash, amt, nil
 }
 
+// parseTxExpiredNtfnParams parses out the transaction ID from the
+// parameters of a txExpired notification.
+func parseTxExpiredNtfnParams(params []json.RawMessage) (*daghash.TxID, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var txIDStr string
+	err := json.Unmarshal(params[0], &txIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return daghash.NewTxIDFromStr(txIDStr)
+}
+
 // parseTxAcceptedVerboseNtfnParams parses out details about a raw transaction
 // from the parameters of a txacceptedverbose notification.
 func parseTxAcceptedVerboseNtfnParams(params []json.RawMessage) (*model.TxRawResult,
@@ -445,6 +515,32 @@ func parseTxAcceptedVerboseNtfnParams(params []json.RawMessage) (*model.TxRawRes
 	return &rawTx, nil
 }
 
+// parseAddressTransactionNtfnParams parses out the address and the transaction
+// details from the parameters of an addressTransaction notification.
+func parseAddressTransactionNtfnParams(params []json.RawMessage) (string,
+	*model.AddressTransactionResult, error) {
+
+	if len(params) != 2 {
+		return "", nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var address string
+	err := json.Unmarshal(params[0], &address)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Unmarshal second parameter as an address transaction result object.
+	var transaction model.AddressTransactionResult
+	err = json.Unmarshal(params[1], &transaction)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return address, &transaction, nil
+}
+
 // FutureNotifyBlocksResult is a future promise to deliver the result of a
 // NotifyBlocksAsync RPC invocation (or an applicable error).
 type FutureNotifyBlocksResult chan *response
@@ -576,6 +672,57 @@ func (c *Client) NotifyNewTransactions(verbose bool, subnetworkID *string) error
 	return c.NotifyNewTransactionsAsync(verbose, subnetworkID).Receive()
 }
 
+// FutureNotifyAddressTransactionsResult is a future promise to deliver the
+// result of a NotifyAddressTransactionsAsync RPC invocation (or an applicable
+// error).
+type FutureNotifyAddressTransactionsResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyAddressTransactionsResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifyAddressTransactionsAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See NotifyAddressTransactions for the blocking version and more details.
+func (c *Client) NotifyAddressTransactionsAsync(addresses []util.Address) FutureNotifyAddressTransactionsResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	addrStrs := make([]string, len(addresses))
+	for i, a := range addresses {
+		addrStrs[i] = a.EncodeAddress()
+	}
+	cmd := model.NewNotifyAddressTransactionsCmd(addrStrs)
+	return c.sendCmd(cmd)
+}
+
+// NotifyAddressTransactions registers the client to receive notifications
+// every time a transaction that credits or debits one of the given addresses
+// is accepted by the selected parent chain. Each call replaces the addresses
+// of any previous call. The notifications are delivered to the notification
+// handlers associated with the client. Calling this function has no effect if
+// there are no notification handlers and will result in an error if the client
+// is configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via
+// OnAddressTransaction.
+func (c *Client) NotifyAddressTransactions(addresses []util.Address) error {
+	return c.NotifyAddressTransactionsAsync(addresses).Receive()
+}
+
 // FutureLoadTxFilterResult is a future promise to deliver the result
 // of a LoadTxFilterAsync RPC invocation (or an applicable error).
 type FutureLoadTxFilterResult chan *response
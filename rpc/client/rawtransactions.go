@@ -61,3 +61,100 @@ func (c *Client) SendRawTransactionAsync(tx *domainmessage.MsgTx, allowHighFees
 func (c *Client) SendRawTransaction(tx *domainmessage.MsgTx, allowHighFees bool) (*daghash.TxID, error) {
 	return c.SendRawTransactionAsync(tx, allowHighFees).Receive()
 }
+
+// FutureTestMempoolAcceptResult is a future promise to deliver the result
+// of a TestMempoolAcceptAsync RPC invocation (or an applicable error).
+type FutureTestMempoolAcceptResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// the transaction would be accepted to the mempool, along with its fee and
+// mass, or the reason it would be rejected.
+func (r FutureTestMempoolAcceptResult) Receive() (*model.TestMempoolAcceptResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.TestMempoolAcceptResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// TestMempoolAcceptAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See TestMempoolAccept for the blocking version and more details.
+func (c *Client) TestMempoolAcceptAsync(tx *domainmessage.MsgTx) FutureTestMempoolAcceptResult {
+	txHex := ""
+	if tx != nil {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		txHex = hex.EncodeToString(buf.Bytes())
+	}
+
+	cmd := model.NewTestMempoolAcceptCmd(txHex)
+	return c.sendCmd(cmd)
+}
+
+// TestMempoolAccept checks whether the server would accept the transaction to
+// its mempool, without adding it to the mempool or relaying it.
+func (c *Client) TestMempoolAccept(tx *domainmessage.MsgTx) (*model.TestMempoolAcceptResult, error) {
+	return c.TestMempoolAcceptAsync(tx).Receive()
+}
+
+// FutureSubmitPackageResult is a future promise to deliver the result
+// of a SubmitPackageAsync RPC invocation (or an applicable error).
+type FutureSubmitPackageResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// the package was accepted to the mempool, along with the results of its
+// transactions.
+func (r FutureSubmitPackageResult) Receive() (*model.SubmitPackageResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.SubmitPackageResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// SubmitPackageAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See SubmitPackage for the blocking version and more details.
+func (c *Client) SubmitPackageAsync(txs []*domainmessage.MsgTx) FutureSubmitPackageResult {
+	txHexes := make([]string, len(txs))
+	for i, tx := range txs {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		txHexes[i] = hex.EncodeToString(buf.Bytes())
+	}
+
+	cmd := model.NewSubmitPackageCmd(txHexes)
+	return c.sendCmd(cmd)
+}
+
+// SubmitPackage submits a package of transactions, ordered so that every
+// transaction comes after its parents, to the server, which accepts or rejects
+// them as a unit and then relays them to the network.
+func (c *Client) SubmitPackage(txs []*domainmessage.MsgTx) (*model.SubmitPackageResult, error) {
+	return c.SubmitPackageAsync(txs).Receive()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
 	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/rpc/model"
 	"github.com/kaspanet/kaspad/txscript"
@@ -356,3 +357,79 @@ func hashesToGetBlockVerboseResults(s *Server, hashes []*daghash.Hash) ([]model.
 	}
 	return getBlockVerboseResults, nil
 }
+
+// fetchTxByID returns the transaction with the given ID. The mempool is
+// searched first, and only then the transaction index. For transactions
+// that are not in the mempool, the hash of the block that contains them
+// is returned as well.
+func fetchTxByID(s *Server, txID *daghash.TxID) (tx *util.Tx, blockHash *daghash.Hash, isInMempool bool, err error) {
+	tx, ok := s.txMempool.FetchTransaction(txID)
+	if ok {
+		return tx, nil, true, nil
+	}
+
+	if s.txIndex == nil {
+		return nil, nil, false, &model.RPCError{
+			Code: model.ErrRPCNoTxIndex,
+			Message: "The transaction index must be " +
+				"enabled to query confirmed transactions " +
+				"(specify --txindex)",
+		}
+	}
+	tx, blockHash, err = s.txIndex.Tx(txID)
+	if err != nil {
+		if dbaccess.IsNotFoundError(err) {
+			return nil, nil, false, rpcNoTxInfoError(txID)
+		}
+		context := "Failed to fetch transaction from the transaction index"
+		return nil, nil, false, internalRPCError(err.Error(), context)
+	}
+	return tx, blockHash, false, nil
+}
+
+// txAcceptanceData returns the hash of the chain block that accepted the
+// transaction of the given ID, which is in the block of the given hash,
+// along with the amount of confirmations the transaction has. The accepting
+// block hash is nil if the transaction had not been accepted by any chain
+// block yet.
+//
+// The acceptance index is used if it's enabled, since it also knows about
+// transactions that were accepted as part of another block that contains
+// them. Otherwise, the acceptance is taken from the acceptance data of the
+// chain block that accepted the given block, which can't be calculated for
+// blocks below the finality point.
+func txAcceptanceData(s *Server, txID *daghash.TxID, blockHash *daghash.Hash) (
+	acceptingBlockHash *daghash.Hash, confirmations uint64, err error) {
+
+	if s.acceptanceIndex != nil {
+		acceptingBlockHash, err = s.acceptanceIndex.AcceptingBlockHash(txID)
+		if err != nil && !dbaccess.IsNotFoundError(err) {
+			context := "Failed to get accepting block from the acceptance index"
+			return nil, 0, internalRPCError(err.Error(), context)
+		}
+	} else {
+		acceptingBlockHash, err = s.dag.TxAcceptingBlockHash(txID, blockHash)
+		if err != nil {
+			if s.dag.IsKnownFinalizedBlock(blockHash) {
+				return nil, 0, &model.RPCError{
+					Code: model.ErrRPCNoAcceptanceIndex,
+					Message: "The acceptance index must be enabled to get the " +
+						"acceptance of transactions below the finality point " +
+						"(specify --acceptanceindex)",
+				}
+			}
+			context := "Failed to get accepting block"
+			return nil, 0, internalRPCError(err.Error(), context)
+		}
+	}
+	if acceptingBlockHash == nil {
+		return nil, 0, nil
+	}
+
+	confirmations, err = s.dag.AcceptingBlockConfirmations(acceptingBlockHash)
+	if err != nil {
+		context := "Failed to get transaction confirmations"
+		return nil, 0, internalRPCError(err.Error(), context)
+	}
+	return acceptingBlockHash, confirmations, nil
+}
@@ -0,0 +1,7 @@
+package rpc
+
+// handleClearBanned handles clearBanned commands.
+func handleClearBanned(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	s.addressManager.ClearBanned()
+	return nil, nil
+}
@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/pkg/errors"
+)
+
+// handleEstimateFee implements the estimateFee command.
+func handleEstimateFee(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*model.EstimateFeeCmd)
+
+	if c.TargetBlueScores < 1 || c.TargetBlueScores > mempool.FeeEstimatorMaxTarget {
+		return nil, &model.RPCError{
+			Code: model.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("targetBlueScores must be between 1 and %d",
+				mempool.FeeEstimatorMaxTarget),
+		}
+	}
+
+	feeRate, err := s.feeEstimator.EstimateFee(c.TargetBlueScores)
+	if err != nil {
+		if errors.Is(err, mempool.ErrNotEnoughFeeData) {
+			return nil, &model.RPCError{
+				Code:    model.ErrRPCMisc,
+				Message: err.Error(),
+			}
+		}
+		return nil, err
+	}
+
+	// Transactions that pay less than the minimum relay
+	// fee are not relayed, regardless of the estimate.
+	if feeRate < s.cfg.MinRelayTxFee {
+		feeRate = s.cfg.MinRelayTxFee
+	}
+
+	return &model.EstimateFeeResult{
+		FeeRate:          feeRate.ToKAS(),
+		TargetBlueScores: c.TargetBlueScores,
+	}, nil
+}
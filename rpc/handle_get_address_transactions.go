@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"encoding/hex"
+
+	"github.com/kaspanet/kaspad/blockdag/indexers"
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// maxAddressTransactionsLimit is the maximum number of transactions
+// that a single getAddressTransactions call may return.
+const maxAddressTransactionsLimit = 1000
+
+// handleGetAddressTransactions implements the getAddressTransactions command.
+func handleGetAddressTransactions(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.addrIndex == nil {
+		return nil, &model.RPCError{
+			Code: model.ErrRPCNoAddrIndex,
+			Message: "The address index must be " +
+				"enabled to get transactions by address " +
+				"(specify --addrindex)",
+		}
+	}
+
+	c := cmd.(*model.GetAddressTransactionsCmd)
+	address, err := util.DecodeAddress(c.Address, s.dag.Params.Prefix)
+	if err != nil {
+		return nil, &model.RPCError{
+			Code:    model.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	limit := uint64(maxAddressTransactionsLimit)
+	if c.Limit != nil && *c.Limit < limit {
+		limit = *c.Limit
+	}
+
+	var cursor []byte
+	if c.Cursor != nil {
+		cursor, err = hex.DecodeString(*c.Cursor)
+		if err == nil {
+			err = indexers.ValidateAddrIndexCursor(cursor)
+		}
+		if err != nil {
+			return nil, &model.RPCError{
+				Code:    model.ErrRPCInvalidParameter,
+				Message: "Invalid cursor: " + err.Error(),
+			}
+		}
+	}
+
+	entries, nextCursor, err := s.addrIndex.TxsByAddress(address.EncodeAddress(), cursor, int(limit))
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to fetch address transactions")
+	}
+
+	selectedTipBlueScore := s.dag.SelectedTipBlueScore()
+	transactions := make([]model.AddressTransactionResult, len(entries))
+	for i, entry := range entries {
+		transactions[i] = addressTransactionResult(entry, selectedTipBlueScore)
+	}
+
+	result := &model.GetAddressTransactionsResult{
+		Address:      c.Address,
+		Transactions: transactions,
+	}
+	if nextCursor != nil {
+		nextCursorHex := hex.EncodeToString(nextCursor)
+		result.NextCursor = &nextCursorHex
+	}
+	return result, nil
+}
+
+// addressTransactionResult converts the given address index entry into
+// its RPC representation.
+func addressTransactionResult(entry *indexers.AddrIndexEntry, selectedTipBlueScore uint64) model.AddressTransactionResult {
+	return model.AddressTransactionResult{
+		TxID:                    entry.TxID.String(),
+		AcceptingBlockHash:      entry.AcceptingBlockHash.String(),
+		AcceptingBlockBlueScore: entry.AcceptingBlockBlueScore,
+		Received:                util.Amount(entry.Received).ToKAS(),
+		Sent:                    util.Amount(entry.Sent).ToKAS(),
+		Confirmations:           selectedTipBlueScore - entry.AcceptingBlockBlueScore + 1,
+	}
+}
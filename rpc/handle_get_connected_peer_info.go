@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/rpc/model"
 )
 
@@ -9,6 +10,7 @@ func handleGetConnectedPeerInfo(s *Server, cmd interface{}, closeChan <-chan str
 	peers := s.protocolManager.Peers()
 	infos := make([]*model.GetConnectedPeerInfoResult, 0, len(peers))
 	for _, peer := range peers {
+		stats := peer.Connection().Stats()
 		info := &model.GetConnectedPeerInfoResult{
 			ID:                        peer.ID().String(),
 			Address:                   peer.Address(),
@@ -20,8 +22,30 @@ func handleGetConnectedPeerInfo(s *Server, cmd interface{}, closeChan <-chan str
 			UserAgent:                 peer.UserAgent(),
 			AdvertisedProtocolVersion: peer.AdvertisedProtocolVersion(),
 			TimeConnected:             peer.TimeConnected().Milliseconds(),
+			BanScore:                  s.connectionManager.BanScore(peer.Connection()),
+			PingWait:                  peer.PingWait().Milliseconds(),
+			BytesSent:                 stats.BytesSent,
+			BytesReceived:             stats.BytesReceived,
+			LastSend:                  stats.LastSend.UnixMilliseconds(),
+			LastReceive:               stats.LastReceive.UnixMilliseconds(),
+			MessagesSent:              messageCounts(stats.MessagesSent),
+			MessagesReceived:          messageCounts(stats.MessagesReceived),
 		}
 		infos = append(infos, info)
 	}
 	return infos, nil
 }
+
+// messageCounts converts the given message counts to a map keyed by
+// the names of the message commands.
+func messageCounts(counts map[domainmessage.MessageCommand]uint64) map[string]uint64 {
+	result := make(map[string]uint64, len(counts))
+	for command, count := range counts {
+		commandName, ok := domainmessage.MessageCommandToString[command]
+		if !ok {
+			commandName = command.String()
+		}
+		result[commandName] = count
+	}
+	return result
+}
@@ -1,21 +1,22 @@
 package rpc
 
 import (
+	"github.com/kaspanet/kaspad/mempool"
 	"github.com/kaspanet/kaspad/rpc/model"
 	"github.com/kaspanet/kaspad/util/daghash"
-	"github.com/pkg/errors"
 )
 
+// handleGetMempoolEntry implements the getMempoolEntry command.
 func handleGetMempoolEntry(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*model.GetMempoolEntryCmd)
 	txID, err := daghash.NewTxIDFromStr(c.TxID)
 	if err != nil {
-		return nil, err
+		return nil, rpcDecodeHexError(c.TxID)
 	}
 
-	txDesc, ok := s.txMempool.FetchTxDesc(txID)
+	txDesc, txFamily, ok := s.txMempool.FetchTxFamily(txID)
 	if !ok {
-		return nil, errors.Errorf("transaction is not in the pool")
+		return nil, rpcNoTxInfoError(txID)
 	}
 
 	tx := txDesc.Tx
@@ -25,9 +26,43 @@ func handleGetMempoolEntry(s *Server, cmd interface{}, closeChan <-chan struct{}
 		return nil, err
 	}
 
+	// The aggregated ancestor and descendant data
+	// includes the transaction itself.
+	ancestorCount, ancestorFees, ancestorMass := aggregateTxDescs(txFamily.Ancestors)
+	descendantCount, descendantFees, descendantMass := aggregateTxDescs(txFamily.Descendants)
+
 	return &model.GetMempoolEntryResult{
-		Fee:   txDesc.Fee,
-		Time:  txDesc.Added.UnixMilliseconds(),
-		RawTx: *rawTx,
+		Fee:             txDesc.Fee,
+		Mass:            txDesc.Mass,
+		Time:            txDesc.Added.UnixMilliseconds(),
+		Depends:         txDescIDs(txFamily.Parents),
+		SpentBy:         txDescIDs(txFamily.Children),
+		AncestorCount:   ancestorCount + 1,
+		AncestorFees:    ancestorFees + txDesc.Fee,
+		AncestorMass:    ancestorMass + txDesc.Mass,
+		DescendantCount: descendantCount + 1,
+		DescendantFees:  descendantFees + txDesc.Fee,
+		DescendantMass:  descendantMass + txDesc.Mass,
+		RawTx:           *rawTx,
 	}, nil
 }
+
+// aggregateTxDescs returns the number of the given transactions,
+// along with their total fee and total mass.
+func aggregateTxDescs(txDescs []*mempool.TxDesc) (count uint64, fees uint64, mass uint64) {
+	for _, txDesc := range txDescs {
+		fees += txDesc.Fee
+		mass += txDesc.Mass
+	}
+	return uint64(len(txDescs)), fees, mass
+}
+
+// txDescIDs returns the string representations of the
+// IDs of the given transactions.
+func txDescIDs(txDescs []*mempool.TxDesc) []string {
+	txIDs := make([]string, len(txDescs))
+	for i, txDesc := range txDescs {
+		txIDs[i] = txDesc.Tx.ID().String()
+	}
+	return txIDs
+}
@@ -12,8 +12,11 @@ func handleGetMempoolInfo(s *Server, cmd interface{}, closeChan <-chan struct{})
 	}
 
 	ret := &model.GetMempoolInfoResult{
-		Size:  int64(len(mempoolTxns)),
-		Bytes: numBytes,
+		Size:    int64(len(mempoolTxns)),
+		Bytes:   numBytes,
+		Mass:    s.txMempool.TotalMass(),
+		MaxMass: s.cfg.MaxMempoolMass,
+		MinFee:  s.txMempool.MinFeeRate().ToKAS(),
 	}
 
 	return ret, nil
@@ -0,0 +1,15 @@
+package rpc
+
+import "github.com/kaspanet/kaspad/rpc/model"
+
+// handleGetMempoolLoadStatus implements the getMempoolLoadStatus command.
+func handleGetMempoolLoadStatus(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	loadStatus := s.txMempool.LoadStatus()
+	return &model.GetMempoolLoadStatusResult{
+		Loaded:   loadStatus.IsLoaded,
+		Total:    loadStatus.Total,
+		Accepted: loadStatus.Accepted,
+		Rejected: loadStatus.Rejected,
+		Error:    loadStatus.Error,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/pointers"
+)
+
+// handleGetRawTransaction implements the getRawTransaction command.
+func handleGetRawTransaction(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*model.GetRawTransactionCmd)
+
+	txID, err := daghash.NewTxIDFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	tx, blockHash, isInMempool, err := fetchTxByID(s, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	txHex, err := msgTxToHex(tx.MsgTx())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.GetRawTransactionResult{
+		Hex:         txHex,
+		IsInMempool: isInMempool,
+	}
+	if blockHash != nil {
+		acceptingBlockHash, confirmations, err := txAcceptanceData(s, txID, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		result.BlockHash = blockHash.String()
+		if acceptingBlockHash != nil {
+			result.AcceptedBy = pointers.String(acceptingBlockHash.String())
+		}
+		result.Confirmations = confirmations
+	}
+
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// handleGetTransaction implements the getTransaction command.
+func handleGetTransaction(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*model.GetTransactionCmd)
+
+	txID, err := daghash.NewTxIDFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	tx, blockHash, isInMempool, err := fetchTxByID(s, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockHash == nil {
+		rawTx, err := createTxRawResult(s.dag.Params, tx.MsgTx(), tx.ID().String(),
+			nil, "", nil, isInMempool)
+		if err != nil {
+			return nil, err
+		}
+		confirmations := uint64(0)
+		rawTx.Confirmations = &confirmations
+		return rawTx, nil
+	}
+
+	var blockHeader *domainmessage.BlockHeader
+	blockHeader, err = s.dag.HeaderByHash(blockHash)
+	if err != nil {
+		context := "Failed to get block header"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	acceptingBlockHash, confirmations, err := txAcceptanceData(s, txID, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rawTx, err := createTxRawResult(s.dag.Params, tx.MsgTx(), tx.ID().String(),
+		blockHeader, blockHash.String(), acceptingBlockHash, isInMempool)
+	if err != nil {
+		return nil, err
+	}
+	rawTx.Confirmations = &confirmations
+	return rawTx, nil
+}
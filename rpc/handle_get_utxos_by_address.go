@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// handleGetUTXOsByAddress implements the getUTXOsByAddress command.
+func handleGetUTXOsByAddress(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.utxoIndex == nil {
+		return nil, &model.RPCError{
+			Code: model.ErrRPCNoUTXOIndex,
+			Message: "The UTXO index must be " +
+				"enabled to get UTXOs by address " +
+				"(specify --utxoindex)",
+		}
+	}
+
+	c := cmd.(*model.GetUTXOsByAddressCmd)
+	address, err := util.DecodeAddress(c.Address, s.dag.Params.Prefix)
+	if err != nil {
+		return nil, &model.RPCError{
+			Code:    model.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	scriptPubKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create scriptPubKey")
+	}
+
+	utxos, err := s.utxoIndex.UTXOsByScriptPubKey(scriptPubKey)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to fetch UTXOs")
+	}
+
+	selectedTipBlueScore := s.dag.SelectedTipBlueScore()
+	utxoResults := make([]model.UTXOResult, 0, len(utxos))
+	for outpoint, entry := range utxos {
+		utxoResults = append(utxoResults, model.UTXOResult{
+			TxID:           outpoint.TxID.String(),
+			Index:          outpoint.Index,
+			Value:          util.Amount(entry.Amount()).ToKAS(),
+			ScriptPubKey:   hex.EncodeToString(entry.ScriptPubKey()),
+			BlockBlueScore: entry.BlockBlueScore(),
+			Confirmations:  selectedTipBlueScore - entry.BlockBlueScore() + 1,
+			IsCoinbase:     entry.IsCoinbase(),
+		})
+	}
+	sort.Slice(utxoResults, func(i, j int) bool {
+		if utxoResults[i].BlockBlueScore != utxoResults[j].BlockBlueScore {
+			return utxoResults[i].BlockBlueScore < utxoResults[j].BlockBlueScore
+		}
+		if utxoResults[i].TxID != utxoResults[j].TxID {
+			return utxoResults[i].TxID < utxoResults[j].TxID
+		}
+		return utxoResults[i].Index < utxoResults[j].Index
+	})
+
+	return &model.GetUTXOsByAddressResult{
+		Address: c.Address,
+		UTXOs:   utxoResults,
+	}, nil
+}
@@ -0,0 +1,19 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpc/model"
+)
+
+// handleListBanned handles listBanned commands.
+func handleListBanned(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	bannedNetworks := s.addressManager.BannedNetworks()
+	results := make([]model.ListBannedResult, len(bannedNetworks))
+	for i, bannedNetwork := range bannedNetworks {
+		results[i] = model.ListBannedResult{
+			Address:   bannedNetwork.Network.String(),
+			BanTime:   bannedNetwork.BannedTime.UnixMilliseconds(),
+			BanExpiry: bannedNetwork.Expiry.UnixMilliseconds(),
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// handleNotifyAddressTransactions implements the notifyAddressTransactions
+// command extension for websocket connections.
+func handleNotifyAddressTransactions(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*model.NotifyAddressTransactionsCmd)
+	if !ok {
+		return nil, model.ErrRPCInternal
+	}
+
+	if wsc.server.addrIndex == nil {
+		return nil, &model.RPCError{
+			Code: model.ErrRPCNoAddrIndex,
+			Message: "The address index must be " +
+				"enabled to receive address transactions " +
+				"(specify --addrindex)",
+		}
+	}
+
+	watchedAddresses := make(map[string]struct{}, len(cmd.Addresses))
+	for _, addressStr := range cmd.Addresses {
+		address, err := util.DecodeAddress(addressStr, wsc.server.dag.Params.Prefix)
+		if err != nil {
+			return nil, &model.RPCError{
+				Code:    model.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+		watchedAddresses[address.EncodeAddress()] = struct{}{}
+	}
+
+	wsc.setWatchedAddresses(watchedAddresses)
+	wsc.server.ntfnMgr.RegisterAddressTransactions(wsc)
+	return nil, nil
+}
@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/rpc/model"
+)
+
+// handleSaveMempool implements the saveMempool command.
+func handleSaveMempool(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Saving the mempool before it finished loading
+	// would overwrite the transactions that weren't
+	// loaded yet.
+	if !s.cfg.NoPersistMempool && !s.txMempool.LoadStatus().IsLoaded {
+		return nil, &model.RPCError{
+			Code:    model.ErrRPCMisc,
+			Message: "The mempool hasn't finished loading yet",
+		}
+	}
+
+	err := s.txMempool.SaveToFile(mempool.DumpFilePath(s.cfg.DataDir))
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to save the mempool")
+	}
+	return nil, nil
+}
@@ -3,6 +3,7 @@ package rpc
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"github.com/kaspanet/kaspad/domainmessage"
 	"github.com/kaspanet/kaspad/mempool"
 	"github.com/kaspanet/kaspad/rpc/model"
@@ -36,9 +37,10 @@ func handleSendRawTransaction(s *Server, cmd interface{}, closeChan <-chan struc
 		}
 
 		log.Debugf("Rejected transaction %s: %s", tx.ID(), err)
+		rejectCode, reason := mempool.ErrToRejectErr(err)
 		return nil, &model.RPCError{
 			Code:    model.ErrRPCVerify,
-			Message: "TX rejected: " + err.Error(),
+			Message: fmt.Sprintf("TX rejected (%s): %s", rejectCode, reason),
 		}
 	}
 
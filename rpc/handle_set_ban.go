@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaspanet/kaspad/addressmanager"
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util/network"
+	"github.com/pkg/errors"
+)
+
+// handleSetBan handles setBan commands.
+func handleSetBan(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*model.SetBanCmd)
+
+	ipNetwork, err := network.ParseIPNetwork(c.Address)
+	if err != nil {
+		return nil, &model.RPCError{
+			Code:    model.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	switch c.SubCmd {
+	case model.SBAdd:
+		banDuration := s.cfg.BanDuration
+		if c.BanTime != nil && *c.BanTime != 0 {
+			banDuration = time.Duration(*c.BanTime) * time.Second
+		}
+		s.addressManager.BanNetwork(ipNetwork, banDuration)
+		s.connectionManager.DisconnectNetwork(ipNetwork)
+	case model.SBRemove:
+		err := s.addressManager.UnbanNetwork(ipNetwork)
+		if errors.Is(err, addressmanager.ErrBanNotFound) {
+			return nil, &model.RPCError{
+				Code:    model.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &model.RPCError{
+			Code:    model.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("invalid subcommand %s for setBan", c.SubCmd),
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,9 @@
+package rpc
+
+// handleStopNotifyAddressTransactions implements the stopNotifyAddressTransactions
+// command extension for websocket connections.
+func handleStopNotifyAddressTransactions(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterAddressTransactions(wsc)
+	wsc.setWatchedAddresses(nil)
+	return nil, nil
+}
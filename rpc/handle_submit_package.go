@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// handleSubmitPackage implements the submitPackage command.
+func handleSubmitPackage(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*model.SubmitPackageCmd)
+	txs := make([]*util.Tx, len(c.HexTxs))
+	for i, hexStr := range c.HexTxs {
+		serializedTx, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(hexStr)
+		}
+		var msgTx domainmessage.MsgTx
+		err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, &model.RPCError{
+				Code:    model.ErrRPCDeserialization,
+				Message: fmt.Sprintf("TX %d decode failed: %s", i, err),
+			}
+		}
+		txs[i] = util.NewTx(&msgTx)
+	}
+
+	result := &model.SubmitPackageResult{
+		Transactions: make([]model.SubmitPackageTransaction, len(txs)),
+	}
+	for i, tx := range txs {
+		result.Transactions[i].TxID = tx.ID().String()
+	}
+
+	err := s.protocolManager.AddPackage(txs)
+	if err == nil {
+		result.Accepted = true
+		for i := range result.Transactions {
+			result.Transactions[i].Accepted = true
+		}
+		return result, nil
+	}
+	if !errors.As(err, &mempool.RuleError{}) {
+		return nil, internalRPCError(err.Error(), "Failed to submit the package")
+	}
+
+	log.Debugf("Rejected a package of %d transactions: %s", len(txs), err)
+
+	// Rejections of the package as a whole apply to
+	// all of its transactions.
+	var packageErr mempool.PackageError
+	if !errors.As(err, &packageErr) {
+		rejectCode, reason := mempool.ErrToRejectErr(err)
+		for i := range result.Transactions {
+			result.Transactions[i].RejectCode = rejectCode.String()
+			result.Transactions[i].RejectReason = reason
+		}
+		return result, nil
+	}
+
+	rejectedTxID := txs[packageErr.TxIndex].ID()
+	for i := range result.Transactions {
+		if i == packageErr.TxIndex {
+			rejectCode, reason := mempool.ErrToRejectErr(packageErr.Err)
+			result.Transactions[i].RejectCode = rejectCode.String()
+			result.Transactions[i].RejectReason = reason
+			continue
+		}
+		result.Transactions[i].RejectReason = fmt.Sprintf("transaction %s "+
+			"in the package was rejected", rejectedTxID)
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/kaspanet/kaspad/domainmessage"
+	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/rpc/model"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// handleTestMempoolAccept implements the testMempoolAccept command.
+func handleTestMempoolAccept(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*model.TestMempoolAcceptCmd)
+	hexStr := c.HexTx
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var msgTx domainmessage.MsgTx
+	err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &model.RPCError{
+			Code:    model.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	tx := util.NewTx(&msgTx)
+	fee, mass, err := s.txMempool.CheckTransactionAcceptance(tx)
+	if err != nil {
+		if !errors.As(err, &mempool.RuleError{}) {
+			return nil, internalRPCError(err.Error(), "Failed to check the transaction")
+		}
+
+		rejectCode, reason := mempool.ErrToRejectErr(err)
+		return &model.TestMempoolAcceptResult{
+			TxID:         tx.ID().String(),
+			Allowed:      false,
+			RejectCode:   rejectCode.String(),
+			RejectReason: reason,
+		}, nil
+	}
+
+	return &model.TestMempoolAcceptResult{
+		TxID:    tx.ID().String(),
+		Allowed: true,
+		Fee:     fee,
+		Mass:    mass,
+	}, nil
+}
@@ -59,6 +59,9 @@ const (
 	ErrRPCOutOfRange         RPCErrorCode = -1
 	ErrRPCNoTxInfo           RPCErrorCode = -5
 	ErrRPCNoAcceptanceIndex  RPCErrorCode = -5
+	ErrRPCNoUTXOIndex        RPCErrorCode = -5
+	ErrRPCNoTxIndex          RPCErrorCode = -5
+	ErrRPCNoAddrIndex        RPCErrorCode = -5
 	ErrRPCNoNewestBlockInfo  RPCErrorCode = -5
 	ErrRPCInvalidTxVout      RPCErrorCode = -5
 	ErrRPCSubnetworkNotFound RPCErrorCode = -5
@@ -40,6 +40,19 @@ func NewDisconnectCmd(address string) *DisconnectCmd {
 	}
 }
 
+// EstimateFeeCmd defines the estimateFee JSON-RPC command.
+type EstimateFeeCmd struct {
+	TargetBlueScores uint64
+}
+
+// NewEstimateFeeCmd returns a new instance which can be used to issue an
+// estimateFee JSON-RPC command.
+func NewEstimateFeeCmd(targetBlueScores uint64) *EstimateFeeCmd {
+	return &EstimateFeeCmd{
+		TargetBlueScores: targetBlueScores,
+	}
+}
+
 // TransactionInput represents the inputs to a transaction. Specifically a
 // transaction hash and output number pair.
 type TransactionInput struct {
@@ -56,6 +69,26 @@ func NewGetSelectedTipHashCmd() *GetSelectedTipHashCmd {
 	return &GetSelectedTipHashCmd{}
 }
 
+// GetAddressTransactionsCmd defines the getAddressTransactions JSON-RPC command.
+type GetAddressTransactionsCmd struct {
+	Address string
+	Limit   *uint64 `jsonrpcdefault:"100"`
+	Cursor  *string
+}
+
+// NewGetAddressTransactionsCmd returns a new instance which can be used to issue a
+// getAddressTransactions JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressTransactionsCmd(address string, limit *uint64, cursor *string) *GetAddressTransactionsCmd {
+	return &GetAddressTransactionsCmd{
+		Address: address,
+		Limit:   limit,
+		Cursor:  cursor,
+	}
+}
+
 // GetBlockCmd defines the getBlock JSON-RPC command.
 type GetBlockCmd struct {
 	Hash       string
@@ -332,6 +365,19 @@ func NewGetRawMempoolCmd(verbose *bool) *GetRawMempoolCmd {
 	}
 }
 
+// GetRawTransactionCmd defines the getRawTransaction JSON-RPC command.
+type GetRawTransactionCmd struct {
+	TxID string
+}
+
+// NewGetRawTransactionCmd returns a new instance which can be used to issue a
+// getRawTransaction JSON-RPC command.
+func NewGetRawTransactionCmd(txID string) *GetRawTransactionCmd {
+	return &GetRawTransactionCmd{
+		TxID: txID,
+	}
+}
+
 // GetSubnetworkCmd defines the getSubnetwork JSON-RPC command.
 type GetSubnetworkCmd struct {
 	SubnetworkID string
@@ -345,6 +391,19 @@ func NewGetSubnetworkCmd(subnetworkID string) *GetSubnetworkCmd {
 	}
 }
 
+// GetTransactionCmd defines the getTransaction JSON-RPC command.
+type GetTransactionCmd struct {
+	TxID string
+}
+
+// NewGetTransactionCmd returns a new instance which can be used to issue a
+// getTransaction JSON-RPC command.
+func NewGetTransactionCmd(txID string) *GetTransactionCmd {
+	return &GetTransactionCmd{
+		TxID: txID,
+	}
+}
+
 // GetTxOutCmd defines the getTxOut JSON-RPC command.
 type GetTxOutCmd struct {
 	TxID           string
@@ -365,6 +424,19 @@ func NewGetTxOutCmd(txHash string, vout uint32, includeMempool *bool) *GetTxOutC
 	}
 }
 
+// GetUTXOsByAddressCmd defines the getUTXOsByAddress JSON-RPC command.
+type GetUTXOsByAddressCmd struct {
+	Address string
+}
+
+// NewGetUTXOsByAddressCmd returns a new instance which can be used to issue a
+// getUTXOsByAddress JSON-RPC command.
+func NewGetUTXOsByAddressCmd(address string) *GetUTXOsByAddressCmd {
+	return &GetUTXOsByAddressCmd{
+		Address: address,
+	}
+}
+
 // GetTxOutSetInfoCmd defines the getTxOutSetInfo JSON-RPC command.
 type GetTxOutSetInfoCmd struct{}
 
@@ -390,6 +462,15 @@ func NewHelpCmd(command *string) *HelpCmd {
 	}
 }
 
+// GetMempoolLoadStatusCmd defines the getMempoolLoadStatus JSON-RPC command.
+type GetMempoolLoadStatusCmd struct{}
+
+// NewGetMempoolLoadStatusCmd returns a new instance which can be used to
+// issue a getMempoolLoadStatus JSON-RPC command.
+func NewGetMempoolLoadStatusCmd() *GetMempoolLoadStatusCmd {
+	return &GetMempoolLoadStatusCmd{}
+}
+
 // PingCmd defines the ping JSON-RPC command.
 type PingCmd struct{}
 
@@ -399,6 +480,66 @@ func NewPingCmd() *PingCmd {
 	return &PingCmd{}
 }
 
+// ListBannedCmd defines the listBanned JSON-RPC command.
+type ListBannedCmd struct{}
+
+// NewListBannedCmd returns a new instance which can be used to issue a
+// listBanned JSON-RPC command.
+func NewListBannedCmd() *ListBannedCmd {
+	return &ListBannedCmd{}
+}
+
+// SetBanSubCmd defines the type used in the setBan JSON-RPC command for the
+// sub command field.
+type SetBanSubCmd string
+
+const (
+	// SBAdd indicates the specified address or subnet should be banned.
+	SBAdd SetBanSubCmd = "add"
+
+	// SBRemove indicates the ban of the specified address or subnet
+	// should be lifted.
+	SBRemove SetBanSubCmd = "remove"
+)
+
+// SetBanCmd defines the setBan JSON-RPC command.
+type SetBanCmd struct {
+	Address string
+	SubCmd  SetBanSubCmd `jsonrpcusage:"\"add|remove\""`
+	BanTime *uint64      `jsonrpcdefault:"0"`
+}
+
+// NewSetBanCmd returns a new instance which can be used to issue a setBan
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewSetBanCmd(address string, subCmd SetBanSubCmd, banTime *uint64) *SetBanCmd {
+	return &SetBanCmd{
+		Address: address,
+		SubCmd:  subCmd,
+		BanTime: banTime,
+	}
+}
+
+// ClearBannedCmd defines the clearBanned JSON-RPC command.
+type ClearBannedCmd struct{}
+
+// NewClearBannedCmd returns a new instance which can be used to issue a
+// clearBanned JSON-RPC command.
+func NewClearBannedCmd() *ClearBannedCmd {
+	return &ClearBannedCmd{}
+}
+
+// SaveMempoolCmd defines the saveMempool JSON-RPC command.
+type SaveMempoolCmd struct{}
+
+// NewSaveMempoolCmd returns a new instance which can be used to issue a
+// saveMempool JSON-RPC command.
+func NewSaveMempoolCmd() *SaveMempoolCmd {
+	return &SaveMempoolCmd{}
+}
+
 // SendRawTransactionCmd defines the sendRawTransaction JSON-RPC command.
 type SendRawTransactionCmd struct {
 	HexTx         string
@@ -451,6 +592,32 @@ func NewSubmitBlockCmd(hexBlock string, options *SubmitBlockOptions) *SubmitBloc
 	}
 }
 
+// SubmitPackageCmd defines the submitPackage JSON-RPC command.
+type SubmitPackageCmd struct {
+	HexTxs []string
+}
+
+// NewSubmitPackageCmd returns a new instance which can be used to issue a
+// submitPackage JSON-RPC command.
+func NewSubmitPackageCmd(hexTxs []string) *SubmitPackageCmd {
+	return &SubmitPackageCmd{
+		HexTxs: hexTxs,
+	}
+}
+
+// TestMempoolAcceptCmd defines the testMempoolAccept JSON-RPC command.
+type TestMempoolAcceptCmd struct {
+	HexTx string
+}
+
+// NewTestMempoolAcceptCmd returns a new instance which can be used to issue a
+// testMempoolAccept JSON-RPC command.
+func NewTestMempoolAcceptCmd(hexTx string) *TestMempoolAcceptCmd {
+	return &TestMempoolAcceptCmd{
+		HexTx: hexTx,
+	}
+}
+
 // UptimeCmd defines the uptime JSON-RPC command.
 type UptimeCmd struct{}
 
@@ -592,8 +759,11 @@ func init() {
 	// No special flags for commands in this file.
 	flags := UsageFlag(0)
 
+	MustRegisterCommand("clearBanned", (*ClearBannedCmd)(nil), flags)
 	MustRegisterCommand("connect", (*ConnectCmd)(nil), flags)
+	MustRegisterCommand("estimateFee", (*EstimateFeeCmd)(nil), flags)
 	MustRegisterCommand("getSelectedTipHash", (*GetSelectedTipHashCmd)(nil), flags)
+	MustRegisterCommand("getAddressTransactions", (*GetAddressTransactionsCmd)(nil), flags)
 	MustRegisterCommand("getBlock", (*GetBlockCmd)(nil), flags)
 	MustRegisterCommand("getBlocks", (*GetBlocksCmd)(nil), flags)
 	MustRegisterCommand("getBlockDagInfo", (*GetBlockDAGInfoCmd)(nil), flags)
@@ -607,20 +777,29 @@ func init() {
 	MustRegisterCommand("getInfo", (*GetInfoCmd)(nil), flags)
 	MustRegisterCommand("getMempoolEntry", (*GetMempoolEntryCmd)(nil), flags)
 	MustRegisterCommand("getMempoolInfo", (*GetMempoolInfoCmd)(nil), flags)
+	MustRegisterCommand("getMempoolLoadStatus", (*GetMempoolLoadStatusCmd)(nil), flags)
 	MustRegisterCommand("getNetworkInfo", (*GetNetworkInfoCmd)(nil), flags)
 	MustRegisterCommand("getNetTotals", (*GetNetTotalsCmd)(nil), flags)
 	MustRegisterCommand("getConnectedPeerInfo", (*GetConnectedPeerInfoCmd)(nil), flags)
 	MustRegisterCommand("getPeerAddresses", (*GetPeerAddressesCmd)(nil), flags)
 	MustRegisterCommand("getRawMempool", (*GetRawMempoolCmd)(nil), flags)
+	MustRegisterCommand("getRawTransaction", (*GetRawTransactionCmd)(nil), flags)
 	MustRegisterCommand("getSubnetwork", (*GetSubnetworkCmd)(nil), flags)
+	MustRegisterCommand("getTransaction", (*GetTransactionCmd)(nil), flags)
 	MustRegisterCommand("getTxOut", (*GetTxOutCmd)(nil), flags)
 	MustRegisterCommand("getTxOutSetInfo", (*GetTxOutSetInfoCmd)(nil), flags)
+	MustRegisterCommand("getUTXOsByAddress", (*GetUTXOsByAddressCmd)(nil), flags)
 	MustRegisterCommand("help", (*HelpCmd)(nil), flags)
+	MustRegisterCommand("listBanned", (*ListBannedCmd)(nil), flags)
 	MustRegisterCommand("ping", (*PingCmd)(nil), flags)
 	MustRegisterCommand("disconnect", (*DisconnectCmd)(nil), flags)
+	MustRegisterCommand("saveMempool", (*SaveMempoolCmd)(nil), flags)
 	MustRegisterCommand("sendRawTransaction", (*SendRawTransactionCmd)(nil), flags)
+	MustRegisterCommand("setBan", (*SetBanCmd)(nil), flags)
 	MustRegisterCommand("stop", (*StopCmd)(nil), flags)
 	MustRegisterCommand("submitBlock", (*SubmitBlockCmd)(nil), flags)
+	MustRegisterCommand("submitPackage", (*SubmitPackageCmd)(nil), flags)
+	MustRegisterCommand("testMempoolAccept", (*TestMempoolAcceptCmd)(nil), flags)
 	MustRegisterCommand("uptime", (*UptimeCmd)(nil), flags)
 	MustRegisterCommand("validateAddress", (*ValidateAddressCmd)(nil), flags)
 	MustRegisterCommand("debugLevel", (*DebugLevelCmd)(nil), flags)
@@ -42,6 +42,17 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"connect","params":["127.0.0.1"],"id":1}`,
 			unmarshalled: &model.ConnectCmd{Address: "127.0.0.1", IsPermanent: pointers.Bool(false)},
 		},
+		{
+			name: "estimateFee",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("estimateFee", 6)
+			},
+			staticCmd: func() interface{} {
+				return model.NewEstimateFeeCmd(6)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"estimateFee","params":[6],"id":1}`,
+			unmarshalled: &model.EstimateFeeCmd{TargetBlueScores: 6},
+		},
 		{
 			name: "getSelectedTipHash",
 			newCmd: func() (interface{}, error) {
@@ -334,6 +345,17 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getMempoolInfo","params":[],"id":1}`,
 			unmarshalled: &model.GetMempoolInfoCmd{},
 		},
+		{
+			name: "getMempoolLoadStatus",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("getMempoolLoadStatus")
+			},
+			staticCmd: func() interface{} {
+				return model.NewGetMempoolLoadStatusCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getMempoolLoadStatus","params":[],"id":1}`,
+			unmarshalled: &model.GetMempoolLoadStatusCmd{},
+		},
 		{
 			name: "getNetworkInfo",
 			newCmd: func() (interface{}, error) {
@@ -447,6 +469,76 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getTxOutSetInfo","params":[],"id":1}`,
 			unmarshalled: &model.GetTxOutSetInfoCmd{},
 		},
+		{
+			name: "getAddressTransactions",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("getAddressTransactions", "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx")
+			},
+			staticCmd: func() interface{} {
+				return model.NewGetAddressTransactionsCmd("kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getAddressTransactions","params":["kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx"],"id":1}`,
+			unmarshalled: &model.GetAddressTransactionsCmd{
+				Address: "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+				Limit:   pointers.Uint64(100),
+				Cursor:  nil,
+			},
+		},
+		{
+			name: "getAddressTransactions optional",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("getAddressTransactions", "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx", 10, "abcd")
+			},
+			staticCmd: func() interface{} {
+				return model.NewGetAddressTransactionsCmd("kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+					pointers.Uint64(10), pointers.String("abcd"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getAddressTransactions","params":["kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",10,"abcd"],"id":1}`,
+			unmarshalled: &model.GetAddressTransactionsCmd{
+				Address: "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+				Limit:   pointers.Uint64(10),
+				Cursor:  pointers.String("abcd"),
+			},
+		},
+		{
+			name: "getUTXOsByAddress",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("getUTXOsByAddress", "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx")
+			},
+			staticCmd: func() interface{} {
+				return model.NewGetUTXOsByAddressCmd("kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getUTXOsByAddress","params":["kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx"],"id":1}`,
+			unmarshalled: &model.GetUTXOsByAddressCmd{
+				Address: "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+			},
+		},
+		{
+			name: "getRawTransaction",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("getRawTransaction", "123")
+			},
+			staticCmd: func() interface{} {
+				return model.NewGetRawTransactionCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getRawTransaction","params":["123"],"id":1}`,
+			unmarshalled: &model.GetRawTransactionCmd{
+				TxID: "123",
+			},
+		},
+		{
+			name: "getTransaction",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("getTransaction", "123")
+			},
+			staticCmd: func() interface{} {
+				return model.NewGetTransactionCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getTransaction","params":["123"],"id":1}`,
+			unmarshalled: &model.GetTransactionCmd{
+				TxID: "123",
+			},
+		},
 		{
 			name: "help",
 			newCmd: func() (interface{}, error) {
@@ -495,6 +587,69 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"disconnect","params":["127.0.0.1"],"id":1}`,
 			unmarshalled: &model.DisconnectCmd{Address: "127.0.0.1"},
 		},
+		{
+			name: "listBanned",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("listBanned")
+			},
+			staticCmd: func() interface{} {
+				return model.NewListBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listBanned","params":[],"id":1}`,
+			unmarshalled: &model.ListBannedCmd{},
+		},
+		{
+			name: "setBan",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("setBan", "10.0.0.0/16", model.SBAdd)
+			},
+			staticCmd: func() interface{} {
+				return model.NewSetBanCmd("10.0.0.0/16", model.SBAdd, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setBan","params":["10.0.0.0/16","add"],"id":1}`,
+			unmarshalled: &model.SetBanCmd{
+				Address: "10.0.0.0/16",
+				SubCmd:  model.SBAdd,
+				BanTime: pointers.Uint64(0),
+			},
+		},
+		{
+			name: "setBan optional",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("setBan", "1.1.1.1", model.SBRemove, 3600)
+			},
+			staticCmd: func() interface{} {
+				return model.NewSetBanCmd("1.1.1.1", model.SBRemove, pointers.Uint64(3600))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setBan","params":["1.1.1.1","remove",3600],"id":1}`,
+			unmarshalled: &model.SetBanCmd{
+				Address: "1.1.1.1",
+				SubCmd:  model.SBRemove,
+				BanTime: pointers.Uint64(3600),
+			},
+		},
+		{
+			name: "clearBanned",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("clearBanned")
+			},
+			staticCmd: func() interface{} {
+				return model.NewClearBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"clearBanned","params":[],"id":1}`,
+			unmarshalled: &model.ClearBannedCmd{},
+		},
+		{
+			name: "saveMempool",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("saveMempool")
+			},
+			staticCmd: func() interface{} {
+				return model.NewSaveMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"saveMempool","params":[],"id":1}`,
+			unmarshalled: &model.SaveMempoolCmd{},
+		},
 		{
 			name: "sendRawTransaction",
 			newCmd: func() (interface{}, error) {
@@ -567,6 +722,32 @@ func TestRPCServerCommands(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "submitPackage",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("submitPackage", `["1122","3344"]`)
+			},
+			staticCmd: func() interface{} {
+				return model.NewSubmitPackageCmd([]string{"1122", "3344"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"submitPackage","params":[["1122","3344"]],"id":1}`,
+			unmarshalled: &model.SubmitPackageCmd{
+				HexTxs: []string{"1122", "3344"},
+			},
+		},
+		{
+			name: "testMempoolAccept",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("testMempoolAccept", "1122")
+			},
+			staticCmd: func() interface{} {
+				return model.NewTestMempoolAcceptCmd("1122")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"testMempoolAccept","params":["1122"],"id":1}`,
+			unmarshalled: &model.TestMempoolAcceptCmd{
+				HexTx: "1122",
+			},
+		},
 		{
 			name: "uptime",
 			newCmd: func() (interface{}, error) {
@@ -153,19 +153,54 @@ type GetBlockTemplateResult struct {
 	RejectReason string   `json:"rejectReason,omitempty"`
 }
 
+// EstimateFeeResult models the data returned from the estimateFee command.
+type EstimateFeeResult struct {
+	FeeRate          float64 `json:"feeRate"`
+	TargetBlueScores uint64  `json:"targetBlueScores"`
+}
+
 // GetMempoolEntryResult models the data returned from the getMempoolEntry
 // command.
 type GetMempoolEntryResult struct {
-	Fee   uint64      `json:"fee"`
-	Time  int64       `json:"time"`
-	RawTx TxRawResult `json:"rawTx"`
+	Fee             uint64      `json:"fee"`
+	Mass            uint64      `json:"mass"`
+	Time            int64       `json:"time"`
+	Depends         []string    `json:"depends"`
+	SpentBy         []string    `json:"spentBy"`
+	AncestorCount   uint64      `json:"ancestorCount"`
+	AncestorFees    uint64      `json:"ancestorFees"`
+	AncestorMass    uint64      `json:"ancestorMass"`
+	DescendantCount uint64      `json:"descendantCount"`
+	DescendantFees  uint64      `json:"descendantFees"`
+	DescendantMass  uint64      `json:"descendantMass"`
+	RawTx           TxRawResult `json:"rawTx"`
 }
 
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size    int64   `json:"size"`
+	Bytes   int64   `json:"bytes"`
+	Mass    uint64  `json:"mass"`
+	MaxMass uint64  `json:"maxMass"`
+	MinFee  float64 `json:"minFee"`
+}
+
+// GetMempoolLoadStatusResult models the data returned from the
+// getMempoolLoadStatus command.
+type GetMempoolLoadStatusResult struct {
+	Loaded   bool   `json:"loaded"`
+	Total    int    `json:"total"`
+	Accepted int    `json:"accepted"`
+	Rejected int    `json:"rejected"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ListBannedResult models the data returned from the listBanned command.
+type ListBannedResult struct {
+	Address   string `json:"address"`
+	BanTime   int64  `json:"banTime"`
+	BanExpiry int64  `json:"banExpiry"`
 }
 
 // NetworksResult models the networks data from the getnetworkinfo command.
@@ -215,6 +250,15 @@ type GetConnectedPeerInfoResult struct {
 	UserAgent                 string `json:"userAgent"`
 	AdvertisedProtocolVersion uint32 `json:"advertisedProtocolVersion"`
 	TimeConnected             int64  `json:"timeConnected"`
+	BanScore                  uint32 `json:"banScore"`
+	PingWait                  int64  `json:"pingWait,omitempty"`
+	BytesSent                 uint64 `json:"bytesSent"`
+	BytesReceived             uint64 `json:"bytesReceived"`
+	LastSend                  int64  `json:"lastSend"`
+	LastReceive               int64  `json:"lastReceive"`
+
+	MessagesSent     map[string]uint64 `json:"messagesSent"`
+	MessagesReceived map[string]uint64 `json:"messagesReceived"`
 }
 
 // GetPeerAddressesResult models the data returned from the getPeerAddresses command.
@@ -266,6 +310,15 @@ type ScriptPubKeyResult struct {
 	Address *string `json:"address,omitempty"`
 }
 
+// GetRawTransactionResult models the data from the getRawTransaction command.
+type GetRawTransactionResult struct {
+	Hex           string  `json:"hex"`
+	BlockHash     string  `json:"blockHash,omitempty"`
+	AcceptedBy    *string `json:"acceptedBy,omitempty"`
+	Confirmations uint64  `json:"confirmations"`
+	IsInMempool   bool    `json:"isInMempool"`
+}
+
 // GetSubnetworkResult models the data from the getSubnetwork command.
 type GetSubnetworkResult struct {
 	GasLimit *uint64 `json:"gasLimit"`
@@ -281,6 +334,40 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// GetUTXOsByAddressResult models the data from the getUTXOsByAddress command.
+type GetUTXOsByAddressResult struct {
+	Address string       `json:"address"`
+	UTXOs   []UTXOResult `json:"utxos"`
+}
+
+// GetAddressTransactionsResult models the data from the getAddressTransactions command.
+type GetAddressTransactionsResult struct {
+	Address      string                     `json:"address"`
+	Transactions []AddressTransactionResult `json:"transactions"`
+	NextCursor   *string                    `json:"nextCursor,omitempty"`
+}
+
+// AddressTransactionResult models a transaction that credits or debits an address.
+type AddressTransactionResult struct {
+	TxID                    string  `json:"txId"`
+	AcceptingBlockHash      string  `json:"acceptingBlockHash"`
+	AcceptingBlockBlueScore uint64  `json:"acceptingBlockBlueScore"`
+	Received                float64 `json:"received"`
+	Sent                    float64 `json:"sent"`
+	Confirmations           uint64  `json:"confirmations"`
+}
+
+// UTXOResult models a single unspent transaction output.
+type UTXOResult struct {
+	TxID           string  `json:"txId"`
+	Index          uint32  `json:"index"`
+	Value          float64 `json:"value"`
+	ScriptPubKey   string  `json:"scriptPubKey"`
+	BlockBlueScore uint64  `json:"blockBlueScore"`
+	Confirmations  uint64  `json:"confirmations"`
+	IsCoinbase     bool    `json:"isCoinbase"`
+}
+
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
 	TotalBytesRecv uint64 `json:"totalBytesRecv"`
@@ -401,23 +488,24 @@ type InfoDAGResult struct {
 
 // TxRawResult models transaction result data.
 type TxRawResult struct {
-	Hex         string  `json:"hex"`
-	TxID        string  `json:"txId"`
-	Hash        string  `json:"hash,omitempty"`
-	Size        int32   `json:"size,omitempty"`
-	Version     int32   `json:"version"`
-	LockTime    uint64  `json:"lockTime"`
-	Subnetwork  string  `json:"subnetwork"`
-	Gas         uint64  `json:"gas"`
-	PayloadHash string  `json:"payloadHash"`
-	Payload     string  `json:"payload"`
-	Vin         []Vin   `json:"vin"`
-	Vout        []Vout  `json:"vout"`
-	BlockHash   string  `json:"blockHash,omitempty"`
-	AcceptedBy  *string `json:"acceptedBy,omitempty"`
-	IsInMempool bool    `json:"isInMempool"`
-	Time        uint64  `json:"time,omitempty"`
-	BlockTime   uint64  `json:"blockTime,omitempty"`
+	Hex           string  `json:"hex"`
+	TxID          string  `json:"txId"`
+	Hash          string  `json:"hash,omitempty"`
+	Size          int32   `json:"size,omitempty"`
+	Version       int32   `json:"version"`
+	LockTime      uint64  `json:"lockTime"`
+	Subnetwork    string  `json:"subnetwork"`
+	Gas           uint64  `json:"gas"`
+	PayloadHash   string  `json:"payloadHash"`
+	Payload       string  `json:"payload"`
+	Vin           []Vin   `json:"vin"`
+	Vout          []Vout  `json:"vout"`
+	BlockHash     string  `json:"blockHash,omitempty"`
+	AcceptedBy    *string `json:"acceptedBy,omitempty"`
+	Confirmations *uint64 `json:"confirmations,omitempty"`
+	IsInMempool   bool    `json:"isInMempool"`
+	Time          uint64  `json:"time,omitempty"`
+	BlockTime     uint64  `json:"blockTime,omitempty"`
 }
 
 // TxRawDecodeResult models the data from the decoderawtransaction command.
@@ -429,6 +517,32 @@ type TxRawDecodeResult struct {
 	Vout     []Vout `json:"vout"`
 }
 
+// SubmitPackageResult models the data returned from the submitPackage command.
+type SubmitPackageResult struct {
+	Accepted     bool                       `json:"accepted"`
+	Transactions []SubmitPackageTransaction `json:"transactions"`
+}
+
+// SubmitPackageTransaction models the result of submitting a single
+// transaction of a package.
+type SubmitPackageTransaction struct {
+	TxID         string `json:"txId"`
+	Accepted     bool   `json:"accepted"`
+	RejectCode   string `json:"rejectCode,omitempty"`
+	RejectReason string `json:"rejectReason,omitempty"`
+}
+
+// TestMempoolAcceptResult models the data returned from the testMempoolAccept
+// command.
+type TestMempoolAcceptResult struct {
+	TxID         string `json:"txId"`
+	Allowed      bool   `json:"allowed"`
+	Fee          uint64 `json:"fee,omitempty"`
+	Mass         uint64 `json:"mass,omitempty"`
+	RejectCode   string `json:"rejectCode,omitempty"`
+	RejectReason string `json:"rejectReason,omitempty"`
+}
+
 // ValidateAddressResult models the data returned by the kaspa rpc server
 // validateaddress command.
 type ValidateAddressResult struct {
@@ -77,6 +77,28 @@ func NewNotifyNewTransactionsCmd(verbose *bool, subnetworkID *string) *NotifyNew
 	}
 }
 
+// NotifyAddressTransactionsCmd defines the notifyAddressTransactions JSON-RPC command.
+type NotifyAddressTransactionsCmd struct {
+	Addresses []string
+}
+
+// NewNotifyAddressTransactionsCmd returns a new instance which can be used to issue
+// a notifyAddressTransactions JSON-RPC command.
+func NewNotifyAddressTransactionsCmd(addresses []string) *NotifyAddressTransactionsCmd {
+	return &NotifyAddressTransactionsCmd{
+		Addresses: addresses,
+	}
+}
+
+// StopNotifyAddressTransactionsCmd defines the stopNotifyAddressTransactions JSON-RPC command.
+type StopNotifyAddressTransactionsCmd struct{}
+
+// NewStopNotifyAddressTransactionsCmd returns a new instance which can be used to issue
+// a stopNotifyAddressTransactions JSON-RPC command.
+func NewStopNotifyAddressTransactionsCmd() *StopNotifyAddressTransactionsCmd {
+	return &StopNotifyAddressTransactionsCmd{}
+}
+
 // SessionCmd defines the session JSON-RPC command.
 type SessionCmd struct{}
 
@@ -141,10 +163,12 @@ func init() {
 
 	MustRegisterCommand("authenticate", (*AuthenticateCmd)(nil), flags)
 	MustRegisterCommand("loadTxFilter", (*LoadTxFilterCmd)(nil), flags)
+	MustRegisterCommand("notifyAddressTransactions", (*NotifyAddressTransactionsCmd)(nil), flags)
 	MustRegisterCommand("notifyBlocks", (*NotifyBlocksCmd)(nil), flags)
 	MustRegisterCommand("notifyChainChanges", (*NotifyChainChangesCmd)(nil), flags)
 	MustRegisterCommand("notifyNewTransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCommand("session", (*SessionCmd)(nil), flags)
+	MustRegisterCommand("stopNotifyAddressTransactions", (*StopNotifyAddressTransactionsCmd)(nil), flags)
 	MustRegisterCommand("stopNotifyBlocks", (*StopNotifyBlocksCmd)(nil), flags)
 	MustRegisterCommand("stopNotifyChainChanges", (*StopNotifyChainChangesCmd)(nil), flags)
 	MustRegisterCommand("stopNotifyNewTransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
@@ -42,6 +42,30 @@ func TestRPCServerWebsocketCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"authenticate","params":["user","pass"],"id":1}`,
 			unmarshalled: &model.AuthenticateCmd{Username: "user", Passphrase: "pass"},
 		},
+		{
+			name: "notifyAddressTransactions",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("notifyAddressTransactions", []string{"kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx"})
+			},
+			staticCmd: func() interface{} {
+				return model.NewNotifyAddressTransactionsCmd([]string{"kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyAddressTransactions","params":[["kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx"]],"id":1}`,
+			unmarshalled: &model.NotifyAddressTransactionsCmd{
+				Addresses: []string{"kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx"},
+			},
+		},
+		{
+			name: "stopNotifyAddressTransactions",
+			newCmd: func() (interface{}, error) {
+				return model.NewCommand("stopNotifyAddressTransactions")
+			},
+			staticCmd: func() interface{} {
+				return model.NewStopNotifyAddressTransactionsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopNotifyAddressTransactions","params":[],"id":1}`,
+			unmarshalled: &model.StopNotifyAddressTransactionsCmd{},
+		},
 		{
 			name: "notifyBlocks",
 			newCmd: func() (interface{}, error) {
@@ -32,6 +32,17 @@ const (
 	// from the kaspa rpc server that inform a client that the selected chain
 	// has changed.
 	ChainChangedNtfnMethod = "chainChanged"
+
+	// AddressTransactionNtfnMethod is the method used for notifications
+	// from the kaspa rpc server that inform a client that a transaction
+	// that credits or debits a watched address was accepted by the
+	// selected parent chain.
+	AddressTransactionNtfnMethod = "addressTransaction"
+
+	// TxExpiredNtfnMethod is the method used for notifications from the
+	// kaspa rpc server that a transaction has been removed from the
+	// mempool since it had not been accepted for too long.
+	TxExpiredNtfnMethod = "txExpired"
 )
 
 // FilteredBlockAddedNtfn defines the filteredBlockAdded JSON-RPC
@@ -77,6 +88,22 @@ func NewChainChangedNtfn(removedChainBlockHashes []string,
 	}}
 }
 
+// AddressTransactionNtfn defines the addressTransaction JSON-RPC
+// notification.
+type AddressTransactionNtfn struct {
+	Address     string
+	Transaction AddressTransactionResult
+}
+
+// NewAddressTransactionNtfn returns a new instance which can be used to
+// issue an addressTransaction JSON-RPC notification.
+func NewAddressTransactionNtfn(address string, transaction AddressTransactionResult) *AddressTransactionNtfn {
+	return &AddressTransactionNtfn{
+		Address:     address,
+		Transaction: transaction,
+	}
+}
+
 // BlockDetails describes details of a tx in a block.
 type BlockDetails struct {
 	Height uint64 `json:"height"`
@@ -113,6 +140,19 @@ func NewTxAcceptedVerboseNtfn(rawTx TxRawResult) *TxAcceptedVerboseNtfn {
 	}
 }
 
+// TxExpiredNtfn defines the txExpired JSON-RPC notification.
+type TxExpiredNtfn struct {
+	TxID string
+}
+
+// NewTxExpiredNtfn returns a new instance which can be used to issue a
+// txExpired JSON-RPC notification.
+func NewTxExpiredNtfn(txID string) *TxExpiredNtfn {
+	return &TxExpiredNtfn{
+		TxID: txID,
+	}
+}
+
 // RelevantTxAcceptedNtfn defines the parameters to the relevantTxAccepted
 // JSON-RPC notification.
 type RelevantTxAcceptedNtfn struct {
@@ -135,4 +175,6 @@ func init() {
 	MustRegisterCommand(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCommand(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
 	MustRegisterCommand(ChainChangedNtfnMethod, (*ChainChangedNtfn)(nil), flags)
+	MustRegisterCommand(AddressTransactionNtfnMethod, (*AddressTransactionNtfn)(nil), flags)
+	MustRegisterCommand(TxExpiredNtfnMethod, (*TxExpiredNtfn)(nil), flags)
 }
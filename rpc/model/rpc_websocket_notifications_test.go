@@ -61,6 +61,19 @@ func TestRPCServerWebsocketNotifications(t *testing.T) {
 				Amount: 1.5,
 			},
 		},
+		{
+			name: "txExpired",
+			newNtfn: func() (interface{}, error) {
+				return model.NewCommand("txExpired", "123")
+			},
+			staticNtfn: func() interface{} {
+				return model.NewTxExpiredNtfn("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txExpired","params":["123"],"id":null}`,
+			unmarshalled: &model.TxExpiredNtfn{
+				TxID: "123",
+			},
+		},
 		{
 			name: "txAcceptedVerbose",
 			newNtfn: func() (interface{}, error) {
@@ -127,6 +140,34 @@ func TestRPCServerWebsocketNotifications(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "addressTransaction",
+			newNtfn: func() (interface{}, error) {
+				return model.NewCommand("addressTransaction", "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+					`{"txId":"123","acceptingBlockHash":"456","acceptingBlockBlueScore":7,"received":1.5,"sent":0,"confirmations":2}`)
+			},
+			staticNtfn: func() interface{} {
+				return model.NewAddressTransactionNtfn("kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+					model.AddressTransactionResult{
+						TxID:                    "123",
+						AcceptingBlockHash:      "456",
+						AcceptingBlockBlueScore: 7,
+						Received:                1.5,
+						Confirmations:           2,
+					})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"addressTransaction","params":["kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",{"txId":"123","acceptingBlockHash":"456","acceptingBlockBlueScore":7,"received":1.5,"sent":0,"confirmations":2}],"id":null}`,
+			unmarshalled: &model.AddressTransactionNtfn{
+				Address: "kaspa:qr35ennsep3hxfe7lnz5ee7j5jgmkjswss74as46gx",
+				Transaction: model.AddressTransactionResult{
+					TxID:                    "123",
+					AcceptingBlockHash:      "456",
+					AcceptingBlockBlueScore: 7,
+					Received:                1.5,
+					Confirmations:           2,
+				},
+			},
+		},
 		{
 			name: "relevantTxAccepted",
 			newNtfn: func() (interface{}, error) {
@@ -37,6 +37,7 @@ import (
 	"github.com/kaspanet/kaspad/mining"
 	"github.com/kaspanet/kaspad/rpc/model"
 	"github.com/kaspanet/kaspad/util/fs"
+	"github.com/kaspanet/kaspad/util/metrics"
 	"github.com/kaspanet/kaspad/util/network"
 )
 
@@ -57,86 +58,113 @@ type commandHandler func(*Server, interface{}, <-chan struct{}) (interface{}, er
 // a dependency loop.
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
-	"connect":              handleConnect,
-	"debugLevel":           handleDebugLevel,
-	"getSelectedTip":       handleGetSelectedTip,
-	"getSelectedTipHash":   handleGetSelectedTipHash,
-	"getBlock":             handleGetBlock,
-	"getBlocks":            handleGetBlocks,
-	"getBlockDagInfo":      handleGetBlockDAGInfo,
-	"getBlockCount":        handleGetBlockCount,
-	"getBlockHeader":       handleGetBlockHeader,
-	"getBlockTemplate":     handleGetBlockTemplate,
-	"getChainFromBlock":    handleGetChainFromBlock,
-	"getConnectionCount":   handleGetConnectionCount,
-	"getCurrentNet":        handleGetCurrentNet,
-	"getDifficulty":        handleGetDifficulty,
-	"getHeaders":           handleGetHeaders,
-	"getTopHeaders":        handleGetTopHeaders,
-	"getInfo":              handleGetInfo,
-	"getMempoolInfo":       handleGetMempoolInfo,
-	"getMempoolEntry":      handleGetMempoolEntry,
-	"getNetTotals":         handleGetNetTotals,
-	"getConnectedPeerInfo": handleGetConnectedPeerInfo,
-	"getPeerAddresses":     handleGetPeerAddresses,
-	"getRawMempool":        handleGetRawMempool,
-	"getSubnetwork":        handleGetSubnetwork,
-	"getTxOut":             handleGetTxOut,
-	"help":                 handleHelp,
-	"disconnect":           handleDisconnect,
-	"sendRawTransaction":   handleSendRawTransaction,
-	"stop":                 handleStop,
-	"submitBlock":          handleSubmitBlock,
-	"uptime":               handleUptime,
-	"version":              handleVersion,
+	"clearBanned":            handleClearBanned,
+	"connect":                handleConnect,
+	"debugLevel":             handleDebugLevel,
+	"estimateFee":            handleEstimateFee,
+	"getSelectedTip":         handleGetSelectedTip,
+	"getSelectedTipHash":     handleGetSelectedTipHash,
+	"getAddressTransactions": handleGetAddressTransactions,
+	"getBlock":               handleGetBlock,
+	"getBlocks":              handleGetBlocks,
+	"getBlockDagInfo":        handleGetBlockDAGInfo,
+	"getBlockCount":          handleGetBlockCount,
+	"getBlockHeader":         handleGetBlockHeader,
+	"getBlockTemplate":       handleGetBlockTemplate,
+	"getChainFromBlock":      handleGetChainFromBlock,
+	"getConnectionCount":     handleGetConnectionCount,
+	"getCurrentNet":          handleGetCurrentNet,
+	"getDifficulty":          handleGetDifficulty,
+	"getHeaders":             handleGetHeaders,
+	"getTopHeaders":          handleGetTopHeaders,
+	"getInfo":                handleGetInfo,
+	"getMempoolInfo":         handleGetMempoolInfo,
+	"getMempoolLoadStatus":   handleGetMempoolLoadStatus,
+	"getMempoolEntry":        handleGetMempoolEntry,
+	"getNetTotals":           handleGetNetTotals,
+	"getConnectedPeerInfo":   handleGetConnectedPeerInfo,
+	"getPeerAddresses":       handleGetPeerAddresses,
+	"getRawMempool":          handleGetRawMempool,
+	"getRawTransaction":      handleGetRawTransaction,
+	"getSubnetwork":          handleGetSubnetwork,
+	"getTransaction":         handleGetTransaction,
+	"getTxOut":               handleGetTxOut,
+	"getUTXOsByAddress":      handleGetUTXOsByAddress,
+	"help":                   handleHelp,
+	"listBanned":             handleListBanned,
+	"disconnect":             handleDisconnect,
+	"saveMempool":            handleSaveMempool,
+	"sendRawTransaction":     handleSendRawTransaction,
+	"setBan":                 handleSetBan,
+	"stop":                   handleStop,
+	"submitBlock":            handleSubmitBlock,
+	"submitPackage":          handleSubmitPackage,
+	"testMempoolAccept":      handleTestMempoolAccept,
+	"uptime":                 handleUptime,
+	"version":                handleVersion,
 }
 
+// RequestDurations tracks the number and the durations of the handled
+// RPC requests of every method.
+var RequestDurations = metrics.NewSummary("kaspad_rpc_request_duration_seconds",
+	"The durations of handled RPC requests", "method")
+
 // Commands that are currently unimplemented, but should ultimately be.
 var rpcUnimplemented = map[string]struct{}{
-	"getMempoolEntry": {},
-	"getNetworkInfo":  {},
+	"getNetworkInfo": {},
 }
 
 // Commands that are available to a limited user
 var rpcLimited = map[string]struct{}{
 	// Websockets commands
-	"loadTxFilter":          {},
-	"notifyBlocks":          {},
-	"notifyChainChanges":    {},
-	"notifyNewTransactions": {},
-	"notifyReceived":        {},
-	"notifySpent":           {},
-	"rescan":                {},
-	"rescanBlocks":          {},
-	"session":               {},
+	"loadTxFilter":                  {},
+	"notifyBlocks":                  {},
+	"notifyChainChanges":            {},
+	"notifyNewTransactions":         {},
+	"notifyAddressTransactions":     {},
+	"stopNotifyAddressTransactions": {},
+	"notifyReceived":                {},
+	"notifySpent":                   {},
+	"rescan":                        {},
+	"rescanBlocks":                  {},
+	"session":                       {},
 
 	// Websockets AND HTTP/S commands
 	"help": {},
 
 	// HTTP/S-only commands
-	"createRawTransaction": {},
-	"decodeRawTransaction": {},
-	"decodeScript":         {},
-	"getSelectedTip":       {},
-	"getSelectedTipHash":   {},
-	"getBlock":             {},
-	"getBlocks":            {},
-	"getBlockCount":        {},
-	"getBlockHash":         {},
-	"getBlockHeader":       {},
-	"getChainFromBlock":    {},
-	"getCurrentNet":        {},
-	"getDifficulty":        {},
-	"getHeaders":           {},
-	"getInfo":              {},
-	"getNetTotals":         {},
-	"getRawMempool":        {},
-	"getTxOut":             {},
-	"sendRawTransaction":   {},
-	"submitBlock":          {},
-	"uptime":               {},
-	"validateAddress":      {},
-	"version":              {},
+	"createRawTransaction":   {},
+	"decodeRawTransaction":   {},
+	"decodeScript":           {},
+	"estimateFee":            {},
+	"getSelectedTip":         {},
+	"getSelectedTipHash":     {},
+	"getAddressTransactions": {},
+	"getBlock":               {},
+	"getBlocks":              {},
+	"getBlockCount":          {},
+	"getBlockHash":           {},
+	"getBlockHeader":         {},
+	"getChainFromBlock":      {},
+	"getCurrentNet":          {},
+	"getDifficulty":          {},
+	"getHeaders":             {},
+	"getInfo":                {},
+	"getMempoolEntry":        {},
+	"getMempoolLoadStatus":   {},
+	"getNetTotals":           {},
+	"getRawMempool":          {},
+	"getRawTransaction":      {},
+	"getTransaction":         {},
+	"getTxOut":               {},
+	"getUTXOsByAddress":      {},
+	"sendRawTransaction":     {},
+	"submitBlock":            {},
+	"submitPackage":          {},
+	"testMempoolAccept":      {},
+	"uptime":                 {},
+	"validateAddress":        {},
+	"version":                {},
 }
 
 // handleUnimplemented is the handler for commands that should ultimately be
@@ -166,7 +194,11 @@ type Server struct {
 
 	dag                    *blockdag.BlockDAG
 	txMempool              *mempool.TxPool
+	feeEstimator           *mempool.FeeEstimator
 	acceptanceIndex        *indexers.AcceptanceIndex
+	utxoIndex              *indexers.UTXOIndex
+	txIndex                *indexers.TxIndex
+	addrIndex              *indexers.AddrIndex
 	blockTemplateGenerator *mining.BlkTmplGenerator
 	connectionManager      *connmanager.ConnectionManager
 	addressManager         *addressmanager.AddressManager
@@ -378,6 +410,7 @@ func (s *Server) standardCmdResult(cmd *parsedRPCCmd, closeChan <-chan struct{})
 	return nil, model.ErrRPCMethodNotFound
 handled:
 
+	defer RequestDurations.ObserveDuration(cmd.method, time.Now())
 	return handler(s, cmd.cmd, closeChan)
 }
 
@@ -692,7 +725,11 @@ func NewRPCServer(
 	cfg *config.Config,
 	dag *blockdag.BlockDAG,
 	txMempool *mempool.TxPool,
+	feeEstimator *mempool.FeeEstimator,
 	acceptanceIndex *indexers.AcceptanceIndex,
+	utxoIndex *indexers.UTXOIndex,
+	txIndex *indexers.TxIndex,
+	addrIndex *indexers.AddrIndex,
 	blockTemplateGenerator *mining.BlkTmplGenerator,
 	connectionManager *connmanager.ConnectionManager,
 	addressManager *addressmanager.AddressManager,
@@ -720,7 +757,11 @@ func NewRPCServer(
 
 		dag:                    dag,
 		txMempool:              txMempool,
+		feeEstimator:           feeEstimator,
 		acceptanceIndex:        acceptanceIndex,
+		utxoIndex:              utxoIndex,
+		txIndex:                txIndex,
+		addrIndex:              addrIndex,
 		blockTemplateGenerator: blockTemplateGenerator,
 		connectionManager:      connectionManager,
 		addressManager:         addressManager,
@@ -738,6 +779,7 @@ func NewRPCServer(
 	}
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 	rpc.dag.Subscribe(rpc.handleBlockDAGNotification)
+	rpc.txMempool.Subscribe(rpc.handleMempoolNotification)
 
 	return &rpc, nil
 }
@@ -770,6 +812,12 @@ func (s *Server) handleBlockDAGNotification(notification *blockdag.Notification)
 			break
 		}
 
+		// Notify registered websocket clients of the address index
+		// entries that the new chain blocks added.
+		if s.addrIndex != nil {
+			s.ntfnMgr.NotifyAddressTransactions(data.AddedChainBlockHashes)
+		}
+
 		// If the acceptance index is off we aren't capable of serving
 		// ChainChanged notifications.
 		if s.acceptanceIndex == nil {
@@ -782,6 +830,26 @@ func (s *Server) handleBlockDAGNotification(notification *blockdag.Notification)
 	}
 }
 
+// Callback for notifications from the mempool. It notifies websocket
+// clients about transactions that were removed from the mempool since
+// they had expired.
+func (s *Server) handleMempoolNotification(notification *mempool.Notification) {
+	switch notification.Type {
+	case mempool.NTTransactionsExpired:
+		data, ok := notification.Data.(*mempool.TransactionsExpiredNotificationData)
+		if !ok {
+			log.Warnf("Transactions expired notification data is of wrong type.")
+			break
+		}
+
+		// Block templates that include the expired transactions
+		// are stale now.
+		s.gbtWorkState.NotifyMempoolTx(s.txMempool.LastUpdated())
+
+		s.ntfnMgr.NotifyMempoolTxsExpired(data.Transactions)
+	}
+}
+
 func init() {
 	rpcHandlers = rpcHandlersBeforeInit
 	rand.Seed(time.Now().UnixNano())
@@ -34,6 +34,15 @@ var helpDescsEnUS = map[string]string{
 	"connect-address":     "IP address and port of the peer to connect",
 	"connect-isPermanent": "Whether the connection for this address should be permanent",
 
+	// EstimateFeeCmd help.
+	"estimateFee--synopsis": "Estimates the fee rate a transaction has to pay in order to be included in a block within the given number of blue-score confirmations.\n" +
+		"The fee of a transaction is its mass multiplied by the fee rate and divided by 1000.",
+	"estimateFee-targetBlueScores": "The number of blue-score confirmations to estimate the fee rate for",
+
+	// EstimateFeeResult help.
+	"estimateFeeResult-feeRate":          "The estimated fee rate in KAS per 1000 grams of transaction mass",
+	"estimateFeeResult-targetBlueScores": "The number of blue-score confirmations the fee rate was estimated for",
+
 	// TransactionInput help.
 	"transactionInput-txId": "The hash of the input transaction",
 	"transactionInput-vout": "The specific output of the input transaction to redeem",
@@ -159,24 +168,25 @@ var helpDescsEnUS = map[string]string{
 	"-status":                     "A bool which indicates if the soft fork is active",
 
 	// TxRawResult help.
-	"txRawResult-hex":         "Hex-encoded transaction",
-	"txRawResult-txId":        "The hash of the transaction",
-	"txRawResult-version":     "The transaction version",
-	"txRawResult-lockTime":    "The transaction lock time",
-	"txRawResult-subnetwork":  "The transaction subnetwork",
-	"txRawResult-gas":         "The transaction gas",
-	"txRawResult-mass":        "The transaction mass",
-	"txRawResult-payloadHash": "The transaction payload hash",
-	"txRawResult-payload":     "The transaction payload",
-	"txRawResult-vin":         "The transaction inputs as JSON objects",
-	"txRawResult-vout":        "The transaction outputs as JSON objects",
-	"txRawResult-blockHash":   "Hash of the block the transaction is part of",
-	"txRawResult-isInMempool": "Whether the transaction is in the mempool",
-	"txRawResult-time":        "Transaction time in seconds since 1 Jan 1970 GMT",
-	"txRawResult-blockTime":   "Block time in seconds since the 1 Jan 1970 GMT",
-	"txRawResult-size":        "The size of the transaction in bytes",
-	"txRawResult-hash":        "The hash of the transaction",
-	"txRawResult-acceptedBy":  "The block in which the transaction got accepted in",
+	"txRawResult-hex":           "Hex-encoded transaction",
+	"txRawResult-txId":          "The hash of the transaction",
+	"txRawResult-version":       "The transaction version",
+	"txRawResult-lockTime":      "The transaction lock time",
+	"txRawResult-subnetwork":    "The transaction subnetwork",
+	"txRawResult-gas":           "The transaction gas",
+	"txRawResult-mass":          "The transaction mass",
+	"txRawResult-payloadHash":   "The transaction payload hash",
+	"txRawResult-payload":       "The transaction payload",
+	"txRawResult-vin":           "The transaction inputs as JSON objects",
+	"txRawResult-vout":          "The transaction outputs as JSON objects",
+	"txRawResult-blockHash":     "Hash of the block the transaction is part of",
+	"txRawResult-isInMempool":   "Whether the transaction is in the mempool",
+	"txRawResult-time":          "Transaction time in seconds since 1 Jan 1970 GMT",
+	"txRawResult-blockTime":     "Block time in seconds since the 1 Jan 1970 GMT",
+	"txRawResult-size":          "The size of the transaction in bytes",
+	"txRawResult-hash":          "The hash of the transaction",
+	"txRawResult-acceptedBy":    "The block in which the transaction got accepted in",
+	"txRawResult-confirmations": "The number of confirmations of the transaction",
 
 	// GetBlockVerboseResult help.
 	"getBlockVerboseResult-hash":                 "The hash of the block (same as provided)",
@@ -346,16 +356,38 @@ var helpDescsEnUS = map[string]string{
 	"getMempoolEntry-txId":      "The transaction ID",
 
 	// getMempoolEntryResult help.
-	"getMempoolEntryResult-fee":   "Transaction fee in sompis",
-	"getMempoolEntryResult-time":  "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
-	"getMempoolEntryResult-rawTx": "The transaction as a JSON object",
+	"getMempoolEntryResult-fee":             "Transaction fee in sompis",
+	"getMempoolEntryResult-mass":            "Transaction mass",
+	"getMempoolEntryResult-time":            "Local time transaction entered pool in milliseconds since 1 Jan 1970 GMT",
+	"getMempoolEntryResult-depends":         "The IDs of the transactions in the pool that this transaction spends outputs of",
+	"getMempoolEntryResult-spentBy":         "The IDs of the transactions in the pool that spend outputs of this transaction",
+	"getMempoolEntryResult-ancestorCount":   "The number of in-pool ancestors of this transaction, including this one",
+	"getMempoolEntryResult-ancestorFees":    "The total fee in sompis of the in-pool ancestors of this transaction, including this one",
+	"getMempoolEntryResult-ancestorMass":    "The total mass of the in-pool ancestors of this transaction, including this one",
+	"getMempoolEntryResult-descendantCount": "The number of in-pool descendants of this transaction, including this one",
+	"getMempoolEntryResult-descendantFees":  "The total fee in sompis of the in-pool descendants of this transaction, including this one",
+	"getMempoolEntryResult-descendantMass":  "The total mass of the in-pool descendants of this transaction, including this one",
+	"getMempoolEntryResult-rawTx":           "The transaction as a JSON object",
 
 	// GetMempoolInfoCmd help.
 	"getMempoolInfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getMempoolInfoResult-bytes": "Size in bytes of the mempool",
-	"getMempoolInfoResult-size":  "Number of transactions in the mempool",
+	"getMempoolInfoResult-bytes":   "Size in bytes of the mempool",
+	"getMempoolInfoResult-size":    "Number of transactions in the mempool",
+	"getMempoolInfoResult-mass":    "Total mass of the transactions in the mempool",
+	"getMempoolInfoResult-maxMass": "Maximum total mass of the mempool, above which the transactions with the lowest fee rates are evicted",
+	"getMempoolInfoResult-minFee":  "Minimum fee rate for transactions to be accepted into the mempool, in KAS per 1000 grams of transaction mass",
+
+	// GetMempoolLoadStatusCmd help.
+	"getMempoolLoadStatus--synopsis": "Returns the status of loading the mempool transactions that were saved to the data directory before the last shutdown.",
+
+	// GetMempoolLoadStatusResult help.
+	"getMempoolLoadStatusResult-loaded":   "Whether all the saved transactions were processed. Always false if mempool persistence is disabled",
+	"getMempoolLoadStatusResult-total":    "The number of saved transactions, including orphans",
+	"getMempoolLoadStatusResult-accepted": "The number of saved transactions that were accepted to the mempool",
+	"getMempoolLoadStatusResult-rejected": "The number of saved transactions that were rejected, usually since they had been included in blocks or double spent while the node was down",
+	"getMempoolLoadStatusResult-error":    "Why the saved transactions couldn't be read, if they couldn't",
 
 	// GetNetTotalsCmd help.
 	"getNetTotals--synopsis": "Returns a JSON object containing network traffic statistics.",
@@ -376,6 +408,20 @@ var helpDescsEnUS = map[string]string{
 	"getConnectedPeerInfoResult-userAgent":                 "The user agent of the peer",
 	"getConnectedPeerInfoResult-advertisedProtocolVersion": "The advertised p2p protocol version of the peer",
 	"getConnectedPeerInfoResult-timeConnected":             "The timestamp of when the peer connected to this node",
+	"getConnectedPeerInfoResult-banScore":                  "The current ban score of the IP of the peer, which decays over time. The peer is banned once it reaches the ban threshold",
+	"getConnectedPeerInfoResult-pingWait":                  "How long the pending ping to the peer has been waiting for a pong in milliseconds (only when a ping is pending)",
+	"getConnectedPeerInfoResult-bytesSent":                 "The number of bytes sent to the peer",
+	"getConnectedPeerInfoResult-bytesReceived":             "The number of bytes received from the peer",
+	"getConnectedPeerInfoResult-lastSend":                  "The time a message was last sent to the peer in milliseconds since the epoch, or 0 if none was sent",
+	"getConnectedPeerInfoResult-lastReceive":               "The time a message was last received from the peer in milliseconds since the epoch, or 0 if none was received",
+	"getConnectedPeerInfoResult-messagesSent":              "Message counts by command",
+	"getConnectedPeerInfoResult-messagesSent--desc":        "The number of messages sent to the peer per message command",
+	"getConnectedPeerInfoResult-messagesSent--key":         "command",
+	"getConnectedPeerInfoResult-messagesSent--value":       "The number of messages",
+	"getConnectedPeerInfoResult-messagesReceived":          "Message counts by command",
+	"getConnectedPeerInfoResult-messagesReceived--desc":    "The number of messages received from the peer per message command",
+	"getConnectedPeerInfoResult-messagesReceived--key":     "command",
+	"getConnectedPeerInfoResult-messagesReceived--value":   "The number of messages",
 
 	// GetConnectedPeerInfoCmd help.
 	"getConnectedPeerInfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
@@ -446,6 +492,57 @@ var helpDescsEnUS = map[string]string{
 	"getTxOut-vout":           "The index of the output",
 	"getTxOut-includeMempool": "Include the mempool when true",
 
+	// GetUTXOsByAddressCmd help.
+	"getUTXOsByAddress--synopsis": "Returns all the unspent transaction outputs that pay to the given address. Requires --utxoindex.",
+	"getUTXOsByAddress-address":   "The address to fetch the unspent transaction outputs for",
+
+	// GetUTXOsByAddressResult help.
+	"getUtxOsByAddressResult-address": "The address the unspent transaction outputs pay to",
+	"getUtxOsByAddressResult-utxos":   "The unspent transaction outputs that pay to the address",
+
+	// UTXOResult help.
+	"utxoResult-txId":           "The ID of the transaction that created the output",
+	"utxoResult-index":          "The index of the output within its transaction",
+	"utxoResult-value":          "The output amount in KAS",
+	"utxoResult-scriptPubKey":   "The public key script of the output, hex-encoded",
+	"utxoResult-blockBlueScore": "The blue score of the chain block that accepted the output",
+	"utxoResult-confirmations":  "The number of confirmations",
+	"utxoResult-isCoinbase":     "Whether or not the output belongs to a coinbase transaction",
+
+	// GetAddressTransactionsCmd help.
+	"getAddressTransactions--synopsis": "Returns the transactions that credit or debit the given address, ordered from oldest to newest. Requires --addrindex.",
+	"getAddressTransactions-address":   "The address to fetch the transactions of",
+	"getAddressTransactions-limit":     "The maximum number of transactions to return (at most 1000)",
+	"getAddressTransactions-cursor":    "The nextCursor of a previous call, to continue from where it stopped",
+
+	// GetAddressTransactionsResult help.
+	"getAddressTransactionsResult-address":      "The address the transactions credit or debit",
+	"getAddressTransactionsResult-transactions": "The transactions that credit or debit the address",
+	"getAddressTransactionsResult-nextCursor":   "The cursor to pass in order to fetch the next transactions. Omitted if there are none",
+
+	// AddressTransactionResult help.
+	"addressTransactionResult-txId":                    "The ID of the transaction",
+	"addressTransactionResult-acceptingBlockHash":      "The hash of the chain block that accepted the transaction",
+	"addressTransactionResult-acceptingBlockBlueScore": "The blue score of the chain block that accepted the transaction",
+	"addressTransactionResult-received":                "The total amount in KAS that the transaction pays to the address",
+	"addressTransactionResult-sent":                    "The total amount in KAS of the address's outputs that the transaction spends",
+	"addressTransactionResult-confirmations":           "The number of confirmations",
+
+	// GetRawTransactionCmd help.
+	"getRawTransaction--synopsis": "Returns the hex-encoded transaction of the given ID. Requires --txindex for transactions that are not in the mempool.",
+	"getRawTransaction-txId":      "The ID of the transaction",
+
+	// GetRawTransactionResult help.
+	"getRawTransactionResult-hex":           "Hex-encoded transaction",
+	"getRawTransactionResult-blockHash":     "Hash of the block that contains the transaction",
+	"getRawTransactionResult-acceptedBy":    "Hash of the chain block that accepted the transaction",
+	"getRawTransactionResult-confirmations": "The number of confirmations of the transaction",
+	"getRawTransactionResult-isInMempool":   "Whether the transaction is in the mempool",
+
+	// GetTransactionCmd help.
+	"getTransaction--synopsis": "Returns a JSON object representing the transaction of the given ID. Requires --txindex for transactions that are not in the mempool.",
+	"getTransaction-txId":      "The ID of the transaction",
+
 	// HelpCmd help.
 	"help--synopsis":   "Returns a list of all commands or help for a specified command.",
 	"help-command":     "The command to retrieve help for",
@@ -458,6 +555,26 @@ var helpDescsEnUS = map[string]string{
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getConnectedPeerInfo via the pingtime and pingwait fields.",
 
+	// SaveMempoolCmd help.
+	"saveMempool--synopsis": "Saves the transactions in the mempool to the data directory, from which they are loaded on startup.",
+
+	// ListBannedCmd help.
+	"listBanned--synopsis": "Returns the banned IP addresses and subnets.",
+
+	// ListBannedResult help.
+	"listBannedResult-address":   "The banned IP address or subnet, in CIDR notation",
+	"listBannedResult-banTime":   "The time the address or subnet was banned, in milliseconds since the epoch",
+	"listBannedResult-banExpiry": "The time the ban expires, in milliseconds since the epoch",
+
+	// SetBanCmd help.
+	"setBan--synopsis": "Bans an IP address or subnet, disconnecting any connected peers in it, or lifts its ban.",
+	"setBan-address":   "The IP address or subnet (in CIDR notation, e.g. 10.0.0.0/16) to ban or unban",
+	"setBan-subCmd":    "'add' to ban the address or subnet, 'remove' to lift its ban",
+	"setBan-banTime":   "How long to ban the address or subnet, in seconds. 0 uses the duration set with --banduration",
+
+	// ClearBannedCmd help.
+	"clearBanned--synopsis": "Lifts the bans of all the banned IP addresses and subnets.",
+
 	// DisconnectCmd help.
 	"disconnect--synopsis": "Disconnects a peer",
 	"disconnect-address":   "IP address and port of the peer to disconnect",
@@ -483,6 +600,32 @@ var helpDescsEnUS = map[string]string{
 	"submitBlock--condition1": "Block rejected",
 	"submitBlock--result1":    "The reason the block was rejected",
 
+	// SubmitPackageCmd help.
+	"submitPackage--synopsis": "Submits a package of serialized, hex-encoded transactions to the local peer, which accepts or rejects them as a unit, and relays them to the network.",
+	"submitPackage-hexTxs":    "Serialized, hex-encoded signed transactions, ordered so that every transaction comes after its parents",
+
+	// SubmitPackageResult help.
+	"submitPackageResult-accepted":     "Whether the transactions were accepted to the mempool",
+	"submitPackageResult-transactions": "The results of the transactions in the package, in their order",
+
+	// SubmitPackageTransaction help.
+	"submitPackageTransaction-txId":         "The ID of the transaction",
+	"submitPackageTransaction-accepted":     "Whether the transaction was accepted to the mempool",
+	"submitPackageTransaction-rejectCode":   "The code of the reason the transaction was rejected (only when accepted is false)",
+	"submitPackageTransaction-rejectReason": "The reason the transaction was rejected (only when accepted is false)",
+
+	// TestMempoolAcceptCmd help.
+	"testMempoolAccept--synopsis": "Runs all the checks that a transaction has to pass in order to be accepted to the mempool, without adding it to the mempool or relaying it.",
+	"testMempoolAccept-hexTx":     "Serialized, hex-encoded signed transaction",
+
+	// TestMempoolAcceptResult help.
+	"testMempoolAcceptResult-txId":         "The ID of the transaction",
+	"testMempoolAcceptResult-allowed":      "Whether the transaction would be accepted to the mempool",
+	"testMempoolAcceptResult-fee":          "The fee the transaction pays in sompi (only when allowed is true)",
+	"testMempoolAcceptResult-mass":         "The mass of the transaction (only when allowed is true)",
+	"testMempoolAcceptResult-rejectCode":   "The code of the reason the transaction would be rejected (only when allowed is false)",
+	"testMempoolAcceptResult-rejectReason": "The reason the transaction would be rejected (only when allowed is false)",
+
 	// ValidateAddressResult help.
 	"validateAddressResult-isValid": "Whether or not the address is valid",
 	"validateAddressResult-address": "The kaspa address (only when isvalid is true)",
@@ -510,13 +653,20 @@ var helpDescsEnUS = map[string]string{
 	"stopNotifyChainChanges--synopsis": "Cancel registered notifications for whenever the selected parent chain changes.",
 
 	// NotifyNewTransactionsCmd help.
-	"notifyNewTransactions--synopsis":  "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
+	"notifyNewTransactions--synopsis":  "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool, and a txExpired notification when a transaction is removed from the mempool since it had not been accepted for too long.",
 	"notifyNewTransactions-verbose":    "Specifies which type of notification to receive. If verbose is true, then the caller receives txacceptedverbose, otherwise the caller receives txaccepted",
 	"notifyNewTransactions-subnetwork": "Specifies which subnetwork to receive full transactions of. Requires verbose=true. Not allowed when node subnetwork is Native. Must be equal to node subnetwork when node is partial.",
 
 	// StopNotifyNewTransactionsCmd help.
 	"stopNotifyNewTransactions--synopsis": "Stop sending either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 
+	// NotifyAddressTransactionsCmd help.
+	"notifyAddressTransactions--synopsis": "Send an addressTransaction notification whenever a transaction that credits or debits one of the given addresses is accepted by the selected parent chain. Requires --addrindex.",
+	"notifyAddressTransactions-addresses": "The addresses to watch. Replaces any previously watched addresses",
+
+	// StopNotifyAddressTransactionsCmd help.
+	"stopNotifyAddressTransactions--synopsis": "Stop sending addressTransaction notifications.",
+
 	// Outpoint help.
 	"outpoint-txid":  "The hex-encoded bytes of the outpoint transaction ID",
 	"outpoint-index": "The index of the outpoint",
@@ -559,52 +709,66 @@ var helpDescsEnUS = map[string]string{
 // This information is used to generate the help. Each result type must be a
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
-	"connect":              nil,
-	"debugLevel":           {(*string)(nil), (*string)(nil)},
-	"getSelectedTip":       {(*model.GetBlockVerboseResult)(nil)},
-	"getSelectedTipHash":   {(*string)(nil)},
-	"getBlock":             {(*string)(nil), (*model.GetBlockVerboseResult)(nil)},
-	"getBlocks":            {(*model.GetBlocksResult)(nil)},
-	"getBlockCount":        {(*int64)(nil)},
-	"getBlockHeader":       {(*string)(nil), (*model.GetBlockHeaderVerboseResult)(nil)},
-	"getBlockTemplate":     {(*model.GetBlockTemplateResult)(nil), (*string)(nil), nil},
-	"getBlockDagInfo":      {(*model.GetBlockDAGInfoResult)(nil)},
-	"getChainFromBlock":    {(*model.GetChainFromBlockResult)(nil)},
-	"getConnectionCount":   {(*int32)(nil)},
-	"getCurrentNet":        {(*uint32)(nil)},
-	"getDifficulty":        {(*float64)(nil)},
-	"getTopHeaders":        {(*[]string)(nil)},
-	"getHeaders":           {(*[]string)(nil)},
-	"getInfo":              {(*model.InfoDAGResult)(nil)},
-	"getMempoolInfo":       {(*model.GetMempoolInfoResult)(nil)},
-	"getMempoolEntry":      {(*model.GetMempoolEntryResult)(nil)},
-	"getNetTotals":         {(*model.GetNetTotalsResult)(nil)},
-	"getConnectedPeerInfo": {(*[]model.GetConnectedPeerInfoResult)(nil)},
-	"getPeerAddresses":     {(*[]model.GetPeerAddressesResult)(nil)},
-	"getRawMempool":        {(*[]string)(nil), (*model.GetRawMempoolVerboseResult)(nil)},
-	"getSubnetwork":        {(*model.GetSubnetworkResult)(nil)},
-	"getTxOut":             {(*model.GetTxOutResult)(nil)},
-	"node":                 nil,
-	"help":                 {(*string)(nil), (*string)(nil)},
-	"ping":                 nil,
-	"disconnect":           nil,
-	"sendRawTransaction":   {(*string)(nil)},
-	"stop":                 {(*string)(nil)},
-	"submitBlock":          {nil, (*string)(nil)},
-	"uptime":               {(*int64)(nil)},
-	"validateAddress":      {(*model.ValidateAddressResult)(nil)},
-	"version":              {(*map[string]model.VersionResult)(nil)},
+	"clearBanned":            nil,
+	"connect":                nil,
+	"debugLevel":             {(*string)(nil), (*string)(nil)},
+	"estimateFee":            {(*model.EstimateFeeResult)(nil)},
+	"getSelectedTip":         {(*model.GetBlockVerboseResult)(nil)},
+	"getSelectedTipHash":     {(*string)(nil)},
+	"getBlock":               {(*string)(nil), (*model.GetBlockVerboseResult)(nil)},
+	"getBlocks":              {(*model.GetBlocksResult)(nil)},
+	"getBlockCount":          {(*int64)(nil)},
+	"getBlockHeader":         {(*string)(nil), (*model.GetBlockHeaderVerboseResult)(nil)},
+	"getBlockTemplate":       {(*model.GetBlockTemplateResult)(nil), (*string)(nil), nil},
+	"getBlockDagInfo":        {(*model.GetBlockDAGInfoResult)(nil)},
+	"getChainFromBlock":      {(*model.GetChainFromBlockResult)(nil)},
+	"getConnectionCount":     {(*int32)(nil)},
+	"getCurrentNet":          {(*uint32)(nil)},
+	"getDifficulty":          {(*float64)(nil)},
+	"getTopHeaders":          {(*[]string)(nil)},
+	"getHeaders":             {(*[]string)(nil)},
+	"getInfo":                {(*model.InfoDAGResult)(nil)},
+	"getMempoolInfo":         {(*model.GetMempoolInfoResult)(nil)},
+	"getMempoolLoadStatus":   {(*model.GetMempoolLoadStatusResult)(nil)},
+	"getMempoolEntry":        {(*model.GetMempoolEntryResult)(nil)},
+	"getNetTotals":           {(*model.GetNetTotalsResult)(nil)},
+	"getConnectedPeerInfo":   {(*[]model.GetConnectedPeerInfoResult)(nil)},
+	"getPeerAddresses":       {(*[]model.GetPeerAddressesResult)(nil)},
+	"getRawMempool":          {(*[]string)(nil), (*model.GetRawMempoolVerboseResult)(nil)},
+	"getSubnetwork":          {(*model.GetSubnetworkResult)(nil)},
+	"getTxOut":               {(*model.GetTxOutResult)(nil)},
+	"getUTXOsByAddress":      {(*model.GetUTXOsByAddressResult)(nil)},
+	"getAddressTransactions": {(*model.GetAddressTransactionsResult)(nil)},
+	"getRawTransaction":      {(*model.GetRawTransactionResult)(nil)},
+	"getTransaction":         {(*model.TxRawResult)(nil)},
+	"node":                   nil,
+	"help":                   {(*string)(nil), (*string)(nil)},
+	"listBanned":             {(*[]model.ListBannedResult)(nil)},
+	"ping":                   nil,
+	"saveMempool":            nil,
+	"disconnect":             nil,
+	"sendRawTransaction":     {(*string)(nil)},
+	"setBan":                 nil,
+	"stop":                   {(*string)(nil)},
+	"submitBlock":            {nil, (*string)(nil)},
+	"submitPackage":          {(*model.SubmitPackageResult)(nil)},
+	"testMempoolAccept":      {(*model.TestMempoolAcceptResult)(nil)},
+	"uptime":                 {(*int64)(nil)},
+	"validateAddress":        {(*model.ValidateAddressResult)(nil)},
+	"version":                {(*map[string]model.VersionResult)(nil)},
 
 	// Websocket commands.
-	"loadTxFilter":              nil,
-	"session":                   {(*model.SessionResult)(nil)},
-	"notifyBlocks":              nil,
-	"stopNotifyBlocks":          nil,
-	"notifyChainChanges":        nil,
-	"stopNotifyChainChanges":    nil,
-	"notifyNewTransactions":     nil,
-	"stopNotifyNewTransactions": nil,
-	"rescanBlocks":              {(*[]model.RescannedBlock)(nil)},
+	"loadTxFilter":                  nil,
+	"session":                       {(*model.SessionResult)(nil)},
+	"notifyBlocks":                  nil,
+	"stopNotifyBlocks":              nil,
+	"notifyChainChanges":            nil,
+	"stopNotifyChainChanges":        nil,
+	"notifyNewTransactions":         nil,
+	"stopNotifyNewTransactions":     nil,
+	"notifyAddressTransactions":     nil,
+	"stopNotifyAddressTransactions": nil,
+	"rescanBlocks":                  {(*[]model.RescannedBlock)(nil)},
 }
 
 // helpCacher provides a concurrent safe type that provides help and usage for
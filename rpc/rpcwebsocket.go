@@ -64,16 +64,18 @@ type wsCommandHandler func(*wsClient, interface{}) (interface{}, error)
 // causes a dependency loop.
 var wsHandlers map[string]wsCommandHandler
 var wsHandlersBeforeInit = map[string]wsCommandHandler{
-	"loadTxFilter":              handleLoadTxFilter,
-	"help":                      handleWebsocketHelp,
-	"notifyBlocks":              handleNotifyBlocks,
-	"notifyChainChanges":        handleNotifyChainChanges,
-	"notifyNewTransactions":     handleNotifyNewTransactions,
-	"session":                   handleSession,
-	"stopNotifyBlocks":          handleStopNotifyBlocks,
-	"stopNotifyChainChanges":    handleStopNotifyChainChanges,
-	"stopNotifyNewTransactions": handleStopNotifyNewTransactions,
-	"rescanBlocks":              handleRescanBlocks,
+	"loadTxFilter":                  handleLoadTxFilter,
+	"help":                          handleWebsocketHelp,
+	"notifyAddressTransactions":     handleNotifyAddressTransactions,
+	"notifyBlocks":                  handleNotifyBlocks,
+	"notifyChainChanges":            handleNotifyChainChanges,
+	"notifyNewTransactions":         handleNotifyNewTransactions,
+	"session":                       handleSession,
+	"stopNotifyAddressTransactions": handleStopNotifyAddressTransactions,
+	"stopNotifyBlocks":              handleStopNotifyBlocks,
+	"stopNotifyChainChanges":        handleStopNotifyChainChanges,
+	"stopNotifyNewTransactions":     handleStopNotifyNewTransactions,
+	"rescanBlocks":                  handleRescanBlocks,
 }
 
 // WebsocketHandler handles a new websocket client by creating a new wsClient,
@@ -229,6 +231,22 @@ func (m *wsNotificationManager) NotifyChainChanged(removedChainBlockHashes []*da
 	}
 }
 
+// NotifyAddressTransactions passes the hashes of chain blocks newly-added
+// to the selected parent chain to the notification manager, so that the
+// address index entries they added could be sent to watching clients.
+func (m *wsNotificationManager) NotifyAddressTransactions(addedChainBlockHashes []*daghash.Hash) {
+	n := notificationAddressTransactions(addedChainBlockHashes)
+
+	// As NotifyAddressTransactions will be called by the DAG manager
+	// and the RPC server may no longer be running, use a select
+	// statement to unblock enqueuing the notification once the RPC
+	// server has begun shutting down.
+	select {
+	case m.queueNotification <- &n:
+	case <-m.quit:
+	}
+}
+
 // NotifyMempoolTx passes a transaction accepted by mempool to the
 // notification manager for transaction notification processing. If
 // isNew is true, the tx is is a new transaction, rather than one
@@ -249,6 +267,23 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *util.Tx, isNew bool) {
 	}
 }
 
+// NotifyMempoolTxsExpired passes transactions that were removed from
+// the mempool since they had expired to the notification manager, so
+// that clients that are notified about new mempool transactions could
+// be notified about their removal too.
+func (m *wsNotificationManager) NotifyMempoolTxsExpired(txs []*util.Tx) {
+	n := notificationTxsExpiredFromMempool(txs)
+
+	// As NotifyMempoolTxsExpired will be called by mempool and the RPC
+	// server may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun shutting
+	// down.
+	select {
+	case m.queueNotification <- &n:
+	case <-m.quit:
+	}
+}
+
 // wsClientFilter tracks relevant addresses for each websocket client for
 // the `rescanBlocks` extension. It is modified by the `loadTxFilter` command.
 //
@@ -377,6 +412,8 @@ type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *util.Tx
 }
+type notificationAddressTransactions []*daghash.Hash
+type notificationTxsExpiredFromMempool []*util.Tx
 
 // Notification control requests
 type notificationRegisterClient wsClient
@@ -387,6 +424,8 @@ type notificationRegisterChainChanges wsClient
 type notificationUnregisterChainChanges wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
+type notificationRegisterAddressTransactions wsClient
+type notificationUnregisterAddressTransactions wsClient
 
 // notificationHandler reads notifications and control messages from the queue
 // handler and processes one at a time.
@@ -404,6 +443,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	blockNotifications := make(map[chan struct{}]*wsClient)
 	chainChangeNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
+	addressTxNotifications := make(map[chan struct{}]*wsClient)
 
 out:
 	for {
@@ -432,6 +472,16 @@ out:
 				}
 				m.notifyRelevantTxAccepted(n.tx, clients)
 
+			case *notificationAddressTransactions:
+				if len(addressTxNotifications) != 0 {
+					m.notifyAddressTransactions(addressTxNotifications, *n)
+				}
+
+			case *notificationTxsExpiredFromMempool:
+				if len(txNotifications) != 0 {
+					m.notifyTxsExpired(txNotifications, *n)
+				}
+
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
 				blockNotifications[wsc.quit] = wsc
@@ -459,6 +509,7 @@ out:
 				delete(blockNotifications, wsc.quit)
 				delete(chainChangeNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				delete(addressTxNotifications, wsc.quit)
 				delete(clients, wsc.quit)
 
 			case *notificationRegisterNewMempoolTxs:
@@ -469,6 +520,14 @@ out:
 				wsc := (*wsClient)(n)
 				delete(txNotifications, wsc.quit)
 
+			case *notificationRegisterAddressTransactions:
+				wsc := (*wsClient)(n)
+				addressTxNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterAddressTransactions:
+				wsc := (*wsClient)(n)
+				delete(addressTxNotifications, wsc.quit)
+
 			default:
 				log.Warn("Unhandled notification type")
 			}
@@ -677,6 +736,58 @@ func (m *wsNotificationManager) UnregisterNewMempoolTxsUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterNewMempoolTxs)(wsc)
 }
 
+// RegisterAddressTransactions requests notifications to the passed websocket
+// client when transactions that involve its watched addresses are accepted by
+// the selected parent chain.
+func (m *wsNotificationManager) RegisterAddressTransactions(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterAddressTransactions)(wsc)
+}
+
+// UnregisterAddressTransactions removes address transaction notifications for
+// the passed websocket client.
+func (m *wsNotificationManager) UnregisterAddressTransactions(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterAddressTransactions)(wsc)
+}
+
+// notifyAddressTransactions notifies websocket clients that have registered
+// for address transactions of every address index entry of the given chain
+// blocks whose address they watch.
+func (m *wsNotificationManager) notifyAddressTransactions(clients map[chan struct{}]*wsClient,
+	addedChainBlockHashes []*daghash.Hash) {
+
+	selectedTipBlueScore := m.server.dag.SelectedTipBlueScore()
+	for _, chainBlockHash := range addedChainBlockHashes {
+		entries, err := m.server.addrIndex.EntriesByChainBlock(chainBlockHash)
+		if err != nil {
+			// The chain block may have not been indexed if the
+			// address index is waiting to be rebuilt.
+			log.Debugf("Failed to fetch the address index entries "+
+				"of chain block %s: %s", chainBlockHash, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			var marshalledJSON []byte
+			for _, wsc := range clients {
+				if !wsc.isWatchingAddress(entry.Address) {
+					continue
+				}
+				if marshalledJSON == nil {
+					ntfn := model.NewAddressTransactionNtfn(entry.Address,
+						addressTransactionResult(entry, selectedTipBlueScore))
+					marshalledJSON, err = model.MarshalCommand(nil, ntfn)
+					if err != nil {
+						log.Errorf("Failed to marshal address transaction "+
+							"notification: %s", err)
+						return
+					}
+				}
+				wsc.QueueNotification(marshalledJSON)
+			}
+		}
+	}
+}
+
 // notifyForNewTx notifies websocket clients that have registered for updates
 // when a new transaction is added to the memory pool.
 func (m *wsNotificationManager) notifyForNewTx(clients map[chan struct{}]*wsClient, tx *util.Tx) {
@@ -754,6 +865,23 @@ func (m *wsNotificationManager) notifyForNewTx(clients map[chan struct{}]*wsClie
 	}
 }
 
+// notifyTxsExpired notifies websocket clients that have registered for
+// new mempool transactions that the given transactions were removed from
+// the mempool since they had expired.
+func (m *wsNotificationManager) notifyTxsExpired(clients map[chan struct{}]*wsClient, txs []*util.Tx) {
+	for _, tx := range txs {
+		ntfn := model.NewTxExpiredNtfn(tx.ID().String())
+		marshalledJSON, err := model.MarshalCommand(nil, ntfn)
+		if err != nil {
+			log.Errorf("Failed to marshal tx expired notification: %s", err)
+			return
+		}
+		for _, wsc := range clients {
+			wsc.QueueNotification(marshalledJSON)
+		}
+	}
+}
+
 // txHexString returns the serialized transaction encoded in hexadecimal.
 func txHexString(tx *domainmessage.MsgTx) string {
 	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
@@ -890,6 +1018,10 @@ type wsClient struct {
 	// new transaction information from a specific subnetwork.
 	subnetworkIDForTxUpdates *subnetworkid.SubnetworkID
 
+	// watchedAddresses is the set of encoded addresses that the client
+	// has requested address transaction notifications for.
+	watchedAddresses map[string]struct{}
+
 	// filterData is the new generation transaction filter backported from
 	// github.com/decred/dcrd for the new backported `loadTxFilter` and
 	// `rescanBlocks` methods.
@@ -1295,6 +1427,23 @@ func (c *wsClient) WaitForShutdown() {
 	c.wg.Wait()
 }
 
+// setWatchedAddresses replaces the set of addresses that the client
+// receives address transaction notifications for.
+func (c *wsClient) setWatchedAddresses(addresses map[string]struct{}) {
+	c.Lock()
+	defer c.Unlock()
+	c.watchedAddresses = addresses
+}
+
+// isWatchingAddress returns whether the client receives address
+// transaction notifications for the given encoded address.
+func (c *wsClient) isWatchingAddress(address string) bool {
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.watchedAddresses[address]
+	return ok
+}
+
 // FilterData returns the websocket client filter data.
 func (c *wsClient) FilterData() *wsClientFilter {
 	c.Lock()
@@ -0,0 +1,228 @@
+// Package metrics implements a minimal set of metric types that are exported
+// over HTTP in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// collector is a metric, or a family of metrics, that writes
+// its current values in the Prometheus text exposition format.
+type collector interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry is a set of metrics that are exported together.
+// It is safe for concurrent access.
+type Registry struct {
+	lock       sync.Mutex
+	collectors []collector
+	names      map[string]struct{}
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		names: make(map[string]struct{}),
+	}
+}
+
+func (r *Registry) register(c collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, exists := r.names[c.name()]; exists {
+		panic(errors.Errorf("metric %s is already registered", c.name()))
+	}
+	r.names[c.name()] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// RegisterGauge registers a gauge whose value is returned by valueFunc
+// every time the metrics are exported.
+func (r *Registry) RegisterGauge(name, help string, valueFunc func() float64) {
+	r.register(&gaugeFunc{
+		metricName: name,
+		help:       help,
+		valueFunc:  valueFunc,
+	})
+}
+
+// RegisterSummary registers the given summary. A summary may be registered
+// in more than one registry.
+func (r *Registry) RegisterSummary(summary *Summary) {
+	r.register(summary)
+}
+
+// Write writes the current values of all the registered metrics to w
+// in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.lock.Unlock()
+
+	bufferedWriter := bufio.NewWriter(w)
+	for _, c := range collectors {
+		err := c.write(bufferedWriter)
+		if err != nil {
+			return err
+		}
+	}
+	return bufferedWriter.Flush()
+}
+
+// ServeHTTP serves the current values of all the registered metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	err := r.Write(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type gaugeFunc struct {
+	metricName string
+	help       string
+	valueFunc  func() float64
+}
+
+func (g *gaugeFunc) name() string {
+	return g.metricName
+}
+
+func (g *gaugeFunc) write(w io.Writer) error {
+	err := writeHeader(w, g.metricName, g.help, "gauge")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s %s\n", g.metricName, formatValue(g.valueFunc()))
+	return err
+}
+
+// Summary tracks the count and the sum of observations, such as durations,
+// optionally partitioned by the value of a single label. It doesn't track
+// quantiles. It is safe for concurrent access.
+type Summary struct {
+	metricName string
+	help       string
+	label      string
+
+	lock   sync.Mutex
+	values map[string]*summaryValue
+}
+
+type summaryValue struct {
+	count uint64
+	sum   float64
+}
+
+// NewSummary returns a new Summary. If label is not empty, the observations
+// are partitioned by the value of that label.
+func NewSummary(name, help, label string) *Summary {
+	return &Summary{
+		metricName: name,
+		help:       help,
+		label:      label,
+		values:     make(map[string]*summaryValue),
+	}
+}
+
+// Observe adds an observation with the given label value.
+// The label value is ignored if the summary has no label.
+func (s *Summary) Observe(labelValue string, value float64) {
+	if s.label == "" {
+		labelValue = ""
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	observations, ok := s.values[labelValue]
+	if !ok {
+		observations = &summaryValue{}
+		s.values[labelValue] = observations
+	}
+	observations.count++
+	observations.sum += value
+}
+
+// ObserveDuration adds an observation of the time that passed since start,
+// in seconds, with the given label value.
+func (s *Summary) ObserveDuration(labelValue string, start time.Time) {
+	s.Observe(labelValue, time.Since(start).Seconds())
+}
+
+func (s *Summary) name() string {
+	return s.metricName
+}
+
+func (s *Summary) write(w io.Writer) error {
+	s.lock.Lock()
+	labelValues := make([]string, 0, len(s.values))
+	values := make(map[string]summaryValue, len(s.values))
+	for labelValue, value := range s.values {
+		labelValues = append(labelValues, labelValue)
+		values[labelValue] = *value
+	}
+	s.lock.Unlock()
+	sort.Strings(labelValues)
+
+	err := writeHeader(w, s.metricName, s.help, "summary")
+	if err != nil {
+		return err
+	}
+	for _, labelValue := range labelValues {
+		labels := ""
+		if s.label != "" {
+			labels = fmt.Sprintf("{%s=\"%s\"}", s.label, escapeLabelValue(labelValue))
+		}
+		value := values[labelValue]
+		_, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n",
+			s.metricName, labels, formatValue(value.sum), s.metricName, labels, value.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeader(w io.Writer, name, help, metricType string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, metricType)
+	return err
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(labelValue string) string {
+	return labelValueEscaper.Replace(labelValue)
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	registry := NewRegistry()
+
+	value := 1.5
+	registry.RegisterGauge("test_gauge", "A test gauge\nwith two lines", func() float64 { return value })
+	registry.RegisterGauge("test_nan_gauge", "A gauge that is not a number", func() float64 { return math.NaN() })
+
+	summary := NewSummary("test_duration_seconds", "Test durations", "method")
+	summary.Observe("b", 2)
+	summary.Observe("a", 0.25)
+	summary.Observe("b", 3)
+	summary.Observe(`"quoted"`, 1)
+	registry.RegisterSummary(summary)
+
+	unlabeledSummary := NewSummary("test_unlabeled_seconds", "Unlabeled durations", "")
+	unlabeledSummary.Observe("ignored", 1)
+	unlabeledSummary.Observe("", 1)
+	registry.RegisterSummary(unlabeledSummary)
+
+	expected := `# HELP test_gauge A test gauge\nwith two lines
+# TYPE test_gauge gauge
+test_gauge 1.5
+# HELP test_nan_gauge A gauge that is not a number
+# TYPE test_nan_gauge gauge
+test_nan_gauge NaN
+# HELP test_duration_seconds Test durations
+# TYPE test_duration_seconds summary
+test_duration_seconds_sum{method="\"quoted\""} 1
+test_duration_seconds_count{method="\"quoted\""} 1
+test_duration_seconds_sum{method="a"} 0.25
+test_duration_seconds_count{method="a"} 1
+test_duration_seconds_sum{method="b"} 5
+test_duration_seconds_count{method="b"} 2
+# HELP test_unlabeled_seconds Unlabeled durations
+# TYPE test_unlabeled_seconds summary
+test_unlabeled_seconds_sum 2
+test_unlabeled_seconds_count 2
+`
+	buffer := &bytes.Buffer{}
+	err := registry.Write(buffer)
+	if err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if buffer.String() != expected {
+		t.Fatalf("Write: expected:\n%s\ngot:\n%s", expected, buffer.String())
+	}
+
+	// Gauges should be evaluated every time the metrics are written.
+	value = 2
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP: expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	if !bytes.Contains(recorder.Body.Bytes(), []byte("\ntest_gauge 2\n")) {
+		t.Fatalf("ServeHTTP: expected the updated gauge value, got:\n%s", recorder.Body.String())
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterGauge("test_gauge", "A test gauge", func() float64 { return 0 })
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("RegisterGauge: expected a panic when registering a duplicate metric")
+		}
+	}()
+	registry.RegisterGauge("test_gauge", "A test gauge", func() float64 { return 0 })
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/kaspanet/kaspad/logs"
+	"github.com/kaspanet/kaspad/util/panics"
+)
+
+// Start starts an HTTP server that serves the metrics
+// in the given registry at /metrics
+func Start(port string, registry *Registry, log *logs.Logger) {
+	spawn := panics.GoroutineWrapperFunc(log)
+	spawn("metrics.Start", func() {
+		listenAddr := net.JoinHostPort("", port)
+		log.Infof("Metrics server listening on %s", listenAddr)
+		serveMux := http.NewServeMux()
+		serveMux.Handle("/metrics", registry)
+		log.Error(http.ListenAndServe(listenAddr, serveMux))
+	})
+}
@@ -2,6 +2,8 @@ package network
 
 import (
 	"net"
+
+	"github.com/pkg/errors"
 )
 
 // NormalizeAddresses returns a new slice with all the passed peer addresses
@@ -50,3 +52,27 @@ func removeDuplicateAddresses(addrs []string) []string {
 	}
 	return result
 }
+
+// ParseIPNetwork parses an IP network in CIDR notation (e.g. 192.168.1.0/24),
+// or a single IP address, which is returned as a network that contains only
+// that address.
+func ParseIPNetwork(s string) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err == nil {
+		return ipNet, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.Errorf("'%s' is neither an IP address nor an IP network", s)
+	}
+	return SingleIPNetwork(ip), nil
+}
+
+// SingleIPNetwork returns the IP network that contains only the passed IP
+// address.
+func SingleIPNetwork(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}